@@ -1,23 +1,24 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 
 	"github.com/gorilla/mux"
 	"github.com/maxzhirnov/go-task-manager/internal/handlers"
+	"github.com/maxzhirnov/go-task-manager/internal/jobs"
 	"github.com/maxzhirnov/go-task-manager/internal/middleware"
+	"github.com/maxzhirnov/go-task-manager/internal/models"
 	"github.com/maxzhirnov/go-task-manager/pkg/analytics"
 	"github.com/maxzhirnov/go-task-manager/pkg/config"
 	"github.com/maxzhirnov/go-task-manager/pkg/database"
 	"github.com/maxzhirnov/go-task-manager/pkg/email"
 )
 
-func setupRouter(cfg *config.Config) *mux.Router {
-	db, err := database.InitDB()
-	if err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
-	}
+func setupRouter(cfg *config.Config, db database.DB) *mux.Router {
+	// Apply task hierarchy limits
+	models.MaxTaskDepth = cfg.Tasks.MaxDepth
 
 	// Initialize Mixpanel
 	mixpanel := analytics.NewMixpanel(cfg.Mixpanel.Token)
@@ -51,19 +52,72 @@ func setupRouter(cfg *config.Config) *mux.Router {
 
 	// Task handlers
 	taskHandler := handlers.NewTaskHandler(db, mixpanel)
+	labelHandler := handlers.NewLabelHandler(db, mixpanel)
+	projectHandler := handlers.NewProjectHandler(db, mixpanel)
 	userHandler := handlers.NewUserHandler(db)
+	preferencesHandler := handlers.NewPreferencesHandler(db, mixpanel)
+	workflowHandler := handlers.NewWorkflowHandler(db, mixpanel)
+	boardHandler := handlers.NewBoardHandler(db, mixpanel)
+	templateHandler := handlers.NewTemplateHandler(db, mixpanel)
+	timeEntryHandler := handlers.NewTimeEntryHandler(db, mixpanel)
 
 	api := r.PathPrefix("/api").Subrouter()
 
 	api.Use(middleware.JWTAuthMiddleware)
 	api.HandleFunc("/tasks", taskHandler.GetTasks).Methods("GET")
 	api.HandleFunc("/tasks", taskHandler.CreateTask).Methods("POST")
+	api.HandleFunc("/tasks/trash", taskHandler.GetTrash).Methods("GET")
+	api.HandleFunc("/tasks/trash", taskHandler.EmptyTrash).Methods("DELETE")
+	api.HandleFunc("/tasks/search", taskHandler.SearchTasks).Methods("GET")
 	api.HandleFunc("/tasks/{id}", taskHandler.GetTask).Methods("GET")
 	api.HandleFunc("/tasks/positions", taskHandler.UpdateTaskPositions).Methods("PUT")
+	api.HandleFunc("/tasks/bulk", taskHandler.BulkUpdateTasks).Methods("POST")
 	api.HandleFunc("/tasks/{id}", taskHandler.UpdateTask).Methods("PUT")
+	api.HandleFunc("/tasks/{id}", taskHandler.PatchTask).Methods("PATCH")
 	api.HandleFunc("/tasks/{id}", taskHandler.DeleteTask).Methods("DELETE")
+	api.HandleFunc("/tasks/{id}/series", taskHandler.DeleteTaskSeries).Methods("DELETE")
+	api.HandleFunc("/tasks/{id}/history", taskHandler.GetTaskHistory).Methods("GET")
+	api.HandleFunc("/tasks/{id}/restore", taskHandler.RestoreTask).Methods("POST")
+	api.HandleFunc("/tasks/{id}/purge", taskHandler.PurgeTask).Methods("DELETE")
+	api.HandleFunc("/tasks/{id}/move", boardHandler.MoveTask).Methods("POST")
+	api.HandleFunc("/tasks/{id}/dependencies", taskHandler.AddTaskDependency).Methods("POST")
+	api.HandleFunc("/tasks/{id}/dependencies/{blockedById}", taskHandler.RemoveTaskDependency).Methods("DELETE")
+	api.HandleFunc("/board", boardHandler.GetBoard).Methods("GET")
+
+	api.HandleFunc("/labels", labelHandler.GetLabels).Methods("GET")
+	api.HandleFunc("/labels", labelHandler.CreateLabel).Methods("POST")
+	api.HandleFunc("/labels/{id}", labelHandler.UpdateLabel).Methods("PUT")
+	api.HandleFunc("/labels/{id}", labelHandler.DeleteLabel).Methods("DELETE")
+	api.HandleFunc("/projects", projectHandler.GetProjects).Methods("GET")
+	api.HandleFunc("/projects", projectHandler.CreateProject).Methods("POST")
+	api.HandleFunc("/projects/statistics", projectHandler.GetProjectsStatistics).Methods("GET")
+	api.HandleFunc("/projects/{id}", projectHandler.GetProject).Methods("GET")
+	api.HandleFunc("/projects/{id}", projectHandler.UpdateProject).Methods("PUT")
+	api.HandleFunc("/projects/{id}", projectHandler.DeleteProject).Methods("DELETE")
+	api.HandleFunc("/projects/{id}/statistics", projectHandler.GetProjectStatistics).Methods("GET")
+
+	api.HandleFunc("/templates", templateHandler.GetTemplates).Methods("GET")
+	api.HandleFunc("/templates", templateHandler.CreateTemplate).Methods("POST")
+	api.HandleFunc("/templates/{id}", templateHandler.GetTemplate).Methods("GET")
+	api.HandleFunc("/templates/{id}", templateHandler.DeleteTemplate).Methods("DELETE")
+	api.HandleFunc("/templates/{id}/instantiate", templateHandler.InstantiateTemplate).Methods("POST")
+
+	api.HandleFunc("/tasks/{id}/timer", timeEntryHandler.StartTimer).Methods("POST")
+	api.HandleFunc("/tasks/{id}/time-entries", timeEntryHandler.GetTimeEntries).Methods("GET")
+	api.HandleFunc("/tasks/{id}/time-entries", timeEntryHandler.CreateTimeEntry).Methods("POST")
+	api.HandleFunc("/timer", timeEntryHandler.GetTimer).Methods("GET")
+	api.HandleFunc("/timer/stop", timeEntryHandler.StopTimer).Methods("POST")
+	api.HandleFunc("/time-entries/report", timeEntryHandler.GetTimeReport).Methods("GET")
+	api.HandleFunc("/time-entries/{id}", timeEntryHandler.DeleteTimeEntry).Methods("DELETE")
 
 	api.HandleFunc("/users/statistics", taskHandler.GetUserStatistics).Methods("GET")
+	api.HandleFunc("/users/statistics/flow", taskHandler.GetFlowStatistics).Methods("GET")
+	api.HandleFunc("/users/statistics/activity", taskHandler.GetActivityStatistics).Methods("GET")
+	api.HandleFunc("/users/statistics/burndown", taskHandler.GetBurndownStatistics).Methods("GET")
+	api.HandleFunc("/preferences", preferencesHandler.GetPreferences).Methods("GET")
+	api.HandleFunc("/preferences", preferencesHandler.UpdatePreferences).Methods("PUT")
+	api.HandleFunc("/workflow", workflowHandler.GetWorkflow).Methods("GET")
+	api.HandleFunc("/workflow", workflowHandler.UpdateWorkflow).Methods("PUT")
 	api.HandleFunc("/profile", userHandler.UpdateProfile).Methods("PUT")
 
 	// Static files for Svelte assets (CSS, JS)
@@ -90,7 +144,29 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	r := setupRouter(cfg)
+	db, err := database.InitDB()
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	// Start background cleanup of old deleted tasks and expired tokens
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if cfg.Retention.Enabled {
+		retention := jobs.NewRetentionJob(db, jobs.RetentionPolicy{
+			Interval:     cfg.Retention.Interval,
+			DeletedTasks: cfg.Retention.DeletedTasks,
+			TokenGrace:   cfg.Retention.TokenGrace,
+		})
+		go retention.Start(ctx)
+	}
+
+	// Keep task ranks short by rebalancing lists with long ranks
+	rebalance := jobs.NewRankRebalanceJob(db, cfg.Tasks.RankRebalanceInterval, cfg.Tasks.MaxRankLength)
+	go rebalance.Start(ctx)
+
+	r := setupRouter(cfg, db)
 
 	serverAddr := ":" + cfg.Server.Port
 	log.Printf("Server starting on port %s", cfg.Server.Port)
@@ -169,7 +169,8 @@ func (h *AuthHandler) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		log.Printf("Failed to get verification token: %v", err)
 	} else {
-		if err := h.EmailService.SendVerificationEmail(user.Email, user.Username, token); err != nil {
+		locale := emailLocale(models.DefaultPreferences(user.ID))
+		if err := h.EmailService.SendVerificationEmail(user.Email, user.Username, token, locale); err != nil {
 			log.Printf("Failed to send verification email: %v", err)
 		}
 	}
@@ -612,7 +613,7 @@ func (h *AuthHandler) ResendVerificationHandler(w http.ResponseWriter, r *http.R
 	}
 
 	// Send verification email
-	if err := h.EmailService.SendVerificationEmail(user.Email, user.Username, token.Token); err != nil {
+	if err := h.EmailService.SendVerificationEmail(user.Email, user.Username, token.Token, h.recipientLocale(user.ID)); err != nil {
 		h.Analytics.Track(ctx, "Verification Resend Failed", strconv.Itoa(user.ID), map[string]any{
 			"reason":  "email_sending_failed",
 			"error":   err.Error(),
@@ -749,7 +750,7 @@ func (h *AuthHandler) ForgotPasswordHandler(w http.ResponseWriter, r *http.Reque
 	log.Printf("Generated reset link for user %d", user.ID)
 
 	// Send email with reset link
-	err = h.EmailService.SendPasswordResetEmail(user.Email, resetLink)
+	err = h.EmailService.SendPasswordResetEmail(user.Email, resetLink, h.recipientLocale(user.ID))
 	if err != nil {
 		h.Analytics.Track(ctx, "Password Reset Failed", strconv.Itoa(user.ID), map[string]any{
 			"reason":     "email_send_failed",
@@ -908,3 +909,24 @@ func (h *AuthHandler) ResetPasswordHandler(w http.ResponseWriter, r *http.Reques
 		"message": "Password has been reset successfully",
 	})
 }
+
+// recipientLocale returns how emails to the user should be rendered.
+// Emails are still sent with the default locale when the user's
+// preferences cannot be read.
+func (h *AuthHandler) recipientLocale(userID int) email.Locale {
+	prefs, err := models.GetPreferences(h.DB, userID)
+	if err != nil {
+		log.Printf("Failed to get preferences for user %d, using defaults: %v", userID, err)
+		prefs = models.DefaultPreferences(userID)
+	}
+	return emailLocale(prefs)
+}
+
+// emailLocale converts user preferences into the locale of an email.
+func emailLocale(prefs models.Preferences) email.Locale {
+	return email.Locale{
+		Language:   prefs.Locale,
+		Location:   prefs.Location(),
+		DateLayout: prefs.DateLayout(),
+	}
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/maxzhirnov/go-task-manager/internal/middleware"
+	"github.com/maxzhirnov/go-task-manager/internal/models"
+	"github.com/maxzhirnov/go-task-manager/pkg/analytics"
+	"github.com/maxzhirnov/go-task-manager/pkg/database"
+)
+
+// BoardHandler shows tasks as a board with one column per workflow status
+// and moves tasks between and within the columns.
+type BoardHandler struct {
+	// DB provides database access for board operations
+	DB        database.DB
+	analytics analytics.Tracker
+}
+
+// NewBoardHandler creates a new instance of BoardHandler.
+//
+// Parameters:
+//   - db: Database interface for board operations
+//   - analytics: Tracker for board events
+//
+// Returns:
+//   - *BoardHandler: Configured board handler
+func NewBoardHandler(db database.DB, analytics analytics.Tracker) *BoardHandler {
+	return &BoardHandler{
+		DB:        db,
+		analytics: analytics,
+	}
+}
+
+// GetBoard retrieves the board of a project or of the inbox: its top-level
+// tasks grouped by status, one column per status of the user's workflow.
+//
+// Query Parameters:
+//   - project_id: Project to show; omitted or "inbox" shows tasks without
+//     a project
+//
+// HTTP Responses:
+//   - 200 OK: Successfully retrieved board
+//   - 400 Bad Request: Invalid project ID
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 404 Not Found: Project doesn't exist
+//   - 500 Internal Server Error: Database or server errors
+//
+// Example success response:
+//
+//	{
+//	    "project_id": 3,
+//	    "columns": [
+//	        {"key": "pending", "name": "Pending", "category": "todo", "wip_limit": null, "tasks": [...]},
+//	        {"key": "in_progress", "name": "In progress", "category": "doing", "wip_limit": 3, "tasks": [...]},
+//	        {"key": "completed", "name": "Completed", "category": "done", "wip_limit": null, "tasks": [...]}
+//	    ]
+//	}
+func (h *BoardHandler) GetBoard(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var projectID *int
+	if value := r.URL.Query().Get("project_id"); value != "" && value != "inbox" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			JSONError(w, "Invalid project ID", http.StatusBadRequest)
+			return
+		}
+		projectID = &id
+	}
+
+	board, err := models.GetBoard(h.DB, claims.UserID, projectID)
+	if err != nil {
+		if err.Error() == "project not found" {
+			JSONError(w, "Project not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error fetching board for user %d: %v", claims.UserID, err)
+		JSONError(w, "Failed to fetch board", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(board)
+}
+
+// MoveTask moves a task to a column of its board and a place within that
+// column. The status and the position change together or not at all.
+//
+// URL Parameters:
+//   - id: Task identifier (integer)
+//
+// Headers:
+//   - If-Match: Optional ETag from GetTask; the task is only moved when it
+//     has not changed since
+//
+// Query Parameters:
+//   - ignore_blockers: "true" lets a blocked task move to a doing or done
+//     column anyway
+//
+// Request Body:
+//
+//	{
+//	    "status": "in_progress",   // Optional: target column, omitted keeps the column
+//	    "before": 12               // Optional: one of "before", "after" or "position"
+//	}
+//
+// "before" and "after" name a task of the target column, "position" is the
+// zero-based index within the column; without any the task goes to the top.
+//
+// HTTP Responses:
+//   - 200 OK: Successfully moved task, returned in the body
+//   - 400 Bad Request: Invalid task ID, body, move, status, If-Match header
+//     or ignore_blockers value
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 404 Not Found: Task doesn't exist
+//   - 409 Conflict: The target column is at its WIP limit, or the task is
+//     blocked by unfinished tasks
+//   - 412 Precondition Failed: Task was modified; the body holds the current task
+//   - 422 Unprocessable Entity: The workflow doesn't allow the transition
+//   - 500 Internal Server Error: Database or server errors
+func (h *BoardHandler) MoveTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := ctx.Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Extract and validate task ID from URL parameters
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Printf("Invalid task ID format: %s", vars["id"])
+		JSONError(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+
+	var move models.BoardMove
+	if err := json.NewDecoder(r.Body).Decode(&move); err != nil {
+		log.Printf("Error decoding board move: %v", err)
+		JSONError(w, "Invalid input data", http.StatusBadRequest)
+		return
+	}
+	if move.Version, err = parseIfMatch(r); err != nil {
+		JSONError(w, "Invalid If-Match header", http.StatusBadRequest)
+		return
+	}
+	if move.IgnoreBlockers, err = parseIgnoreBlockers(r); err != nil {
+		JSONError(w, "Invalid ignore_blockers value", http.StatusBadRequest)
+		return
+	}
+
+	task, err := models.MoveTask(h.DB, claims.UserID, id, move)
+	if err != nil {
+		log.Printf("Error moving task %d: %v", id, err)
+		h.analytics.Track(ctx, "Task Move Failed", strconv.Itoa(claims.UserID), map[string]any{
+			"reason":  "move_failed",
+			"error":   err.Error(),
+			"task_id": id,
+			"user_id": claims.UserID,
+		})
+		switch {
+		case err.Error() == "task not found":
+			JSONError(w, "Task not found", http.StatusNotFound)
+		case err.Error() == "version conflict":
+			current, err := models.GetTask(h.DB, id)
+			if err != nil {
+				log.Printf("Error retrieving task %d after version conflict: %v", id, err)
+				JSONError(w, "Failed to move task", http.StatusInternalServerError)
+				return
+			}
+			writePreconditionFailed(w, current)
+		case strings.HasPrefix(err.Error(), "invalid move"), strings.HasPrefix(err.Error(), "invalid status"):
+			JSONError(w, err.Error(), http.StatusBadRequest)
+		case strings.HasPrefix(err.Error(), "wip limit reached"):
+			JSONError(w, err.Error(), http.StatusConflict)
+		case err.Error() == "task is blocked":
+			JSONError(w, "Task is blocked by unfinished tasks", http.StatusConflict)
+		case err.Error() == "status transition not allowed":
+			JSONError(w, err.Error(), http.StatusUnprocessableEntity)
+		default:
+			JSONError(w, "Failed to move task", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.analytics.Track(ctx, "Task Moved On Board", strconv.Itoa(claims.UserID), map[string]any{
+		"task_id":    id,
+		"user_id":    claims.UserID,
+		"new_status": task.Status,
+	})
+	log.Printf("Successfully moved task ID: %d", id)
+
+	w.Header().Set("ETag", taskETag(task))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(task)
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/maxzhirnov/go-task-manager/internal/middleware"
+	"github.com/maxzhirnov/go-task-manager/pkg/analytics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetBoard(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		mockSetup      func(sqlmock.Sqlmock)
+		expectedStatus int
+	}{
+		{
+			name:  "Inbox board",
+			query: "",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				expectDefaultWorkflow(mock)
+				mock.ExpectQuery("SELECT (.+) FROM (.+) tasks WHERE user_id = \\$1 (.+) project_id IS NULL").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(taskColumnNames).
+						AddRow(newTaskRow(1, "Task", "", "in_progress", 1, 0)...))
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "Foreign project",
+			query: "?project_id=9",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT EXISTS").
+					WithArgs(9, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Invalid project ID",
+			query:          "?project_id=abc",
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			tt.mockSetup(mock)
+
+			handler := NewBoardHandler(db, analytics.NewMock("test-key", false))
+			req, err := http.NewRequest("GET", "/api/board"+tt.query, nil)
+			assert.NoError(t, err)
+			req = req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
+
+			rr := httptest.NewRecorder()
+			handler.GetBoard(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var board struct {
+					Columns []struct {
+						Key   string            `json:"key"`
+						Tasks []json.RawMessage `json:"tasks"`
+					} `json:"columns"`
+				}
+				assert.NoError(t, json.NewDecoder(rr.Body).Decode(&board))
+				assert.Len(t, board.Columns, 3)
+				assert.Equal(t, "in_progress", board.Columns[1].Key)
+				assert.Len(t, board.Columns[1].Tasks, 1)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestMoveTask(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		mockSetup      func(sqlmock.Sqlmock)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name: "Column at its WIP limit",
+			body: `{"status": "in_progress"}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				row := newTaskRow(5, "Task", "", "pending", 1, 0)
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1 AND user_id = \\$2").
+					WithArgs(5, 1).
+					WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(row...))
+				mock.ExpectQuery("SELECT id, rank, status FROM tasks").
+					WithArgs(1, nil, nil).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "rank", "status"}).
+						AddRow(5, "i", "pending"))
+				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1 FOR UPDATE").
+					WithArgs(5).
+					WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(row...))
+				mock.ExpectQuery("FROM workflow_statuses").
+					WillReturnRows(sqlmock.NewRows([]string{"key", "name", "category", "transitions", "wip_limit"}).
+						AddRow("pending", "Pending", "todo", nil, nil).
+						AddRow("in_progress", "In progress", "doing", nil, 2).
+						AddRow("completed", "Completed", "done", nil, nil))
+				mock.ExpectExec("SELECT id FROM users WHERE id = \\$1 FOR UPDATE").
+					WithArgs(1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT COUNT").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+				mock.ExpectRollback()
+			},
+			expectedStatus: http.StatusConflict,
+			expectedError:  "wip limit reached: in_progress allows 2 tasks",
+		},
+		{
+			name: "Task not found",
+			body: `{"status": "in_progress", "position": 0}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1 AND user_id = \\$2").
+					WithArgs(5, 1).
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "Task not found",
+		},
+		{
+			name:           "Several destinations",
+			body:           `{"before": 3, "after": 4}`,
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid move: at most one of position, before or after is allowed",
+		},
+		{
+			name:           "Invalid JSON",
+			body:           `{"status":`,
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "Invalid input data",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			tt.mockSetup(mock)
+
+			handler := NewBoardHandler(db, analytics.NewMock("test-key", false))
+			req, err := http.NewRequest("POST", "/api/tasks/5/move", strings.NewReader(tt.body))
+			assert.NoError(t, err)
+			req = mux.SetURLVars(req, map[string]string{"id": "5"})
+			req = req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
+
+			rr := httptest.NewRecorder()
+			handler.MoveTask(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			var response map[string]interface{}
+			assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+			assert.Equal(t, tt.expectedError, response["error"])
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
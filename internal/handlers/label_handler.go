@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/maxzhirnov/go-task-manager/internal/middleware"
+	"github.com/maxzhirnov/go-task-manager/internal/models"
+	"github.com/maxzhirnov/go-task-manager/pkg/analytics"
+	"github.com/maxzhirnov/go-task-manager/pkg/database"
+)
+
+// LabelHandler manages label-related HTTP requests.
+// Labels are scoped to the authenticated user and can be attached to
+// tasks through the label_ids field of the task endpoints.
+type LabelHandler struct {
+	// DB provides database access for label operations
+	DB        database.DB
+	analytics analytics.Tracker
+}
+
+// NewLabelHandler creates a new instance of LabelHandler.
+//
+// Parameters:
+//   - db: Database interface for label operations
+//   - analytics: Tracker for label events
+//
+// Returns:
+//   - *LabelHandler: Configured label handler
+func NewLabelHandler(db database.DB, analytics analytics.Tracker) *LabelHandler {
+	return &LabelHandler{
+		DB:        db,
+		analytics: analytics,
+	}
+}
+
+// GetLabels retrieves all labels of the authenticated user.
+//
+// HTTP Responses:
+//   - 200 OK: Successfully retrieved labels
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 500 Internal Server Error: Database or server errors
+//
+// Example success response:
+//
+//	[
+//	    {
+//	        "id": 1,
+//	        "user_id": 123,
+//	        "name": "work",
+//	        "color": "#ff8800",
+//	        "created_at": "2024-01-01T12:00:00Z",
+//	        "updated_at": "2024-01-01T12:00:00Z"
+//	    }
+//	]
+func (h *LabelHandler) GetLabels(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	labels, err := models.GetLabels(h.DB, claims.UserID)
+	if err != nil {
+		log.Printf("Error fetching labels for user %d: %v", claims.UserID, err)
+		JSONError(w, "Failed to fetch labels", http.StatusInternalServerError)
+		return
+	}
+
+	// Ensure null is never returned for labels array
+	if labels == nil {
+		labels = []models.Label{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(labels)
+}
+
+// CreateLabel creates a new label for the authenticated user.
+//
+// Request Body:
+//
+//	{
+//	    "name": "work",      // Required, unique per user
+//	    "color": "#ff8800"   // Optional, defaults to "#808080"
+//	}
+//
+// HTTP Responses:
+//   - 201 Created: Successfully created label
+//   - 400 Bad Request: Invalid input data
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 409 Conflict: A label with the same name exists
+//   - 500 Internal Server Error: Database or server errors
+func (h *LabelHandler) CreateLabel(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := ctx.Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var label models.Label
+	if err := json.NewDecoder(r.Body).Decode(&label); err != nil {
+		log.Printf("Error decoding label: %v", err)
+		JSONError(w, "Invalid input data", http.StatusBadRequest)
+		return
+	}
+
+	label.UserID = claims.UserID
+	if err := label.Validate(); err != nil {
+		JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := label.CreateLabel(h.DB); err != nil {
+		log.Printf("Error creating label for user %d: %v", claims.UserID, err)
+		if err.Error() == "label already exists" {
+			JSONError(w, "Label already exists", http.StatusConflict)
+			return
+		}
+		JSONError(w, "Failed to create label", http.StatusInternalServerError)
+		return
+	}
+
+	h.analytics.Track(ctx, "Label Created", strconv.Itoa(claims.UserID), map[string]any{
+		"user_id":  claims.UserID,
+		"label_id": label.ID,
+	})
+	log.Printf("Successfully created label ID: %d for user ID: %d", label.ID, claims.UserID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(label)
+}
+
+// UpdateLabel renames or recolors a label of the authenticated user.
+//
+// URL Parameters:
+//   - id: Label identifier (integer)
+//
+// Request Body:
+//
+//	{
+//	    "name": "personal",  // Optional, keeps the current name when omitted
+//	    "color": "#00aa00"   // Optional, keeps the current color when omitted
+//	}
+//
+// HTTP Responses:
+//   - 200 OK: Successfully updated label
+//   - 400 Bad Request: Invalid label ID or input data
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 404 Not Found: Label doesn't exist
+//   - 409 Conflict: A label with the same name exists
+//   - 500 Internal Server Error: Database or server errors
+func (h *LabelHandler) UpdateLabel(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := ctx.Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		JSONError(w, "Invalid label ID", http.StatusBadRequest)
+		return
+	}
+
+	// Load current label so omitted fields keep their values
+	label, err := models.GetLabel(h.DB, claims.UserID, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			JSONError(w, "Label not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error retrieving label %d: %v", id, err)
+		JSONError(w, "Failed to update label", http.StatusInternalServerError)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&label); err != nil {
+		JSONError(w, "Invalid input data", http.StatusBadRequest)
+		return
+	}
+	label.ID = id
+	label.UserID = claims.UserID
+
+	if err := label.Validate(); err != nil {
+		JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := label.UpdateLabel(h.DB); err != nil {
+		log.Printf("Error updating label %d: %v", id, err)
+		switch err.Error() {
+		case "label not found":
+			JSONError(w, "Label not found", http.StatusNotFound)
+		case "label already exists":
+			JSONError(w, "Label already exists", http.StatusConflict)
+		default:
+			JSONError(w, "Failed to update label", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.analytics.Track(ctx, "Label Updated", strconv.Itoa(claims.UserID), map[string]any{
+		"user_id":  claims.UserID,
+		"label_id": label.ID,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(label)
+}
+
+// DeleteLabel permanently removes a label and detaches it from all tasks.
+//
+// URL Parameters:
+//   - id: Label identifier (integer)
+//
+// HTTP Responses:
+//   - 204 No Content: Successfully deleted label
+//   - 400 Bad Request: Invalid label ID format
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 404 Not Found: Label doesn't exist
+//   - 500 Internal Server Error: Database or server errors
+func (h *LabelHandler) DeleteLabel(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := ctx.Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		JSONError(w, "Invalid label ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := models.DeleteLabel(h.DB, claims.UserID, id); err != nil {
+		log.Printf("Error deleting label %d: %v", id, err)
+		if err.Error() == "label not found" {
+			JSONError(w, "Label not found", http.StatusNotFound)
+			return
+		}
+		JSONError(w, "Failed to delete label", http.StatusInternalServerError)
+		return
+	}
+
+	h.analytics.Track(ctx, "Label Deleted", strconv.Itoa(claims.UserID), map[string]any{
+		"user_id":  claims.UserID,
+		"label_id": id,
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
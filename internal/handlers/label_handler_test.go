@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+	"github.com/maxzhirnov/go-task-manager/internal/middleware"
+	"github.com/maxzhirnov/go-task-manager/pkg/analytics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateLabel(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		mockSetup      func(sqlmock.Sqlmock)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name: "Successful creation",
+			body: `{"name": "work", "color": "#ff8800"}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO labels").
+					WithArgs(1, "work", "#ff8800", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "Invalid color",
+			body:           `{"name": "work", "color": "orange"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid color: orange",
+		},
+		{
+			name:           "Missing name",
+			body:           `{"color": "#ff8800"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "label name is required",
+		},
+		{
+			name: "Duplicate name",
+			body: `{"name": "work"}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO labels").
+					WithArgs(1, "work", "#808080", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnError(&pq.Error{Code: "23505"})
+			},
+			expectedStatus: http.StatusConflict,
+			expectedError:  "Label already exists",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			if tt.mockSetup != nil {
+				tt.mockSetup(mock)
+			}
+
+			handler := NewLabelHandler(db, analytics.NewMock("test-key", false))
+			req, err := http.NewRequest("POST", "/api/labels", bytes.NewBufferString(tt.body))
+			assert.NoError(t, err)
+			req = req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
+
+			rr := httptest.NewRecorder()
+			handler.CreateLabel(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedError != "" {
+				var response map[string]string
+				assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+				assert.Equal(t, tt.expectedError, response["error"])
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDeleteLabelNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec("DELETE FROM labels").
+		WithArgs(9, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	handler := NewLabelHandler(db, analytics.NewMock("test-key", false))
+	req, err := http.NewRequest("DELETE", "/api/labels/9", nil)
+	assert.NoError(t, err)
+	req = mux.SetURLVars(req, map[string]string{"id": "9"})
+	req = req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
+
+	rr := httptest.NewRecorder()
+	handler.DeleteLabel(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
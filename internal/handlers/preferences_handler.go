@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/maxzhirnov/go-task-manager/internal/middleware"
+	"github.com/maxzhirnov/go-task-manager/internal/models"
+	"github.com/maxzhirnov/go-task-manager/pkg/analytics"
+	"github.com/maxzhirnov/go-task-manager/pkg/database"
+)
+
+// PreferencesHandler manages the personal settings of the authenticated
+// user: the time zone statistics and due dates are calculated in, the
+// language of emails, the first day of the week and the date format.
+type PreferencesHandler struct {
+	// DB provides database access for preference operations
+	DB        database.DB
+	analytics analytics.Tracker
+}
+
+// NewPreferencesHandler creates a new instance of PreferencesHandler.
+//
+// Parameters:
+//   - db: Database interface for preference operations
+//   - analytics: Tracker for preference events
+//
+// Returns:
+//   - *PreferencesHandler: Configured preferences handler
+func NewPreferencesHandler(db database.DB, analytics analytics.Tracker) *PreferencesHandler {
+	return &PreferencesHandler{
+		DB:        db,
+		analytics: analytics,
+	}
+}
+
+// GetPreferences retrieves the preferences of the authenticated user.
+// Users who never saved preferences receive the defaults.
+//
+// HTTP Responses:
+//   - 200 OK: Successfully retrieved preferences
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 500 Internal Server Error: Database or server errors
+//
+// Example success response:
+//
+//	{
+//	    "user_id": 123,
+//	    "time_zone": "Europe/Berlin",
+//	    "locale": "en",
+//	    "week_start": "monday",
+//	    "date_format": "DD.MM.YYYY",
+//	    "updated_at": "2024-05-20T08:00:00Z"
+//	}
+func (h *PreferencesHandler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	prefs, err := models.GetPreferences(h.DB, claims.UserID)
+	if err != nil {
+		log.Printf("Error fetching preferences for user %d: %v", claims.UserID, err)
+		JSONError(w, "Failed to fetch preferences", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(prefs)
+}
+
+// UpdatePreferences changes the preferences of the authenticated user.
+// Fields omitted from the request keep their current values.
+//
+// Request Body:
+//
+//	{
+//	    "time_zone": "Europe/Berlin",   // IANA time zone name
+//	    "locale": "ru",                 // Email language: "en" or "ru"
+//	    "week_start": "sunday",         // "monday" or "sunday"
+//	    "date_format": "DD.MM.YYYY"     // "YYYY-MM-DD", "DD.MM.YYYY", "DD/MM/YYYY" or "MM/DD/YYYY"
+//	}
+//
+// HTTP Responses:
+//   - 200 OK: Successfully updated preferences
+//   - 400 Bad Request: Invalid input data or an unsupported value
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 500 Internal Server Error: Database or server errors
+func (h *PreferencesHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := ctx.Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	prefs, err := models.GetPreferences(h.DB, claims.UserID)
+	if err != nil {
+		log.Printf("Error fetching preferences for user %d: %v", claims.UserID, err)
+		JSONError(w, "Failed to fetch preferences", http.StatusInternalServerError)
+		return
+	}
+
+	// Decode over the current preferences so omitted fields are kept
+	if err := json.NewDecoder(r.Body).Decode(&prefs); err != nil {
+		log.Printf("Error decoding preferences: %v", err)
+		JSONError(w, "Invalid input data", http.StatusBadRequest)
+		return
+	}
+
+	prefs.UserID = claims.UserID
+	if err := prefs.Validate(); err != nil {
+		JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := prefs.SavePreferences(h.DB); err != nil {
+		log.Printf("Error saving preferences for user %d: %v", claims.UserID, err)
+		JSONError(w, "Failed to update preferences", http.StatusInternalServerError)
+		return
+	}
+
+	h.analytics.Track(ctx, "Preferences Updated", strconv.Itoa(claims.UserID), map[string]any{
+		"user_id":     claims.UserID,
+		"time_zone":   prefs.TimeZone,
+		"locale":      prefs.Locale,
+		"week_start":  prefs.WeekStart,
+		"date_format": prefs.DateFormat,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(prefs)
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/maxzhirnov/go-task-manager/internal/middleware"
+	"github.com/maxzhirnov/go-task-manager/pkg/analytics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdatePreferences(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		mockSetup      func(sqlmock.Sqlmock)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name: "Successful update",
+			body: `{"time_zone": "Europe/Berlin", "locale": "ru", "date_format": "DD.MM.YYYY"}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM user_preferences").
+					WithArgs(1).
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectExec("INSERT INTO user_preferences").
+					WithArgs(1, "Europe/Berlin", "ru", "monday", "DD.MM.YYYY", sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Unknown time zone",
+			body: `{"time_zone": "Berlin"}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM user_preferences").
+					WithArgs(1).
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid time zone: Berlin",
+		},
+		{
+			name: "Unsupported locale",
+			body: `{"locale": "xx"}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM user_preferences").
+					WithArgs(1).
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid locale: xx",
+		},
+		{
+			name: "Invalid JSON",
+			body: `{"time_zone":`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM user_preferences").
+					WithArgs(1).
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "Invalid input data",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			tt.mockSetup(mock)
+
+			handler := NewPreferencesHandler(db, analytics.NewMock("test-key", false))
+			req, err := http.NewRequest("PUT", "/api/preferences", bytes.NewBufferString(tt.body))
+			assert.NoError(t, err)
+			req = req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
+
+			rr := httptest.NewRecorder()
+			handler.UpdatePreferences(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			var response map[string]interface{}
+			assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+			if tt.expectedError != "" {
+				assert.Equal(t, tt.expectedError, response["error"])
+			} else {
+				assert.Equal(t, "Europe/Berlin", response["time_zone"])
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,384 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/maxzhirnov/go-task-manager/internal/middleware"
+	"github.com/maxzhirnov/go-task-manager/internal/models"
+	"github.com/maxzhirnov/go-task-manager/pkg/analytics"
+	"github.com/maxzhirnov/go-task-manager/pkg/database"
+)
+
+// ProjectHandler manages project-related HTTP requests.
+// Projects are scoped to the authenticated user; tasks join a project
+// through the project_id field of the task endpoints.
+type ProjectHandler struct {
+	// DB provides database access for project operations
+	DB        database.DB
+	analytics analytics.Tracker
+}
+
+// NewProjectHandler creates a new instance of ProjectHandler.
+//
+// Parameters:
+//   - db: Database interface for project operations
+//   - analytics: Tracker for project events
+//
+// Returns:
+//   - *ProjectHandler: Configured project handler
+func NewProjectHandler(db database.DB, analytics analytics.Tracker) *ProjectHandler {
+	return &ProjectHandler{
+		DB:        db,
+		analytics: analytics,
+	}
+}
+
+// GetProjects retrieves all projects of the authenticated user.
+//
+// HTTP Responses:
+//   - 200 OK: Successfully retrieved projects
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 500 Internal Server Error: Database or server errors
+//
+// Example success response:
+//
+//	[
+//	    {
+//	        "id": 1,
+//	        "user_id": 123,
+//	        "name": "Home",
+//	        "description": "Chores and errands",
+//	        "color": "#00aa00",
+//	        "created_at": "2024-01-01T12:00:00Z",
+//	        "updated_at": "2024-01-01T12:00:00Z"
+//	    }
+//	]
+func (h *ProjectHandler) GetProjects(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	projects, err := models.GetProjects(h.DB, claims.UserID)
+	if err != nil {
+		log.Printf("Error fetching projects for user %d: %v", claims.UserID, err)
+		JSONError(w, "Failed to fetch projects", http.StatusInternalServerError)
+		return
+	}
+
+	// Ensure null is never returned for projects array
+	if projects == nil {
+		projects = []models.Project{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(projects)
+}
+
+// GetProject retrieves a single project of the authenticated user.
+//
+// URL Parameters:
+//   - id: Project identifier (integer)
+//
+// HTTP Responses:
+//   - 200 OK: Successfully retrieved project
+//   - 400 Bad Request: Invalid project ID format
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 404 Not Found: Project doesn't exist
+//   - 500 Internal Server Error: Database or server errors
+func (h *ProjectHandler) GetProject(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		JSONError(w, "Invalid project ID", http.StatusBadRequest)
+		return
+	}
+
+	project, err := models.GetProject(h.DB, claims.UserID, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			JSONError(w, "Project not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error retrieving project %d: %v", id, err)
+		JSONError(w, "Failed to fetch project", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(project)
+}
+
+// CreateProject creates a new project for the authenticated user.
+//
+// Request Body:
+//
+//	{
+//	    "name": "Home",                      // Required, unique per user
+//	    "description": "Chores and errands", // Optional
+//	    "color": "#00aa00"                   // Optional, defaults to "#808080"
+//	}
+//
+// HTTP Responses:
+//   - 201 Created: Successfully created project
+//   - 400 Bad Request: Invalid input data
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 409 Conflict: A project with the same name exists
+//   - 500 Internal Server Error: Database or server errors
+func (h *ProjectHandler) CreateProject(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := ctx.Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var project models.Project
+	if err := json.NewDecoder(r.Body).Decode(&project); err != nil {
+		log.Printf("Error decoding project: %v", err)
+		JSONError(w, "Invalid input data", http.StatusBadRequest)
+		return
+	}
+
+	project.UserID = claims.UserID
+	if err := project.Validate(); err != nil {
+		JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := project.CreateProject(h.DB); err != nil {
+		log.Printf("Error creating project for user %d: %v", claims.UserID, err)
+		if err.Error() == "project already exists" {
+			JSONError(w, "Project already exists", http.StatusConflict)
+			return
+		}
+		JSONError(w, "Failed to create project", http.StatusInternalServerError)
+		return
+	}
+
+	h.analytics.Track(ctx, "Project Created", strconv.Itoa(claims.UserID), map[string]any{
+		"user_id":    claims.UserID,
+		"project_id": project.ID,
+	})
+	log.Printf("Successfully created project ID: %d for user ID: %d", project.ID, claims.UserID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(project)
+}
+
+// UpdateProject modifies the name, description or color of a project.
+//
+// URL Parameters:
+//   - id: Project identifier (integer)
+//
+// Request Body:
+//
+//	{
+//	    "name": "Household",   // Optional, keeps the current name when omitted
+//	    "description": "",     // Optional, keeps the current description when omitted
+//	    "color": "#00aa00"     // Optional, keeps the current color when omitted
+//	}
+//
+// HTTP Responses:
+//   - 200 OK: Successfully updated project
+//   - 400 Bad Request: Invalid project ID or input data
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 404 Not Found: Project doesn't exist
+//   - 409 Conflict: A project with the same name exists
+//   - 500 Internal Server Error: Database or server errors
+func (h *ProjectHandler) UpdateProject(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := ctx.Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		JSONError(w, "Invalid project ID", http.StatusBadRequest)
+		return
+	}
+
+	// Load current project so omitted fields keep their values
+	project, err := models.GetProject(h.DB, claims.UserID, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			JSONError(w, "Project not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error retrieving project %d: %v", id, err)
+		JSONError(w, "Failed to update project", http.StatusInternalServerError)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&project); err != nil {
+		JSONError(w, "Invalid input data", http.StatusBadRequest)
+		return
+	}
+	project.ID = id
+	project.UserID = claims.UserID
+
+	if err := project.Validate(); err != nil {
+		JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := project.UpdateProject(h.DB); err != nil {
+		log.Printf("Error updating project %d: %v", id, err)
+		switch err.Error() {
+		case "project not found":
+			JSONError(w, "Project not found", http.StatusNotFound)
+		case "project already exists":
+			JSONError(w, "Project already exists", http.StatusConflict)
+		default:
+			JSONError(w, "Failed to update project", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.analytics.Track(ctx, "Project Updated", strconv.Itoa(claims.UserID), map[string]any{
+		"user_id":    claims.UserID,
+		"project_id": project.ID,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(project)
+}
+
+// DeleteProject permanently removes a project. Its tasks are kept and
+// moved to the end of the user's inbox.
+//
+// URL Parameters:
+//   - id: Project identifier (integer)
+//
+// HTTP Responses:
+//   - 204 No Content: Successfully deleted project
+//   - 400 Bad Request: Invalid project ID format
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 404 Not Found: Project doesn't exist
+//   - 500 Internal Server Error: Database or server errors
+func (h *ProjectHandler) DeleteProject(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := ctx.Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		JSONError(w, "Invalid project ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := models.DeleteProject(h.DB, claims.UserID, id); err != nil {
+		log.Printf("Error deleting project %d: %v", id, err)
+		if err.Error() == "project not found" {
+			JSONError(w, "Project not found", http.StatusNotFound)
+			return
+		}
+		JSONError(w, "Failed to delete project", http.StatusInternalServerError)
+		return
+	}
+
+	h.analytics.Track(ctx, "Project Deleted", strconv.Itoa(claims.UserID), map[string]any{
+		"user_id":    claims.UserID,
+		"project_id": id,
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetProjectsStatistics retrieves task statistics for every project
+// of the authenticated user.
+//
+// HTTP Responses:
+//   - 200 OK: Successfully retrieved statistics
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 500 Internal Server Error: Database or server errors
+//
+// Example success response:
+//
+//	[
+//	    {
+//	        "project_id": 1,
+//	        "name": "Home",
+//	        "total_tasks": 10,
+//	        "completed_tasks": 5,
+//	        "pending_tasks": 3,
+//	        "in_progress_tasks": 2,
+//	        "deleted_tasks": 0,
+//	        "overdue_tasks": 1,
+//	        "completion_rate": 50.00
+//	    }
+//	]
+func (h *ProjectHandler) GetProjectsStatistics(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	stats, err := models.GetProjectStatistics(h.DB, claims.UserID, nil)
+	if err != nil {
+		log.Printf("Error fetching project statistics for user %d: %v", claims.UserID, err)
+		JSONError(w, "Failed to fetch statistics", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// GetProjectStatistics retrieves task statistics for a single project
+// of the authenticated user.
+//
+// URL Parameters:
+//   - id: Project identifier (integer)
+//
+// HTTP Responses:
+//   - 200 OK: Successfully retrieved statistics
+//   - 400 Bad Request: Invalid project ID format
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 404 Not Found: Project doesn't exist
+//   - 500 Internal Server Error: Database or server errors
+func (h *ProjectHandler) GetProjectStatistics(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		JSONError(w, "Invalid project ID", http.StatusBadRequest)
+		return
+	}
+
+	stats, err := models.GetProjectStatistics(h.DB, claims.UserID, &id)
+	if err != nil {
+		log.Printf("Error fetching statistics for project %d: %v", id, err)
+		JSONError(w, "Failed to fetch statistics", http.StatusInternalServerError)
+		return
+	}
+	if len(stats) == 0 {
+		JSONError(w, "Project not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats[0])
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+	"github.com/maxzhirnov/go-task-manager/internal/middleware"
+	"github.com/maxzhirnov/go-task-manager/pkg/analytics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateProject(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		mockSetup      func(sqlmock.Sqlmock)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name: "Successful creation",
+			body: `{"name": "Home", "description": "Chores"}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO projects").
+					WithArgs(1, "Home", "Chores", "#808080", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "Missing name",
+			body:           `{"description": "Chores"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "project name is required",
+		},
+		{
+			name: "Duplicate name",
+			body: `{"name": "Home"}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO projects").
+					WithArgs(1, "Home", "", "#808080", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnError(&pq.Error{Code: "23505"})
+			},
+			expectedStatus: http.StatusConflict,
+			expectedError:  "Project already exists",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			if tt.mockSetup != nil {
+				tt.mockSetup(mock)
+			}
+
+			handler := NewProjectHandler(db, analytics.NewMock("test-key", false))
+			req, err := http.NewRequest("POST", "/api/projects", bytes.NewBufferString(tt.body))
+			assert.NoError(t, err)
+			req = req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
+
+			rr := httptest.NewRecorder()
+			handler.CreateProject(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedError != "" {
+				var response map[string]string
+				assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+				assert.Equal(t, tt.expectedError, response["error"])
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetProjectStatisticsNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT (.+) FROM project_statistics").
+		WithArgs(1, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"project_id", "name", "total_tasks", "completed_tasks", "pending_tasks",
+			"in_progress_tasks", "deleted_tasks", "overdue_tasks", "completion_rate",
+		}))
+
+	handler := NewProjectHandler(db, analytics.NewMock("test-key", false))
+	req, err := http.NewRequest("GET", "/api/projects/9/statistics", nil)
+	assert.NoError(t, err)
+	req = mux.SetURLVars(req, map[string]string{"id": "9"})
+	req = req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
+
+	rr := httptest.NewRecorder()
+	handler.GetProjectStatistics(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -9,17 +9,26 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
+	"mime"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/maxzhirnov/go-task-manager/internal/middleware"
 	"github.com/maxzhirnov/go-task-manager/internal/models"
 	"github.com/maxzhirnov/go-task-manager/pkg/analytics"
 	"github.com/maxzhirnov/go-task-manager/pkg/database"
+	"github.com/maxzhirnov/go-task-manager/pkg/jsonpatch"
 )
 
 // TaskHandler manages task-related HTTP requests.
@@ -53,8 +62,34 @@ func NewTaskHandler(db database.DB, analytics analytics.Tracker) *TaskHandler {
 // Authorization:
 //   - Requires valid JWT token in request context
 //
+// Query Parameters:
+//   - overdue: "true" to return only active tasks past their due date
+//   - priority: Comma-separated priorities to include (e.g. "high,urgent")
+//   - labels: Comma-separated label IDs (e.g. "1,4")
+//   - label_mode: "any" (default) to match any of the labels, "all" to require every label
+//   - project_id: Project ID to list, or "inbox" for tasks without a project
+//   - parent_id: Task ID whose direct subtasks to list; top-level tasks are listed by default
+//   - status: Comma-separated statuses to include (e.g. "pending,in_progress")
+//   - created_after, created_before: Creation time range, RFC 3339 timestamps or dates (YYYY-MM-DD)
+//   - updated_after, updated_before: Modification time range in the same format
+//   - q: Text that the title or description must contain, ignoring case
+//   - sort: "position" (default), "priority", "created_at", "updated_at" or "title"
+//   - order: "asc" or "desc" to override the natural direction of the sort
+//   - limit: Page size up to 200; all tasks are returned when omitted
+//   - cursor: Value of X-Next-Cursor from the previous page
+//
+// Pagination:
+//
+// When more tasks follow the page, the response carries the cursor of the
+// next page in the X-Next-Cursor header and a Link header with rel="next"
+// pointing at the same request with the cursor applied.
+//
+// The ETag header identifies the returned page; it changes whenever a listed
+// task is modified.
+//
 // HTTP Responses:
 //   - 200 OK: Successfully retrieved tasks
+//   - 400 Bad Request: Invalid query parameters
 //   - 401 Unauthorized: Missing or invalid JWT token
 //   - 500 Internal Server Error: Database or server errors
 //
@@ -66,8 +101,13 @@ func NewTaskHandler(db database.DB, analytics analytics.Tracker) *TaskHandler {
 //	        "title": "Complete project",
 //	        "description": "Finish the task manager project",
 //	        "status": "pending",
+//	        "priority": "high",
 //	        "user_id": 123,
-//	        "position": 1
+//	        "position": 1,
+//	        "due_at": "2024-01-05T00:00:00Z",
+//	        "all_day": true,
+//	        "overdue": false,
+//	        "labels": [{"id": 4, "name": "work", "color": "#ff8800"}]
 //	    }
 //	]
 func (h *TaskHandler) GetTasks(w http.ResponseWriter, r *http.Request) {
@@ -79,8 +119,16 @@ func (h *TaskHandler) GetTasks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Parse optional filters from query string
+	filter, err := parseTaskFilter(r)
+	if err != nil {
+		log.Printf("Invalid task filter for user %d: %v", claims.UserID, err)
+		JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Fetch tasks from database
-	tasks, err := models.GetTasks(h.DB, claims.UserID)
+	page, err := models.GetTaskPage(h.DB, claims.UserID, filter)
 	if err != nil {
 		log.Printf("Error fetching tasks for user %d: %v", claims.UserID, err)
 		http.Error(w, `{"error": "Failed to fetch tasks"}`, http.StatusInternalServerError)
@@ -88,33 +136,151 @@ func (h *TaskHandler) GetTasks(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Ensure null is never returned for tasks array
+	tasks := page.Tasks
 	if tasks == nil {
 		tasks = []models.Task{}
 	}
 
+	// Advertise the next page
+	if page.NextCursor != "" {
+		next := *r.URL
+		query := next.Query()
+		query.Set("cursor", page.NextCursor)
+		next.RawQuery = query.Encode()
+
+		w.Header().Set("X-Next-Cursor", page.NextCursor)
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.RequestURI()))
+	}
+
 	// Send successful response
+	w.Header().Set("ETag", taskListETag(tasks, page.NextCursor))
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(tasks)
 
 	log.Printf("Successfully retrieved tasks for user %d", claims.UserID)
 }
 
+// SearchTasks performs a full-text search over the authenticated user's tasks.
+//
+// Every word of the query is matched as a prefix against task titles and
+// descriptions. Results are ordered by relevance, with title matches ranking
+// above description matches.
+//
+// Authorization:
+//   - Requires valid JWT token in request context
+//
+// Query Parameters:
+//   - q: Search text (required)
+//   - include_deleted: "true" to also search tasks in the trash
+//   - limit: Maximum number of results, 1-100 (default: 20)
+//
+// HTTP Responses:
+//   - 200 OK: Search results, possibly empty
+//   - 400 Bad Request: Missing query or invalid parameters
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 500 Internal Server Error: Database or server errors
+//
+// Example success response:
+//
+//	[
+//	    {
+//	        "id": 12,
+//	        "title": "Quarterly report",
+//	        "status": "pending",
+//	        ...
+//	        "rank": 0.6079271,
+//	        "title_highlight": "Quarterly <mark>report</mark>",
+//	        "snippet": "Collect numbers for the <mark>report</mark> and send it to finance"
+//	    }
+//	]
+//
+// Note: Highlights and snippets contain the raw task text; clients must
+// escape it before rendering the <mark> tags as HTML.
+func (h *TaskHandler) SearchTasks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := ctx.Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	var opts models.TaskSearchOptions
+
+	if value := query.Get("include_deleted"); value != "" {
+		includeDeleted, err := strconv.ParseBool(value)
+		if err != nil {
+			JSONError(w, "Invalid include_deleted value", http.StatusBadRequest)
+			return
+		}
+		opts.IncludeDeleted = includeDeleted
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			JSONError(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		opts.Limit = limit
+	}
+
+	results, err := models.SearchTasks(h.DB, claims.UserID, query.Get("q"), opts)
+	if err != nil {
+		if err.Error() == "search query is required" {
+			JSONError(w, "Search query is required", http.StatusBadRequest)
+			return
+		}
+		if strings.HasPrefix(err.Error(), "limit must be") {
+			JSONError(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		log.Printf("Error searching tasks for user %d: %v", claims.UserID, err)
+		JSONError(w, "Failed to search tasks", http.StatusInternalServerError)
+		return
+	}
+
+	// Ensure null is never returned for results array
+	if results == nil {
+		results = []models.TaskSearchResult{}
+	}
+
+	h.analytics.Track(ctx, "Tasks Searched", strconv.Itoa(claims.UserID), map[string]any{
+		"user_id":         claims.UserID,
+		"results":         len(results),
+		"include_deleted": opts.IncludeDeleted,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
 // GetTask retrieves a specific task by its ID.
 //
 // It validates the task ID from the URL parameters and ensures the task exists.
-// The handler returns detailed task information in JSON format.
+// The handler returns detailed task information in JSON format, including
+// the dependency graph of the task: the tasks it waits for and the tasks
+// waiting for it, directly or transitively.
 //
 // URL Parameters:
 //   - id: Task identifier (integer)
 //
+// Query Parameters:
+//   - subtasks: "nested" to include descendants as a tree, "flat" to include
+//     them as a depth-first list with depth and parent_id; omitted by default
+//
 // Authorization:
 //   - Requires valid JWT token in request context
-//   - User must have access to the requested task
+//   - User must own the requested task
+//
+// The ETag header carries the task version, to be sent back in If-Match
+// when updating or deleting the task.
 //
 // HTTP Responses:
 //   - 200 OK: Successfully retrieved task
-//   - 400 Bad Request: Invalid task ID format
-//   - 404 Not Found: Task doesn't exist
+//   - 400 Bad Request: Invalid task ID format or subtasks mode
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 404 Not Found: Task doesn't exist or belongs to another user
 //   - 500 Internal Server Error: Database or server errors
 //
 // Example success response:
@@ -126,10 +292,33 @@ func (h *TaskHandler) GetTasks(w http.ResponseWriter, r *http.Request) {
 //	    "status": "pending",
 //	    "user_id": 123,
 //	    "position": 1,
+//	    "subtask_count": 2,
+//	    "completed_subtasks": 1,
+//	    "progress": 50,
 //	    "created_at": "2024-01-01T12:00:00Z",
-//	    "updated_at": "2024-01-01T12:00:00Z"
+//	    "updated_at": "2024-01-01T12:00:00Z",
+//	    "subtasks": [
+//	        {"id": 2, "title": "Write tests", "parent_id": 1, "depth": 1, "subtasks": [...]}
+//	    ],
+//	    "blocked": true,
+//	    "dependencies": {
+//	        "blocked_by": [5],
+//	        "blocks": [],
+//	        "nodes": [
+//	            {"id": 1, "title": "Complete project", "status": "pending", "status_category": "todo"},
+//	            {"id": 4, "title": "Get access", "status": "completed", "status_category": "done"},
+//	            {"id": 5, "title": "Design schema", "status": "in_progress", "status_category": "doing"}
+//	        ],
+//	        "edges": [{"task_id": 1, "blocked_by_id": 5}, {"task_id": 5, "blocked_by_id": 4}]
+//	    }
 //	}
 func (h *TaskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	// Extract and validate task ID from URL parameters
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
@@ -139,6 +328,13 @@ func (h *TaskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Validate requested subtask layout
+	mode := r.URL.Query().Get("subtasks")
+	if mode != "" && mode != models.SubtasksNested && mode != models.SubtasksFlat {
+		JSONError(w, "Invalid subtasks mode", http.StatusBadRequest)
+		return
+	}
+
 	// Retrieve task from database
 	task, err := models.GetTask(h.DB, id)
 	if err != nil {
@@ -154,7 +350,42 @@ func (h *TaskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Tasks of other users are reported as missing
+	if task.UserID != claims.UserID {
+		log.Printf("Task %d requested by user %d belongs to another user", id, claims.UserID)
+		JSONError(w, "Task not found", http.StatusNotFound)
+		return
+	}
+
+	// Load descendants when requested
+	if mode != "" {
+		subtasks, err := models.GetSubtasks(h.DB, id)
+		if err != nil {
+			log.Printf("Error retrieving subtasks of task %d: %v", id, err)
+			JSONError(w, "Failed to fetch subtasks", http.StatusInternalServerError)
+			return
+		}
+		if mode == models.SubtasksNested {
+			task.NestSubtasks(subtasks)
+		} else {
+			task.Subtasks = subtasks
+		}
+		if task.Subtasks == nil {
+			task.Subtasks = []models.Task{}
+		}
+	}
+
+	// Attach the dependency graph
+	graph, err := models.GetDependencyGraph(h.DB, claims.UserID, id)
+	if err != nil {
+		log.Printf("Error retrieving dependencies of task %d: %v", id, err)
+		JSONError(w, "Failed to fetch dependencies", http.StatusInternalServerError)
+		return
+	}
+	task.Dependencies = &graph
+
 	// Send successful response
+	w.Header().Set("ETag", taskETag(task))
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(task)
 
@@ -175,14 +406,24 @@ func (h *TaskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
 //	{
 //	    "title": "Complete project",        // Required
 //	    "description": "Project details",   // Optional
-//	    "status": "pending",               // Optional, defaults to "pending"
-//	    "position": 1                      // Optional
+//	    "status": "pending",               // Optional, defaults to the first todo status of the workflow
+//	    "priority": "high",                // Optional, defaults to "none"
+//	    "estimate_points": 5,              // Optional, story points (0-1000)
+//	    "estimate_minutes": 90,            // Optional, estimated minutes
+//	    "position": 1,                     // Optional
+//	    "start_at": "2024-01-02T09:00:00Z", // Optional
+//	    "due_at": "2024-01-05T00:00:00Z",   // Optional
+//	    "all_day": true,                   // Optional, treat dates as calendar days
+//	    "label_ids": [4, 7],               // Optional, labels to attach
+//	    "parent_id": 12,                   // Optional, creates a subtask
+//	    "recurrence_rule": "FREQ=WEEKLY;BYDAY=MO" // Optional, repeats the task
 //	}
 //
 // HTTP Responses:
 //   - 201 Created: Successfully created task
 //   - 400 Bad Request: Invalid input data or missing required fields
 //   - 401 Unauthorized: Missing or invalid JWT token
+//   - 409 Conflict: The board column of the status is at its WIP limit
 //   - 500 Internal Server Error: Database or server errors
 //
 // Example success response:
@@ -238,10 +479,57 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Set default status if not provided
-	if task.Status == "" {
-		log.Printf("Setting default status 'pending' for task")
-		task.Status = "pending"
+	// Set default priority if not provided
+	if task.Priority == "" {
+		task.Priority = models.PriorityNone
+	}
+
+	// Validate task priority
+	if err := task.ValidatePriority(); err != nil {
+		h.analytics.Track(ctx, "Task Creation Failed", strconv.Itoa(claims.UserID), map[string]any{
+			"reason":  "invalid_priority",
+			"error":   err.Error(),
+			"user_id": claims.UserID,
+		})
+		log.Printf("Task creation failed: %v", err)
+		JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Validate effort estimates
+	if err := task.ValidateEstimate(); err != nil {
+		h.analytics.Track(ctx, "Task Creation Failed", strconv.Itoa(claims.UserID), map[string]any{
+			"reason":  "invalid_estimate",
+			"error":   err.Error(),
+			"user_id": claims.UserID,
+		})
+		log.Printf("Task creation failed: %v", err)
+		JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Validate and normalize schedule
+	if err := task.ValidateDates(); err != nil {
+		h.analytics.Track(ctx, "Task Creation Failed", strconv.Itoa(claims.UserID), map[string]any{
+			"reason":  "invalid_dates",
+			"error":   err.Error(),
+			"user_id": claims.UserID,
+		})
+		log.Printf("Task creation failed: %v", err)
+		JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Validate and normalize recurrence rule
+	if err := task.ValidateRecurrence(); err != nil {
+		h.analytics.Track(ctx, "Task Creation Failed", strconv.Itoa(claims.UserID), map[string]any{
+			"reason":  "invalid_recurrence",
+			"error":   err.Error(),
+			"user_id": claims.UserID,
+		})
+		log.Printf("Task creation failed: %v", err)
+		JSONError(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
 	// Associate task with authenticated user
@@ -250,6 +538,46 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 
 	// Create task in database
 	if err := task.CreateTask(h.DB); err != nil {
+		if strings.HasPrefix(err.Error(), "invalid status") {
+			h.analytics.Track(ctx, "Task Creation Failed", strconv.Itoa(claims.UserID), map[string]any{
+				"reason":  "invalid_status",
+				"error":   err.Error(),
+				"user_id": claims.UserID,
+			})
+			log.Printf("Task creation failed: %v", err)
+			JSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if strings.HasPrefix(err.Error(), "wip limit reached") {
+			h.analytics.Track(ctx, "Task Creation Failed", strconv.Itoa(claims.UserID), map[string]any{
+				"reason":  "wip_limit",
+				"error":   err.Error(),
+				"user_id": claims.UserID,
+			})
+			log.Printf("Task creation failed: %v", err)
+			JSONError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err.Error() == "label not found" {
+			log.Printf("Task creation failed: %v", err)
+			JSONError(w, "Label not found", http.StatusBadRequest)
+			return
+		}
+		if err.Error() == "project not found" {
+			log.Printf("Task creation failed: %v", err)
+			JSONError(w, "Project not found", http.StatusBadRequest)
+			return
+		}
+		if err.Error() == "parent task not found" {
+			log.Printf("Task creation failed: %v", err)
+			JSONError(w, "Parent task not found", http.StatusBadRequest)
+			return
+		}
+		if err.Error() == "maximum subtask depth exceeded" {
+			log.Printf("Task creation failed: %v", err)
+			JSONError(w, "Maximum subtask depth exceeded", http.StatusBadRequest)
+			return
+		}
 		h.analytics.Track(ctx, "Task Creation Failed", strconv.Itoa(claims.UserID), map[string]any{
 			"reason":     "database_error",
 			"error":      err.Error(),
@@ -266,7 +594,11 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 		"task_id":         task.ID,
 		"task_title":      task.Title,
 		"task_status":     task.Status,
+		"task_priority":   task.Priority,
 		"has_description": task.Description != "",
+		"has_due_date":    task.DueAt != nil,
+		"label_count":     len(task.Labels),
+		"is_recurring":    task.RecurrenceRule != "",
 	})
 
 	log.Printf("Successfully created task ID: %d for user ID: %d", task.ID, task.UserID)
@@ -282,8 +614,10 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 // UpdateTask handles the modification of an existing task.
 //
 // It validates the task ID from the URL parameters and the update data
-// from the request body. The handler ensures the task status is valid
-// and updates the task in the database.
+// from the request body. The request body is applied on top of the stored
+// task, so fields omitted from the body keep their current values. The
+// handler ensures the task status and schedule are valid and updates the
+// task in the database.
 //
 // URL Parameters:
 //   - id: Task identifier (integer)
@@ -297,15 +631,36 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 //	{
 //	    "title": "Updated project",        // Optional
 //	    "description": "New details",      // Optional
-//	    "status": "in_progress",          // Optional, must be valid status
-//	    "position": 2                     // Optional
+//	    "status": "in_progress",          // Optional, must be a status of the workflow
+//	    "priority": "urgent",             // Optional, must be valid priority
+//	    "estimate_points": 3,             // Optional, null clears the estimate
+//	    "position": 2,                    // Optional
+//	    "due_at": null,                   // Optional, null clears the due date
+//	    "label_ids": [4],                 // Optional, replaces attached labels
+//	    "recurrence_rule": ""             // Optional, empty stops the recurrence
 //	}
 //
+// Moving a recurring task to a done status creates its next occurrence, which
+// is returned in the next_occurrence field of the response.
+//
+// Headers:
+//   - If-Match: Optional ETag from GetTask; the update is rejected when the
+//     task has changed since. The version field of the body is ignored.
+//
+// Query Parameters:
+//   - ignore_blockers: "true" lets a blocked task move to a doing or done
+//     status anyway
+//
 // HTTP Responses:
 //   - 200 OK: Successfully updated task
-//   - 400 Bad Request: Invalid task ID, status, or input data
+//   - 400 Bad Request: Invalid task ID, status, input data, If-Match header
+//     or ignore_blockers value
 //   - 401 Unauthorized: Missing or invalid JWT token
 //   - 404 Not Found: Task doesn't exist
+//   - 409 Conflict: The board column of the new status is at its WIP limit,
+//     or the task is blocked by unfinished tasks
+//   - 412 Precondition Failed: Task was modified; the body holds the current task
+//   - 422 Unprocessable Entity: The workflow doesn't allow the status transition
 //   - 500 Internal Server Error: Database or server errors
 //
 // Example success response:
@@ -322,33 +677,14 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 //	}
 func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 	log.Printf("Received task update request")
-	ctx := r.Context()
-	claims, ok := ctx.Value("claims").(*middleware.Claims)
+	task, claims, version, ok := h.loadTaskForUpdate(w, r)
 	if !ok {
-		h.analytics.Track(ctx, "Task Update Failed", "", map[string]any{
-			"reason":     "unauthorized",
-			"ip_address": r.RemoteAddr,
-		})
-		JSONError(w, "Unauthorized", http.StatusUnauthorized)
-		return
-	}
-
-	// Extract and validate task ID from URL parameters
-	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
-	if err != nil {
-		h.analytics.Track(ctx, "Task Update Failed", strconv.Itoa(claims.UserID), map[string]any{
-			"reason":  "invalid_task_id",
-			"task_id": vars["id"],
-			"user_id": claims.UserID,
-		})
-		log.Printf("Invalid task ID format: %s", vars["id"])
-		JSONError(w, "Invalid task ID", http.StatusBadRequest)
 		return
 	}
+	ctx := r.Context()
+	id := task.ID
 
 	// Parse and validate request body
-	var task models.Task
 	if err := json.NewDecoder(r.Body).Decode(&task); err != nil {
 		h.analytics.Track(ctx, "Task Update Failed", strconv.Itoa(claims.UserID), map[string]any{
 			"reason":  "invalid_input",
@@ -361,112 +697,466 @@ func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Set task ID from URL parameter
-	task.ID = id
-	log.Printf("Updating task ID: %d with data: %+v", id, task)
-
-	// Validate task status if provided
-	if err := task.ValidateStatus(); err != nil {
-		h.analytics.Track(ctx, "Task Update Failed", strconv.Itoa(claims.UserID), map[string]any{
-			"reason":  "invalid_status",
-			"error":   err.Error(),
-			"task_id": id,
-			"user_id": claims.UserID,
-		})
-		log.Printf("Invalid task status: %v", err)
-		JSONError(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	// Update task in database
-	if err := task.UpdateTask(h.DB); err != nil {
-		h.analytics.Track(ctx, "Task Update Failed", strconv.Itoa(claims.UserID), map[string]any{
-			"reason":  "database_error",
-			"error":   err.Error(),
-			"task_id": id,
-			"user_id": claims.UserID,
-		})
-		log.Printf("Error updating task %d: %v", id, err)
-		JSONError(w, "Failed to update task", http.StatusInternalServerError)
-		return
-	}
-
-	h.analytics.Track(ctx, "Task Updated", strconv.Itoa(claims.UserID), map[string]any{
-		"task_id":         id,
-		"user_id":         claims.UserID,
-		"new_status":      task.Status,
-		"has_description": task.Description != "",
-		"title_updated":   task.Title != "",
-	})
-	log.Printf("Successfully updated task ID: %d", id)
-
-	// Send successful response
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(task)
+	h.saveTaskUpdate(w, r, claims, id, task, version)
 }
 
-// DeleteTask handles the removal of an existing task.
+// PatchTask applies a partial update to an existing task.
 //
-// It validates the task ID from the URL parameters and permanently
-// removes the task from the database. This operation cannot be undone.
+// The request body is either a JSON Merge Patch (RFC 7396) or a JSON Patch
+// (RFC 6902), selected by the Content-Type header. The patch is applied to
+// the JSON representation of the task as returned by GetTask, and the result
+// is validated and saved like a full update. Members of the representation
+// that cannot be changed through UpdateTask, such as id or subtask_count,
+// are ignored.
 //
 // URL Parameters:
 //   - id: Task identifier (integer)
 //
+// Headers:
+//   - Content-Type: "application/merge-patch+json" (or "application/json")
+//     for a merge patch, "application/json-patch+json" for a JSON Patch
+//   - If-Match: Optional ETag from GetTask; the patch is rejected when the
+//     task has changed since
+//
+// Query Parameters:
+//   - ignore_blockers: "true" lets a blocked task move to a doing or done
+//     status anyway
+//
 // Authorization:
 //   - Requires valid JWT token in request context
-//   - User must own the task being deleted
+//   - User must own the task being updated
 //
 // HTTP Responses:
-//   - 204 No Content: Successfully deleted task
-//   - 400 Bad Request: Invalid task ID format
+//   - 200 OK: Successfully updated task
+//   - 400 Bad Request: Invalid task ID, malformed patch, If-Match header or resulting task
 //   - 401 Unauthorized: Missing or invalid JWT token
 //   - 404 Not Found: Task doesn't exist
+//   - 409 Conflict: A JSON Patch "test" operation failed, the board column
+//     of the new status is at its WIP limit, or the task is blocked by
+//     unfinished tasks
+//   - 412 Precondition Failed: Task was modified; the body holds the current task
+//   - 415 Unsupported Media Type: Content-Type is not a supported patch format
+//   - 422 Unprocessable Entity: A JSON Patch operation targets a missing path,
+//     or the workflow doesn't allow the status transition
 //   - 500 Internal Server Error: Database or server errors
 //
-// Example request:
+// Example merge patch request:
 //
-//	DELETE /api/tasks/1
-func (h *TaskHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-	claims, ok := ctx.Value("claims").(*middleware.Claims)
+//	PATCH /api/tasks/1
+//	Content-Type: application/merge-patch+json
+//
+//	{"status": "completed", "due_at": null}
+//
+// Example JSON Patch request:
+//
+//	PATCH /api/tasks/1
+//	Content-Type: application/json-patch+json
+//
+//	[
+//	    {"op": "test", "path": "/status", "value": "in_progress"},
+//	    {"op": "replace", "path": "/status", "value": "completed"},
+//	    {"op": "add", "path": "/label_ids", "value": [4]}
+//	]
+func (h *TaskHandler) PatchTask(w http.ResponseWriter, r *http.Request) {
+	log.Printf("Received task patch request")
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (mediaType != jsonpatch.MergePatchType && mediaType != jsonpatch.JSONPatchType &&
+		mediaType != "application/json") {
+		JSONError(w, "Unsupported patch format", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	task, claims, version, ok := h.loadTaskForUpdate(w, r)
 	if !ok {
-		h.analytics.Track(ctx, "Task Deletion Failed", "", map[string]any{
-			"reason":     "unauthorized",
-			"ip_address": r.RemoteAddr,
-		})
-		JSONError(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
+	ctx := r.Context()
+	id := task.ID
 
-	// Extract and validate task ID from URL parameters
-	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
+	patch, err := io.ReadAll(r.Body)
 	if err != nil {
-		h.analytics.Track(ctx, "Task Deletion Failed", strconv.Itoa(claims.UserID), map[string]any{
-			"reason":  "invalid_task_id",
-			"task_id": vars["id"],
-			"user_id": claims.UserID,
-		})
-		log.Printf("Invalid task ID format: %s", vars["id"])
-		JSONError(w, "Invalid task ID", http.StatusBadRequest)
+		log.Printf("Error reading task patch: %v", err)
+		JSONError(w, "Invalid input data", http.StatusBadRequest)
+		return
+	}
+	current, err := json.Marshal(task)
+	if err != nil {
+		log.Printf("Error encoding task %d: %v", id, err)
+		JSONError(w, "Failed to update task", http.StatusInternalServerError)
 		return
 	}
 
-	// Delete task from database
-	if err := models.DeleteTask(h.DB, id); err != nil {
-		h.analytics.Track(ctx, "Task Deletion Failed", strconv.Itoa(claims.UserID), map[string]any{
-			"reason":  "database_error",
+	// Apply the patch to the current representation
+	var patched []byte
+	if mediaType == jsonpatch.JSONPatchType {
+		var ops jsonpatch.Patch
+		if ops, err = jsonpatch.Parse(patch); err == nil {
+			patched, err = ops.Apply(current)
+		}
+	} else {
+		patched, err = jsonpatch.MergePatch(current, patch)
+	}
+	if err != nil {
+		h.analytics.Track(ctx, "Task Update Failed", strconv.Itoa(claims.UserID), map[string]any{
+			"reason":  "invalid_patch",
 			"error":   err.Error(),
 			"task_id": id,
 			"user_id": claims.UserID,
 		})
-		log.Printf("Error deleting task %d: %v", id, err)
-		JSONError(w, err.Error(), http.StatusInternalServerError)
+		log.Printf("Error applying patch to task %d: %v", id, err)
+
+		var applyErr *jsonpatch.ApplyError
+		switch {
+		case errors.As(err, &applyErr) && applyErr.Test:
+			JSONError(w, err.Error(), http.StatusConflict)
+		case errors.As(err, &applyErr):
+			JSONError(w, err.Error(), http.StatusUnprocessableEntity)
+		default:
+			JSONError(w, err.Error(), http.StatusBadRequest)
+		}
 		return
 	}
 
-	h.analytics.Track(ctx, "Task Deleted", strconv.Itoa(claims.UserID), map[string]any{
+	// Decode into an empty task so that members removed by the patch are cleared
+	var updated models.Task
+	if err := json.Unmarshal(patched, &updated); err != nil {
+		h.analytics.Track(ctx, "Task Update Failed", strconv.Itoa(claims.UserID), map[string]any{
+			"reason":  "invalid_input",
+			"error":   err.Error(),
+			"task_id": id,
+			"user_id": claims.UserID,
+		})
+		log.Printf("Error decoding patched task %d: %v", id, err)
+		JSONError(w, "Invalid input data", http.StatusBadRequest)
+		return
+	}
+
+	h.saveTaskUpdate(w, r, claims, id, updated, version)
+}
+
+// loadTaskForUpdate loads the task addressed by an update request and checks
+// the If-Match precondition. When ok is false the error response has been
+// written already.
+func (h *TaskHandler) loadTaskForUpdate(w http.ResponseWriter, r *http.Request) (task models.Task, claims *middleware.Claims, version int, ok bool) {
+	ctx := r.Context()
+	claims, ok = ctx.Value("claims").(*middleware.Claims)
+	if !ok {
+		h.analytics.Track(ctx, "Task Update Failed", "", map[string]any{
+			"reason":     "unauthorized",
+			"ip_address": r.RemoteAddr,
+		})
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return task, claims, 0, false
+	}
+
+	// Extract and validate task ID from URL parameters
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.analytics.Track(ctx, "Task Update Failed", strconv.Itoa(claims.UserID), map[string]any{
+			"reason":  "invalid_task_id",
+			"task_id": vars["id"],
+			"user_id": claims.UserID,
+		})
+		log.Printf("Invalid task ID format: %s", vars["id"])
+		JSONError(w, "Invalid task ID", http.StatusBadRequest)
+		return task, claims, 0, false
+	}
+
+	// Load current task so omitted fields keep their values.
+	// Tasks in the trash must be restored before they can be edited.
+	task, err = models.GetTask(h.DB, id)
+	if err != nil || task.UserID != claims.UserID || task.Status == models.StatusDeleted {
+		if err != nil && err != sql.ErrNoRows {
+			h.analytics.Track(ctx, "Task Update Failed", strconv.Itoa(claims.UserID), map[string]any{
+				"reason":  "database_error",
+				"error":   err.Error(),
+				"task_id": id,
+				"user_id": claims.UserID,
+			})
+			log.Printf("Error retrieving task %d: %v", id, err)
+			JSONError(w, "Failed to update task", http.StatusInternalServerError)
+			return task, claims, 0, false
+		}
+		h.analytics.Track(ctx, "Task Update Failed", strconv.Itoa(claims.UserID), map[string]any{
+			"reason":  "not_found",
+			"task_id": id,
+			"user_id": claims.UserID,
+		})
+		log.Printf("Task not found: ID %d", id)
+		JSONError(w, "Task not found", http.StatusNotFound)
+		return task, claims, 0, false
+	}
+
+	// Reject edits based on an outdated version of the task
+	version, err = parseIfMatch(r)
+	if err != nil {
+		JSONError(w, "Invalid If-Match header", http.StatusBadRequest)
+		return task, claims, 0, false
+	}
+	if version != 0 && version != task.Version {
+		h.trackVersionConflict(ctx, "Task Update Failed", id, claims.UserID, version, task.Version)
+		writePreconditionFailed(w, task)
+		return task, claims, 0, false
+	}
+
+	return task, claims, version, true
+}
+
+// saveTaskUpdate validates the changed task and stores it, writing the
+// response of UpdateTask and PatchTask.
+func (h *TaskHandler) saveTaskUpdate(w http.ResponseWriter, r *http.Request, claims *middleware.Claims, id int, task models.Task, version int) {
+	ctx := r.Context()
+
+	// Identity fields always come from the URL and the token, the
+	// required version from If-Match
+	task.ID = id
+	task.UserID = claims.UserID
+	task.Version = version
+	log.Printf("Updating task ID: %d with data: %+v", id, task)
+
+	ignoreBlockers, err := parseIgnoreBlockers(r)
+	if err != nil {
+		JSONError(w, "Invalid ignore_blockers value", http.StatusBadRequest)
+		return
+	}
+	task.IgnoreBlockers = ignoreBlockers
+
+	// Validate task priority
+	if err := task.ValidatePriority(); err != nil {
+		h.analytics.Track(ctx, "Task Update Failed", strconv.Itoa(claims.UserID), map[string]any{
+			"reason":  "invalid_priority",
+			"error":   err.Error(),
+			"task_id": id,
+			"user_id": claims.UserID,
+		})
+		log.Printf("Invalid task priority: %v", err)
+		JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Validate effort estimates
+	if err := task.ValidateEstimate(); err != nil {
+		h.analytics.Track(ctx, "Task Update Failed", strconv.Itoa(claims.UserID), map[string]any{
+			"reason":  "invalid_estimate",
+			"error":   err.Error(),
+			"task_id": id,
+			"user_id": claims.UserID,
+		})
+		log.Printf("Invalid task estimate: %v", err)
+		JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Validate and normalize schedule
+	if err := task.ValidateDates(); err != nil {
+		h.analytics.Track(ctx, "Task Update Failed", strconv.Itoa(claims.UserID), map[string]any{
+			"reason":  "invalid_dates",
+			"error":   err.Error(),
+			"task_id": id,
+			"user_id": claims.UserID,
+		})
+		log.Printf("Invalid task dates: %v", err)
+		JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Validate and normalize recurrence rule
+	if err := task.ValidateRecurrence(); err != nil {
+		h.analytics.Track(ctx, "Task Update Failed", strconv.Itoa(claims.UserID), map[string]any{
+			"reason":  "invalid_recurrence",
+			"error":   err.Error(),
+			"task_id": id,
+			"user_id": claims.UserID,
+		})
+		log.Printf("Invalid recurrence rule: %v", err)
+		JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Update task in database
+	if err := task.UpdateTask(h.DB); err != nil {
+		if err.Error() == "version conflict" {
+			// The task changed between reading and locking it
+			current, err := models.GetTask(h.DB, id)
+			if err != nil {
+				log.Printf("Error retrieving task %d after version conflict: %v", id, err)
+				JSONError(w, "Failed to update task", http.StatusInternalServerError)
+				return
+			}
+			h.trackVersionConflict(ctx, "Task Update Failed", id, claims.UserID, version, current.Version)
+			writePreconditionFailed(w, current)
+			return
+		}
+		if strings.HasPrefix(err.Error(), "invalid status") || err.Error() == "status transition not allowed" {
+			h.analytics.Track(ctx, "Task Update Failed", strconv.Itoa(claims.UserID), map[string]any{
+				"reason":  "invalid_status",
+				"error":   err.Error(),
+				"task_id": id,
+				"user_id": claims.UserID,
+			})
+			log.Printf("Invalid task status: %v", err)
+			status := http.StatusBadRequest
+			if err.Error() == "status transition not allowed" {
+				status = http.StatusUnprocessableEntity
+			}
+			JSONError(w, err.Error(), status)
+			return
+		}
+		if strings.HasPrefix(err.Error(), "wip limit reached") {
+			h.analytics.Track(ctx, "Task Update Failed", strconv.Itoa(claims.UserID), map[string]any{
+				"reason":  "wip_limit",
+				"error":   err.Error(),
+				"task_id": id,
+				"user_id": claims.UserID,
+			})
+			log.Printf("Task update failed: %v", err)
+			JSONError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err.Error() == "task is blocked" {
+			h.analytics.Track(ctx, "Task Update Failed", strconv.Itoa(claims.UserID), map[string]any{
+				"reason":  "blocked",
+				"task_id": id,
+				"user_id": claims.UserID,
+			})
+			log.Printf("Task update failed: task %d is blocked", id)
+			JSONError(w, "Task is blocked by unfinished tasks", http.StatusConflict)
+			return
+		}
+		if err.Error() == "label not found" {
+			log.Printf("Task update failed: %v", err)
+			JSONError(w, "Label not found", http.StatusBadRequest)
+			return
+		}
+		if err.Error() == "project not found" {
+			log.Printf("Task update failed: %v", err)
+			JSONError(w, "Project not found", http.StatusBadRequest)
+			return
+		}
+		h.analytics.Track(ctx, "Task Update Failed", strconv.Itoa(claims.UserID), map[string]any{
+			"reason":  "database_error",
+			"error":   err.Error(),
+			"task_id": id,
+			"user_id": claims.UserID,
+		})
+		log.Printf("Error updating task %d: %v", id, err)
+		JSONError(w, "Failed to update task", http.StatusInternalServerError)
+		return
+	}
+
+	h.analytics.Track(ctx, "Task Updated", strconv.Itoa(claims.UserID), map[string]any{
+		"task_id":         id,
+		"user_id":         claims.UserID,
+		"new_status":      task.Status,
+		"new_priority":    task.Priority,
+		"has_description": task.Description != "",
+		"title_updated":   task.Title != "",
+		"next_occurrence": task.NextOccurrence != nil,
+	})
+	log.Printf("Successfully updated task ID: %d", id)
+
+	// Send successful response
+	w.Header().Set("ETag", taskETag(task))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(task)
+}
+
+// DeleteTask handles the removal of an existing task.
+//
+// It validates the task ID from the URL parameters and permanently
+// removes the task from the database. This operation cannot be undone.
+//
+// URL Parameters:
+//   - id: Task identifier (integer)
+//
+// Authorization:
+//   - Requires valid JWT token in request context
+//   - User must own the task being deleted
+//
+// Headers:
+//   - If-Match: Optional ETag from GetTask; the task is only deleted when it
+//     has not changed since
+//
+// HTTP Responses:
+//   - 204 No Content: Successfully deleted task
+//   - 400 Bad Request: Invalid task ID format or If-Match header
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 404 Not Found: Task doesn't exist
+//   - 412 Precondition Failed: Task was modified; the body holds the current task
+//   - 500 Internal Server Error: Database or server errors
+//
+// Example request:
+//
+//	DELETE /api/tasks/1
+func (h *TaskHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := ctx.Value("claims").(*middleware.Claims)
+	if !ok {
+		h.analytics.Track(ctx, "Task Deletion Failed", "", map[string]any{
+			"reason":     "unauthorized",
+			"ip_address": r.RemoteAddr,
+		})
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Extract and validate task ID from URL parameters
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.analytics.Track(ctx, "Task Deletion Failed", strconv.Itoa(claims.UserID), map[string]any{
+			"reason":  "invalid_task_id",
+			"task_id": vars["id"],
+			"user_id": claims.UserID,
+		})
+		log.Printf("Invalid task ID format: %s", vars["id"])
+		JSONError(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+
+	version, err := parseIfMatch(r)
+	if err != nil {
+		JSONError(w, "Invalid If-Match header", http.StatusBadRequest)
+		return
+	}
+
+	// Delete task from database, unless it changed since the client read it.
+	// Tasks of other users are reported as not found before any version check.
+	if err := models.DeleteTaskVersion(h.DB, claims.UserID, id, version); err != nil {
+		switch err.Error() {
+		case "task not found":
+			h.analytics.Track(ctx, "Task Deletion Failed", strconv.Itoa(claims.UserID), map[string]any{
+				"reason":  "not_found",
+				"task_id": id,
+				"user_id": claims.UserID,
+			})
+			JSONError(w, "Task not found", http.StatusNotFound)
+			return
+		case "version conflict":
+			// The model only reports a conflict on the caller's own task
+			current, err := models.GetTask(h.DB, id)
+			if err != nil || current.UserID != claims.UserID {
+				log.Printf("Error retrieving task %d after version conflict: %v", id, err)
+				JSONError(w, "Failed to delete task", http.StatusInternalServerError)
+				return
+			}
+			h.trackVersionConflict(ctx, "Task Deletion Failed", id, claims.UserID, version, current.Version)
+			writePreconditionFailed(w, current)
+			return
+		}
+		h.analytics.Track(ctx, "Task Deletion Failed", strconv.Itoa(claims.UserID), map[string]any{
+			"reason":  "database_error",
+			"error":   err.Error(),
+			"task_id": id,
+			"user_id": claims.UserID,
+		})
+		log.Printf("Error deleting task %d: %v", id, err)
+		JSONError(w, "Failed to delete task", http.StatusInternalServerError)
+		return
+	}
+
+	h.analytics.Track(ctx, "Task Deleted", strconv.Itoa(claims.UserID), map[string]any{
 		"task_id": id,
 		"user_id": claims.UserID,
 	})
@@ -480,11 +1170,12 @@ func (h *TaskHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// UpdateTaskPositions handles the reordering of multiple tasks for a user.
+// UpdateTaskPositions handles the reordering of tasks within one list: the
+// subtasks of a task, the top-level tasks of a project or the inbox.
 //
-// It processes a map of task IDs to their new positions, allowing for bulk
-// updates of task ordering. The handler ensures all tasks belong to the
-// authenticated user before making any changes.
+// The request lists moves that are applied in order. Each move puts a task
+// at a position or directly before or after another task of the same list.
+// All tasks must belong to the authenticated user.
 //
 // Authorization:
 //   - Requires valid JWT token in request context
@@ -493,81 +1184,309 @@ func (h *TaskHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
 // Request Body:
 //
 //	{
+//	    "moves": [
+//	        {"task_id": 7, "before": 3},   // Task 7 moves directly above task 3
+//	        {"task_id": 5, "after": 7},    // Task 5 moves directly below task 7
+//	        {"task_id": 9, "position": 0}  // Task 9 moves to the top
+//	    ]
+//	}
+//
+// A map of task IDs to target positions is accepted as well; every listed
+// task ends up at its position, the others keep their relative order:
+//
+//	{
 //	    "1": 3,    // Task ID 1 moves to position 3
 //	    "2": 1,    // Task ID 2 moves to position 1
 //	    "3": 2     // Task ID 3 moves to position 2
 //	}
 //
 // HTTP Responses:
-//   - 200 OK: Successfully updated task positions
-//   - 400 Bad Request: Invalid input format
+//   - 200 OK: Successfully reordered; the body holds the reordered list
+//   - 400 Bad Request: Invalid input format, invalid move or tasks from different lists
 //   - 401 Unauthorized: Missing or invalid JWT token
 //   - 404 Not Found: One or more tasks don't exist
 //   - 500 Internal Server Error: Database or server errors
 //
 // Example success response:
 //
-//	{
-//	    "message": "Positions updated successfully"
-//	}
+//	[
+//	    {"id": 9, "title": "Plan week", "position": 0, ...},
+//	    {"id": 7, "title": "Write report", "position": 1, ...},
+//	    {"id": 5, "title": "Send report", "position": 2, ...},
+//	    {"id": 3, "title": "Review", "position": 3, ...}
+//	]
 //
-// Note: This operation is atomic - if any task update fails,
+// Note: This operation is atomic - if any move fails,
 // none of the position changes will be applied.
 func (h *TaskHandler) UpdateTaskPositions(w http.ResponseWriter, r *http.Request) {
 	log.Printf("Received task positions update request")
+	ctx := r.Context()
 
 	// Extract user ID from JWT claims
-	claims := r.Context().Value("claims").(*middleware.Claims)
+	claims, ok := ctx.Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 	userID := claims.UserID
 	log.Printf("Processing position updates for user ID: %d", userID)
 
 	// Parse and validate request body
-	var positions map[int]int
-	if err := json.NewDecoder(r.Body).Decode(&positions); err != nil {
+	moves, positions, err := decodeTaskMoves(r)
+	if err != nil {
+		h.analytics.Track(ctx, "Task Reorder Failed", strconv.Itoa(userID), map[string]any{
+			"reason":  "invalid_input",
+			"error":   err.Error(),
+			"user_id": userID,
+		})
 		log.Printf("Error decoding position updates: %v", err)
 		JSONError(w, "Invalid input", http.StatusBadRequest)
 		return
 	}
 
-	log.Printf("Updating positions for %d tasks: %+v", len(positions), positions)
-
-	// Update each task's position
-	for taskID, newPosition := range positions {
-		// Verify task exists and belongs to user
-		task, err := models.GetTask(h.DB, taskID)
-		if err != nil {
-			log.Printf("Failed to find task ID %d: %v", taskID, err)
+	var tasks []models.Task
+	if positions != nil {
+		log.Printf("Updating positions for %d tasks: %+v", len(positions), positions)
+		tasks, err = models.SetTaskPositions(h.DB, userID, positions)
+	} else {
+		log.Printf("Applying %d moves: %+v", len(moves), moves)
+		tasks, err = models.ReorderTasks(h.DB, userID, moves)
+	}
+	if err != nil {
+		h.analytics.Track(ctx, "Task Reorder Failed", strconv.Itoa(userID), map[string]any{
+			"reason":  "reorder_failed",
+			"error":   err.Error(),
+			"user_id": userID,
+		})
+		log.Printf("Failed to reorder tasks for user %d: %v", userID, err)
+		switch {
+		case err.Error() == "task not found":
 			JSONError(w, "Task not found", http.StatusNotFound)
-			return
-		}
-
-		// Update task position
-		if err := task.UpdateTaskPosition(h.DB, userID, newPosition); err != nil {
-			log.Printf("Failed to update position for task ID %d to position %d: %v",
-				taskID, newPosition, err)
+		case err.Error() == "tasks are not in the same list" || strings.HasPrefix(err.Error(), "invalid move"):
+			JSONError(w, err.Error(), http.StatusBadRequest)
+		default:
 			JSONError(w, "Failed to update position", http.StatusInternalServerError)
-			return
 		}
-
-		log.Printf("Updated task ID %d to position %d", taskID, newPosition)
+		return
+	}
+	if tasks == nil {
+		tasks = []models.Task{}
 	}
 
-	// Send successful response
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{
-		"message": "Positions updated successfully",
+	h.analytics.Track(ctx, "Tasks Reordered", strconv.Itoa(userID), map[string]any{
+		"user_id":    userID,
+		"move_count": len(moves) + len(positions),
+		"list_size":  len(tasks),
 	})
 
-	log.Printf("Successfully updated positions for %d tasks", len(positions))
+	// Send successful response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tasks)
+
+	log.Printf("Successfully reordered %d tasks for user %d", len(moves)+len(positions), userID)
 }
 
-// GetUserStatistics retrieves task-related statistics for the authenticated user.
+// decodeTaskMoves reads a reorder request, given either as a "moves" list
+// or as a map of task IDs to positions. Exactly one of the results is set.
+func decodeTaskMoves(r *http.Request) ([]models.TaskMove, map[int]int, error) {
+	var body map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return nil, nil, err
+	}
+
+	if raw, ok := body["moves"]; ok {
+		var moves []models.TaskMove
+		if err := json.Unmarshal(raw, &moves); err != nil {
+			return nil, nil, err
+		}
+		return moves, nil, nil
+	}
+
+	positions := make(map[int]int, len(body))
+	for key, raw := range body {
+		id, err := strconv.Atoi(key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid task ID: %s", key)
+		}
+		var position int
+		if err := json.Unmarshal(raw, &position); err != nil {
+			return nil, nil, fmt.Errorf("invalid position for task %d", id)
+		}
+		positions[id] = position
+	}
+	return nil, positions, nil
+}
+
+// BulkUpdateTasks applies one action to many tasks of the authenticated user
+// in a single transaction.
 //
-// It provides a summary of the user's tasks including total count, status breakdowns,
-// and recent activity metrics. This endpoint is useful for dashboards and progress tracking.
+// The tasks are selected either by ID or by a filter that takes the same
+// parameters as GET /api/tasks (as strings); a filter selects active tasks
+// only, so restore requires task IDs. At most models.MaxBulkTasks tasks can
+// be changed per request.
 //
-// Authorization:
-//   - Requires valid JWT token in request context
+// Request Body:
+//
+//	{
+//	    "action": "move",                       // complete, delete, restore, relabel or move
+//	    "task_ids": [4, 8, 15],                 // Either task_ids
+//	    "filter": {"status": "pending"},        // or filter
+//	    "project_id": 3,                        // move: destination, null for the inbox
+//	    "add_label_ids": [2],                   // relabel: labels to attach
+//	    "remove_label_ids": [5],                // relabel: labels to detach
+//	    "atomic": false                         // Optional: all or nothing
+//	}
+//
+// Every task is applied on its own, so one failing task doesn't stop the
+// others unless "atomic" is set, in which case nothing is changed when any
+// task fails.
+//
+// Authorization:
+//   - Requires valid JWT token in request context
+//
+// HTTP Responses:
+//   - 200 OK: Operation applied, per-task results in the body
+//   - 400 Bad Request: Invalid body, action, filter or too many tasks
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 404 Not Found: Destination project or a label doesn't exist
+//   - 422 Unprocessable Entity: Atomic operation rolled back, per-task
+//     results in the body
+//   - 500 Internal Server Error: Database or server errors
+//
+// Example success response:
+//
+//	{
+//	    "action": "complete",
+//	    "succeeded": 2,
+//	    "failed": 1,
+//	    "results": [
+//	        {"task_id": 4, "ok": true, "task": {"id": 4, "status": "completed", ...}},
+//	        {"task_id": 8, "ok": true, "task": {"id": 8, "status": "completed", ...}},
+//	        {"task_id": 15, "ok": false, "error": "task not found"}
+//	    ]
+//	}
+//
+// Analytics:
+//   - Tracks a single "Tasks Bulk Updated" event per request
+func (h *TaskHandler) BulkUpdateTasks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := ctx.Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var body struct {
+		models.BulkOperation
+		Filter map[string]string `json:"filter"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		log.Printf("Error decoding bulk operation: %v", err)
+		JSONError(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	op := body.BulkOperation
+	if err := op.ValidateAction(); err != nil {
+		log.Printf("Invalid bulk operation: %v", err)
+		JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Select the tasks by filter
+	selection := "ids"
+	if body.Filter != nil {
+		if len(op.TaskIDs) > 0 {
+			JSONError(w, "Use either task_ids or filter", http.StatusBadRequest)
+			return
+		}
+		if op.Action == models.BulkActionRestore {
+			JSONError(w, "Restore requires task_ids", http.StatusBadRequest)
+			return
+		}
+
+		query := url.Values{}
+		for key, value := range body.Filter {
+			query.Set(key, value)
+		}
+		filter, err := parseTaskFilterValues(query)
+		if err != nil {
+			log.Printf("Invalid bulk filter: %v", err)
+			JSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		filter.Cursor = ""
+		filter.Limit = models.MaxBulkTasks
+
+		page, err := models.GetTaskPage(h.DB, claims.UserID, filter)
+		if err != nil {
+			log.Printf("Error selecting tasks for bulk operation: %v", err)
+			JSONError(w, "Failed to select tasks", http.StatusInternalServerError)
+			return
+		}
+		if page.NextCursor != "" {
+			JSONError(w, fmt.Sprintf("too many tasks: at most %d per request", models.MaxBulkTasks), http.StatusBadRequest)
+			return
+		}
+		op.TaskIDs = []int{}
+		for _, task := range page.Tasks {
+			op.TaskIDs = append(op.TaskIDs, task.ID)
+		}
+		selection = "filter"
+	}
+
+	// An empty filter result is not an error
+	if selection == "filter" && len(op.TaskIDs) == 0 {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(models.BulkResult{Action: op.Action, Results: []models.BulkItemResult{}})
+		return
+	}
+
+	if err := op.Validate(); err != nil {
+		log.Printf("Invalid bulk operation: %v", err)
+		JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := models.BulkUpdateTasks(h.DB, claims.UserID, op)
+	if err != nil {
+		log.Printf("Error applying bulk %s for user %d: %v", op.Action, claims.UserID, err)
+		switch err.Error() {
+		case "project not found":
+			JSONError(w, "Project not found", http.StatusNotFound)
+		case "label not found":
+			JSONError(w, "Label not found", http.StatusNotFound)
+		default:
+			JSONError(w, "Failed to update tasks", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.analytics.Track(ctx, "Tasks Bulk Updated", strconv.Itoa(claims.UserID), map[string]any{
+		"user_id":     claims.UserID,
+		"action":      op.Action,
+		"selection":   selection,
+		"task_count":  len(result.Results),
+		"succeeded":   result.Succeeded,
+		"failed":      result.Failed,
+		"atomic":      op.Atomic,
+		"rolled_back": result.RolledBack,
+	})
+	log.Printf("Bulk %s for user %d: %d succeeded, %d failed", op.Action, claims.UserID, result.Succeeded, result.Failed)
+
+	w.Header().Set("Content-Type", "application/json")
+	if result.RolledBack {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetUserStatistics retrieves task-related statistics for the authenticated user.
+//
+// It provides a summary of the user's tasks including total count, status breakdowns,
+// and recent activity metrics. This endpoint is useful for dashboards and progress tracking.
+//
+// Authorization:
+//   - Requires valid JWT token in request context
 //
 // HTTP Responses:
 //   - 200 OK: Successfully retrieved statistics
@@ -584,7 +1503,17 @@ func (h *TaskHandler) UpdateTaskPositions(w http.ResponseWriter, r *http.Request
 //	    "pending_tasks": 3,
 //	    "in_progress_tasks": 2,
 //	    "deleted_tasks": 1,
-//	    "tasks_created_today": 2
+//	    "tasks_created_today": 2,
+//	    "overdue_tasks": 1,
+//	    "due_today_tasks": 0,
+//	    "priority_counts": {"none": 2, "low": 0, "medium": 1, "high": 1, "urgent": 1},
+//	    "effort": {
+//	        "remaining_points": 21,
+//	        "completed_points": 13,
+//	        "remaining_minutes": 480,
+//	        "completed_minutes": 300,
+//	        "unestimated_tasks": 2
+//	    }
 //	}
 //
 // Note: Statistics are calculated in real-time and reflect the current state
@@ -616,3 +1545,885 @@ func (h *TaskHandler) GetUserStatistics(w http.ResponseWriter, r *http.Request)
 	log.Printf("Successfully retrieved statistics for user %d: total tasks: %d, completed: %d",
 		claims.UserID, stats.TotalTasks, stats.CompletedTasks)
 }
+
+// GetFlowStatistics reports how fast the authenticated user's tasks move
+// from creation and start to completion.
+//
+// Lead time is measured from creation to completion, cycle time from the
+// first move to in_progress to completion. Throughput counts completed
+// tasks per week; weeks begin on the week start of the user's preferences,
+// Monday or Sunday. Days are counted in the time zone of the user's
+// preferences.
+//
+// Authorization:
+//   - Requires valid JWT token in request context
+//
+// Query Parameters:
+//   - from: First day of the range, YYYY-MM-DD (default: 12 weeks before to)
+//   - to: Last day of the range, YYYY-MM-DD, inclusive (default: today in
+//     the user's time zone)
+//
+// HTTP Responses:
+//   - 200 OK: Successfully calculated metrics
+//   - 400 Bad Request: Invalid dates or range longer than 366 days
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 500 Internal Server Error: Database or server errors
+//
+// Example success response:
+//
+//	{
+//	    "from": "2024-03-04T00:00:00+01:00",
+//	    "to": "2024-05-27T00:00:00+02:00",
+//	    "completed_tasks": 14,
+//	    "lead_time": {"count": 14, "average_hours": 52.5, "median_hours": 30.1},
+//	    "cycle_time": {"count": 9, "average_hours": 7.25, "median_hours": 4},
+//	    "throughput": [
+//	        {"week_start": "2024-03-04T00:00:00+01:00", "completed": 2},
+//	        {"week_start": "2024-03-11T00:00:00+01:00", "completed": 0}
+//	    ]
+//	}
+func (h *TaskHandler) GetFlowStatistics(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+
+	// The range covers whole days; to is inclusive
+	var from, to time.Time
+	if value := query.Get("to"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			JSONError(w, "Invalid to date", http.StatusBadRequest)
+			return
+		}
+		to = parsed
+	}
+	if value := query.Get("from"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			JSONError(w, "Invalid from date", http.StatusBadRequest)
+			return
+		}
+		from = parsed
+	}
+
+	// Default to today in the user's time zone
+	if to.IsZero() {
+		today, err := userToday(h.DB, claims.UserID)
+		if err != nil {
+			log.Printf("Error fetching preferences for user %d: %v", claims.UserID, err)
+			JSONError(w, "Failed to fetch flow statistics", http.StatusInternalServerError)
+			return
+		}
+		to = today
+	}
+	to = to.AddDate(0, 0, 1)
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -84)
+	}
+
+	metrics, err := models.GetFlowMetrics(h.DB, claims.UserID, from, to)
+	if err != nil {
+		if err.Error() == "from must be before to" {
+			JSONError(w, "From date must not be after to date", http.StatusBadRequest)
+			return
+		}
+		if strings.HasPrefix(err.Error(), "date range must not exceed") {
+			JSONError(w, "Date range must not exceed 366 days", http.StatusBadRequest)
+			return
+		}
+		log.Printf("Error calculating flow statistics for user %d: %v", claims.UserID, err)
+		JSONError(w, "Failed to fetch flow statistics", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(metrics)
+}
+
+// GetBurndownStatistics returns the authenticated user's remaining effort
+// at the end of each day of a date range, e.g. a sprint.
+//
+// Days are counted in the time zone of the user's preferences. A task counts
+// as remaining from its creation until it is completed or moved to the
+// trash, with its current estimate; tasks without an estimate in the chosen
+// unit are left out. The ideal line burns the effort open at the start of
+// the range down to zero at its end.
+//
+// Authorization:
+//   - Requires valid JWT token in request context
+//
+// Query Parameters:
+//   - from: First day of the range, YYYY-MM-DD (default: 13 days before to)
+//   - to: Last day of the range, YYYY-MM-DD, inclusive (default: today in
+//     the user's time zone)
+//   - unit: "points" (default) or "minutes"
+//   - project_id: Optional project to limit the burndown to
+//
+// HTTP Responses:
+//   - 200 OK: Successfully calculated burndown
+//   - 400 Bad Request: Invalid dates, unit or project ID, unknown project,
+//     or range longer than 366 days
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 500 Internal Server Error: Database or server errors
+//
+// Example success response:
+//
+//	{
+//	    "from": "2024-03-04T00:00:00Z",
+//	    "to": "2024-03-06T00:00:00Z",
+//	    "time_zone": "UTC",
+//	    "unit": "points",
+//	    "project_id": 3,
+//	    "start": 20,
+//	    "days": [
+//	        {"date": "2024-03-04T00:00:00Z", "remaining": 15, "completed": 5, "ideal": 10},
+//	        {"date": "2024-03-05T00:00:00Z", "remaining": 18, "completed": 0, "ideal": 0}
+//	    ]
+//	}
+func (h *TaskHandler) GetBurndownStatistics(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+
+	// The range covers whole days; to is inclusive
+	var from, to time.Time
+	if value := query.Get("to"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			JSONError(w, "Invalid to date", http.StatusBadRequest)
+			return
+		}
+		to = parsed
+	}
+	if value := query.Get("from"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			JSONError(w, "Invalid from date", http.StatusBadRequest)
+			return
+		}
+		from = parsed
+	}
+
+	unit := query.Get("unit")
+	if unit == "" {
+		unit = models.EstimateUnitPoints
+	}
+
+	var projectID *int
+	if value := query.Get("project_id"); value != "" {
+		pid, err := strconv.Atoi(value)
+		if err != nil {
+			JSONError(w, "Invalid project ID", http.StatusBadRequest)
+			return
+		}
+		projectID = &pid
+	}
+
+	// Default to today in the user's time zone
+	if to.IsZero() {
+		today, err := userToday(h.DB, claims.UserID)
+		if err != nil {
+			log.Printf("Error fetching preferences for user %d: %v", claims.UserID, err)
+			JSONError(w, "Failed to fetch burndown", http.StatusInternalServerError)
+			return
+		}
+		to = today
+	}
+	to = to.AddDate(0, 0, 1)
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -14)
+	}
+
+	burndown, err := models.GetBurndown(h.DB, claims.UserID, from, to, unit, projectID)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "invalid unit"):
+			JSONError(w, "Invalid unit", http.StatusBadRequest)
+		case err.Error() == "project not found":
+			JSONError(w, "Project not found", http.StatusBadRequest)
+		case err.Error() == "from must be before to":
+			JSONError(w, "From date must not be after to date", http.StatusBadRequest)
+		case strings.HasPrefix(err.Error(), "date range must not exceed"):
+			JSONError(w, "Date range must not exceed 366 days", http.StatusBadRequest)
+		default:
+			log.Printf("Error calculating burndown for user %d: %v", claims.UserID, err)
+			JSONError(w, "Failed to fetch burndown", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(burndown)
+}
+
+// GetActivityStatistics returns the authenticated user's daily activity
+// for the past year together with the user's completion streaks.
+//
+// Days are counted in the time zone of the user's preferences. The days
+// array always holds 365 entries, oldest first, ready to be drawn as a
+// heatmap.
+//
+// Authorization:
+//   - Requires valid JWT token in request context
+//
+// HTTP Responses:
+//   - 200 OK: Successfully calculated activity
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 500 Internal Server Error: Database or server errors
+//
+// Example success response:
+//
+//	{
+//	    "time_zone": "Europe/Berlin",
+//	    "today": "2024-05-20",
+//	    "current_streak": 3,
+//	    "longest_streak": 12,
+//	    "days": [
+//	        {"date": "2023-05-22", "created": 0, "completed": 0},
+//	        {"date": "2023-05-23", "created": 4, "completed": 2}
+//	    ]
+//	}
+func (h *TaskHandler) GetActivityStatistics(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	activity, err := models.GetActivity(h.DB, claims.UserID, time.Now())
+	if err != nil {
+		log.Printf("Error calculating activity for user %d: %v", claims.UserID, err)
+		JSONError(w, "Failed to fetch activity statistics", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(activity)
+}
+
+// DeleteTaskSeries handles the deletion of all occurrences of a recurring task.
+//
+// Every occurrence of the series the task belongs to is soft-deleted,
+// including completed ones and their subtasks. For a task that never
+// recurred only the task itself is deleted.
+//
+// URL Parameters:
+//   - id: Identifier of any task in the series (integer)
+//
+// Authorization:
+//   - Requires valid JWT token in request context
+//   - User must own the series
+//
+// HTTP Responses:
+//   - 204 No Content: Successfully deleted series
+//   - 400 Bad Request: Invalid task ID format
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 404 Not Found: Task doesn't exist
+//   - 500 Internal Server Error: Database or server errors
+func (h *TaskHandler) DeleteTaskSeries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := ctx.Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Extract and validate task ID from URL parameters
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Printf("Invalid task ID format: %s", vars["id"])
+		JSONError(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := models.DeleteTaskSeries(h.DB, claims.UserID, id)
+	if err != nil {
+		if err.Error() == "task not found" {
+			JSONError(w, "Task not found", http.StatusNotFound)
+			return
+		}
+		h.analytics.Track(ctx, "Task Series Deletion Failed", strconv.Itoa(claims.UserID), map[string]any{
+			"reason":  "database_error",
+			"error":   err.Error(),
+			"task_id": id,
+			"user_id": claims.UserID,
+		})
+		log.Printf("Error deleting series of task %d: %v", id, err)
+		JSONError(w, "Failed to delete task series", http.StatusInternalServerError)
+		return
+	}
+
+	h.analytics.Track(ctx, "Task Series Deleted", strconv.Itoa(claims.UserID), map[string]any{
+		"task_id":       id,
+		"user_id":       claims.UserID,
+		"deleted_count": deleted,
+	})
+	log.Printf("Successfully deleted %d tasks in series of task ID: %d", deleted, id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetTaskHistory retrieves the recorded changes of a task, oldest first.
+//
+// Every create, field change, status transition, reorder, delete and
+// restore is listed with the old and new value of the affected field.
+//
+// URL Parameters:
+//   - id: Task identifier (integer)
+//
+// Authorization:
+//   - Requires valid JWT token in request context
+//   - User must own the task
+//
+// HTTP Responses:
+//   - 200 OK: Successfully retrieved history
+//   - 400 Bad Request: Invalid task ID format
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 404 Not Found: Task doesn't exist
+//   - 500 Internal Server Error: Database or server errors
+//
+// Example success response:
+//
+//	[
+//	    {
+//	        "id": 1,
+//	        "task_id": 12,
+//	        "user_id": 123,
+//	        "type": "created",
+//	        "created_at": "2024-01-01T10:00:00Z"
+//	    },
+//	    {
+//	        "id": 2,
+//	        "task_id": 12,
+//	        "user_id": 123,
+//	        "type": "status_changed",
+//	        "field": "status",
+//	        "old_value": "pending",
+//	        "new_value": "in_progress",
+//	        "created_at": "2024-01-02T09:30:00Z"
+//	    }
+//	]
+func (h *TaskHandler) GetTaskHistory(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		JSONError(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+
+	events, err := models.GetTaskHistory(h.DB, claims.UserID, id)
+	if err != nil {
+		if err.Error() == "task not found" {
+			JSONError(w, "Task not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error fetching history of task %d: %v", id, err)
+		JSONError(w, "Failed to fetch task history", http.StatusInternalServerError)
+		return
+	}
+
+	// Ensure null is never returned for events array
+	if events == nil {
+		events = []models.TaskEvent{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(events)
+}
+
+// GetTrash retrieves the soft-deleted tasks of the authenticated user.
+//
+// Tasks are ordered by deletion time, most recent first. Subtasks deleted
+// together with their parent are only restored or purged through it and
+// are therefore not listed separately.
+//
+// Authorization:
+//   - Requires valid JWT token in request context
+//
+// HTTP Responses:
+//   - 200 OK: Successfully retrieved trash
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 500 Internal Server Error: Database or server errors
+//
+// Example success response:
+//
+//	[
+//	    {
+//	        "id": 1,
+//	        "title": "Old task",
+//	        "status": "deleted",
+//	        "deleted_at": "2024-01-03T08:00:00Z",
+//	        ...
+//	    }
+//	]
+func (h *TaskHandler) GetTrash(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	tasks, err := models.GetTrash(h.DB, claims.UserID)
+	if err != nil {
+		log.Printf("Error fetching trash for user %d: %v", claims.UserID, err)
+		JSONError(w, "Failed to fetch trash", http.StatusInternalServerError)
+		return
+	}
+
+	// Ensure null is never returned for tasks array
+	if tasks == nil {
+		tasks = []models.Task{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tasks)
+}
+
+// RestoreTask moves a task out of the trash.
+//
+// The task regains the status it had before deletion and is placed at the
+// top of its list. Subtasks deleted together with it are restored as well.
+//
+// URL Parameters:
+//   - id: Task identifier (integer)
+//
+// Authorization:
+//   - Requires valid JWT token in request context
+//   - User must own the task
+//
+// HTTP Responses:
+//   - 200 OK: Successfully restored task, returned in the body
+//   - 400 Bad Request: Invalid task ID format
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 404 Not Found: Task doesn't exist
+//   - 409 Conflict: Task is not in the trash or its parent task is deleted
+//   - 500 Internal Server Error: Database or server errors
+func (h *TaskHandler) RestoreTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := ctx.Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Extract and validate task ID from URL parameters
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Printf("Invalid task ID format: %s", vars["id"])
+		JSONError(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+
+	task, err := models.RestoreTask(h.DB, claims.UserID, id)
+	if err != nil {
+		log.Printf("Error restoring task %d: %v", id, err)
+		switch err.Error() {
+		case "task not found":
+			JSONError(w, "Task not found", http.StatusNotFound)
+		case "task is not deleted":
+			JSONError(w, "Task is not deleted", http.StatusConflict)
+		case "parent task is deleted":
+			JSONError(w, "Parent task is deleted", http.StatusConflict)
+		default:
+			JSONError(w, "Failed to restore task", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.analytics.Track(ctx, "Task Restored", strconv.Itoa(claims.UserID), map[string]any{
+		"task_id":     id,
+		"user_id":     claims.UserID,
+		"task_status": task.Status,
+	})
+	log.Printf("Successfully restored task ID: %d", id)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(task)
+}
+
+// PurgeTask permanently deletes a task from the trash.
+//
+// URL Parameters:
+//   - id: Task identifier (integer)
+//
+// Authorization:
+//   - Requires valid JWT token in request context
+//   - User must own the task
+//
+// HTTP Responses:
+//   - 204 No Content: Successfully purged task
+//   - 400 Bad Request: Invalid task ID format
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 404 Not Found: Task doesn't exist or is not in the trash
+//   - 500 Internal Server Error: Database or server errors
+func (h *TaskHandler) PurgeTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := ctx.Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Extract and validate task ID from URL parameters
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Printf("Invalid task ID format: %s", vars["id"])
+		JSONError(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := models.PurgeTask(h.DB, claims.UserID, id); err != nil {
+		log.Printf("Error purging task %d: %v", id, err)
+		if err.Error() == "task not found" {
+			JSONError(w, "Task not found", http.StatusNotFound)
+			return
+		}
+		JSONError(w, "Failed to purge task", http.StatusInternalServerError)
+		return
+	}
+
+	h.analytics.Track(ctx, "Task Purged", strconv.Itoa(claims.UserID), map[string]any{
+		"task_id": id,
+		"user_id": claims.UserID,
+	})
+	log.Printf("Successfully purged task ID: %d", id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// EmptyTrash permanently deletes every task in the authenticated user's trash.
+//
+// Authorization:
+//   - Requires valid JWT token in request context
+//
+// HTTP Responses:
+//   - 200 OK: Trash emptied, the number of removed tasks is returned
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 500 Internal Server Error: Database or server errors
+//
+// Example success response:
+//
+//	{
+//	    "purged": 12
+//	}
+func (h *TaskHandler) EmptyTrash(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := ctx.Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	purged, err := models.EmptyTrash(h.DB, claims.UserID)
+	if err != nil {
+		log.Printf("Error emptying trash for user %d: %v", claims.UserID, err)
+		JSONError(w, "Failed to empty trash", http.StatusInternalServerError)
+		return
+	}
+
+	h.analytics.Track(ctx, "Trash Emptied", strconv.Itoa(claims.UserID), map[string]any{
+		"user_id":      claims.UserID,
+		"purged_count": purged,
+	})
+	log.Printf("Successfully purged %d tasks for user ID: %d", purged, claims.UserID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int64{
+		"purged": purged,
+	})
+}
+
+// AddTaskDependency makes a task wait for another task of the user. While
+// the other task is not done, the task can't move to a doing or done status
+// unless the move ignores blockers.
+//
+// URL Parameters:
+//   - id: Task identifier (integer) of the task that waits
+//
+// Request Body:
+//
+//	{
+//	    "blocked_by_id": 3    // Required: the task that has to be done first
+//	}
+//
+// Authorization:
+//   - Requires valid JWT token in request context
+//   - User must own both tasks
+//
+// HTTP Responses:
+//   - 201 Created: Dependency added, the dependency graph of the task is returned
+//   - 400 Bad Request: Invalid task ID or input data, or a task blocking itself
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 404 Not Found: Either task doesn't exist
+//   - 409 Conflict: The dependency would form a cycle
+//   - 500 Internal Server Error: Database or server errors
+//
+// Example success response:
+//
+//	{
+//	    "blocked_by": [3],
+//	    "blocks": [],
+//	    "nodes": [
+//	        {"id": 3, "title": "Design schema", "status": "in_progress", "status_category": "doing"},
+//	        {"id": 7, "title": "Write migration", "status": "pending", "status_category": "todo"}
+//	    ],
+//	    "edges": [
+//	        {"task_id": 7, "blocked_by_id": 3}
+//	    ]
+//	}
+func (h *TaskHandler) AddTaskDependency(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := ctx.Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Extract and validate task ID from URL parameters
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Printf("Invalid task ID format: %s", vars["id"])
+		JSONError(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+
+	var input struct {
+		BlockedByID int `json:"blocked_by_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.BlockedByID == 0 {
+		log.Printf("Error decoding dependency data: %v", err)
+		JSONError(w, "Invalid input data", http.StatusBadRequest)
+		return
+	}
+
+	if err := models.AddTaskDependency(h.DB, claims.UserID, id, input.BlockedByID); err != nil {
+		log.Printf("Error adding dependency %d -> %d: %v", id, input.BlockedByID, err)
+		h.analytics.Track(ctx, "Task Dependency Failed", strconv.Itoa(claims.UserID), map[string]any{
+			"error":         err.Error(),
+			"task_id":       id,
+			"blocked_by_id": input.BlockedByID,
+			"user_id":       claims.UserID,
+		})
+		switch err.Error() {
+		case "a task cannot block itself":
+			JSONError(w, "A task cannot block itself", http.StatusBadRequest)
+		case "task not found":
+			JSONError(w, "Task not found", http.StatusNotFound)
+		case "dependency cycle":
+			JSONError(w, "Dependency would form a cycle", http.StatusConflict)
+		default:
+			JSONError(w, "Failed to add dependency", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	graph, err := models.GetDependencyGraph(h.DB, claims.UserID, id)
+	if err != nil {
+		log.Printf("Error fetching dependencies of task %d: %v", id, err)
+		JSONError(w, "Failed to fetch dependencies", http.StatusInternalServerError)
+		return
+	}
+
+	h.analytics.Track(ctx, "Task Dependency Added", strconv.Itoa(claims.UserID), map[string]any{
+		"task_id":       id,
+		"blocked_by_id": input.BlockedByID,
+		"user_id":       claims.UserID,
+	})
+	log.Printf("Task %d is now blocked by task %d", id, input.BlockedByID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(graph)
+}
+
+// RemoveTaskDependency lets a task stop waiting for another task.
+//
+// URL Parameters:
+//   - id: Task identifier (integer) of the task that waits
+//   - blockedById: Identifier (integer) of the task it waits for
+//
+// Authorization:
+//   - Requires valid JWT token in request context
+//   - User must own the task
+//
+// HTTP Responses:
+//   - 204 No Content: Dependency removed
+//   - 400 Bad Request: Invalid task ID format
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 404 Not Found: The task doesn't wait for the other task
+//   - 500 Internal Server Error: Database or server errors
+func (h *TaskHandler) RemoveTaskDependency(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := ctx.Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Extract and validate task IDs from URL parameters
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.Printf("Invalid task ID format: %s", vars["id"])
+		JSONError(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+	blockedByID, err := strconv.Atoi(vars["blockedById"])
+	if err != nil {
+		log.Printf("Invalid task ID format: %s", vars["blockedById"])
+		JSONError(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := models.RemoveTaskDependency(h.DB, claims.UserID, id, blockedByID); err != nil {
+		log.Printf("Error removing dependency %d -> %d: %v", id, blockedByID, err)
+		if err.Error() == "dependency not found" {
+			JSONError(w, "Dependency not found", http.StatusNotFound)
+			return
+		}
+		JSONError(w, "Failed to remove dependency", http.StatusInternalServerError)
+		return
+	}
+
+	h.analytics.Track(ctx, "Task Dependency Removed", strconv.Itoa(claims.UserID), map[string]any{
+		"task_id":       id,
+		"blocked_by_id": blockedByID,
+		"user_id":       claims.UserID,
+	})
+	log.Printf("Task %d is no longer blocked by task %d", id, blockedByID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// trackVersionConflict records a write rejected because the client's
+// version of the task was outdated.
+func (h *TaskHandler) trackVersionConflict(ctx context.Context, event string, taskID, userID, expected, current int) {
+	h.analytics.Track(ctx, event, strconv.Itoa(userID), map[string]any{
+		"reason":           "version_conflict",
+		"task_id":          taskID,
+		"user_id":          userID,
+		"expected_version": expected,
+		"current_version":  current,
+	})
+}
+
+// parseTaskFilter builds a models.TaskFilter from the query string of a
+// task listing request. Unknown parameters are ignored.
+func parseTaskFilter(r *http.Request) (models.TaskFilter, error) {
+	return parseTaskFilterValues(r.URL.Query())
+}
+
+// parseTaskFilterValues builds a models.TaskFilter from task listing
+// parameters. Unknown parameters are ignored.
+func parseTaskFilterValues(query url.Values) (models.TaskFilter, error) {
+	var filter models.TaskFilter
+
+	if value := query.Get("overdue"); value != "" {
+		overdue, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid overdue value: %s", value)
+		}
+		filter.Overdue = overdue
+	}
+
+	if value := query.Get("priority"); value != "" {
+		filter.Priorities = strings.Split(value, ",")
+	}
+
+	if value := query.Get("labels"); value != "" {
+		for _, part := range strings.Split(value, ",") {
+			labelID, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				return filter, fmt.Errorf("invalid label ID: %s", part)
+			}
+			filter.LabelIDs = append(filter.LabelIDs, labelID)
+		}
+	}
+
+	filter.LabelMode = query.Get("label_mode")
+
+	if value := query.Get("project_id"); value != "" {
+		if value == "inbox" {
+			filter.Inbox = true
+		} else {
+			projectID, err := strconv.Atoi(value)
+			if err != nil {
+				return filter, fmt.Errorf("invalid project ID: %s", value)
+			}
+			filter.ProjectID = &projectID
+		}
+	}
+
+	if value := query.Get("parent_id"); value != "" {
+		parentID, err := strconv.Atoi(value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid parent ID: %s", value)
+		}
+		filter.ParentID = &parentID
+	}
+
+	if value := query.Get("status"); value != "" {
+		filter.Statuses = strings.Split(value, ",")
+	}
+
+	ranges := []struct {
+		name   string
+		target **time.Time
+	}{
+		{"created_after", &filter.CreatedAfter},
+		{"created_before", &filter.CreatedBefore},
+		{"updated_after", &filter.UpdatedAfter},
+		{"updated_before", &filter.UpdatedBefore},
+	}
+	for _, r := range ranges {
+		if value := query.Get(r.name); value != "" {
+			parsed, err := parseFilterTime(value)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s value: %s", r.name, value)
+			}
+			*r.target = &parsed
+		}
+	}
+
+	filter.Query = query.Get("q")
+	filter.Sort = query.Get("sort")
+	filter.Order = query.Get("order")
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return filter, fmt.Errorf("invalid limit: %s", value)
+		}
+		filter.Limit = limit
+	}
+
+	filter.Cursor = query.Get("cursor")
+
+	return filter, filter.Validate()
+}
+
+// parseFilterTime parses a filter timestamp given either in RFC 3339
+// format or as a date (YYYY-MM-DD), which is taken as midnight UTC.
+func parseFilterTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
@@ -4,9 +4,12 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -18,6 +21,57 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// taskColumnNames lists the columns returned by task SELECT queries.
+var taskColumnNames = []string{
+	"id", "title", "description", "status", "user_id", "position", "created_at", "updated_at",
+	"start_at", "due_at", "all_day", "priority", "project_id", "labels",
+	"parent_id", "subtask_count", "completed_subtasks", "recurrence_rule", "series_id",
+	"deleted_at", "started_at", "completed_at", "version", "rank", "time_zone",
+	"status_category", "blocked", "time_spent", "estimate_points", "estimate_minutes",
+}
+
+// newTaskRow builds a task row for taskColumnNames with fresh timestamps
+// no schedule, no priority, no project, no labels, no subtasks and no recurrence.
+func newTaskRow(id driver.Value, title, description, status string, userID, position int) []driver.Value {
+	return []driver.Value{
+		id, title, description, status, userID, position, time.Now(), time.Now(),
+		nil, nil, false, "none", nil, []byte("[]"),
+		nil, 0, 0, "", nil,
+		nil, nil, nil, 1, "i", "UTC",
+		testStatusCategory(status), false, int64(0), nil, nil,
+	}
+}
+
+// testStatusCategory returns the category of a status of the built-in workflow.
+func testStatusCategory(status string) string {
+	switch status {
+	case "completed":
+		return "done"
+	case "in_progress":
+		return "doing"
+	default:
+		return "todo"
+	}
+}
+
+// expectNoDependencies expects the dependency graph lookup of a task
+// without dependencies.
+func expectNoDependencies(mock sqlmock.Sqlmock, id int) {
+	mock.ExpectQuery("WITH RECURSIVE upstream AS").
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"task_id", "blocked_by_id"}))
+	mock.ExpectQuery("SELECT id, title, status, status_category FROM tasks").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "status", "status_category"}).
+			AddRow(id, "Task", "pending", "todo"))
+}
+
+// expectDefaultWorkflow expects the workflow lookup of a user without
+// custom statuses.
+func expectDefaultWorkflow(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("SELECT key, name, category, transitions, wip_limit FROM workflow_statuses").
+		WillReturnRows(sqlmock.NewRows([]string{"key", "name", "category", "transitions", "wip_limit"}))
+}
+
 func TestGetTasks(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -33,13 +87,9 @@ func TestGetTasks(t *testing.T) {
 				return req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
 			},
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				createdAt := time.Now()
-				updatedAt := time.Now()
-				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE user_id = \\$1").
+				mock.ExpectQuery("SELECT (.+) FROM (.+) tasks WHERE user_id = \\$1").
 					WithArgs(1).
-					WillReturnRows(sqlmock.NewRows([]string{
-						"id", "title", "description", "status", "user_id", "position", "created_at", "updated_at",
-					}).AddRow(1, "Test Task", "Test Description", "pending", 1, 0, createdAt, updatedAt))
+					WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(newTaskRow(1, "Test Task", "Test Description", "pending", 1, 0)...))
 			},
 			expectedStatus: http.StatusOK,
 			expectedTasks: []models.Task{
@@ -59,11 +109,9 @@ func TestGetTasks(t *testing.T) {
 				return req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
 			},
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE user_id = \\$1").
+				mock.ExpectQuery("SELECT (.+) FROM (.+) tasks WHERE user_id = \\$1").
 					WithArgs(1).
-					WillReturnRows(sqlmock.NewRows([]string{
-						"id", "title", "description", "status", "user_id", "position", "created_at", "updated_at",
-					}))
+					WillReturnRows(sqlmock.NewRows(taskColumnNames))
 			},
 			expectedStatus: http.StatusOK,
 			expectedTasks:  []models.Task{},
@@ -85,7 +133,7 @@ func TestGetTasks(t *testing.T) {
 				return req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
 			},
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE user_id = \\$1").
+				mock.ExpectQuery("SELECT (.+) FROM (.+) tasks WHERE user_id = \\$1").
 					WithArgs(1).
 					WillReturnError(sql.ErrConnDone)
 			},
@@ -160,6 +208,159 @@ func TestGetTasks(t *testing.T) {
 	}
 }
 
+func TestGetTasksInvalidFilter(t *testing.T) {
+	queries := []string{
+		"overdue=maybe",
+		"priority=critical",
+		"sort=random",
+		"project_id=home",
+		"status=deleted",
+		"created_after=yesterday",
+		"order=sideways",
+		"limit=0",
+		"limit=500",
+		"cursor=bogus",
+	}
+
+	for _, query := range queries {
+		t.Run(query, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			handler := NewTaskHandler(db, analytics.NewMock("test-key", false))
+			req, err := http.NewRequest("GET", "/api/tasks?"+query, nil)
+			assert.NoError(t, err)
+			req = req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
+
+			rr := httptest.NewRecorder()
+			handler.GetTasks(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetTasksPagination(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT (.+) FROM (.+) tasks WHERE user_id = \\$1 (.+) AND status = ANY\\(\\$2\\) ORDER BY LOWER\\(title\\) ASC, id ASC LIMIT \\$3").
+		WithArgs(1, sqlmock.AnyArg(), 3).
+		WillReturnRows(sqlmock.NewRows(taskColumnNames).
+			AddRow(newTaskRow(1, "Alpha", "", "pending", 1, 0)...).
+			AddRow(newTaskRow(2, "Beta", "", "pending", 1, 1)...).
+			AddRow(newTaskRow(3, "Gamma", "", "pending", 1, 2)...))
+
+	handler := NewTaskHandler(db, analytics.NewMock("test-key", false))
+	req, err := http.NewRequest("GET", "/api/tasks?status=pending&sort=title&limit=2", nil)
+	assert.NoError(t, err)
+	req = req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
+
+	rr := httptest.NewRecorder()
+	handler.GetTasks(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var tasks []models.Task
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&tasks))
+	assert.Len(t, tasks, 2)
+
+	cursor := rr.Header().Get("X-Next-Cursor")
+	assert.NotEmpty(t, cursor)
+	assert.Equal(t, `</api/tasks?cursor=`+cursor+`&limit=2&sort=title&status=pending>; rel="next"`, rr.Header().Get("Link"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTaskSubtasks(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		mockSetup      func(sqlmock.Sqlmock)
+		expectedStatus int
+		expectedCount  int
+	}{
+		{
+			name:  "Nested subtasks",
+			query: "subtasks=nested",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(newTaskRow(1, "Parent", "", "pending", 1, 0)...))
+				child := newTaskRow(2, "Child", "", "pending", 1, 0)
+				child[14] = 1
+				grandchild := newTaskRow(3, "Grandchild", "", "pending", 1, 0)
+				grandchild[14] = 2
+				mock.ExpectQuery("WITH RECURSIVE subtree").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(append(taskColumnNames, "depth")).
+						AddRow(append(child, 1)...).
+						AddRow(append(grandchild, 2)...))
+				expectNoDependencies(mock, 1)
+			},
+			expectedStatus: http.StatusOK,
+			expectedCount:  1,
+		},
+		{
+			name:  "Flat subtasks",
+			query: "subtasks=flat",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(newTaskRow(1, "Parent", "", "pending", 1, 0)...))
+				child := newTaskRow(2, "Child", "", "pending", 1, 0)
+				child[14] = 1
+				grandchild := newTaskRow(3, "Grandchild", "", "pending", 1, 0)
+				grandchild[14] = 2
+				mock.ExpectQuery("WITH RECURSIVE subtree").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(append(taskColumnNames, "depth")).
+						AddRow(append(child, 1)...).
+						AddRow(append(grandchild, 2)...))
+				expectNoDependencies(mock, 1)
+			},
+			expectedStatus: http.StatusOK,
+			expectedCount:  2,
+		},
+		{
+			name:           "Invalid mode",
+			query:          "subtasks=tree",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			if tt.mockSetup != nil {
+				tt.mockSetup(mock)
+			}
+
+			handler := NewTaskHandler(db, analytics.NewMock("test-key", false))
+			req, err := http.NewRequest("GET", "/api/tasks/1?"+tt.query, nil)
+			assert.NoError(t, err)
+			req = mux.SetURLVars(req, map[string]string{"id": "1"})
+			req = req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
+
+			rr := httptest.NewRecorder()
+			handler.GetTask(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var task models.Task
+				assert.NoError(t, json.NewDecoder(rr.Body).Decode(&task))
+				assert.Len(t, task.Subtasks, tt.expectedCount)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
 func TestGetTask(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -173,13 +374,20 @@ func TestGetTask(t *testing.T) {
 			name:   "Successful task retrieval",
 			taskID: "1",
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				createdAt := time.Now()
-				updatedAt := time.Now()
 				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1").
 					WithArgs(1).
-					WillReturnRows(sqlmock.NewRows([]string{
-						"id", "title", "description", "status", "user_id", "position", "created_at", "updated_at",
-					}).AddRow(1, "Test Task", "Test Description", "pending", 1, 0, createdAt, updatedAt))
+					WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(newTaskRow(1, "Test Task", "Test Description", "pending", 1, 0)...))
+				mock.ExpectQuery("WITH RECURSIVE upstream AS (.+) UNION SELECT task_id, blocked_by_id FROM downstream").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"task_id", "blocked_by_id"}).
+						AddRow(1, 5).
+						AddRow(7, 1))
+				mock.ExpectQuery("SELECT id, title, status, status_category FROM tasks WHERE user_id = \\$1 AND id = ANY\\(\\$2\\)").
+					WithArgs(1, "{1,5,7}").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "title", "status", "status_category"}).
+						AddRow(1, "Test Task", "pending", "todo").
+						AddRow(5, "Blocker", "in_progress", "doing").
+						AddRow(7, "Follow-up", "pending", "todo"))
 			},
 			expectedStatus: http.StatusOK,
 			expectedTask: &models.Task{
@@ -211,6 +419,18 @@ func TestGetTask(t *testing.T) {
 			expectedStatus: http.StatusNotFound,
 			expectedError:  "Task not found",
 		},
+		{
+			name:   "Task of another user",
+			taskID: "2",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				// Neither subtasks nor dependencies are loaded
+				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1").
+					WithArgs(2).
+					WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(newTaskRow(2, "Private", "", "pending", 2, 0)...))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "Task not found",
+		},
 		{
 			name:   "Database error",
 			taskID: "1",
@@ -241,6 +461,7 @@ func TestGetTask(t *testing.T) {
 			req, err := http.NewRequest("GET", "/api/tasks/"+tt.taskID, nil)
 			assert.NoError(t, err)
 			req = mux.SetURLVars(req, map[string]string{"id": tt.taskID})
+			req = req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
 
 			// Create response recorder
 			rr := httptest.NewRecorder()
@@ -472,9 +693,11 @@ func TestUpdateTaskPositionsTaskNotFound(t *testing.T) {
 	assert.NoError(t, err)
 	defer db.Close()
 
-	mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = ?").
-		WithArgs(999).
-		WillReturnError(sql.ErrNoRows)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, project_id, parent_id FROM tasks WHERE user_id = \\$1 AND id = ANY\\(\\$2\\)").
+		WithArgs(1, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "project_id", "parent_id"}))
+	mock.ExpectRollback()
 
 	mockAnalytics := analytics.NewMock("test-key", false)
 
@@ -494,6 +717,53 @@ func TestUpdateTaskPositionsTaskNotFound(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestUpdateTaskPositionsMoves(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, project_id, parent_id FROM tasks").
+		WithArgs(1, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "project_id", "parent_id"}).
+			AddRow(3, nil, nil).
+			AddRow(1, nil, nil))
+	mock.ExpectQuery("SELECT id, rank FROM tasks").
+		WithArgs(1, nil, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "rank"}).
+			AddRow(1, "i").
+			AddRow(2, "r").
+			AddRow(3, "z"))
+	mock.ExpectExec("UPDATE tasks SET rank = v.rank").
+		WithArgs("{3}", `{"h"}`, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO task_events").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT (.+) FROM (.+) tasks WHERE user_id = \\$1 AND status != 'deleted' AND parent_id IS NULL AND project_id IS NULL").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(taskColumnNames).
+			AddRow(newTaskRow(3, "Third", "", "pending", 1, 0)...).
+			AddRow(newTaskRow(1, "First", "", "pending", 1, 1)...).
+			AddRow(newTaskRow(2, "Second", "", "pending", 1, 2)...))
+
+	handler := NewTaskHandler(db, analytics.NewMock("test-key", false))
+	body := `{"moves": [{"task_id": 3, "before": 1}]}`
+	req, err := http.NewRequest("PUT", "/api/tasks/positions", strings.NewReader(body))
+	assert.NoError(t, err)
+	req = req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
+
+	rr := httptest.NewRecorder()
+	handler.UpdateTaskPositions(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var tasks []models.Task
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&tasks))
+	assert.Len(t, tasks, 3)
+	assert.Equal(t, 3, tasks[0].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 // func TestDeleteTask(t *testing.T) {
 // 	tests := []struct {
 // 		name           string
@@ -589,3 +859,984 @@ func TestUpdateTaskPositionsTaskNotFound(t *testing.T) {
 // 		})
 // 	}
 // }
+
+func TestDeleteTaskSeries(t *testing.T) {
+	tests := []struct {
+		name           string
+		taskID         string
+		mockSetup      func(sqlmock.Sqlmock)
+		expectedStatus int
+	}{
+		{
+			name:   "Successful deletion",
+			taskID: "7",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("WITH RECURSIVE series AS").
+					WithArgs(sqlmock.AnyArg(), 7, 1).
+					WillReturnResult(sqlmock.NewResult(0, 3))
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:   "Task not found",
+			taskID: "9",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("WITH RECURSIVE series AS").
+					WithArgs(sqlmock.AnyArg(), 9, 1).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Invalid task ID",
+			taskID:         "abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			if tt.mockSetup != nil {
+				tt.mockSetup(mock)
+			}
+
+			handler := NewTaskHandler(db, analytics.NewMock("test-key", false))
+			req, err := http.NewRequest("DELETE", "/api/tasks/"+tt.taskID+"/series", nil)
+			assert.NoError(t, err)
+			req = mux.SetURLVars(req, map[string]string{"id": tt.taskID})
+			req = req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
+
+			rr := httptest.NewRecorder()
+			handler.DeleteTaskSeries(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUpdateTaskIfMatch(t *testing.T) {
+	tests := []struct {
+		name           string
+		ifMatch        string
+		mockSetup      func(sqlmock.Sqlmock)
+		expectedStatus int
+	}{
+		{
+			name:    "Stale version",
+			ifMatch: `"3"`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(newTaskRow(1, "Task", "", "pending", 1, 0)...))
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:    "Changed while updating",
+			ifMatch: `"1"`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				row := newTaskRow(1, "Task", "", "pending", 1, 0)
+				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(row...))
+				row[22] = 2
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1 FOR UPDATE").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(row...))
+				mock.ExpectRollback()
+				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(row...))
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:    "Malformed header",
+			ifMatch: `W/"1"`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(newTaskRow(1, "Task", "", "pending", 1, 0)...))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			tt.mockSetup(mock)
+
+			handler := NewTaskHandler(db, analytics.NewMock("test-key", false))
+			body := `{"title": "Renamed", "status": "pending", "priority": "none", "version": 1}`
+			req, err := http.NewRequest("PUT", "/api/tasks/1", strings.NewReader(body))
+			assert.NoError(t, err)
+			req.Header.Set("If-Match", tt.ifMatch)
+			req = mux.SetURLVars(req, map[string]string{"id": "1"})
+			req = req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
+
+			rr := httptest.NewRecorder()
+			handler.UpdateTask(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedStatus == http.StatusPreconditionFailed {
+				// The client receives the current task to merge with
+				var current models.Task
+				assert.NoError(t, json.NewDecoder(rr.Body).Decode(&current))
+				assert.Equal(t, "Task", current.Title)
+				assert.Equal(t, fmt.Sprintf(`"%d"`, current.Version), rr.Header().Get("ETag"))
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPatchTask(t *testing.T) {
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		mockSetup      func(sqlmock.Sqlmock)
+		expectedStatus int
+	}{
+		{
+			name:        "Merge patch",
+			contentType: "application/merge-patch+json",
+			body:        `{"status": "completed", "description": null}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				row := newTaskRow(1, "Task", "Details", "in_progress", 1, 0)
+				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(row...))
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1 FOR UPDATE").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(row...))
+				expectDefaultWorkflow(mock)
+				mock.ExpectQuery("UPDATE tasks SET title").
+					WithArgs("Task", "", "completed", sqlmock.AnyArg(), nil, nil, false, "none", nil, "i", "",
+						sqlmock.AnyArg(), sqlmock.AnyArg(), "done", nil, nil, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+				mock.ExpectExec("INSERT INTO task_events").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectCommit()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "Invalid status",
+			contentType: "application/json-patch+json",
+			body:        `[{"op": "replace", "path": "/status", "value": "done"}]`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				row := newTaskRow(1, "Task", "", "pending", 1, 0)
+				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(row...))
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1 FOR UPDATE").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(row...))
+				expectDefaultWorkflow(mock)
+				mock.ExpectRollback()
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "Failed test operation",
+			contentType: "application/json-patch+json",
+			body:        `[{"op": "test", "path": "/status", "value": "in_progress"}, {"op": "remove", "path": "/description"}]`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(newTaskRow(1, "Task", "", "pending", 1, 0)...))
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:        "Missing path",
+			contentType: "application/json-patch+json",
+			body:        `[{"op": "replace", "path": "/due_at", "value": "2024-01-01T00:00:00Z"}]`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(newTaskRow(1, "Task", "", "pending", 1, 0)...))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "Unsupported media type",
+			contentType:    "text/plain",
+			body:           `status=completed`,
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			tt.mockSetup(mock)
+
+			handler := NewTaskHandler(db, analytics.NewMock("test-key", false))
+			req, err := http.NewRequest("PATCH", "/api/tasks/1", strings.NewReader(tt.body))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", tt.contentType)
+			req = mux.SetURLVars(req, map[string]string{"id": "1"})
+			req = req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
+
+			rr := httptest.NewRecorder()
+			handler.PatchTask(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var task models.Task
+				assert.NoError(t, json.NewDecoder(rr.Body).Decode(&task))
+				assert.Equal(t, "Task", task.Title)
+				assert.Equal(t, "", task.Description)
+				assert.Equal(t, `"2"`, rr.Header().Get("ETag"))
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDeleteTaskVersionConflict(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec("SELECT id FROM tasks WHERE id = \\$2 AND user_id = \\$3 AND version = \\$4").
+		WithArgs(sqlmock.AnyArg(), 1, 1, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT EXISTS").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	row := newTaskRow(1, "Task", "", "pending", 1, 0)
+	row[22] = 2
+	mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(row...))
+
+	handler := NewTaskHandler(db, analytics.NewMock("test-key", false))
+	req, err := http.NewRequest("DELETE", "/api/tasks/1", nil)
+	assert.NoError(t, err)
+	req.Header.Set("If-Match", `"1"`)
+	req = mux.SetURLVars(req, map[string]string{"id": "1"})
+	req = req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
+
+	rr := httptest.NewRecorder()
+	handler.DeleteTask(rr, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+	assert.Equal(t, `"2"`, rr.Header().Get("ETag"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteTaskOfAnotherUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	// Task 1 belongs to another user: not found, whatever its version
+	mock.ExpectExec("SELECT id FROM tasks WHERE id = \\$2 AND user_id = \\$3 AND version = \\$4").
+		WithArgs(sqlmock.AnyArg(), 1, 2, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT EXISTS").
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	handler := NewTaskHandler(db, analytics.NewMock("test-key", false))
+	req, err := http.NewRequest("DELETE", "/api/tasks/1", nil)
+	assert.NoError(t, err)
+	req.Header.Set("If-Match", `"1"`)
+	req = mux.SetURLVars(req, map[string]string{"id": "1"})
+	req = req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 2}))
+
+	rr := httptest.NewRecorder()
+	handler.DeleteTask(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	var response map[string]string
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+	assert.Equal(t, "Task not found", response["error"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRestoreTaskConflict(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT status, deleted_at").
+		WithArgs(3, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "deleted_at", "project_id", "parent_id"}).
+			AddRow("pending", nil, nil, nil))
+	mock.ExpectRollback()
+
+	handler := NewTaskHandler(db, analytics.NewMock("test-key", false))
+	req, err := http.NewRequest("POST", "/api/tasks/3/restore", nil)
+	assert.NoError(t, err)
+	req = mux.SetURLVars(req, map[string]string{"id": "3"})
+	req = req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
+
+	rr := httptest.NewRecorder()
+	handler.RestoreTask(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTrashEmpty(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT (.+) FROM (.+) tasks WHERE user_id = \\$1 AND status = 'deleted'").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(taskColumnNames))
+
+	handler := NewTaskHandler(db, analytics.NewMock("test-key", false))
+	req, err := http.NewRequest("GET", "/api/tasks/trash", nil)
+	assert.NoError(t, err)
+	req = req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
+
+	rr := httptest.NewRecorder()
+	handler.GetTrash(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, "[]", rr.Body.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSearchTasks(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		mockSetup      func(sqlmock.Sqlmock)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:  "Successful search",
+			query: "q=report&include_deleted=true",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				columns := append(append([]string{}, taskColumnNames...), "search_rank", "title_highlight", "snippet")
+				mock.ExpectQuery("SELECT (.+) FROM (.+) tasks, to_tsquery").
+					WithArgs(1, "report:*", 20).
+					WillReturnRows(sqlmock.NewRows(columns).AddRow(append(newTaskRow(2, "Report", "", "deleted", 1, 0),
+						0.6, "<mark>Report</mark>", "")...))
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Missing query",
+			query:          "q=%20",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "Search query is required",
+		},
+		{
+			name:           "Invalid limit",
+			query:          "q=report&limit=1000",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "Invalid limit",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			if tt.mockSetup != nil {
+				tt.mockSetup(mock)
+			}
+
+			handler := NewTaskHandler(db, analytics.NewMock("test-key", false))
+			req, err := http.NewRequest("GET", "/api/tasks/search?"+tt.query, nil)
+			assert.NoError(t, err)
+			req = req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
+
+			rr := httptest.NewRecorder()
+			handler.SearchTasks(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedError != "" {
+				var response map[string]string
+				assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+				assert.Equal(t, tt.expectedError, response["error"])
+			} else {
+				var results []models.TaskSearchResult
+				assert.NoError(t, json.NewDecoder(rr.Body).Decode(&results))
+				assert.Len(t, results, 1)
+				assert.Equal(t, "<mark>Report</mark>", results[0].TitleHighlight)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestBulkUpdateTasks(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		mockSetup      func(sqlmock.Sqlmock)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name: "Delete by filter",
+			body: `{"action": "delete", "filter": {"status": "completed"}}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE (.+) ORDER BY rank ASC, id ASC LIMIT").
+					WithArgs(1, "{\"completed\"}", 501).
+					WillReturnRows(sqlmock.NewRows(taskColumnNames).
+						AddRow(newTaskRow(3, "Done", "", "completed", 1, 0)...))
+				mock.ExpectBegin()
+				mock.ExpectExec("SAVEPOINT bulk_task").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1 AND user_id = \\$2 AND status != 'deleted' FOR UPDATE").
+					WithArgs(3, 1).
+					WillReturnRows(sqlmock.NewRows(taskColumnNames).
+						AddRow(newTaskRow(3, "Done", "", "completed", 1, 0)...))
+				mock.ExpectExec("deleted AS \\( UPDATE tasks").
+					WithArgs(sqlmock.AnyArg(), 3, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("RELEASE SAVEPOINT bulk_task").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Empty filter result",
+			body: `{"action": "complete", "filter": {"overdue": "true"}}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM tasks").
+					WillReturnRows(sqlmock.NewRows(taskColumnNames))
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "IDs and filter",
+			body:           `{"action": "complete", "task_ids": [3], "filter": {}}`,
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "Use either task_ids or filter",
+		},
+		{
+			name:           "Restore by filter",
+			body:           `{"action": "restore", "filter": {}}`,
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "Restore requires task_ids",
+		},
+		{
+			name:           "Invalid action",
+			body:           `{"action": "archive", "task_ids": [3]}`,
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid action: archive",
+		},
+		{
+			name:           "Invalid action by filter",
+			body:           `{"action": "archive", "filter": {"overdue": "true"}}`,
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid action: archive",
+		},
+		{
+			name:           "Relabel by filter without labels",
+			body:           `{"action": "relabel", "filter": {"overdue": "true"}}`,
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "labels are required",
+		},
+		{
+			name: "Foreign project",
+			body: `{"action": "move", "task_ids": [3], "project_id": 9}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT EXISTS").
+					WithArgs(9, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectRollback()
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "Project not found",
+		},
+		{
+			name: "Atomic rollback",
+			body: `{"action": "complete", "task_ids": [4], "atomic": true}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				expectDefaultWorkflow(mock)
+				mock.ExpectExec("SAVEPOINT bulk_task").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1").
+					WithArgs(4, 1).
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectExec("ROLLBACK TO SAVEPOINT bulk_task").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			tt.mockSetup(mock)
+
+			handler := NewTaskHandler(db, analytics.NewMock("test-key", false))
+			req, err := http.NewRequest("POST", "/api/tasks/bulk", strings.NewReader(tt.body))
+			assert.NoError(t, err)
+			req = req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
+
+			rr := httptest.NewRecorder()
+			handler.BulkUpdateTasks(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedError != "" {
+				var response map[string]string
+				json.NewDecoder(rr.Body).Decode(&response)
+				assert.Equal(t, tt.expectedError, response["error"])
+			} else {
+				var result models.BulkResult
+				assert.NoError(t, json.NewDecoder(rr.Body).Decode(&result))
+				assert.NotNil(t, result.Results)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetTaskHistoryNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT EXISTS").
+		WithArgs(9, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	handler := NewTaskHandler(db, analytics.NewMock("test-key", false))
+	req, err := http.NewRequest("GET", "/api/tasks/9/history", nil)
+	assert.NoError(t, err)
+	req = mux.SetURLVars(req, map[string]string{"id": "9"})
+	req = req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
+
+	rr := httptest.NewRecorder()
+	handler.GetTaskHistory(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetFlowStatistics(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		mockSetup      func(sqlmock.Sqlmock)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:  "Inclusive date range",
+			query: "from=2024-05-06&to=2024-05-12",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				from := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+				to := time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC)
+				mock.ExpectQuery("FROM user_preferences").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"time_zone", "locale", "week_start", "date_format", "updated_at"}).
+						AddRow("America/Los_Angeles", "en", "monday", "YYYY-MM-DD", time.Now()))
+				// Local midnights in Los Angeles are 07:00 UTC in May
+				mock.ExpectQuery("WITH durations AS").
+					WithArgs(1, from.Add(7*time.Hour), to.Add(7*time.Hour)).
+					WillReturnRows(sqlmock.NewRows([]string{"count", "lead_avg", "lead_median", "cycle_count", "cycle_avg", "cycle_median"}).
+						AddRow(0, 0, 0, 0, 0, 0))
+				mock.ExpectQuery("FROM generate_series").
+					WithArgs(1, from, to, 0, "America/Los_Angeles").
+					WillReturnRows(sqlmock.NewRows([]string{"week_start", "count"}).AddRow(from, 0))
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "Defaults to today in the user's time zone",
+			query: "",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				kiritimati, _ := time.LoadLocation("Pacific/Kiritimati")
+				now := time.Now().In(kiritimati)
+				to := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, kiritimati)
+				from := to.AddDate(0, 0, -84)
+				for i := 0; i < 2; i++ {
+					mock.ExpectQuery("FROM user_preferences").
+						WithArgs(1).
+						WillReturnRows(sqlmock.NewRows([]string{"time_zone", "locale", "week_start", "date_format", "updated_at"}).
+							AddRow("Pacific/Kiritimati", "en", "monday", "YYYY-MM-DD", time.Now()))
+				}
+				mock.ExpectQuery("WITH durations AS").
+					WithArgs(1, from.UTC(), to.UTC()).
+					WillReturnRows(sqlmock.NewRows([]string{"count", "lead_avg", "lead_median", "cycle_count", "cycle_avg", "cycle_median"}).
+						AddRow(0, 0, 0, 0, 0, 0))
+				mock.ExpectQuery("FROM generate_series").
+					WillReturnRows(sqlmock.NewRows([]string{"week_start", "count"}))
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid date",
+			query:          "from=May",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "Invalid from date",
+		},
+		{
+			name:           "Reversed range",
+			query:          "from=2024-05-13&to=2024-05-06",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "From date must not be after to date",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			if tt.mockSetup != nil {
+				tt.mockSetup(mock)
+			}
+
+			handler := NewTaskHandler(db, analytics.NewMock("test-key", false))
+			req, err := http.NewRequest("GET", "/api/users/statistics/flow?"+tt.query, nil)
+			assert.NoError(t, err)
+			req = req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
+
+			rr := httptest.NewRecorder()
+			handler.GetFlowStatistics(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedError != "" {
+				var response map[string]string
+				assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+				assert.Equal(t, tt.expectedError, response["error"])
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetBurndownStatistics(t *testing.T) {
+	// The range defaults to the last two weeks up to today in the user's
+	// time zone
+	expectDefaultRange := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery("FROM user_preferences").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"time_zone", "locale", "week_start", "date_format", "updated_at"}).
+				AddRow("Pacific/Kiritimati", "en", "monday", "YYYY-MM-DD", time.Now()))
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		mockSetup      func(sqlmock.Sqlmock)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:  "Project sprint in minutes",
+			query: "from=2024-03-04&to=2024-03-05&unit=minutes&project_id=3",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM projects").
+					WithArgs(3, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+				mock.ExpectQuery("FROM user_preferences").
+					WithArgs(1).
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery("SUM\\(t.estimate_minutes\\)").
+					WithArgs(1, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC), "UTC", 3).
+					WillReturnRows(sqlmock.NewRows([]string{"day", "remaining", "completed"}).
+						AddRow(time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC), 120, 0).
+						AddRow(time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 90, 30).
+						AddRow(time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), 0, 90))
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "Defaults to today in the user's time zone",
+			query: "",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				kiritimati, _ := time.LoadLocation("Pacific/Kiritimati")
+				now := time.Now().In(kiritimati)
+				to := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+				expectDefaultRange(mock)
+				expectDefaultRange(mock)
+				mock.ExpectQuery("SUM\\(t.estimate_points\\)").
+					WithArgs(1, to.AddDate(0, 0, -14), to, "Pacific/Kiritimati", nil).
+					WillReturnRows(sqlmock.NewRows([]string{"day", "remaining", "completed"}).
+						AddRow(to.AddDate(0, 0, -3), 120, 0).
+						AddRow(to.AddDate(0, 0, -2), 90, 30).
+						AddRow(to.AddDate(0, 0, -1), 0, 90))
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid unit",
+			query:          "unit=hours",
+			mockSetup:      expectDefaultRange,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "Invalid unit",
+		},
+		{
+			name:  "Foreign project",
+			query: "project_id=9",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				expectDefaultRange(mock)
+				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM projects").
+					WithArgs(9, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "Project not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			if tt.mockSetup != nil {
+				tt.mockSetup(mock)
+			}
+
+			handler := NewTaskHandler(db, analytics.NewMock("test-key", false))
+			req, err := http.NewRequest("GET", "/api/users/statistics/burndown?"+tt.query, nil)
+			assert.NoError(t, err)
+			req = req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
+
+			rr := httptest.NewRecorder()
+			handler.GetBurndownStatistics(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedError != "" {
+				var response map[string]string
+				assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+				assert.Equal(t, tt.expectedError, response["error"])
+			} else {
+				var burndown struct {
+					Start int `json:"start"`
+					Days  []struct {
+						Remaining int     `json:"remaining"`
+						Ideal     float64 `json:"ideal"`
+					} `json:"days"`
+				}
+				assert.NoError(t, json.NewDecoder(rr.Body).Decode(&burndown))
+				assert.Equal(t, 120, burndown.Start)
+				assert.Len(t, burndown.Days, 2)
+				assert.Equal(t, 90, burndown.Days[0].Remaining)
+				assert.Equal(t, float64(60), burndown.Days[0].Ideal)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAddTaskDependency(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		mockSetup      func(sqlmock.Sqlmock)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name: "Adds dependency",
+			body: `{"blocked_by_id": 3}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("SELECT id FROM users WHERE id = \\$1 FOR UPDATE").
+					WithArgs(1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT COUNT").
+					WithArgs(1, "{7,3}").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+				mock.ExpectQuery("WITH RECURSIVE upstream AS").
+					WithArgs(3, 7).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectExec("INSERT INTO task_dependencies").
+					WithArgs(7, 3).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				mock.ExpectQuery("WITH RECURSIVE upstream AS (.+) downstream AS").
+					WithArgs(7).
+					WillReturnRows(sqlmock.NewRows([]string{"task_id", "blocked_by_id"}).AddRow(7, 3))
+				mock.ExpectQuery("SELECT id, title, status, status_category FROM tasks").
+					WithArgs(1, "{7,3}").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "title", "status", "status_category"}).
+						AddRow(3, "Design", "in_progress", "doing").
+						AddRow(7, "Build", "pending", "todo"))
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "Dependency cycle",
+			body: `{"blocked_by_id": 3}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("SELECT id FROM users WHERE id = \\$1 FOR UPDATE").
+					WithArgs(1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT COUNT").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+				mock.ExpectQuery("WITH RECURSIVE upstream AS").
+					WithArgs(3, 7).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+				mock.ExpectRollback()
+			},
+			expectedStatus: http.StatusConflict,
+			expectedError:  "Dependency would form a cycle",
+		},
+		{
+			name:           "Blocks itself",
+			body:           `{"blocked_by_id": 7}`,
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "A task cannot block itself",
+		},
+		{
+			name:           "Missing blocker",
+			body:           `{}`,
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "Invalid input data",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			tt.mockSetup(mock)
+
+			handler := NewTaskHandler(db, analytics.NewMock("test-key", false))
+			req, err := http.NewRequest("POST", "/api/tasks/7/dependencies", strings.NewReader(tt.body))
+			assert.NoError(t, err)
+			req = mux.SetURLVars(req, map[string]string{"id": "7"})
+			req = req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
+
+			rr := httptest.NewRecorder()
+			handler.AddTaskDependency(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedError != "" {
+				var errorResponse map[string]string
+				assert.NoError(t, json.NewDecoder(rr.Body).Decode(&errorResponse))
+				assert.Equal(t, tt.expectedError, errorResponse["error"])
+			} else {
+				var graph models.DependencyGraph
+				assert.NoError(t, json.NewDecoder(rr.Body).Decode(&graph))
+				assert.Equal(t, []int{3}, graph.BlockedBy)
+				assert.Len(t, graph.Nodes, 2)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRemoveTaskDependencyNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec("DELETE FROM task_dependencies").
+		WithArgs(7, 3, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	handler := NewTaskHandler(db, analytics.NewMock("test-key", false))
+	req, err := http.NewRequest("DELETE", "/api/tasks/7/dependencies/3", nil)
+	assert.NoError(t, err)
+	req = mux.SetURLVars(req, map[string]string{"id": "7", "blockedById": "3"})
+	req = req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
+
+	rr := httptest.NewRecorder()
+	handler.RemoveTaskDependency(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateBlockedTask(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		mockSetup      func(sqlmock.Sqlmock, []driver.Value)
+		expectedStatus int
+	}{
+		{
+			name:  "Blocked",
+			query: "",
+			mockSetup: func(mock sqlmock.Sqlmock, row []driver.Value) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1 FOR UPDATE").
+					WithArgs(7).
+					WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(row...))
+				expectDefaultWorkflow(mock)
+				mock.ExpectRollback()
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "Invalid override",
+			query:          "?ignore_blockers=maybe",
+			mockSetup:      func(mock sqlmock.Sqlmock, row []driver.Value) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			row := newTaskRow(7, "Build", "", "pending", 1, 0)
+			row[26] = true
+			mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1").
+				WithArgs(7).
+				WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(row...))
+			tt.mockSetup(mock, row)
+
+			handler := NewTaskHandler(db, analytics.NewMock("test-key", false))
+			body := `{"title": "Build", "status": "in_progress", "priority": "none"}`
+			req, err := http.NewRequest("PUT", "/api/tasks/7"+tt.query, strings.NewReader(body))
+			assert.NoError(t, err)
+			req = mux.SetURLVars(req, map[string]string{"id": "7"})
+			req = req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
+
+			rr := httptest.NewRecorder()
+			handler.UpdateTask(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,346 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/maxzhirnov/go-task-manager/internal/middleware"
+	"github.com/maxzhirnov/go-task-manager/internal/models"
+	"github.com/maxzhirnov/go-task-manager/pkg/analytics"
+	"github.com/maxzhirnov/go-task-manager/pkg/database"
+)
+
+// TemplateHandler manages task templates: reusable task trees such as
+// onboarding or release checklists that create a task and its subtasks
+// in one request.
+type TemplateHandler struct {
+	// DB provides database access for template operations
+	DB        database.DB
+	analytics analytics.Tracker
+}
+
+// NewTemplateHandler creates a new instance of TemplateHandler.
+//
+// Parameters:
+//   - db: Database interface for template operations
+//   - analytics: Tracker for template events
+//
+// Returns:
+//   - *TemplateHandler: Configured template handler
+func NewTemplateHandler(db database.DB, analytics analytics.Tracker) *TemplateHandler {
+	return &TemplateHandler{
+		DB:        db,
+		analytics: analytics,
+	}
+}
+
+// GetTemplates retrieves all templates of the authenticated user.
+//
+// HTTP Responses:
+//   - 200 OK: Successfully retrieved templates
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 500 Internal Server Error: Database or server errors
+//
+// Example success response:
+//
+//	[
+//	    {
+//	        "id": 1,
+//	        "user_id": 123,
+//	        "name": "Release checklist",
+//	        "title": "Release",
+//	        "description": "",
+//	        "status": "",
+//	        "priority": "high",
+//	        "label_ids": [4],
+//	        "subtasks": [
+//	            {"title": "Tag version", "description": "", "status": "", "priority": "none", "label_ids": [], "subtasks": []}
+//	        ],
+//	        "created_at": "2024-01-01T12:00:00Z",
+//	        "updated_at": "2024-01-01T12:00:00Z"
+//	    }
+//	]
+func (h *TemplateHandler) GetTemplates(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	templates, err := models.GetTemplates(h.DB, claims.UserID)
+	if err != nil {
+		log.Printf("Error fetching templates for user %d: %v", claims.UserID, err)
+		JSONError(w, "Failed to fetch templates", http.StatusInternalServerError)
+		return
+	}
+
+	// Ensure null is never returned for templates array
+	if templates == nil {
+		templates = []models.TaskTemplate{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(templates)
+}
+
+// GetTemplate retrieves a single template of the authenticated user.
+//
+// URL Parameters:
+//   - id: Template identifier (integer)
+//
+// HTTP Responses:
+//   - 200 OK: Successfully retrieved template
+//   - 400 Bad Request: Invalid template ID format
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 404 Not Found: Template doesn't exist
+//   - 500 Internal Server Error: Database or server errors
+func (h *TemplateHandler) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		JSONError(w, "Invalid template ID", http.StatusBadRequest)
+		return
+	}
+
+	template, err := models.GetTemplate(h.DB, claims.UserID, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			JSONError(w, "Template not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error retrieving template %d: %v", id, err)
+		JSONError(w, "Failed to fetch template", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(template)
+}
+
+// CreateTemplate saves a new template for the authenticated user, either
+// described in the body or copied from an existing task and its subtasks.
+//
+// Request Body:
+//
+//	{
+//	    "name": "Release checklist",   // Required, unique per user
+//	    "title": "Release",            // Required unless task_id is given
+//	    "description": "",             // Optional
+//	    "status": "",                  // Optional, empty starts in the first todo status
+//	    "priority": "high",            // Optional, defaults to "none"
+//	    "label_ids": [4],              // Optional
+//	    "subtasks": [                  // Optional, same fields, nested
+//	        {"title": "Tag version"}
+//	    ]
+//	}
+//
+// or, to save an existing task with its subtasks:
+//
+//	{
+//	    "name": "Onboarding",
+//	    "task_id": 42
+//	}
+//
+// HTTP Responses:
+//   - 201 Created: Successfully created template, returned in the body
+//   - 400 Bad Request: Invalid input data or unknown label
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 404 Not Found: The task to copy doesn't exist
+//   - 409 Conflict: A template with the same name exists
+//   - 500 Internal Server Error: Database or server errors
+func (h *TemplateHandler) CreateTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := ctx.Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var input struct {
+		models.TaskTemplate
+		TaskID *int `json:"task_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		log.Printf("Error decoding template: %v", err)
+		JSONError(w, "Invalid input data", http.StatusBadRequest)
+		return
+	}
+
+	template := input.TaskTemplate
+	if input.TaskID != nil {
+		var err error
+		template, err = models.NewTemplateFromTask(h.DB, claims.UserID, *input.TaskID, input.Name)
+		if err != nil {
+			if err.Error() == "task not found" {
+				JSONError(w, "Task not found", http.StatusNotFound)
+				return
+			}
+			log.Printf("Error copying task %d into a template: %v", *input.TaskID, err)
+			JSONError(w, "Failed to create template", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	template.UserID = claims.UserID
+	if err := template.Validate(); err != nil {
+		JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := template.CreateTemplate(h.DB); err != nil {
+		log.Printf("Error creating template for user %d: %v", claims.UserID, err)
+		switch err.Error() {
+		case "label not found":
+			JSONError(w, "Label not found", http.StatusBadRequest)
+		case "template already exists":
+			JSONError(w, "Template already exists", http.StatusConflict)
+		default:
+			JSONError(w, "Failed to create template", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.analytics.Track(ctx, "Template Created", strconv.Itoa(claims.UserID), map[string]any{
+		"user_id":     claims.UserID,
+		"template_id": template.ID,
+		"from_task":   input.TaskID != nil,
+	})
+	log.Printf("Successfully created template ID: %d for user ID: %d", template.ID, claims.UserID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(template)
+}
+
+// DeleteTemplate removes a template. Tasks created from it are kept.
+//
+// URL Parameters:
+//   - id: Template identifier (integer)
+//
+// HTTP Responses:
+//   - 204 No Content: Successfully deleted template
+//   - 400 Bad Request: Invalid template ID format
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 404 Not Found: Template doesn't exist
+//   - 500 Internal Server Error: Database or server errors
+func (h *TemplateHandler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := ctx.Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		JSONError(w, "Invalid template ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := models.DeleteTemplate(h.DB, claims.UserID, id); err != nil {
+		if err.Error() == "template not found" {
+			JSONError(w, "Template not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error deleting template %d: %v", id, err)
+		JSONError(w, "Failed to delete template", http.StatusInternalServerError)
+		return
+	}
+
+	h.analytics.Track(ctx, "Template Deleted", strconv.Itoa(claims.UserID), map[string]any{
+		"user_id":     claims.UserID,
+		"template_id": id,
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// InstantiateTemplate creates the task tree of a template: the top-level
+// task at the top of the project or inbox and its subtasks in template
+// order. Either every task is created or none.
+//
+// URL Parameters:
+//   - id: Template identifier (integer)
+//
+// Query Parameters:
+//   - project_id: Project of the new tasks; omitted or "inbox" creates
+//     them in the inbox
+//
+// HTTP Responses:
+//   - 201 Created: Tasks created, the top-level task with nested subtasks is returned
+//   - 400 Bad Request: Invalid template or project ID, unknown project, or
+//     a template nested deeper than subtasks may be
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 404 Not Found: Template doesn't exist
+//   - 409 Conflict: The board column of the top-level task is at its WIP limit
+//   - 500 Internal Server Error: Database or server errors
+func (h *TemplateHandler) InstantiateTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := ctx.Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		JSONError(w, "Invalid template ID", http.StatusBadRequest)
+		return
+	}
+
+	var projectID *int
+	if value := r.URL.Query().Get("project_id"); value != "" && value != "inbox" {
+		pid, err := strconv.Atoi(value)
+		if err != nil {
+			JSONError(w, "Invalid project ID", http.StatusBadRequest)
+			return
+		}
+		projectID = &pid
+	}
+
+	task, err := models.InstantiateTemplate(h.DB, claims.UserID, id, projectID)
+	if err != nil {
+		log.Printf("Error instantiating template %d: %v", id, err)
+		h.analytics.Track(ctx, "Template Instantiation Failed", strconv.Itoa(claims.UserID), map[string]any{
+			"error":       err.Error(),
+			"template_id": id,
+			"user_id":     claims.UserID,
+		})
+		switch {
+		case err.Error() == "template not found":
+			JSONError(w, "Template not found", http.StatusNotFound)
+		case err.Error() == "project not found":
+			JSONError(w, "Project not found", http.StatusBadRequest)
+		case err.Error() == "maximum subtask depth exceeded":
+			JSONError(w, "Maximum subtask depth exceeded", http.StatusBadRequest)
+		case strings.HasPrefix(err.Error(), "wip limit reached"):
+			JSONError(w, err.Error(), http.StatusConflict)
+		default:
+			JSONError(w, "Failed to instantiate template", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.analytics.Track(ctx, "Template Instantiated", strconv.Itoa(claims.UserID), map[string]any{
+		"user_id":       claims.UserID,
+		"template_id":   id,
+		"task_id":       task.ID,
+		"subtask_count": task.SubtaskCount,
+	})
+	log.Printf("Successfully instantiated template %d as task ID: %d", id, task.ID)
+
+	w.Header().Set("ETag", taskETag(task))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(task)
+}
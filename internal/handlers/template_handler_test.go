@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+	"github.com/maxzhirnov/go-task-manager/internal/middleware"
+	"github.com/maxzhirnov/go-task-manager/pkg/analytics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateTemplate(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		mockSetup      func(sqlmock.Sqlmock)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name: "From body",
+			body: `{"name": "Release", "title": "Release", "subtasks": [{"title": "Tag"}]}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO task_templates").
+					WithArgs(1, "Release", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "From task",
+			body: `{"name": "Onboarding", "task_id": 5}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1").
+					WithArgs(5).
+					WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(newTaskRow(5, "Onboarding", "", "pending", 1, 0)...))
+				mock.ExpectQuery("WITH RECURSIVE subtree AS").
+					WithArgs(5).
+					WillReturnRows(sqlmock.NewRows(append(taskColumnNames, "depth")))
+				mock.ExpectQuery("INSERT INTO task_templates").
+					WithArgs(1, "Onboarding", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "Task not found",
+			body: `{"name": "Onboarding", "task_id": 5}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1").
+					WithArgs(5).
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "Task not found",
+		},
+		{
+			name:           "Missing title",
+			body:           `{"name": "Release"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "template task title is required",
+		},
+		{
+			name: "Duplicate name",
+			body: `{"name": "Release", "title": "Release"}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO task_templates").
+					WillReturnError(&pq.Error{Code: "23505"})
+			},
+			expectedStatus: http.StatusConflict,
+			expectedError:  "Template already exists",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			if tt.mockSetup != nil {
+				tt.mockSetup(mock)
+			}
+
+			handler := NewTemplateHandler(db, analytics.NewMock("test-key", false))
+			req, err := http.NewRequest("POST", "/api/templates", bytes.NewBufferString(tt.body))
+			assert.NoError(t, err)
+			req = req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
+
+			rr := httptest.NewRecorder()
+			handler.CreateTemplate(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			var response map[string]interface{}
+			assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+			if tt.expectedError != "" {
+				assert.Equal(t, tt.expectedError, response["error"])
+			} else {
+				assert.Equal(t, "none", response["priority"])
+				assert.NotNil(t, response["subtasks"])
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestInstantiateTemplate(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		mockSetup      func(sqlmock.Sqlmock)
+		expectedStatus int
+	}{
+		{
+			name:  "Creates task",
+			query: "?project_id=inbox",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT (.+) FROM task_templates").
+					WithArgs(3, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "task", "created_at", "updated_at"}).
+						AddRow(3, 1, "Release", []byte(`{"title": "Release", "priority": "none", "label_ids": [], "subtasks": []}`), time.Now(), time.Now()))
+				expectDefaultWorkflow(mock)
+				expectDefaultWorkflow(mock)
+				mock.ExpectQuery("SELECT MIN\\(rank\\) FROM tasks").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow(nil))
+				mock.ExpectQuery("INSERT INTO tasks").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "time_zone"}).AddRow(10, "UTC"))
+				mock.ExpectExec("INSERT INTO task_events").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:  "Foreign project",
+			query: "?project_id=9",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT (.+) FROM task_templates").
+					WithArgs(3, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "task", "created_at", "updated_at"}).
+						AddRow(3, 1, "Release", []byte(`{"title": "Release", "priority": "none", "label_ids": [], "subtasks": []}`), time.Now(), time.Now()))
+				expectDefaultWorkflow(mock)
+				expectDefaultWorkflow(mock)
+				mock.ExpectQuery("SELECT EXISTS").
+					WithArgs(9, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectRollback()
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "Template not found",
+			query: "",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT (.+) FROM task_templates").
+					WithArgs(3, 1).
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Invalid project ID",
+			query:          "?project_id=abc",
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			tt.mockSetup(mock)
+
+			handler := NewTemplateHandler(db, analytics.NewMock("test-key", false))
+			req, err := http.NewRequest("POST", "/api/templates/3/instantiate"+tt.query, nil)
+			assert.NoError(t, err)
+			req = mux.SetURLVars(req, map[string]string{"id": "3"})
+			req = req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
+
+			rr := httptest.NewRecorder()
+			handler.InstantiateTemplate(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedStatus == http.StatusCreated {
+				var task struct {
+					ID     int    `json:"id"`
+					Status string `json:"status"`
+				}
+				assert.NoError(t, json.NewDecoder(rr.Body).Decode(&task))
+				assert.Equal(t, 10, task.ID)
+				assert.Equal(t, "pending", task.Status)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,456 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/maxzhirnov/go-task-manager/internal/middleware"
+	"github.com/maxzhirnov/go-task-manager/internal/models"
+	"github.com/maxzhirnov/go-task-manager/pkg/analytics"
+	"github.com/maxzhirnov/go-task-manager/pkg/database"
+)
+
+// TimeEntryHandler manages time tracking: a start/stop timer per user,
+// manual time entries and reports of the time spent.
+type TimeEntryHandler struct {
+	// DB provides database access for time entry operations
+	DB        database.DB
+	analytics analytics.Tracker
+}
+
+// NewTimeEntryHandler creates a new instance of TimeEntryHandler.
+//
+// Parameters:
+//   - db: Database interface for time entry operations
+//   - analytics: Tracker for time tracking events
+//
+// Returns:
+//   - *TimeEntryHandler: Configured time entry handler
+func NewTimeEntryHandler(db database.DB, analytics analytics.Tracker) *TimeEntryHandler {
+	return &TimeEntryHandler{
+		DB:        db,
+		analytics: analytics,
+	}
+}
+
+// StartTimer starts measuring time on a task. A user has at most one
+// running timer; a timer running on another task is stopped first.
+//
+// URL Parameters:
+//   - id: Task identifier (integer)
+//
+// HTTP Responses:
+//   - 201 Created: Timer started, the running entry is returned
+//   - 400 Bad Request: Invalid task ID format
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 404 Not Found: Task doesn't exist or is deleted
+//   - 500 Internal Server Error: Database or server errors
+//
+// Example success response:
+//
+//	{
+//	    "id": 31,
+//	    "user_id": 123,
+//	    "task_id": 42,
+//	    "started_at": "2024-03-01T09:00:00Z",
+//	    "ended_at": null,
+//	    "duration_seconds": 0,
+//	    "note": "",
+//	    "created_at": "2024-03-01T09:00:00Z",
+//	    "updated_at": "2024-03-01T09:00:00Z"
+//	}
+func (h *TimeEntryHandler) StartTimer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := ctx.Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	taskID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		JSONError(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+
+	entry, err := models.StartTimer(h.DB, claims.UserID, taskID)
+	if err != nil {
+		if err.Error() == "task not found" {
+			JSONError(w, "Task not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error starting timer on task %d: %v", taskID, err)
+		JSONError(w, "Failed to start timer", http.StatusInternalServerError)
+		return
+	}
+
+	h.analytics.Track(ctx, "Timer Started", strconv.Itoa(claims.UserID), map[string]any{
+		"user_id": claims.UserID,
+		"task_id": taskID,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(entry)
+}
+
+// StopTimer stops the running timer of the authenticated user.
+//
+// HTTP Responses:
+//   - 200 OK: Timer stopped, the finished entry is returned
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 404 Not Found: No timer is running
+//   - 500 Internal Server Error: Database or server errors
+func (h *TimeEntryHandler) StopTimer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := ctx.Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	entry, err := models.StopTimer(h.DB, claims.UserID)
+	if err != nil {
+		if err.Error() == "no running timer" {
+			JSONError(w, "No running timer", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error stopping timer of user %d: %v", claims.UserID, err)
+		JSONError(w, "Failed to stop timer", http.StatusInternalServerError)
+		return
+	}
+
+	h.analytics.Track(ctx, "Timer Stopped", strconv.Itoa(claims.UserID), map[string]any{
+		"user_id":          claims.UserID,
+		"task_id":          entry.TaskID,
+		"duration_seconds": entry.Duration,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entry)
+}
+
+// GetTimer retrieves the running timer of the authenticated user with its
+// duration counted up to now.
+//
+// HTTP Responses:
+//   - 200 OK: The running entry
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 404 Not Found: No timer is running
+//   - 500 Internal Server Error: Database or server errors
+func (h *TimeEntryHandler) GetTimer(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	entry, err := models.GetRunningTimer(h.DB, claims.UserID)
+	if err != nil {
+		if err.Error() == "no running timer" {
+			JSONError(w, "No running timer", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error fetching timer of user %d: %v", claims.UserID, err)
+		JSONError(w, "Failed to fetch timer", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entry)
+}
+
+// GetTimeEntries retrieves the time entries of a task, most recent first.
+//
+// URL Parameters:
+//   - id: Task identifier (integer)
+//
+// HTTP Responses:
+//   - 200 OK: Successfully retrieved entries
+//   - 400 Bad Request: Invalid task ID format
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 404 Not Found: Task doesn't exist
+//   - 500 Internal Server Error: Database or server errors
+func (h *TimeEntryHandler) GetTimeEntries(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	taskID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		JSONError(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+
+	entries, err := models.GetTimeEntries(h.DB, claims.UserID, taskID)
+	if err != nil {
+		if err.Error() == "task not found" {
+			JSONError(w, "Task not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error fetching time entries of task %d: %v", taskID, err)
+		JSONError(w, "Failed to fetch time entries", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// CreateTimeEntry records time spent on a task after the fact.
+//
+// URL Parameters:
+//   - id: Task identifier (integer)
+//
+// Request Body:
+//
+//	{
+//	    "started_at": "2024-03-01T09:00:00Z",  // Required
+//	    "ended_at": "2024-03-01T10:30:00Z",    // Required unless duration_seconds is given
+//	    "duration_seconds": 5400,              // Optional alternative to ended_at
+//	    "note": "Code review"                  // Optional, up to 1000 characters
+//	}
+//
+// HTTP Responses:
+//   - 201 Created: Entry recorded and returned in the body
+//   - 400 Bad Request: Invalid input data, an end before the start or an
+//     entry longer than 24 hours
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 404 Not Found: Task doesn't exist or is deleted
+//   - 500 Internal Server Error: Database or server errors
+func (h *TimeEntryHandler) CreateTimeEntry(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := ctx.Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	taskID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		JSONError(w, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+
+	var entry models.TimeEntry
+	if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+		log.Printf("Error decoding time entry: %v", err)
+		JSONError(w, "Invalid input data", http.StatusBadRequest)
+		return
+	}
+	entry.UserID = claims.UserID
+	entry.TaskID = taskID
+
+	if err := entry.Validate(); err != nil {
+		JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := entry.CreateTimeEntry(h.DB); err != nil {
+		if err.Error() == "task not found" {
+			JSONError(w, "Task not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error creating time entry on task %d: %v", taskID, err)
+		JSONError(w, "Failed to create time entry", http.StatusInternalServerError)
+		return
+	}
+
+	h.analytics.Track(ctx, "Time Entry Created", strconv.Itoa(claims.UserID), map[string]any{
+		"user_id":          claims.UserID,
+		"task_id":          taskID,
+		"duration_seconds": entry.Duration,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(entry)
+}
+
+// DeleteTimeEntry removes a time entry. Deleting the running timer
+// discards it.
+//
+// URL Parameters:
+//   - id: Time entry identifier (integer)
+//
+// HTTP Responses:
+//   - 204 No Content: Successfully deleted entry
+//   - 400 Bad Request: Invalid time entry ID format
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 404 Not Found: Time entry doesn't exist
+//   - 500 Internal Server Error: Database or server errors
+func (h *TimeEntryHandler) DeleteTimeEntry(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := ctx.Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		JSONError(w, "Invalid time entry ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := models.DeleteTimeEntry(h.DB, claims.UserID, id); err != nil {
+		if err.Error() == "time entry not found" {
+			JSONError(w, "Time entry not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error deleting time entry %d: %v", id, err)
+		JSONError(w, "Failed to delete time entry", http.StatusInternalServerError)
+		return
+	}
+
+	h.analytics.Track(ctx, "Time Entry Deleted", strconv.Itoa(claims.UserID), map[string]any{
+		"user_id":       claims.UserID,
+		"time_entry_id": id,
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetTimeReport sums up the authenticated user's time entries over a range
+// of days, grouped by day, task or label. Entries count toward the day they
+// started on in the time zone of the user's preferences.
+//
+// Query Parameters:
+//   - from: First day of the range, YYYY-MM-DD (default: 30 days before to)
+//   - to: Last day of the range, YYYY-MM-DD, inclusive (default: today in
+//     the user's time zone)
+//   - group_by: "day" (default), "task" or "label"
+//   - format: "json" (default) or "csv"
+//
+// HTTP Responses:
+//   - 200 OK: The report as JSON, or as a CSV attachment with the columns
+//     key, name, entries, seconds and hours
+//   - 400 Bad Request: Invalid dates, group or format, or range longer than 366 days
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 500 Internal Server Error: Database or server errors
+//
+// Example success response:
+//
+//	{
+//	    "from": "2024-03-01T00:00:00+01:00",
+//	    "to": "2024-03-03T00:00:00+01:00",
+//	    "time_zone": "Europe/Berlin",
+//	    "group_by": "task",
+//	    "total_seconds": 9000,
+//	    "rows": [
+//	        {"key": "42", "name": "Review pull requests", "entries": 2, "seconds": 5400},
+//	        {"key": "7", "name": "Write release notes", "entries": 1, "seconds": 3600}
+//	    ]
+//	}
+func (h *TimeEntryHandler) GetTimeReport(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+
+	// The range covers whole days; to is inclusive
+	var from, to time.Time
+	if value := query.Get("to"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			JSONError(w, "Invalid to date", http.StatusBadRequest)
+			return
+		}
+		to = parsed
+	}
+	if value := query.Get("from"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			JSONError(w, "Invalid from date", http.StatusBadRequest)
+			return
+		}
+		from = parsed
+	}
+
+	groupBy := query.Get("group_by")
+	if groupBy == "" {
+		groupBy = models.TimeReportByDay
+	}
+
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		JSONError(w, "Invalid format", http.StatusBadRequest)
+		return
+	}
+
+	// Default to today in the user's time zone
+	if to.IsZero() {
+		today, err := userToday(h.DB, claims.UserID)
+		if err != nil {
+			log.Printf("Error fetching preferences for user %d: %v", claims.UserID, err)
+			JSONError(w, "Failed to fetch time report", http.StatusInternalServerError)
+			return
+		}
+		to = today
+	}
+	to = to.AddDate(0, 0, 1)
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -30)
+	}
+
+	report, err := models.GetTimeReport(h.DB, claims.UserID, from, to, groupBy)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "invalid group"):
+			JSONError(w, "Invalid group_by value", http.StatusBadRequest)
+		case err.Error() == "from must be before to":
+			JSONError(w, "From date must not be after to date", http.StatusBadRequest)
+		case strings.HasPrefix(err.Error(), "date range must not exceed"):
+			JSONError(w, "Date range must not exceed 366 days", http.StatusBadRequest)
+		default:
+			log.Printf("Error building time report for user %d: %v", claims.UserID, err)
+			JSONError(w, "Failed to fetch time report", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if format == "csv" {
+		writeTimeReportCSV(w, report)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// writeTimeReportCSV writes a time report as a CSV attachment named after
+// its group and date range.
+func writeTimeReportCSV(w http.ResponseWriter, report *models.TimeReport) {
+	filename := fmt.Sprintf("time-report-%s-%s-%s.csv", report.GroupBy,
+		report.From.Format("2006-01-02"), report.To.AddDate(0, 0, -1).Format("2006-01-02"))
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"key", "name", "entries", "seconds", "hours"})
+	for _, row := range report.Rows {
+		writer.Write([]string{
+			row.Key,
+			row.Name,
+			strconv.Itoa(row.Entries),
+			strconv.FormatInt(row.Seconds, 10),
+			strconv.FormatFloat(float64(row.Seconds)/3600, 'f', 2, 64),
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		log.Printf("Error writing time report CSV: %v", err)
+	}
+}
@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/maxzhirnov/go-task-manager/internal/middleware"
+	"github.com/maxzhirnov/go-task-manager/pkg/analytics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartTimer(t *testing.T) {
+	tests := []struct {
+		name           string
+		taskID         string
+		mockSetup      func(sqlmock.Sqlmock)
+		expectedStatus int
+	}{
+		{
+			name:   "Starts timer",
+			taskID: "5",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("SELECT id FROM users").
+					WithArgs(1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT EXISTS").
+					WithArgs(5, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+				mock.ExpectExec("UPDATE time_entries SET ended_at").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("INSERT INTO time_entries").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(31))
+				mock.ExpectCommit()
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:   "Task not found",
+			taskID: "5",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("SELECT id FROM users").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT EXISTS").
+					WithArgs(5, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectRollback()
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Invalid task ID",
+			taskID:         "abc",
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			tt.mockSetup(mock)
+
+			handler := NewTimeEntryHandler(db, analytics.NewMock("test-key", false))
+			req, err := http.NewRequest("POST", "/api/tasks/"+tt.taskID+"/timer", nil)
+			assert.NoError(t, err)
+			req = mux.SetURLVars(req, map[string]string{"id": tt.taskID})
+			req = req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
+
+			rr := httptest.NewRecorder()
+			handler.StartTimer(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedStatus == http.StatusCreated {
+				var entry map[string]interface{}
+				assert.NoError(t, json.NewDecoder(rr.Body).Decode(&entry))
+				assert.Equal(t, float64(31), entry["id"])
+				assert.Nil(t, entry["ended_at"])
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCreateTimeEntry(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		mockSetup      func(sqlmock.Sqlmock)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name: "Records entry",
+			body: `{"started_at": "2024-03-01T09:00:00Z", "duration_seconds": 5400, "note": "Review"}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT EXISTS").
+					WithArgs(5, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+				mock.ExpectQuery("INSERT INTO time_entries").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(32))
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "End before start",
+			body:           `{"started_at": "2024-03-01T09:00:00Z", "ended_at": "2024-03-01T08:00:00Z"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "ended_at must be after started_at",
+		},
+		{
+			name: "Task not found",
+			body: `{"started_at": "2024-03-01T09:00:00Z", "ended_at": "2024-03-01T10:00:00Z"}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT EXISTS").
+					WithArgs(5, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "Task not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			if tt.mockSetup != nil {
+				tt.mockSetup(mock)
+			}
+
+			handler := NewTimeEntryHandler(db, analytics.NewMock("test-key", false))
+			req, err := http.NewRequest("POST", "/api/tasks/5/time-entries", bytes.NewBufferString(tt.body))
+			assert.NoError(t, err)
+			req = mux.SetURLVars(req, map[string]string{"id": "5"})
+			req = req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
+
+			rr := httptest.NewRecorder()
+			handler.CreateTimeEntry(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			var response map[string]interface{}
+			assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+			if tt.expectedError != "" {
+				assert.Equal(t, tt.expectedError, response["error"])
+			} else {
+				assert.Equal(t, "2024-03-01T10:30:00Z", response["ended_at"])
+				assert.Equal(t, float64(5400), response["duration_seconds"])
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetTimeReport(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		mockSetup      func(sqlmock.Sqlmock)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:  "CSV by task",
+			query: "?from=2024-03-01&to=2024-03-02&group_by=task&format=csv",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+				to := time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)
+				mock.ExpectQuery("FROM user_preferences").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"time_zone", "locale", "week_start", "date_format", "updated_at"}).
+						AddRow("UTC", "en", "monday", "YYYY-MM-DD", time.Now()))
+				mock.ExpectQuery("SELECT COALESCE\\(SUM\\(seconds\\), 0\\) FROM entries").
+					WithArgs(1, from, to, sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(9000))
+				mock.ExpectQuery("JOIN tasks t ON t.id = e.task_id").
+					WithArgs(1, from, to, sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"key", "name", "entries", "seconds"}).
+						AddRow("42", "Review, merge", 2, 5400).
+						AddRow("7", "Release notes", 1, 3600))
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: "key,name,entries,seconds,hours\n" +
+				"42,\"Review, merge\",2,5400,1.50\n" +
+				"7,Release notes,1,3600,1.00\n",
+		},
+		{
+			name:  "Defaults to today in the user's time zone",
+			query: "?group_by=task",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				kiritimati, _ := time.LoadLocation("Pacific/Kiritimati")
+				now := time.Now().In(kiritimati)
+				end := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, kiritimati).UTC()
+				for i := 0; i < 2; i++ {
+					mock.ExpectQuery("FROM user_preferences").
+						WithArgs(1).
+						WillReturnRows(sqlmock.NewRows([]string{"time_zone", "locale", "week_start", "date_format", "updated_at"}).
+							AddRow("Pacific/Kiritimati", "en", "monday", "YYYY-MM-DD", time.Now()))
+				}
+				mock.ExpectQuery("SELECT COALESCE\\(SUM\\(seconds\\), 0\\) FROM entries").
+					WithArgs(1, sqlmock.AnyArg(), end, sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(0))
+				mock.ExpectQuery("JOIN tasks t ON t.id = e.task_id").
+					WillReturnRows(sqlmock.NewRows([]string{"key", "name", "entries", "seconds"}))
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "Invalid group",
+			query: "?group_by=project",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("FROM user_preferences").
+					WithArgs(1).
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid format",
+			query:          "?format=xml",
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid date",
+			query:          "?from=yesterday",
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			tt.mockSetup(mock)
+
+			handler := NewTimeEntryHandler(db, analytics.NewMock("test-key", false))
+			req, err := http.NewRequest("GET", "/api/time-entries/report"+tt.query, nil)
+			assert.NoError(t, err)
+			req = req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
+
+			rr := httptest.NewRecorder()
+			handler.GetTimeReport(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedBody != "" {
+				assert.Equal(t, "text/csv; charset=utf-8", rr.Header().Get("Content-Type"))
+				assert.Equal(t, `attachment; filename="time-report-task-2024-03-01-2024-03-02.csv"`, rr.Header().Get("Content-Disposition"))
+				assert.Equal(t, tt.expectedBody, rr.Body.String())
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
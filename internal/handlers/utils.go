@@ -2,13 +2,20 @@ package handlers
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"net/mail"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/maxzhirnov/go-task-manager/internal/models"
+	"github.com/maxzhirnov/go-task-manager/pkg/database"
 )
 
 // JSONError writes a standardized JSON error response to the HTTP response writer.
@@ -125,3 +132,84 @@ func validateProfileUpdate(req UpdateProfileRequest) error {
 
 	return nil
 }
+
+// taskETag returns the entity tag of a task, derived from its version.
+// Example: "3"
+func taskETag(task models.Task) string {
+	return `"` + strconv.Itoa(task.Version) + `"`
+}
+
+// taskListETag returns the entity tag of a list of tasks. It changes
+// whenever a task of the list changes or the list itself changes.
+func taskListETag(tasks []models.Task, nextCursor string) string {
+	h := sha256.New()
+	for _, task := range tasks {
+		fmt.Fprintf(h, "%d:%d,", task.ID, task.Version)
+	}
+	h.Write([]byte(nextCursor))
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// parseIfMatch reads the task version required by the If-Match header.
+// It returns 0 when the header is missing or "*", which imposes no
+// version requirement.
+//
+// Returns:
+//   - int: The required version, or 0
+//   - error: If the header is not a single strong ETag issued by taskETag
+func parseIfMatch(r *http.Request) (int, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+
+	if len(value) < 2 || !strings.HasPrefix(value, `"`) || !strings.HasSuffix(value, `"`) {
+		return 0, fmt.Errorf("invalid If-Match header")
+	}
+	version, err := strconv.Atoi(value[1 : len(value)-1])
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("invalid If-Match header")
+	}
+
+	return version, nil
+}
+
+// writePreconditionFailed responds with 412 Precondition Failed and the
+// current representation of the task, so the client can merge its changes.
+func writePreconditionFailed(w http.ResponseWriter, task models.Task) {
+	log.Printf("Precondition failed for task %d: current version %d", task.ID, task.Version)
+
+	w.Header().Set("ETag", taskETag(task))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusPreconditionFailed)
+	json.NewEncoder(w).Encode(task)
+}
+
+// parseIgnoreBlockers reads the ignore_blockers query parameter, which lets
+// a blocked task start or finish anyway. A missing parameter is false.
+//
+// Returns:
+//   - bool: Whether unfinished blockers are ignored
+//   - error: If the value is not a boolean
+func parseIgnoreBlockers(r *http.Request) (bool, error) {
+	value := r.URL.Query().Get("ignore_blockers")
+	if value == "" {
+		return false, nil
+	}
+	ignore, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid ignore_blockers value: %s", value)
+	}
+	return ignore, nil
+}
+
+// userToday returns the current date in the time zone of the user's
+// preferences, at midnight UTC like dates parsed from query parameters.
+func userToday(db database.DB, userID int) (time.Time, error) {
+	prefs, err := models.GetPreferences(db, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	now := time.Now().In(prefs.Location())
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC), nil
+}
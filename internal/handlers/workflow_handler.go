@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/maxzhirnov/go-task-manager/internal/middleware"
+	"github.com/maxzhirnov/go-task-manager/internal/models"
+	"github.com/maxzhirnov/go-task-manager/pkg/analytics"
+	"github.com/maxzhirnov/go-task-manager/pkg/database"
+)
+
+// WorkflowHandler manages the task statuses of the authenticated user:
+// their order, categories and the transitions allowed between them.
+type WorkflowHandler struct {
+	// DB provides database access for workflow operations
+	DB        database.DB
+	analytics analytics.Tracker
+}
+
+// NewWorkflowHandler creates a new instance of WorkflowHandler.
+//
+// Parameters:
+//   - db: Database interface for workflow operations
+//   - analytics: Tracker for workflow events
+//
+// Returns:
+//   - *WorkflowHandler: Configured workflow handler
+func NewWorkflowHandler(db database.DB, analytics analytics.Tracker) *WorkflowHandler {
+	return &WorkflowHandler{
+		DB:        db,
+		analytics: analytics,
+	}
+}
+
+// GetWorkflow retrieves the workflow of the authenticated user.
+// Users who never defined one receive the built-in workflow.
+//
+// HTTP Responses:
+//   - 200 OK: Successfully retrieved workflow
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 500 Internal Server Error: Database or server errors
+//
+// Example success response:
+//
+//	{
+//	    "statuses": [
+//	        {"key": "pending", "name": "Pending", "category": "todo", "position": 0, "transitions": null},
+//	        {"key": "in_progress", "name": "In progress", "category": "doing", "position": 1, "transitions": null},
+//	        {"key": "completed", "name": "Completed", "category": "done", "position": 2, "transitions": null}
+//	    ],
+//	    "custom": false
+//	}
+func (h *WorkflowHandler) GetWorkflow(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	workflow, err := models.GetWorkflow(h.DB, claims.UserID)
+	if err != nil {
+		log.Printf("Error fetching workflow for user %d: %v", claims.UserID, err)
+		JSONError(w, "Failed to fetch workflow", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(workflow)
+}
+
+// UpdateWorkflow replaces the workflow of the authenticated user.
+//
+// The statuses are given in order. Each belongs to a category: new tasks
+// start in the first "todo" status, "doing" statuses start the cycle time
+// and "done" statuses complete a task. "transitions" lists the statuses a
+// task may move to; omitted or null allows all. "wip_limit" caps the tasks
+// of a board column in the status.
+//
+// Request Body:
+//
+//	{
+//	    "statuses": [
+//	        {"key": "todo", "name": "To do", "category": "todo", "transitions": ["doing"]},
+//	        {"key": "doing", "name": "Doing", "category": "doing", "wip_limit": 3},
+//	        {"key": "blocked", "name": "Blocked", "category": "doing", "transitions": ["doing"]},
+//	        {"key": "in_review", "name": "In review", "category": "doing", "transitions": ["doing", "done"]},
+//	        {"key": "done", "name": "Done", "category": "done"}
+//	    ]
+//	}
+//
+// HTTP Responses:
+//   - 200 OK: Successfully updated workflow
+//   - 400 Bad Request: Invalid input data or workflow
+//   - 401 Unauthorized: Missing or invalid JWT token
+//   - 409 Conflict: A removed status is still used by tasks
+//   - 500 Internal Server Error: Database or server errors
+func (h *WorkflowHandler) UpdateWorkflow(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := ctx.Value("claims").(*middleware.Claims)
+	if !ok {
+		JSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var workflow models.Workflow
+	if err := json.NewDecoder(r.Body).Decode(&workflow); err != nil {
+		log.Printf("Error decoding workflow: %v", err)
+		JSONError(w, "Invalid input data", http.StatusBadRequest)
+		return
+	}
+
+	if err := workflow.Validate(); err != nil {
+		JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	workflow, err := models.SetWorkflow(h.DB, claims.UserID, workflow)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "status in use") {
+			JSONError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		log.Printf("Error saving workflow for user %d: %v", claims.UserID, err)
+		JSONError(w, "Failed to update workflow", http.StatusInternalServerError)
+		return
+	}
+
+	restricted := 0
+	for _, status := range workflow.Statuses {
+		if status.Transitions != nil {
+			restricted++
+		}
+	}
+	h.analytics.Track(ctx, "Workflow Updated", strconv.Itoa(claims.UserID), map[string]any{
+		"user_id":             claims.UserID,
+		"status_count":        len(workflow.Statuses),
+		"restricted_statuses": restricted,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(workflow)
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/maxzhirnov/go-task-manager/internal/middleware"
+	"github.com/maxzhirnov/go-task-manager/pkg/analytics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateWorkflow(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		mockSetup      func(sqlmock.Sqlmock)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name: "Successful update",
+			body: `{"statuses": [
+				{"key": "todo", "name": "To do", "category": "todo", "transitions": ["done"]},
+				{"key": "done", "name": "Done", "category": "done"}
+			]}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("SELECT id FROM users WHERE id = \\$1 FOR UPDATE").
+					WithArgs(1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT MIN").
+					WithArgs(1, `{"todo","done"}`).
+					WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow(nil))
+				mock.ExpectExec("DELETE FROM workflow_statuses").
+					WithArgs(1).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("INSERT INTO workflow_statuses").
+					WithArgs(1, "todo", "To do", "todo", 0, `{"done"}`, nil).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO workflow_statuses").
+					WithArgs(1, "done", "Done", "done", 1, nil, nil).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE tasks SET status_category").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Missing done status",
+			body:           `{"statuses": [{"key": "todo", "name": "To do", "category": "todo"}]}`,
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "a todo and a done status are required",
+		},
+		{
+			name: "Removed status in use",
+			body: `{"statuses": [
+				{"key": "todo", "name": "To do", "category": "todo"},
+				{"key": "done", "name": "Done", "category": "done"}
+			]}`,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("SELECT id FROM users WHERE id = \\$1 FOR UPDATE").
+					WithArgs(1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT MIN").
+					WithArgs(1, `{"todo","done"}`).
+					WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow("pending"))
+				mock.ExpectRollback()
+			},
+			expectedStatus: http.StatusConflict,
+			expectedError:  "status in use: pending",
+		},
+		{
+			name:           "Invalid JSON",
+			body:           `{"statuses":`,
+			mockSetup:      func(mock sqlmock.Sqlmock) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "Invalid input data",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			tt.mockSetup(mock)
+
+			handler := NewWorkflowHandler(db, analytics.NewMock("test-key", false))
+			req, err := http.NewRequest("PUT", "/api/workflow", bytes.NewBufferString(tt.body))
+			assert.NoError(t, err)
+			req = req.WithContext(context.WithValue(req.Context(), "claims", &middleware.Claims{UserID: 1}))
+
+			rr := httptest.NewRecorder()
+			handler.UpdateWorkflow(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			var response map[string]interface{}
+			assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+			if tt.expectedError != "" {
+				assert.Equal(t, tt.expectedError, response["error"])
+			} else {
+				assert.Equal(t, true, response["custom"])
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
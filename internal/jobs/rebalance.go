@@ -0,0 +1,84 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/maxzhirnov/go-task-manager/internal/models"
+	"github.com/maxzhirnov/go-task-manager/pkg/database"
+)
+
+// RankRebalanceJob periodically rewrites the ranks of task lists whose
+// ranks grew too long, keeping the order of every list.
+type RankRebalanceJob struct {
+	db        database.DB
+	interval  time.Duration
+	maxLength int
+}
+
+// NewRankRebalanceJob creates a rank rebalancing job for the given database.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - interval: Time between two runs
+//   - maxLength: Rank length above which a list is rebalanced
+//
+// Returns:
+//   - *RankRebalanceJob: Job ready to be started
+//
+// Example Usage:
+//
+//	job := jobs.NewRankRebalanceJob(db, time.Hour, 24)
+//	go job.Start(ctx)
+func NewRankRebalanceJob(db database.DB, interval time.Duration, maxLength int) *RankRebalanceJob {
+	return &RankRebalanceJob{
+		db:        db,
+		interval:  interval,
+		maxLength: maxLength,
+	}
+}
+
+// Start runs the job immediately and then once per interval until the
+// context is cancelled. Failed runs are logged and retried on the next tick.
+//
+// Parameters:
+//   - ctx: Context whose cancellation stops the job
+func (j *RankRebalanceJob) Start(ctx context.Context) {
+	log.Printf("Rank rebalance job started: interval %s, max rank length %d", j.interval, j.maxLength)
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		j.run()
+
+		select {
+		case <-ctx.Done():
+			log.Printf("Rank rebalance job stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// run performs one rebalancing pass and logs its outcome.
+func (j *RankRebalanceJob) run() {
+	lists, err := j.RunOnce()
+	if err != nil {
+		log.Printf("Rank rebalance failed after %d lists: %v", lists, err)
+		return
+	}
+	if lists > 0 {
+		log.Printf("Rank rebalance completed: rebalanced %d task lists", lists)
+	}
+}
+
+// RunOnce performs a single rebalancing pass.
+//
+// Returns:
+//   - int64: Number of rebalanced task lists
+//   - error: The first database error encountered
+func (j *RankRebalanceJob) RunOnce() (int64, error) {
+	return models.RebalanceTaskRanks(j.db, j.maxLength)
+}
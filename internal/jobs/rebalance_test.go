@@ -0,0 +1,40 @@
+package jobs
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRankRebalanceRunOnce(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT user_id, project_id, parent_id FROM tasks GROUP BY user_id, project_id, parent_id HAVING MAX\\(LENGTH\\(rank\\)\\) > \\$1").
+		WithArgs(16).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "project_id", "parent_id"}))
+
+	job := NewRankRebalanceJob(db, time.Hour, 16)
+	lists, err := job.RunOnce()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), lists)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRankRebalanceRunOnceError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT user_id, project_id, parent_id FROM tasks").
+		WillReturnError(errors.New("connection reset"))
+
+	job := NewRankRebalanceJob(db, time.Hour, 24)
+	_, err = job.RunOnce()
+	assert.EqualError(t, err, "failed to find lists to rebalance: connection reset")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
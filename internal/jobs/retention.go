@@ -0,0 +1,120 @@
+// Package jobs contains background jobs that run alongside the API server.
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/maxzhirnov/go-task-manager/internal/models"
+	"github.com/maxzhirnov/go-task-manager/pkg/database"
+)
+
+// RetentionPolicy describes how long stale data is kept before the
+// retention job removes it.
+type RetentionPolicy struct {
+	Interval     time.Duration // Time between two cleanup runs
+	DeletedTasks time.Duration // How long deleted tasks stay in the trash
+	TokenGrace   time.Duration // How long expired tokens are kept after expiry
+}
+
+// RetentionStats summarizes the work done by a single retention run.
+type RetentionStats struct {
+	PurgedTasks        int64         // Deleted tasks removed permanently
+	VerificationTokens int64         // Expired verification tokens removed
+	ResetTokens        int64         // Expired password reset tokens cleared
+	Duration           time.Duration // Time spent on the run
+}
+
+// RetentionJob periodically purges old deleted tasks and expired tokens.
+type RetentionJob struct {
+	db     database.DB
+	policy RetentionPolicy
+	now    func() time.Time
+}
+
+// NewRetentionJob creates a retention job for the given database and policy.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - policy: Retention windows and run interval
+//
+// Returns:
+//   - *RetentionJob: Job ready to be started
+//
+// Example Usage:
+//
+//	job := jobs.NewRetentionJob(db, jobs.RetentionPolicy{
+//	    Interval:     24 * time.Hour,
+//	    DeletedTasks: 30 * 24 * time.Hour,
+//	    TokenGrace:   24 * time.Hour,
+//	})
+//	go job.Start(ctx)
+func NewRetentionJob(db database.DB, policy RetentionPolicy) *RetentionJob {
+	return &RetentionJob{
+		db:     db,
+		policy: policy,
+		now:    time.Now,
+	}
+}
+
+// Start runs the job immediately and then once per policy interval until
+// the context is cancelled. Failed runs are logged and retried on the next
+// tick.
+//
+// Parameters:
+//   - ctx: Context whose cancellation stops the job
+func (j *RetentionJob) Start(ctx context.Context) {
+	log.Printf("Retention job started: interval %s, deleted tasks kept for %s, expired tokens kept for %s",
+		j.policy.Interval, j.policy.DeletedTasks, j.policy.TokenGrace)
+
+	ticker := time.NewTicker(j.policy.Interval)
+	defer ticker.Stop()
+
+	for {
+		j.run()
+
+		select {
+		case <-ctx.Done():
+			log.Printf("Retention job stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// run performs one retention pass and logs its outcome.
+func (j *RetentionJob) run() {
+	stats, err := j.RunOnce()
+	if err != nil {
+		log.Printf("Retention run failed after %s: %v", stats.Duration, err)
+		return
+	}
+
+	log.Printf("Retention run completed in %s: purged %d deleted tasks, %d verification tokens, cleared %d reset tokens",
+		stats.Duration, stats.PurgedTasks, stats.VerificationTokens, stats.ResetTokens)
+}
+
+// RunOnce performs a single retention pass.
+//
+// Returns:
+//   - RetentionStats: Number of rows affected by each cleanup step
+//   - error: The first database error encountered; later steps are skipped
+func (j *RetentionJob) RunOnce() (RetentionStats, error) {
+	start := j.now()
+	stats := RetentionStats{}
+	tokenCutoff := start.Add(-j.policy.TokenGrace)
+
+	var err error
+	if stats.PurgedTasks, err = models.PurgeDeletedTasks(j.db, start.Add(-j.policy.DeletedTasks)); err != nil {
+		err = fmt.Errorf("deleted tasks: %w", err)
+	} else if stats.VerificationTokens, err = models.PurgeExpiredVerificationTokens(j.db, tokenCutoff); err != nil {
+		err = fmt.Errorf("verification tokens: %w", err)
+	} else if stats.ResetTokens, err = models.ClearExpiredResetTokens(j.db, tokenCutoff); err != nil {
+		err = fmt.Errorf("reset tokens: %w", err)
+	}
+
+	stats.Duration = j.now().Sub(start)
+	return stats, err
+}
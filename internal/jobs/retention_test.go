@@ -0,0 +1,85 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetentionRunOnce(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	now := time.Date(2024, 5, 31, 3, 0, 0, 0, time.UTC)
+	job := NewRetentionJob(db, RetentionPolicy{
+		Interval:     time.Hour,
+		DeletedTasks: 30 * 24 * time.Hour,
+		TokenGrace:   24 * time.Hour,
+	})
+	job.now = func() time.Time { return now }
+
+	mock.ExpectExec("DELETE FROM tasks WHERE status = 'deleted'").
+		WithArgs(time.Date(2024, 5, 1, 3, 0, 0, 0, time.UTC)).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("DELETE FROM verification_tokens").
+		WithArgs(time.Date(2024, 5, 30, 3, 0, 0, 0, time.UTC)).
+		WillReturnResult(sqlmock.NewResult(0, 5))
+	mock.ExpectExec("UPDATE users SET reset_password_token = NULL").
+		WithArgs(time.Date(2024, 5, 30, 3, 0, 0, 0, time.UTC)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	stats, err := job.RunOnce()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), stats.PurgedTasks)
+	assert.Equal(t, int64(5), stats.VerificationTokens)
+	assert.Equal(t, int64(1), stats.ResetTokens)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRetentionRunOnceStopsOnError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec("DELETE FROM tasks WHERE status = 'deleted'").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("DELETE FROM verification_tokens").
+		WillReturnError(errors.New("connection reset"))
+
+	job := NewRetentionJob(db, RetentionPolicy{Interval: time.Hour})
+	stats, err := job.RunOnce()
+	assert.EqualError(t, err, "verification tokens: failed to purge verification tokens: connection reset")
+	assert.Equal(t, int64(2), stats.PurgedTasks)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRetentionStartStopsOnCancel(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec("DELETE FROM tasks").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM verification_tokens").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE users").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		NewRetentionJob(db, RetentionPolicy{Interval: time.Hour}).Start(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("retention job did not stop after cancellation")
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,202 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/maxzhirnov/go-task-manager/pkg/database"
+)
+
+// ActivityDays is the number of days covered by the activity heatmap,
+// ending with today.
+const ActivityDays = 365
+
+// activityDateFormat is the layout of the dates in activity responses.
+const activityDateFormat = "2006-01-02"
+
+// ActivityDay holds the number of tasks created and completed on a day.
+type ActivityDay struct {
+	// Date is the calendar day in the user's time zone, YYYY-MM-DD
+	Date string `json:"date"`
+
+	// Created is the number of tasks created on the day
+	Created int `json:"created"`
+
+	// Completed is the number of tasks completed on the day
+	Completed int `json:"completed"`
+}
+
+// Activity summarizes a user's daily task activity for a heatmap along
+// with the user's completion streaks.
+type Activity struct {
+	// TimeZone is the user's time zone the days are counted in
+	TimeZone string `json:"time_zone"`
+
+	// Today is the current day in the user's time zone, YYYY-MM-DD
+	Today string `json:"today"`
+
+	// WeekStart is the user's first day of the week, for laying out the
+	// heatmap in columns
+	WeekStart string `json:"week_start"`
+
+	// CurrentStreak is the number of consecutive days with at least one
+	// completed task, ending today. A streak ending yesterday still counts
+	// until today is over.
+	CurrentStreak int `json:"current_streak"`
+
+	// LongestStreak is the longest run of consecutive days with at least
+	// one completed task ever
+	LongestStreak int `json:"longest_streak"`
+
+	// Days lists the last ActivityDays days, oldest first, including days
+	// without activity
+	Days []ActivityDay `json:"days"`
+}
+
+// GetActivity calculates the daily activity and completion streaks of a
+// user. Days are counted in the time zone of the user's preferences.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: The ID of the user whose activity to calculate
+//   - now: The current time, deciding which day is today
+//
+// Returns:
+//   - *Activity: The heatmap and streaks
+//   - error: Database error if a query fails
+//
+// Example Usage:
+//
+//	activity, err := GetActivity(db, userID, time.Now())
+//	if err != nil {
+//	    return fmt.Errorf("failed to fetch activity: %w", err)
+//	}
+func GetActivity(db database.DB, userID int, now time.Time) (*Activity, error) {
+	prefs, err := GetPreferences(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := prefs.Location()
+	local := now.In(loc)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	first := today.AddDate(0, 0, -(ActivityDays - 1))
+	end := today.AddDate(0, 0, 1)
+
+	activity := &Activity{
+		TimeZone:  prefs.TimeZone,
+		Today:     today.Format(activityDateFormat),
+		WeekStart: prefs.WeekStart,
+		Days:      make([]ActivityDay, 0, ActivityDays),
+	}
+
+	// Timestamps are stored in UTC; convert them to the user's zone
+	// before taking the date
+	rows, err := db.Query(`
+        SELECT day, SUM(created), SUM(completed)
+        FROM (
+            SELECT (created_at AT TIME ZONE 'UTC' AT TIME ZONE $2)::date AS day, 1 AS created, 0 AS completed
+            FROM tasks
+            WHERE user_id = $1
+            AND created_at >= $3 AND created_at < $4
+            UNION ALL
+            SELECT (completed_at AT TIME ZONE 'UTC' AT TIME ZONE $2)::date, 0, 1
+            FROM tasks
+            WHERE user_id = $1
+            AND status != 'deleted' AND status_category = 'done'
+            AND completed_at >= $3 AND completed_at < $4
+        ) activity
+        GROUP BY day
+        ORDER BY day`, userID, prefs.TimeZone, first.UTC(), end.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily activity: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]ActivityDay)
+	for rows.Next() {
+		var day time.Time
+		var d ActivityDay
+		if err := rows.Scan(&day, &d.Created, &d.Completed); err != nil {
+			return nil, err
+		}
+		d.Date = day.Format(activityDateFormat)
+		counts[d.Date] = d
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for day := first; day.Before(end); day = day.AddDate(0, 0, 1) {
+		date := day.Format(activityDateFormat)
+		d, ok := counts[date]
+		if !ok {
+			d = ActivityDay{Date: date}
+		}
+		activity.Days = append(activity.Days, d)
+	}
+
+	// Streaks may reach back beyond the heatmap, so consider all completions
+	dayRows, err := db.Query(`
+        SELECT DISTINCT (completed_at AT TIME ZONE 'UTC' AT TIME ZONE $2)::date AS day
+        FROM tasks
+        WHERE user_id = $1
+        AND status != 'deleted' AND status_category = 'done'
+        AND completed_at IS NOT NULL
+        ORDER BY day`, userID, prefs.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get completion days: %w", err)
+	}
+	defer dayRows.Close()
+
+	var days []string
+	for dayRows.Next() {
+		var day time.Time
+		if err := dayRows.Scan(&day); err != nil {
+			return nil, err
+		}
+		days = append(days, day.Format(activityDateFormat))
+	}
+	if err := dayRows.Err(); err != nil {
+		return nil, err
+	}
+
+	activity.CurrentStreak, activity.LongestStreak = completionStreaks(days, activity.Today)
+	return activity, nil
+}
+
+// completionStreaks calculates the current and longest streak of
+// consecutive days from ascending YYYY-MM-DD dates. The current streak
+// is the run ending today, or yesterday if nothing was completed today yet.
+func completionStreaks(days []string, today string) (current, longest int) {
+	run := 0
+	var previous time.Time
+	for i, date := range days {
+		day, err := time.Parse(activityDateFormat, date)
+		if err != nil {
+			continue
+		}
+		if i > 0 && day.Equal(previous.AddDate(0, 0, 1)) {
+			run++
+		} else {
+			run = 1
+		}
+		if run > longest {
+			longest = run
+		}
+		previous = day
+	}
+
+	if len(days) == 0 {
+		return 0, 0
+	}
+	todayDate, err := time.Parse(activityDateFormat, today)
+	if err != nil {
+		return 0, longest
+	}
+	if previous.Equal(todayDate) || previous.Equal(todayDate.AddDate(0, 0, -1)) {
+		current = run
+	}
+
+	return current, longest
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetActivity(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	// 23:30 UTC is already the next day in Berlin
+	now := time.Date(2024, 5, 19, 23, 30, 0, 0, time.UTC)
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	first := time.Date(2023, 5, 22, 0, 0, 0, 0, berlin)
+	end := time.Date(2024, 5, 21, 0, 0, 0, 0, berlin)
+
+	mock.ExpectQuery("FROM user_preferences").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(preferencesColumns).AddRow("Europe/Berlin", "en", "sunday", "YYYY-MM-DD", now))
+	mock.ExpectQuery("SELECT day, SUM\\(created\\), SUM\\(completed\\) FROM").
+		WithArgs(1, "Europe/Berlin", first.UTC(), end.UTC()).
+		WillReturnRows(sqlmock.NewRows([]string{"day", "created", "completed"}).
+			AddRow(time.Date(2023, 5, 22, 0, 0, 0, 0, time.UTC), 2, 0).
+			AddRow(time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC), 1, 3))
+	mock.ExpectQuery("SELECT DISTINCT (.+) AS day FROM tasks").
+		WithArgs(1, "Europe/Berlin").
+		WillReturnRows(sqlmock.NewRows([]string{"day"}).
+			AddRow(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)).
+			AddRow(time.Date(2024, 5, 19, 0, 0, 0, 0, time.UTC)).
+			AddRow(time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC)))
+
+	activity, err := GetActivity(db, 1, now)
+	assert.NoError(t, err)
+	assert.Equal(t, "Europe/Berlin", activity.TimeZone)
+	assert.Equal(t, "2024-05-20", activity.Today)
+	assert.Equal(t, WeekStartSunday, activity.WeekStart)
+	assert.Len(t, activity.Days, ActivityDays)
+	assert.Equal(t, ActivityDay{Date: "2023-05-22", Created: 2}, activity.Days[0])
+	assert.Equal(t, ActivityDay{Date: "2023-05-23"}, activity.Days[1])
+	assert.Equal(t, ActivityDay{Date: "2024-05-20", Created: 1, Completed: 3}, activity.Days[ActivityDays-1])
+	assert.Equal(t, 2, activity.CurrentStreak)
+	assert.Equal(t, 2, activity.LongestStreak)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetActivityDefaultTimeZone(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	now := time.Date(2024, 5, 19, 23, 30, 0, 0, time.UTC)
+
+	mock.ExpectQuery("FROM user_preferences").
+		WithArgs(1).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("SELECT day, SUM\\(created\\), SUM\\(completed\\) FROM").
+		WithArgs(1, "UTC", time.Date(2023, 5, 21, 0, 0, 0, 0, time.UTC), time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC)).
+		WillReturnRows(sqlmock.NewRows([]string{"day", "created", "completed"}))
+	mock.ExpectQuery("SELECT DISTINCT").
+		WithArgs(1, "UTC").
+		WillReturnRows(sqlmock.NewRows([]string{"day"}))
+
+	activity, err := GetActivity(db, 1, now)
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-05-19", activity.Today)
+	assert.Len(t, activity.Days, ActivityDays)
+	assert.Zero(t, activity.CurrentStreak)
+	assert.Zero(t, activity.LongestStreak)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCompletionStreaks(t *testing.T) {
+	tests := []struct {
+		name            string
+		days            []string
+		expectedCurrent int
+		expectedLongest int
+	}{
+		{"No completions", nil, 0, 0},
+		{"Completed today", []string{"2024-05-18", "2024-05-19", "2024-05-20"}, 3, 3},
+		{"Streak ending yesterday", []string{"2024-05-18", "2024-05-19"}, 2, 2},
+		{"Broken streak", []string{"2024-05-17", "2024-05-18"}, 0, 2},
+		{"Longest in the past", []string{"2024-01-01", "2024-01-02", "2024-01-03", "2024-05-20"}, 1, 3},
+		{"Across month end", []string{"2024-04-29", "2024-04-30", "2024-05-01"}, 0, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current, longest := completionStreaks(tt.days, "2024-05-20")
+			assert.Equal(t, tt.expectedCurrent, current)
+			assert.Equal(t, tt.expectedLongest, longest)
+		})
+	}
+}
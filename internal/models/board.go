@@ -0,0 +1,360 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/maxzhirnov/go-task-manager/pkg/database"
+)
+
+// BoardColumn is one column of a board: a status of the owner's workflow
+// together with the tasks in that status.
+type BoardColumn struct {
+	WorkflowStatus
+
+	// Tasks holds the tasks of the column from top to bottom
+	Tasks []Task `json:"tasks"`
+}
+
+// Board shows the top-level tasks of a project or the inbox grouped by
+// status, with one column per status of the owner's workflow in workflow
+// order.
+type Board struct {
+	// ProjectID is the project shown; nil is the inbox
+	ProjectID *int `json:"project_id"`
+
+	// Columns holds the columns in workflow order
+	Columns []BoardColumn `json:"columns"`
+}
+
+// BoardMove moves a task to a column of its board and a place within that
+// column. At most one of Position, Before and After may be set; without any
+// the task goes to the top of the column.
+type BoardMove struct {
+	// Status is the column to move the task to. Empty keeps the task in
+	// its column.
+	Status string `json:"status"`
+
+	// Position is the zero-based index within the column. Indexes past
+	// the end of the column move the task to the end.
+	Position *int `json:"position,omitempty"`
+
+	// Before places the task directly above the given task of the column
+	Before *int `json:"before,omitempty"`
+
+	// After places the task directly below the given task of the column
+	After *int `json:"after,omitempty"`
+
+	// Version, when non-zero, requires the task to be unchanged since
+	// that version was read
+	Version int `json:"-"`
+
+	// IgnoreBlockers lets a blocked task move to a doing or done column
+	IgnoreBlockers bool `json:"-"`
+}
+
+// Validate checks that the move has at most one valid destination.
+//
+// Returns:
+//   - nil: If the move is valid
+//   - error: "invalid move: ..." describing the problem
+func (m BoardMove) Validate() error {
+	destinations := 0
+	for _, anchor := range []*int{m.Position, m.Before, m.After} {
+		if anchor != nil {
+			destinations++
+		}
+	}
+	if destinations > 1 {
+		return fmt.Errorf("invalid move: at most one of position, before or after is allowed")
+	}
+	if m.Position != nil && *m.Position < 0 {
+		return fmt.Errorf("invalid move: negative position")
+	}
+	return nil
+}
+
+// GetBoard retrieves the board of a project, or of the inbox when projectID
+// is nil. Tasks whose status is not part of the workflow are left out.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: ID of the board owner
+//   - projectID: Project to show, nil for the inbox
+//
+// Returns:
+//   - Board: One column per workflow status, tasks ordered by position
+//   - error: "project not found" if the project doesn't belong to the
+//     user, or database errors
+//
+// Example Usage:
+//
+//	board, err := GetBoard(db, userID, &projectID)
+//	if err != nil {
+//	    return fmt.Errorf("failed to fetch board: %w", err)
+//	}
+func GetBoard(db database.DB, userID int, projectID *int) (Board, error) {
+	if err := verifyProjectOwner(db, userID, projectID); err != nil {
+		return Board{}, err
+	}
+
+	workflow, err := getWorkflow(db, userID)
+	if err != nil {
+		return Board{}, err
+	}
+
+	tasks, err := GetTasks(db, userID, TaskFilter{ProjectID: projectID, Inbox: projectID == nil})
+	if err != nil {
+		return Board{}, err
+	}
+
+	board := Board{ProjectID: projectID, Columns: make([]BoardColumn, len(workflow.Statuses))}
+	columns := make(map[string]int, len(workflow.Statuses))
+	for i, status := range workflow.Statuses {
+		board.Columns[i] = BoardColumn{WorkflowStatus: status, Tasks: []Task{}}
+		columns[status.Key] = i
+	}
+	for _, task := range tasks {
+		if i, ok := columns[task.Status]; ok {
+			board.Columns[i].Tasks = append(board.Columns[i].Tasks, task)
+		}
+	}
+
+	return board, nil
+}
+
+// MoveTask moves a task to another column of its board and a place within
+// that column in a single transaction.
+//
+// The process:
+//  1. Locks the task and its list (the subtasks of its parent, the
+//     top-level tasks of its project or the inbox)
+//  2. Ranks the task next to its new neighbours in the column; a task moved
+//     to an empty column keeps its place in the list
+//  3. Changes the status like UpdateTask, which checks the workflow
+//     transition and the WIP limit of the column
+//  4. Records a "moved" event when the position in the list changed
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: ID of the task owner
+//   - id: ID of the task to move
+//   - move: Destination column and place
+//
+// Returns:
+//   - Task: The moved task
+//   - error: "invalid move: ...", "task not found", "version conflict",
+//     "invalid status: ...", "status transition not allowed",
+//     "task is blocked", "wip limit reached: ..." or database errors
+//
+// Example Usage:
+//
+//	// Put task 7 into review, directly above task 3
+//	before := 3
+//	task, err := MoveTask(db, userID, 7, BoardMove{Status: "in_review", Before: &before})
+//	if err != nil {
+//	    return fmt.Errorf("failed to move task: %w", err)
+//	}
+func MoveTask(db database.DB, userID, id int, move BoardMove) (Task, error) {
+	if err := move.Validate(); err != nil {
+		return Task{}, err
+	}
+	if (move.Before != nil && *move.Before == id) || (move.After != nil && *move.After == id) {
+		return Task{}, fmt.Errorf("invalid move: task %d cannot be placed next to itself", id)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return Task{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback in case of error
+
+	task, err := scanTask(tx.QueryRow(`
+        SELECT `+taskColumns+`
+        FROM tasks
+        WHERE id = $1 AND user_id = $2 AND status != 'deleted'
+        FOR UPDATE`, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Task{}, fmt.Errorf("task not found")
+		}
+		return Task{}, fmt.Errorf("failed to get task: %w", err)
+	}
+	if move.Version != 0 && move.Version != task.Version {
+		return Task{}, fmt.Errorf("version conflict")
+	}
+	if move.Status != "" {
+		task.Status = move.Status
+	}
+
+	// Lock the list in its current order
+	rows, err := tx.Query(`
+        SELECT id, rank, status
+        FROM tasks
+        WHERE user_id = $1
+        AND project_id IS NOT DISTINCT FROM $2
+        AND parent_id IS NOT DISTINCT FROM $3
+        AND status != 'deleted'
+        ORDER BY rank, id
+        FOR UPDATE`, userID, task.ProjectID, task.ParentID)
+	if err != nil {
+		return Task{}, fmt.Errorf("failed to lock task list: %w", err)
+	}
+	var order []int
+	ranks := make(map[int]string)
+	statuses := make(map[int]string)
+	for rows.Next() {
+		var otherID int
+		var rank, status string
+		if err := rows.Scan(&otherID, &rank, &status); err != nil {
+			rows.Close()
+			return Task{}, err
+		}
+		ranks[otherID] = rank
+		statuses[otherID] = status
+		order = append(order, otherID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return Task{}, err
+	}
+
+	newOrder, err := placeInColumn(order, statuses, id, task.Status, move)
+	if err != nil {
+		return Task{}, err
+	}
+
+	// Store the ranks that changed; updateTask reads the new rank back
+	newRanks := rankMoves(newOrder, ranks, []int{id})
+	var changedIDs []int
+	var changedRanks []string
+	for _, otherID := range newOrder {
+		if ranks[otherID] != newRanks[otherID] {
+			changedIDs = append(changedIDs, otherID)
+			changedRanks = append(changedRanks, newRanks[otherID])
+		}
+	}
+	now := time.Now()
+	if err := writeRanks(tx, changedIDs, changedRanks, &now); err != nil {
+		return Task{}, err
+	}
+
+	// Change the status; the version has been checked above
+	task.Version = 0
+	task.IgnoreBlockers = move.IgnoreBlockers
+	if err := task.updateTask(tx); err != nil {
+		return Task{}, err
+	}
+
+	oldPosition, newPosition := indexOfInt(order, id), indexOfInt(newOrder, id)
+	if oldPosition != newPosition {
+		event := newTaskEvent(&task, TaskEventMoved, "position", oldPosition, newPosition)
+		if err := recordTaskEvents(tx, []TaskEvent{event}); err != nil {
+			return Task{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Task{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return task, nil
+}
+
+// placeInColumn returns the task IDs of order with id moved to its place in
+// the column of status as described by move. The place is given relative to
+// the other tasks of the column; when the column has no other tasks the
+// order is kept.
+func placeInColumn(order []int, statuses map[int]string, id int, status string, move BoardMove) ([]int, error) {
+	var rest, column []int
+	for _, other := range order {
+		if other == id {
+			continue
+		}
+		rest = append(rest, other)
+		if statuses[other] == status {
+			column = append(column, other)
+		}
+	}
+
+	var anchor int
+	after := false
+	switch {
+	case move.Before != nil:
+		anchor = *move.Before
+	case move.After != nil:
+		anchor, after = *move.After, true
+	default:
+		position := 0
+		if move.Position != nil {
+			position = *move.Position
+		}
+		switch {
+		case len(column) == 0:
+			return order, nil
+		case position < len(column):
+			anchor = column[position]
+		default:
+			anchor, after = column[len(column)-1], true
+		}
+	}
+	if indexOfInt(column, anchor) < 0 {
+		return nil, fmt.Errorf("invalid move: task %d is not in column %s", anchor, status)
+	}
+
+	target := indexOfInt(rest, anchor)
+	if after {
+		target++
+	}
+	rest = append(rest, 0)
+	copy(rest[target+1:], rest[target:])
+	rest[target] = id
+	return rest, nil
+}
+
+// checkWIPLimit fails when t enters a board column that already holds as
+// many tasks as the WIP limit of its status allows. Only top-level tasks
+// are on a board; old is nil for new tasks.
+func (t *Task) checkWIPLimit(q querier, w Workflow, old *Task) error {
+	status, ok := w.Status(t.Status)
+	if !ok || status.WIPLimit == nil || t.ParentID != nil {
+		return nil
+	}
+	if old != nil && old.Status == t.Status && sameProject(old.ProjectID, t.ProjectID) {
+		return nil
+	}
+
+	// Serialize moves into the columns of the user's boards
+	if _, err := q.Exec(`SELECT id FROM users WHERE id = $1 FOR UPDATE`, t.UserID); err != nil {
+		return fmt.Errorf("failed to lock user: %w", err)
+	}
+
+	var count int
+	err := q.QueryRow(`
+        SELECT COUNT(*)
+        FROM tasks
+        WHERE user_id = $1 AND status = $2
+        AND project_id IS NOT DISTINCT FROM $3
+        AND parent_id IS NULL
+        AND id != $4`,
+		t.UserID, t.Status, t.ProjectID, t.ID).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to count column tasks: %w", err)
+	}
+	if count >= *status.WIPLimit {
+		return fmt.Errorf("wip limit reached: %s allows %d tasks", status.Key, *status.WIPLimit)
+	}
+
+	return nil
+}
+
+// indexOfInt returns the index of value in values, or -1.
+func indexOfInt(values []int, value int) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,198 @@
+package models
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// expectLimitedWorkflow expects the workflow lookup of a user whose
+// in_progress status allows limit tasks per board.
+func expectLimitedWorkflow(mock sqlmock.Sqlmock, limit int) {
+	mock.ExpectQuery("SELECT key, name, category, transitions, wip_limit FROM workflow_statuses").
+		WillReturnRows(sqlmock.NewRows([]string{"key", "name", "category", "transitions", "wip_limit"}).
+			AddRow("pending", "Pending", "todo", nil, nil).
+			AddRow("in_progress", "In progress", "doing", nil, limit).
+			AddRow("completed", "Completed", "done", nil, nil))
+}
+
+func TestPlaceInColumn(t *testing.T) {
+	statuses := map[int]string{1: "pending", 2: "in_progress", 3: "pending", 4: "in_progress", 5: "pending"}
+	order := []int{1, 2, 3, 4, 5}
+	intPtr := func(v int) *int { return &v }
+
+	tests := []struct {
+		name     string
+		id       int
+		status   string
+		move     BoardMove
+		expected []int
+		wantErr  string
+	}{
+		{"Top of column", 5, "in_progress", BoardMove{}, []int{1, 5, 2, 3, 4}, ""},
+		{"Position within column", 1, "in_progress", BoardMove{Position: intPtr(1)}, []int{2, 3, 1, 4, 5}, ""},
+		{"Position past the end", 1, "in_progress", BoardMove{Position: intPtr(9)}, []int{2, 3, 4, 1, 5}, ""},
+		{"Before", 5, "pending", BoardMove{Before: intPtr(3)}, []int{1, 2, 5, 3, 4}, ""},
+		{"After", 1, "pending", BoardMove{After: intPtr(5)}, []int{2, 3, 4, 5, 1}, ""},
+		{"Empty column keeps the order", 3, "completed", BoardMove{}, []int{1, 2, 3, 4, 5}, ""},
+		{"Anchor in another column", 1, "in_progress", BoardMove{Before: intPtr(3)}, nil, "invalid move: task 3 is not in column in_progress"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := placeInColumn(order, statuses, tt.id, tt.status, tt.move)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestGetBoard(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	projectID := 3
+	mock.ExpectQuery("SELECT EXISTS").
+		WithArgs(3, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	expectLimitedWorkflow(mock, 2)
+	mock.ExpectQuery("SELECT (.+) FROM (.+) tasks WHERE user_id = \\$1 (.+) ORDER BY rank ASC").
+		WithArgs(1, 3).
+		WillReturnRows(sqlmock.NewRows(taskColumnNames).
+			AddRow(newTaskRow(7, "Write", "", "in_progress", 1, 0)...).
+			AddRow(newTaskRow(8, "Plan", "", "pending", 1, 1)...).
+			AddRow(newTaskRow(9, "Review", "", "in_progress", 1, 2)...))
+
+	board, err := GetBoard(db, 1, &projectID)
+	assert.NoError(t, err)
+	assert.Equal(t, &projectID, board.ProjectID)
+	assert.Len(t, board.Columns, 3)
+	assert.Equal(t, "in_progress", board.Columns[1].Key)
+	assert.Equal(t, 2, *board.Columns[1].WIPLimit)
+	assert.Len(t, board.Columns[0].Tasks, 1)
+	assert.Equal(t, 7, board.Columns[1].Tasks[0].ID)
+	assert.Equal(t, 9, board.Columns[1].Tasks[1].ID)
+	assert.Empty(t, board.Columns[2].Tasks)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMoveTask(t *testing.T) {
+	listColumns := []string{"id", "rank", "status"}
+	after := 8
+
+	t.Run("Moves to another column", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		row := newTaskRow(5, "Task", "", "pending", 1, 1)
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1 AND user_id = \\$2 AND status != 'deleted' FOR UPDATE").
+			WithArgs(5, 1).
+			WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(row...))
+		mock.ExpectQuery("SELECT id, rank, status FROM tasks WHERE user_id = \\$1 (.+) ORDER BY rank, id FOR UPDATE").
+			WithArgs(1, nil, nil).
+			WillReturnRows(sqlmock.NewRows(listColumns).
+				AddRow(3, "c", "in_progress").
+				AddRow(5, "i", "pending").
+				AddRow(8, "r", "in_progress"))
+		mock.ExpectExec("UPDATE tasks SET rank = v.rank").
+			WithArgs("{5}", sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1 FOR UPDATE").
+			WithArgs(5).
+			WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(row...))
+		expectLimitedWorkflow(mock, 3)
+		mock.ExpectExec("SELECT id FROM users WHERE id = \\$1 FOR UPDATE").
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM tasks WHERE user_id = \\$1 AND status = \\$2").
+			WithArgs(1, "in_progress", nil, 5).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+		mock.ExpectQuery("UPDATE tasks SET title").
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+		mock.ExpectExec("INSERT INTO task_events").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO task_events").
+			WithArgs(sqlmock.AnyArg(), 1, "moved", "position", "1", "2", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		task, err := MoveTask(db, 1, 5, BoardMove{Status: "in_progress", After: &after})
+		assert.NoError(t, err)
+		assert.Equal(t, "in_progress", task.Status)
+		assert.Equal(t, StatusCategoryDoing, task.StatusCategory)
+		assert.Equal(t, 2, task.Version)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Column at its WIP limit", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		row := newTaskRow(5, "Task", "", "pending", 1, 1)
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1 AND user_id = \\$2").
+			WithArgs(5, 1).
+			WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(row...))
+		mock.ExpectQuery("SELECT id, rank, status FROM tasks").
+			WithArgs(1, nil, nil).
+			WillReturnRows(sqlmock.NewRows(listColumns).
+				AddRow(5, "i", "pending").
+				AddRow(8, "r", "in_progress"))
+		mock.ExpectExec("UPDATE tasks SET rank = v.rank").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1 FOR UPDATE").
+			WithArgs(5).
+			WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(row...))
+		expectLimitedWorkflow(mock, 1)
+		mock.ExpectExec("SELECT id FROM users WHERE id = \\$1 FOR UPDATE").
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("SELECT COUNT").
+			WithArgs(1, "in_progress", nil, 5).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectRollback()
+
+		_, err = MoveTask(db, 1, 5, BoardMove{Status: "in_progress", After: &after})
+		assert.EqualError(t, err, "wip limit reached: in_progress allows 1 tasks")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Task not found", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1 AND user_id = \\$2").
+			WithArgs(5, 1).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		_, err = MoveTask(db, 1, 5, BoardMove{Status: "in_progress"})
+		assert.EqualError(t, err, "task not found")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Invalid move", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		position := 0
+		_, err = MoveTask(db, 1, 5, BoardMove{Position: &position, After: &after})
+		assert.EqualError(t, err, "invalid move: at most one of position, before or after is allowed")
+		_, err = MoveTask(db, 1, 8, BoardMove{After: &after})
+		assert.EqualError(t, err, "invalid move: task 8 cannot be placed next to itself")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,339 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/maxzhirnov/go-task-manager/pkg/database"
+)
+
+// Bulk action constants define the operations BulkUpdateTasks can apply
+const (
+	// BulkActionComplete marks the tasks as completed
+	BulkActionComplete = "complete"
+
+	// BulkActionDelete moves the tasks to the trash
+	BulkActionDelete = "delete"
+
+	// BulkActionRestore restores the tasks from the trash
+	BulkActionRestore = "restore"
+
+	// BulkActionRelabel attaches and detaches labels
+	BulkActionRelabel = "relabel"
+
+	// BulkActionMove moves top-level tasks to a project or the inbox
+	BulkActionMove = "move"
+)
+
+// MaxBulkTasks is the maximum number of tasks a single bulk operation
+// may touch.
+const MaxBulkTasks = 500
+
+// BulkOperation describes an action applied to many tasks at once.
+type BulkOperation struct {
+	// Action is one of the BulkAction constants
+	Action string `json:"action"`
+
+	// TaskIDs lists the tasks to apply the action to
+	TaskIDs []int `json:"task_ids,omitempty"`
+
+	// AddLabelIDs and RemoveLabelIDs are attached to and detached from
+	// every task by BulkActionRelabel
+	AddLabelIDs    []int `json:"add_label_ids,omitempty"`
+	RemoveLabelIDs []int `json:"remove_label_ids,omitempty"`
+
+	// ProjectID is the destination of BulkActionMove; nil moves the tasks
+	// to the inbox
+	ProjectID *int `json:"project_id,omitempty"`
+
+	// Atomic rolls back the whole operation when any task fails.
+	// By default the tasks that succeeded are kept.
+	Atomic bool `json:"atomic,omitempty"`
+
+	// IgnoreBlockers lets BulkActionComplete complete blocked tasks
+	IgnoreBlockers bool `json:"ignore_blockers,omitempty"`
+
+	// doneStatus is the status BulkActionComplete moves tasks to
+	doneStatus string
+}
+
+// BulkItemResult is the outcome of a bulk operation for a single task.
+type BulkItemResult struct {
+	// TaskID is the task the result belongs to
+	TaskID int `json:"task_id"`
+
+	// OK reports whether the action was applied to the task
+	OK bool `json:"ok"`
+
+	// Error explains why the action was not applied
+	Error string `json:"error,omitempty"`
+
+	// Task holds the task after the action; it is omitted for deletions
+	Task *Task `json:"task,omitempty"`
+}
+
+// BulkResult summarizes a bulk operation.
+type BulkResult struct {
+	// Action is the applied action
+	Action string `json:"action"`
+
+	// Succeeded and Failed count the tasks by outcome
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+
+	// RolledBack reports that an atomic operation was undone because at
+	// least one task failed
+	RolledBack bool `json:"rolled_back,omitempty"`
+
+	// Results holds one entry per task in the order of the request
+	Results []BulkItemResult `json:"results"`
+}
+
+// bulkItemErrors are the per-task errors reported to clients verbatim,
+// along with WIP limit errors; any other error is logged and reported as
+// an internal error.
+var bulkItemErrors = map[string]bool{
+	"task not found":                  true,
+	"task is not deleted":             true,
+	"parent task is deleted":          true,
+	"subtasks move with their parent": true,
+	"status transition not allowed":   true,
+	"task is blocked":                 true,
+}
+
+// Validate checks the action, its arguments and the task IDs.
+//
+// Returns:
+//   - nil: If the operation is valid
+//   - error: "invalid action: ...", "labels are required",
+//     "task IDs are required" or "too many tasks: ..."
+func (op BulkOperation) Validate() error {
+	if err := op.ValidateAction(); err != nil {
+		return err
+	}
+
+	if len(op.TaskIDs) == 0 {
+		return fmt.Errorf("task IDs are required")
+	}
+	if len(uniqueInts(op.TaskIDs)) > MaxBulkTasks {
+		return fmt.Errorf("too many tasks: at most %d per request", MaxBulkTasks)
+	}
+	return nil
+}
+
+// ValidateAction checks the action and its arguments without looking at the
+// task IDs, for operations whose tasks are selected later.
+//
+// Returns:
+//   - nil: If the action and its arguments are valid
+//   - error: "invalid action: ..." or "labels are required"
+func (op BulkOperation) ValidateAction() error {
+	switch op.Action {
+	case BulkActionComplete, BulkActionDelete, BulkActionRestore, BulkActionMove:
+	case BulkActionRelabel:
+		if len(op.AddLabelIDs) == 0 && len(op.RemoveLabelIDs) == 0 {
+			return fmt.Errorf("labels are required")
+		}
+	default:
+		return fmt.Errorf("invalid action: %s", op.Action)
+	}
+	return nil
+}
+
+// BulkUpdateTasks applies an action to many tasks of a user in a single
+// transaction.
+//
+// Every task is processed within its own savepoint: a task that fails is
+// rolled back on its own and reported in the result, while the other tasks
+// are kept. With op.Atomic set the whole transaction is rolled back instead
+// as soon as one task has failed. Tasks are processed in the given order,
+// duplicates once.
+//
+// The actions reuse the single-task operations, so events, recurrence and
+// subtasks are handled as for individual requests:
+//   - complete: UpdateTask with the first done status of the workflow;
+//     tasks in a done status are kept
+//   - delete: DeleteTask, including subtasks
+//   - restore: RestoreTask, including the subtasks deleted with the task
+//   - relabel: UpdateTask with the labels added and removed
+//   - move: UpdateTask with the new project; subtasks cannot be moved
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: ID of the task owner
+//   - op: The operation; it must be valid
+//
+// Returns:
+//   - BulkResult: Per-task results
+//   - error: "project not found" or "label not found" when the operation
+//     references entities the user doesn't own, or database errors; no
+//     task is changed in that case
+//
+// Example Usage:
+//
+//	result, err := BulkUpdateTasks(db, userID, BulkOperation{
+//	    Action:  BulkActionComplete,
+//	    TaskIDs: []int{4, 8, 15},
+//	})
+//	if err != nil {
+//	    return fmt.Errorf("failed to complete tasks: %w", err)
+//	}
+func BulkUpdateTasks(db database.DB, userID int, op BulkOperation) (BulkResult, error) {
+	result := BulkResult{Action: op.Action, Results: []BulkItemResult{}}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return result, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback in case of error
+
+	// Check the references shared by all tasks once
+	switch op.Action {
+	case BulkActionMove:
+		if err := verifyProjectOwner(tx, userID, op.ProjectID); err != nil {
+			return result, err
+		}
+	case BulkActionRelabel:
+		if err := verifyLabelOwner(tx, userID, append(append([]int{}, op.AddLabelIDs...), op.RemoveLabelIDs...)); err != nil {
+			return result, err
+		}
+	case BulkActionComplete:
+		workflow, err := getWorkflow(tx, userID)
+		if err != nil {
+			return result, err
+		}
+		op.doneStatus = workflow.DoneStatus()
+	}
+
+	for _, id := range uniqueInts(op.TaskIDs) {
+		if _, err := tx.Exec(`SAVEPOINT bulk_task`); err != nil {
+			return result, fmt.Errorf("failed to create savepoint: %w", err)
+		}
+
+		task, err := op.apply(tx, userID, id)
+		if err != nil {
+			if _, rollbackErr := tx.Exec(`ROLLBACK TO SAVEPOINT bulk_task`); rollbackErr != nil {
+				return result, fmt.Errorf("failed to roll back task %d: %w", id, rollbackErr)
+			}
+			result.Results = append(result.Results, BulkItemResult{TaskID: id, Error: bulkItemError(id, err)})
+			result.Failed++
+			continue
+		}
+
+		if _, err := tx.Exec(`RELEASE SAVEPOINT bulk_task`); err != nil {
+			return result, fmt.Errorf("failed to release savepoint: %w", err)
+		}
+		result.Results = append(result.Results, BulkItemResult{TaskID: id, OK: true, Task: task})
+		result.Succeeded++
+	}
+
+	// An atomic operation is all or nothing
+	if op.Atomic && result.Failed > 0 {
+		for i := range result.Results {
+			if result.Results[i].OK {
+				result.Results[i] = BulkItemResult{TaskID: result.Results[i].TaskID, Error: "rolled back"}
+			}
+		}
+		result.Failed += result.Succeeded
+		result.Succeeded = 0
+		result.RolledBack = true
+		return result, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return result, nil
+}
+
+// apply performs the action on a single task within the caller's
+// transaction and returns the task afterwards, or nil for deletions.
+func (op BulkOperation) apply(q querier, userID, id int) (*Task, error) {
+	if op.Action == BulkActionRestore {
+		task, err := restoreTask(q, userID, id)
+		if err != nil {
+			return nil, err
+		}
+		return &task, nil
+	}
+
+	// Lock the task and verify ownership
+	task, err := scanTask(q.QueryRow(`
+        SELECT `+taskColumns+`
+        FROM tasks
+        WHERE id = $1 AND user_id = $2 AND status != 'deleted'
+        FOR UPDATE`, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("task not found")
+		}
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+
+	switch op.Action {
+	case BulkActionDelete:
+		return nil, deleteTask(q, userID, id, 0)
+	case BulkActionComplete:
+		if task.StatusCategory == StatusCategoryDone {
+			return &task, nil
+		}
+		task.Status = op.doneStatus
+		task.IgnoreBlockers = op.IgnoreBlockers
+	case BulkActionMove:
+		if task.ParentID != nil {
+			return nil, fmt.Errorf("subtasks move with their parent")
+		}
+		task.ProjectID = op.ProjectID
+	case BulkActionRelabel:
+		removed := make(map[int]bool, len(op.RemoveLabelIDs))
+		for _, labelID := range op.RemoveLabelIDs {
+			removed[labelID] = true
+		}
+		labelIDs := []int{}
+		for _, label := range task.Labels {
+			if !removed[label.ID] {
+				labelIDs = append(labelIDs, label.ID)
+			}
+		}
+		for _, labelID := range op.AddLabelIDs {
+			if !removed[labelID] {
+				labelIDs = append(labelIDs, labelID)
+			}
+		}
+		task.LabelIDs = uniqueInts(labelIDs)
+	}
+
+	if err := task.updateTask(q); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// verifyLabelOwner checks that every label of labelIDs belongs to the user.
+func verifyLabelOwner(q querier, userID int, labelIDs []int) error {
+	ids := uniqueInts(labelIDs)
+	var count int
+	err := q.QueryRow(`
+        SELECT COUNT(*)
+        FROM labels
+        WHERE user_id = $1 AND id = ANY($2)`, userID, pq.Array(ids)).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to check labels: %w", err)
+	}
+	if count != len(ids) {
+		return fmt.Errorf("label not found")
+	}
+	return nil
+}
+
+// bulkItemError returns the message reported for a task that failed.
+func bulkItemError(id int, err error) string {
+	if bulkItemErrors[err.Error()] || strings.HasPrefix(err.Error(), "wip limit reached") {
+		return err.Error()
+	}
+	log.Printf("Bulk operation failed for task %d: %v", id, err)
+	return "internal error"
+}
@@ -0,0 +1,200 @@
+package models
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulkOperationValidate(t *testing.T) {
+	tooMany := make([]int, MaxBulkTasks+1)
+	for i := range tooMany {
+		tooMany[i] = i + 1
+	}
+
+	tests := []struct {
+		name          string
+		op            BulkOperation
+		expectedError string
+	}{
+		{
+			name: "Valid complete",
+			op:   BulkOperation{Action: BulkActionComplete, TaskIDs: []int{1, 2}},
+		},
+		{
+			name: "Valid relabel",
+			op:   BulkOperation{Action: BulkActionRelabel, TaskIDs: []int{1}, AddLabelIDs: []int{2}},
+		},
+		{
+			name:          "Invalid action",
+			op:            BulkOperation{Action: "archive", TaskIDs: []int{1}},
+			expectedError: "invalid action: archive",
+		},
+		{
+			name:          "Relabel without labels",
+			op:            BulkOperation{Action: BulkActionRelabel, TaskIDs: []int{1}},
+			expectedError: "labels are required",
+		},
+		{
+			name:          "No tasks",
+			op:            BulkOperation{Action: BulkActionDelete},
+			expectedError: "task IDs are required",
+		},
+		{
+			name:          "Too many tasks",
+			op:            BulkOperation{Action: BulkActionDelete, TaskIDs: tooMany},
+			expectedError: "too many tasks: at most 500 per request",
+		},
+		{
+			name: "Duplicates count once",
+			op:   BulkOperation{Action: BulkActionDelete, TaskIDs: append(tooMany[:MaxBulkTasks:MaxBulkTasks], 1)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.op.Validate()
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	// Tasks selected later are not checked with the action
+	assert.NoError(t, BulkOperation{Action: BulkActionDelete}.ValidateAction())
+	assert.EqualError(t, BulkOperation{Action: BulkActionRelabel}.ValidateAction(), "labels are required")
+}
+
+func TestBulkUpdateTasks(t *testing.T) {
+	tests := []struct {
+		name           string
+		op             BulkOperation
+		mockSetup      func(sqlmock.Sqlmock)
+		expectedResult BulkResult
+		expectedError  string
+	}{
+		{
+			name: "Partial success",
+			op:   BulkOperation{Action: BulkActionDelete, TaskIDs: []int{3, 4, 3}},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("SAVEPOINT bulk_task").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1 AND user_id = \\$2 AND status != 'deleted' FOR UPDATE").
+					WithArgs(3, 1).
+					WillReturnRows(sqlmock.NewRows(taskColumnNames).
+						AddRow(newTaskRow(3, "Task", "", StatusPending, 1, 0)...))
+				mock.ExpectExec("deleted AS \\( UPDATE tasks").
+					WithArgs(sqlmock.AnyArg(), 3, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("RELEASE SAVEPOINT bulk_task").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("SAVEPOINT bulk_task").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1 AND user_id = \\$2 AND status != 'deleted' FOR UPDATE").
+					WithArgs(4, 1).
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectExec("ROLLBACK TO SAVEPOINT bulk_task").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			expectedResult: BulkResult{
+				Action:    BulkActionDelete,
+				Succeeded: 1,
+				Failed:    1,
+				Results: []BulkItemResult{
+					{TaskID: 3, OK: true},
+					{TaskID: 4, Error: "task not found"},
+				},
+			},
+		},
+		{
+			name: "Atomic rollback",
+			op:   BulkOperation{Action: BulkActionDelete, TaskIDs: []int{3, 4}, Atomic: true},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("SAVEPOINT bulk_task").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1").
+					WithArgs(3, 1).
+					WillReturnRows(sqlmock.NewRows(taskColumnNames).
+						AddRow(newTaskRow(3, "Task", "", StatusPending, 1, 0)...))
+				mock.ExpectExec("deleted AS \\( UPDATE tasks").
+					WithArgs(sqlmock.AnyArg(), 3, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("RELEASE SAVEPOINT bulk_task").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("SAVEPOINT bulk_task").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1").
+					WithArgs(4, 1).
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectExec("ROLLBACK TO SAVEPOINT bulk_task").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			expectedResult: BulkResult{
+				Action:     BulkActionDelete,
+				Failed:     2,
+				RolledBack: true,
+				Results: []BulkItemResult{
+					{TaskID: 3, Error: "rolled back"},
+					{TaskID: 4, Error: "task not found"},
+				},
+			},
+		},
+		{
+			name: "Foreign label",
+			op:   BulkOperation{Action: BulkActionRelabel, TaskIDs: []int{3}, AddLabelIDs: []int{7, 9}},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM labels WHERE user_id = \\$1 AND id = ANY\\(\\$2\\)").
+					WithArgs(1, "{7,9}").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+				mock.ExpectRollback()
+			},
+			expectedError: "label not found",
+		},
+		{
+			name: "Subtasks are not moved",
+			op:   BulkOperation{Action: BulkActionMove, TaskIDs: []int{5}},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				row := newTaskRow(5, "Subtask", "", StatusPending, 1, 0)
+				row[14] = 3
+				mock.ExpectBegin()
+				mock.ExpectExec("SAVEPOINT bulk_task").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1").
+					WithArgs(5, 1).
+					WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(row...))
+				mock.ExpectExec("ROLLBACK TO SAVEPOINT bulk_task").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			expectedResult: BulkResult{
+				Action: BulkActionMove,
+				Failed: 1,
+				Results: []BulkItemResult{
+					{TaskID: 5, Error: "subtasks move with their parent"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			tt.mockSetup(mock)
+
+			result, err := BulkUpdateTasks(db, 1, tt.op)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+				// Compare without the task bodies
+				for i := range result.Results {
+					result.Results[i].Task = nil
+				}
+				assert.Equal(t, tt.expectedResult, result)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
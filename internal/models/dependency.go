@@ -0,0 +1,288 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/maxzhirnov/go-task-manager/pkg/database"
+)
+
+// DependencyNode is a task of a dependency graph.
+type DependencyNode struct {
+	// ID identifies the task
+	ID int `json:"id"`
+
+	// Title is the title of the task
+	Title string `json:"title"`
+
+	// Status is the current status of the task
+	Status string `json:"status"`
+
+	// StatusCategory is the category of Status; a blocker stops blocking
+	// once it is done
+	StatusCategory string `json:"status_category"`
+}
+
+// DependencyEdge states that a task is blocked by another task.
+type DependencyEdge struct {
+	// TaskID is the task that waits
+	TaskID int `json:"task_id"`
+
+	// BlockedByID is the task that has to be done first
+	BlockedByID int `json:"blocked_by_id"`
+}
+
+// DependencyGraph holds the tasks a task depends on, directly or through
+// other tasks, and the tasks that depend on it. Tasks in the trash are left
+// out.
+type DependencyGraph struct {
+	// BlockedBy lists the IDs of the direct blockers of the task
+	BlockedBy []int `json:"blocked_by"`
+
+	// Blocks lists the IDs of the tasks directly blocked by the task
+	Blocks []int `json:"blocks"`
+
+	// Nodes holds every task of the graph, including the task itself
+	Nodes []DependencyNode `json:"nodes"`
+
+	// Edges holds every dependency between the tasks of Nodes
+	Edges []DependencyEdge `json:"edges"`
+}
+
+// AddTaskDependency makes a task wait for another task of the same user.
+// Adding an existing dependency succeeds without changes.
+//
+// Dependencies must not form cycles: a task cannot be blocked by a task
+// that already waits for it, directly or through other tasks, including
+// tasks in the trash that may be restored.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: ID of the owner of both tasks
+//   - taskID: The task that waits
+//   - blockedByID: The task that has to be done first
+//
+// Returns:
+//   - error: "a task cannot block itself", "task not found" if either task
+//     doesn't exist, is deleted or belongs to another user,
+//     "dependency cycle", or database errors
+//
+// Example Usage:
+//
+//	// Task 7 can't start before task 3 is done
+//	if err := AddTaskDependency(db, userID, 7, 3); err != nil {
+//	    return fmt.Errorf("failed to add dependency: %w", err)
+//	}
+func AddTaskDependency(db database.DB, userID, taskID, blockedByID int) error {
+	if taskID == blockedByID {
+		return fmt.Errorf("a task cannot block itself")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback in case of error
+
+	// Serialize dependency changes of the user so that two concurrent
+	// inserts can't close a cycle
+	if _, err := tx.Exec(`SELECT id FROM users WHERE id = $1 FOR UPDATE`, userID); err != nil {
+		return fmt.Errorf("failed to lock user: %w", err)
+	}
+
+	var found int
+	err = tx.QueryRow(`
+        SELECT COUNT(*)
+        FROM tasks
+        WHERE user_id = $1 AND id = ANY($2) AND status != 'deleted'`,
+		userID, pq.Array([]int{taskID, blockedByID})).Scan(&found)
+	if err != nil {
+		return fmt.Errorf("failed to verify tasks: %w", err)
+	}
+	if found != 2 {
+		return fmt.Errorf("task not found")
+	}
+
+	// The new edge closes a cycle when the blocker already waits for the task
+	var cycle bool
+	err = tx.QueryRow(`
+        WITH RECURSIVE upstream AS (
+            SELECT blocked_by_id AS id FROM task_dependencies WHERE task_id = $1
+            UNION
+            SELECT d.blocked_by_id
+            FROM task_dependencies d
+            JOIN upstream u ON d.task_id = u.id
+        )
+        SELECT EXISTS (SELECT 1 FROM upstream WHERE id = $2)`,
+		blockedByID, taskID).Scan(&cycle)
+	if err != nil {
+		return fmt.Errorf("failed to check dependency cycle: %w", err)
+	}
+	if cycle {
+		return fmt.Errorf("dependency cycle")
+	}
+
+	_, err = tx.Exec(`
+        INSERT INTO task_dependencies (task_id, blocked_by_id)
+        VALUES ($1, $2)
+        ON CONFLICT DO NOTHING`, taskID, blockedByID)
+	if err != nil {
+		return fmt.Errorf("failed to insert dependency: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveTaskDependency lets a task stop waiting for another task.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: ID of the task owner
+//   - taskID: The task that waits
+//   - blockedByID: The task it waits for
+//
+// Returns:
+//   - error: "dependency not found" if the user has no such dependency,
+//     or database errors
+func RemoveTaskDependency(db database.DB, userID, taskID, blockedByID int) error {
+	result, err := db.Exec(`
+        DELETE FROM task_dependencies d
+        USING tasks t
+        WHERE d.task_id = $1 AND d.blocked_by_id = $2
+        AND t.id = d.task_id AND t.user_id = $3`,
+		taskID, blockedByID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete dependency: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("dependency not found")
+	}
+
+	return nil
+}
+
+// GetDependencyGraph retrieves the dependency graph of a task: every task
+// it waits for and every task waiting for it, directly or transitively.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: ID of the task owner
+//   - taskID: The task in the middle of the graph
+//
+// Returns:
+//   - DependencyGraph: The tasks and dependencies of the graph
+//   - error: Database error if a query fails
+//
+// Example Usage:
+//
+//	graph, err := GetDependencyGraph(db, userID, task.ID)
+//	if err != nil {
+//	    return fmt.Errorf("failed to fetch dependencies: %w", err)
+//	}
+//	task.Dependencies = &graph
+func GetDependencyGraph(db database.DB, userID, taskID int) (DependencyGraph, error) {
+	graph := DependencyGraph{
+		BlockedBy: []int{},
+		Blocks:    []int{},
+		Nodes:     []DependencyNode{},
+		Edges:     []DependencyEdge{},
+	}
+
+	// Walk up to the blockers and down to the blocked tasks; UNION stops
+	// at tasks that have been visited already
+	rows, err := db.Query(`
+        WITH RECURSIVE upstream AS (
+            SELECT d.task_id, d.blocked_by_id
+            FROM task_dependencies d
+            JOIN tasks b ON b.id = d.blocked_by_id AND b.status != 'deleted'
+            WHERE d.task_id = $1
+            UNION
+            SELECT d.task_id, d.blocked_by_id
+            FROM task_dependencies d
+            JOIN upstream u ON d.task_id = u.blocked_by_id
+            JOIN tasks b ON b.id = d.blocked_by_id AND b.status != 'deleted'
+        ), downstream AS (
+            SELECT d.task_id, d.blocked_by_id
+            FROM task_dependencies d
+            JOIN tasks t ON t.id = d.task_id AND t.status != 'deleted'
+            WHERE d.blocked_by_id = $1
+            UNION
+            SELECT d.task_id, d.blocked_by_id
+            FROM task_dependencies d
+            JOIN downstream s ON d.blocked_by_id = s.task_id
+            JOIN tasks t ON t.id = d.task_id AND t.status != 'deleted'
+        )
+        SELECT task_id, blocked_by_id FROM upstream
+        UNION
+        SELECT task_id, blocked_by_id FROM downstream
+        ORDER BY task_id, blocked_by_id`, taskID)
+	if err != nil {
+		return DependencyGraph{}, fmt.Errorf("failed to get dependencies: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []int{taskID}
+	for rows.Next() {
+		var edge DependencyEdge
+		if err := rows.Scan(&edge.TaskID, &edge.BlockedByID); err != nil {
+			return DependencyGraph{}, fmt.Errorf("failed to scan dependency: %w", err)
+		}
+		graph.Edges = append(graph.Edges, edge)
+		ids = append(ids, edge.TaskID, edge.BlockedByID)
+
+		if edge.TaskID == taskID {
+			graph.BlockedBy = append(graph.BlockedBy, edge.BlockedByID)
+		}
+		if edge.BlockedByID == taskID {
+			graph.Blocks = append(graph.Blocks, edge.TaskID)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return DependencyGraph{}, fmt.Errorf("failed to read dependencies: %w", err)
+	}
+
+	nodeRows, err := db.Query(`
+        SELECT id, title, status, status_category
+        FROM tasks
+        WHERE user_id = $1 AND id = ANY($2)
+        ORDER BY id`, userID, pq.Array(uniqueInts(ids)))
+	if err != nil {
+		return DependencyGraph{}, fmt.Errorf("failed to get dependency tasks: %w", err)
+	}
+	defer nodeRows.Close()
+
+	for nodeRows.Next() {
+		var node DependencyNode
+		if err := nodeRows.Scan(&node.ID, &node.Title, &node.Status, &node.StatusCategory); err != nil {
+			return DependencyGraph{}, fmt.Errorf("failed to scan dependency task: %w", err)
+		}
+		graph.Nodes = append(graph.Nodes, node)
+	}
+	if err := nodeRows.Err(); err != nil {
+		return DependencyGraph{}, fmt.Errorf("failed to read dependency tasks: %w", err)
+	}
+
+	return graph, nil
+}
+
+// checkBlockers fails when a blocked task would start or finish, that is
+// enter a doing or done category from another category, unless
+// t.IgnoreBlockers is set. old is the stored task.
+func (t *Task) checkBlockers(old *Task) error {
+	if !old.Blocked || t.IgnoreBlockers {
+		return nil
+	}
+	if t.StatusCategory == StatusCategoryTodo || t.StatusCategory == old.StatusCategory {
+		return nil
+	}
+	return fmt.Errorf("task is blocked")
+}
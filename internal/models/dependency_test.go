@@ -0,0 +1,193 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddTaskDependency(t *testing.T) {
+	tests := []struct {
+		name      string
+		taskID    int
+		blockedBy int
+		mockSetup func(sqlmock.Sqlmock)
+		wantErr   string
+	}{
+		{
+			name:      "Adds dependency",
+			taskID:    7,
+			blockedBy: 3,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("SELECT id FROM users WHERE id = \\$1 FOR UPDATE").
+					WithArgs(1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM tasks WHERE user_id = \\$1 AND id = ANY\\(\\$2\\)").
+					WithArgs(1, "{7,3}").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+				mock.ExpectQuery("WITH RECURSIVE upstream AS").
+					WithArgs(3, 7).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectExec("INSERT INTO task_dependencies (.+) ON CONFLICT DO NOTHING").
+					WithArgs(7, 3).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:      "Closes a cycle",
+			taskID:    7,
+			blockedBy: 3,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("SELECT id FROM users WHERE id = \\$1 FOR UPDATE").
+					WithArgs(1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT COUNT").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+				mock.ExpectQuery("WITH RECURSIVE upstream AS").
+					WithArgs(3, 7).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+				mock.ExpectRollback()
+			},
+			wantErr: "dependency cycle",
+		},
+		{
+			name:      "Task of another user",
+			taskID:    7,
+			blockedBy: 3,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("SELECT id FROM users WHERE id = \\$1 FOR UPDATE").
+					WithArgs(1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT COUNT").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+				mock.ExpectRollback()
+			},
+			wantErr: "task not found",
+		},
+		{
+			name:      "Blocks itself",
+			taskID:    7,
+			blockedBy: 7,
+			mockSetup: func(mock sqlmock.Sqlmock) {},
+			wantErr:   "a task cannot block itself",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			tt.mockSetup(mock)
+
+			err = AddTaskDependency(db, 1, tt.taskID, tt.blockedBy)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRemoveTaskDependency(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec("DELETE FROM task_dependencies d USING tasks t").
+		WithArgs(7, 3, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM task_dependencies d USING tasks t").
+		WithArgs(7, 4, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, RemoveTaskDependency(db, 1, 7, 3))
+	assert.EqualError(t, RemoveTaskDependency(db, 1, 7, 4), "dependency not found")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetDependencyGraph(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	// Task 7 waits for 3, which waits for 1; task 9 waits for 7
+	mock.ExpectQuery("WITH RECURSIVE upstream AS (.+) downstream AS").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"task_id", "blocked_by_id"}).
+			AddRow(3, 1).
+			AddRow(7, 3).
+			AddRow(9, 7))
+	mock.ExpectQuery("SELECT id, title, status, status_category FROM tasks WHERE user_id = \\$1 AND id = ANY\\(\\$2\\)").
+		WithArgs(1, "{7,3,1,9}").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "status", "status_category"}).
+			AddRow(1, "Research", "completed", "done").
+			AddRow(3, "Design", "in_progress", "doing").
+			AddRow(7, "Build", "pending", "todo").
+			AddRow(9, "Release", "pending", "todo"))
+
+	graph, err := GetDependencyGraph(db, 1, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{3}, graph.BlockedBy)
+	assert.Equal(t, []int{9}, graph.Blocks)
+	assert.Len(t, graph.Nodes, 4)
+	assert.Equal(t, []DependencyEdge{{3, 1}, {7, 3}, {9, 7}}, graph.Edges)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCheckBlockers(t *testing.T) {
+	tests := []struct {
+		name     string
+		blocked  bool
+		ignore   bool
+		from, to string
+		wantErr  bool
+	}{
+		{"Blocked task starts", true, false, StatusCategoryTodo, StatusCategoryDoing, true},
+		{"Blocked task finishes", true, false, StatusCategoryDoing, StatusCategoryDone, true},
+		{"Blocked task goes back", true, false, StatusCategoryDone, StatusCategoryTodo, false},
+		{"Blocked task keeps its category", true, false, StatusCategoryDoing, StatusCategoryDoing, false},
+		{"Blockers ignored", true, true, StatusCategoryTodo, StatusCategoryDone, false},
+		{"Unblocked task", false, false, StatusCategoryTodo, StatusCategoryDone, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := &Task{Blocked: tt.blocked, StatusCategory: tt.from}
+			task := &Task{StatusCategory: tt.to, IgnoreBlockers: tt.ignore}
+			err := task.checkBlockers(old)
+			if tt.wantErr {
+				assert.EqualError(t, err, "task is blocked")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestUpdateTaskBlocked(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	row := newTaskRow(7, "Build", "", "pending", 1, 0)
+	row[26] = true
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1 FOR UPDATE").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(row...))
+	expectDefaultWorkflow(mock)
+	mock.ExpectRollback()
+
+	task := &Task{ID: 7, UserID: 1, Title: "Build", Status: "in_progress", Priority: PriorityNone}
+	assert.EqualError(t, task.UpdateTask(db), "task is blocked")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
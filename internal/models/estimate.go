@@ -0,0 +1,277 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/maxzhirnov/go-task-manager/pkg/database"
+)
+
+// Estimate unit constants select the estimate a burndown is measured in
+const (
+	// EstimateUnitPoints measures effort in story points
+	EstimateUnitPoints = "points"
+
+	// EstimateUnitMinutes measures effort in minutes
+	EstimateUnitMinutes = "minutes"
+)
+
+// MaxEstimatePoints limits the story points of a single task.
+const MaxEstimatePoints = 1000
+
+// MaxEstimateMinutes limits the estimated minutes of a single task (one year).
+const MaxEstimateMinutes = 365 * 24 * 60
+
+// MaxBurndownRangeDays limits the date range of a burndown.
+const MaxBurndownRangeDays = 366
+
+// estimateColumns maps the estimate units to their task columns.
+var estimateColumns = map[string]string{
+	EstimateUnitPoints:  "estimate_points",
+	EstimateUnitMinutes: "estimate_minutes",
+}
+
+// EffortStatistics sums the estimates of a user's tasks that are not
+// deleted. Tasks without an estimate add nothing.
+type EffortStatistics struct {
+	// RemainingPoints sums the story points of tasks that are not done
+	RemainingPoints int `json:"remaining_points"`
+
+	// CompletedPoints sums the story points of done tasks
+	CompletedPoints int `json:"completed_points"`
+
+	// RemainingMinutes sums the estimated minutes of tasks that are not done
+	RemainingMinutes int `json:"remaining_minutes"`
+
+	// CompletedMinutes sums the estimated minutes of done tasks
+	CompletedMinutes int `json:"completed_minutes"`
+
+	// UnestimatedTasks is the number of tasks that are not done and have
+	// neither estimate
+	UnestimatedTasks int `json:"unestimated_tasks"`
+}
+
+// BurndownDay is the remaining effort at the end of one day.
+type BurndownDay struct {
+	// Date is the day in the user's time zone
+	Date time.Time `json:"date"`
+
+	// Remaining is the effort of the tasks open at the end of the day
+	Remaining int `json:"remaining"`
+
+	// Completed is the effort of the tasks completed during the day
+	Completed int `json:"completed"`
+
+	// Ideal is the remaining effort of a steady burndown from the effort
+	// open at the start of the range to zero at its end
+	Ideal float64 `json:"ideal"`
+}
+
+// Burndown is the series of remaining effort per day within a date range.
+type Burndown struct {
+	// From is the inclusive start of the range in the user's time zone
+	From time.Time `json:"from"`
+
+	// To is the exclusive end of the range in the user's time zone
+	To time.Time `json:"to"`
+
+	// TimeZone is the time zone days are counted in
+	TimeZone string `json:"time_zone"`
+
+	// Unit is the estimate the effort is measured in, one of the
+	// EstimateUnit constants
+	Unit string `json:"unit"`
+
+	// ProjectID limits the burndown to the tasks of one project; nil
+	// covers all tasks of the user
+	ProjectID *int `json:"project_id"`
+
+	// Start is the effort open at the start of the range
+	Start int `json:"start"`
+
+	// Days lists the remaining effort per day, oldest first, including
+	// days without changes
+	Days []BurndownDay `json:"days"`
+}
+
+// ValidateEstimate checks the task's optional estimates. Both may be
+// given at once.
+//
+// Returns:
+//   - nil: If the estimates are empty or valid
+//   - error: "estimate_points must be between 0 and 1000" or
+//     "estimate_minutes must be between 0 and 525600"
+//
+// Example Usage:
+//
+//	points := 5
+//	task := &Task{EstimatePoints: &points}
+//	if err := task.ValidateEstimate(); err != nil {
+//	    return fmt.Errorf("validation failed: %w", err)
+//	}
+func (t *Task) ValidateEstimate() error {
+	if t.EstimatePoints != nil && (*t.EstimatePoints < 0 || *t.EstimatePoints > MaxEstimatePoints) {
+		return fmt.Errorf("estimate_points must be between 0 and %d", MaxEstimatePoints)
+	}
+	if t.EstimateMinutes != nil && (*t.EstimateMinutes < 0 || *t.EstimateMinutes > MaxEstimateMinutes) {
+		return fmt.Errorf("estimate_minutes must be between 0 and %d", MaxEstimateMinutes)
+	}
+	return nil
+}
+
+// getEffortStatistics sums the estimates of a user's tasks that are not
+// deleted, split into remaining and completed effort.
+func getEffortStatistics(db database.DB, userID int) (EffortStatistics, error) {
+	var effort EffortStatistics
+	err := db.QueryRow(`
+        SELECT
+            COALESCE(SUM(estimate_points) FILTER (WHERE status_category != 'done'), 0),
+            COALESCE(SUM(estimate_points) FILTER (WHERE status_category = 'done'), 0),
+            COALESCE(SUM(estimate_minutes) FILTER (WHERE status_category != 'done'), 0),
+            COALESCE(SUM(estimate_minutes) FILTER (WHERE status_category = 'done'), 0),
+            COUNT(*) FILTER (
+                WHERE status_category != 'done'
+                AND estimate_points IS NULL AND estimate_minutes IS NULL
+            )
+        FROM tasks
+        WHERE user_id = $1 AND status != 'deleted'`, userID).Scan(
+		&effort.RemainingPoints,
+		&effort.CompletedPoints,
+		&effort.RemainingMinutes,
+		&effort.CompletedMinutes,
+		&effort.UnestimatedTasks,
+	)
+	if err != nil {
+		return EffortStatistics{}, fmt.Errorf("failed to get effort statistics: %w", err)
+	}
+	return effort, nil
+}
+
+// GetBurndown calculates the effort a user had left at the end of each day
+// of a date range. Days are counted in the time zone of the user's
+// preferences.
+//
+// A task counts as open from its creation until it is completed or moved to
+// the trash, with its current estimate; tasks without an estimate in unit
+// add nothing. Tasks that were purged are no longer known.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: The ID of the user whose effort to measure
+//   - from: First day of the range; only the date is used
+//   - to: Day after the last day of the range; only the date is used
+//   - unit: One of the EstimateUnit constants
+//   - projectID: Optional project to limit the burndown to
+//
+// Returns:
+//   - *Burndown: The remaining effort per day
+//   - error: "invalid unit: ...", "from must be before to",
+//     "date range must not exceed 366 days", "project not found" or
+//     database errors
+//
+// Example Usage:
+//
+//	// Two-week sprint measured in story points
+//	start := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+//	burndown, err := GetBurndown(db, userID, start, start.AddDate(0, 0, 14), EstimateUnitPoints, &projectID)
+//	if err != nil {
+//	    return fmt.Errorf("failed to fetch burndown: %w", err)
+//	}
+func GetBurndown(db database.DB, userID int, from, to time.Time, unit string, projectID *int) (*Burndown, error) {
+	column, ok := estimateColumns[unit]
+	if !ok {
+		return nil, fmt.Errorf("invalid unit: %s", unit)
+	}
+
+	// Work with calendar dates only
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	if !from.Before(to) {
+		return nil, fmt.Errorf("from must be before to")
+	}
+	if to.Sub(from) > MaxBurndownRangeDays*24*time.Hour {
+		return nil, fmt.Errorf("date range must not exceed %d days", MaxBurndownRangeDays)
+	}
+
+	if err := verifyProjectOwner(db, userID, projectID); err != nil {
+		return nil, err
+	}
+
+	prefs, err := GetPreferences(db, userID)
+	if err != nil {
+		return nil, err
+	}
+	loc := prefs.Location()
+
+	burndown := &Burndown{
+		From:      time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc),
+		To:        time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, loc),
+		TimeZone:  prefs.TimeZone,
+		Unit:      unit,
+		ProjectID: projectID,
+		Days:      []BurndownDay{},
+	}
+
+	// Local days converted to UTC boundaries, starting with the day before
+	// the range whose end is the start of the burndown. Timestamps are
+	// stored in UTC.
+	rows, err := db.Query(`
+        WITH days AS (
+            SELECT day,
+                   (day AT TIME ZONE $4) AT TIME ZONE 'UTC' AS day_start,
+                   ((day + INTERVAL '1 day') AT TIME ZONE $4) AT TIME ZONE 'UTC' AS day_end
+            FROM generate_series(
+                $2::timestamp - INTERVAL '1 day',
+                $3::timestamp - INTERVAL '1 day',
+                INTERVAL '1 day'
+            ) AS series(day)
+        )
+        SELECT days.day,
+            COALESCE(SUM(t.`+column+`) FILTER (
+                WHERE t.created_at < days.day_end
+                AND (t.completed_at IS NULL OR t.completed_at >= days.day_end)
+                AND (t.deleted_at IS NULL OR t.deleted_at >= days.day_end)
+            ), 0),
+            COALESCE(SUM(t.`+column+`) FILTER (
+                WHERE t.completed_at >= days.day_start AND t.completed_at < days.day_end
+                AND (t.deleted_at IS NULL OR t.deleted_at >= days.day_end)
+            ), 0)
+        FROM days
+        LEFT JOIN tasks t
+            ON t.user_id = $1
+            AND t.`+column+` IS NOT NULL
+            AND ($5::integer IS NULL OR t.project_id = $5)
+        GROUP BY days.day
+        ORDER BY days.day`, userID, from, to, prefs.TimeZone, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate burndown: %w", err)
+	}
+	defer rows.Close()
+
+	first := true
+	for rows.Next() {
+		var day BurndownDay
+		if err := rows.Scan(&day.Date, &day.Remaining, &day.Completed); err != nil {
+			return nil, err
+		}
+		if first {
+			burndown.Start = day.Remaining
+			first = false
+			continue
+		}
+		day.Date = time.Date(day.Date.Year(), day.Date.Month(), day.Date.Day(), 0, 0, 0, 0, loc)
+		burndown.Days = append(burndown.Days, day)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// A steady burndown reaches zero at the end of the last day
+	for i := range burndown.Days {
+		left := float64(len(burndown.Days)-i-1) / float64(len(burndown.Days))
+		burndown.Days[i].Ideal = math.Round(float64(burndown.Start)*left*100) / 100
+	}
+
+	return burndown, nil
+}
@@ -0,0 +1,151 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateEstimate(t *testing.T) {
+	value := func(v int) *int { return &v }
+
+	tests := []struct {
+		name    string
+		task    Task
+		wantErr string
+	}{
+		{"No estimate", Task{}, ""},
+		{"Both estimates", Task{EstimatePoints: value(8), EstimateMinutes: value(90)}, ""},
+		{"Zero points", Task{EstimatePoints: value(0)}, ""},
+		{"Negative points", Task{EstimatePoints: value(-1)}, "estimate_points must be between 0 and 1000"},
+		{"Too many points", Task{EstimatePoints: value(1001)}, "estimate_points must be between 0 and 1000"},
+		{"Too many minutes", Task{EstimateMinutes: value(MaxEstimateMinutes + 1)}, "estimate_minutes must be between 0 and 525600"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.task.ValidateEstimate()
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestGetEffortStatistics(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT (.+) FROM tasks WHERE user_id = \\$1 AND status != 'deleted'").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"remaining_points", "completed_points", "remaining_minutes", "completed_minutes", "unestimated"}).
+			AddRow(21, 13, 480, 300, 2))
+
+	effort, err := getEffortStatistics(db, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, EffortStatistics{
+		RemainingPoints:  21,
+		CompletedPoints:  13,
+		RemainingMinutes: 480,
+		CompletedMinutes: 300,
+		UnestimatedTasks: 2,
+	}, effort)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetBurndown(t *testing.T) {
+	from := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Points per day", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		berlin, _ := time.LoadLocation("Europe/Berlin")
+		mock.ExpectQuery("FROM user_preferences").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(preferencesColumns).AddRow("Europe/Berlin", "en", "monday", "YYYY-MM-DD", time.Now()))
+		// The first row is the day before the range
+		rows := sqlmock.NewRows([]string{"day", "remaining", "completed"}).
+			AddRow(time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC), 20, 0).
+			AddRow(time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 15, 5).
+			AddRow(time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), 18, 0).
+			AddRow(time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC), 8, 10).
+			AddRow(time.Date(2024, 3, 7, 0, 0, 0, 0, time.UTC), 0, 8)
+		mock.ExpectQuery("SUM\\(t.estimate_points\\) (.+) LEFT JOIN tasks t").
+			WithArgs(1, from, to, "Europe/Berlin", nil).
+			WillReturnRows(rows)
+
+		burndown, err := GetBurndown(db, 1, from, to, EstimateUnitPoints, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, 3, 4, 0, 0, 0, 0, berlin), burndown.From)
+		assert.Equal(t, 20, burndown.Start)
+		assert.Len(t, burndown.Days, 4)
+		assert.Equal(t, time.Date(2024, 3, 4, 0, 0, 0, 0, berlin), burndown.Days[0].Date)
+		assert.Equal(t, 15, burndown.Days[0].Remaining)
+		assert.Equal(t, 5, burndown.Days[0].Completed)
+
+		// The ideal line reaches zero at the end of the last day
+		var ideal []float64
+		for _, day := range burndown.Days {
+			ideal = append(ideal, day.Ideal)
+		}
+		assert.Equal(t, []float64{15, 10, 5, 0}, ideal)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Unknown project", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		projectID := 9
+		mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM projects").
+			WithArgs(9, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+		_, err = GetBurndown(db, 1, from, to, EstimateUnitMinutes, &projectID)
+		assert.EqualError(t, err, "project not found")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Invalid arguments", func(t *testing.T) {
+		_, err := GetBurndown(nil, 1, from, to, "hours", nil)
+		assert.EqualError(t, err, "invalid unit: hours")
+		_, err = GetBurndown(nil, 1, to, from, EstimateUnitPoints, nil)
+		assert.EqualError(t, err, "from must be before to")
+		_, err = GetBurndown(nil, 1, from, from.AddDate(0, 0, 367), EstimateUnitPoints, nil)
+		assert.EqualError(t, err, "date range must not exceed 366 days")
+	})
+}
+
+func TestUpdateTaskRecordsEstimateChange(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	points := 5
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1 FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(newTaskRow(1, "Task", "", "pending", 1, 0)...))
+	expectDefaultWorkflow(mock)
+	mock.ExpectQuery("UPDATE tasks").
+		WithArgs("Task", "", "pending", sqlmock.AnyArg(), nil, nil, false, "none", nil, "i", "",
+			nil, nil, "todo", &points, nil, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+	mock.ExpectExec("INSERT INTO task_events").
+		WithArgs(1, 1, TaskEventUpdated, "estimate_points", nil, "5", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	task := Task{ID: 1, Title: "Task", Status: "pending", Priority: PriorityNone, UserID: 1, EstimatePoints: &points}
+	assert.NoError(t, task.UpdateTask(db))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
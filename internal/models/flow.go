@@ -0,0 +1,175 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/maxzhirnov/go-task-manager/pkg/database"
+)
+
+// MaxFlowRangeDays limits the date range of flow metrics.
+const MaxFlowRangeDays = 366
+
+// DurationStats summarizes a set of durations in hours.
+type DurationStats struct {
+	// Count is the number of tasks the durations were measured on
+	Count int `json:"count"`
+
+	// AverageHours is the mean duration in hours; zero when Count is zero
+	AverageHours float64 `json:"average_hours"`
+
+	// MedianHours is the median duration in hours; zero when Count is zero
+	MedianHours float64 `json:"median_hours"`
+}
+
+// WeeklyThroughput is the number of tasks completed in one week.
+type WeeklyThroughput struct {
+	// WeekStart is the first day of the week in the user's time zone, a
+	// Monday or a Sunday depending on the user's preferences
+	WeekStart time.Time `json:"week_start"`
+
+	// Completed is the number of tasks completed in the week
+	Completed int `json:"completed"`
+}
+
+// FlowMetrics describes how fast a user's tasks move through the workflow
+// within a date range.
+type FlowMetrics struct {
+	// From is the inclusive start of the range in the user's time zone
+	From time.Time `json:"from"`
+
+	// To is the exclusive end of the range in the user's time zone
+	To time.Time `json:"to"`
+
+	// CompletedTasks is the number of tasks completed within the range
+	CompletedTasks int `json:"completed_tasks"`
+
+	// LeadTime measures from creation to completion
+	LeadTime DurationStats `json:"lead_time"`
+
+	// CycleTime measures from the first move to in_progress to completion.
+	// Tasks completed without ever being in progress are not counted.
+	CycleTime DurationStats `json:"cycle_time"`
+
+	// Throughput lists the completed tasks per week, oldest week first,
+	// including weeks without completions. Weeks begin on the first day of
+	// the week of the user's preferences.
+	Throughput []WeeklyThroughput `json:"throughput"`
+}
+
+// GetFlowMetrics calculates lead time, cycle time and weekly throughput of
+// the tasks a user completed within [from, to). Days are counted in the time
+// zone of the user's preferences, and weeks begin on their week start.
+//
+// Only tasks that are currently in a done status of the user's workflow are
+// considered, so reopened or deleted tasks do not count.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: The ID of the user whose tasks to measure
+//   - from: First day of the range; only the date is used
+//   - to: Day after the last day of the range; only the date is used
+//
+// Returns:
+//   - *FlowMetrics: The calculated metrics
+//   - error: "from must be before to", "date range must not exceed 366 days"
+//     or database errors
+//
+// Example Usage:
+//
+//	to := time.Date(2024, 5, 27, 0, 0, 0, 0, time.UTC)
+//	metrics, err := GetFlowMetrics(db, userID, to.AddDate(0, 0, -84), to)
+//	if err != nil {
+//	    return fmt.Errorf("failed to fetch flow metrics: %w", err)
+//	}
+func GetFlowMetrics(db database.DB, userID int, from, to time.Time) (*FlowMetrics, error) {
+	// Work with calendar dates only
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	if !from.Before(to) {
+		return nil, fmt.Errorf("from must be before to")
+	}
+	if to.Sub(from) > MaxFlowRangeDays*24*time.Hour {
+		return nil, fmt.Errorf("date range must not exceed %d days", MaxFlowRangeDays)
+	}
+
+	prefs, err := GetPreferences(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := prefs.Location()
+
+	metrics := &FlowMetrics{
+		From: time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc),
+		To:   time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, loc),
+	}
+
+	// Durations in hours; AVG and PERCENTILE_CONT skip the NULL cycle
+	// times of tasks that were never in progress
+	err = db.QueryRow(`
+        WITH durations AS (
+            SELECT
+                EXTRACT(EPOCH FROM (completed_at - created_at)) / 3600 AS lead_hours,
+                EXTRACT(EPOCH FROM (completed_at - started_at)) / 3600 AS cycle_hours
+            FROM tasks
+            WHERE user_id = $1
+            AND status != 'deleted' AND status_category = 'done'
+            AND completed_at >= $2
+            AND completed_at < $3
+        )
+        SELECT
+            COUNT(*),
+            COALESCE(AVG(lead_hours), 0),
+            COALESCE(PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY lead_hours), 0),
+            COUNT(cycle_hours),
+            COALESCE(AVG(cycle_hours), 0),
+            COALESCE(PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY cycle_hours), 0)
+        FROM durations`, userID, metrics.From.UTC(), metrics.To.UTC()).Scan(
+		&metrics.CompletedTasks,
+		&metrics.LeadTime.AverageHours,
+		&metrics.LeadTime.MedianHours,
+		&metrics.CycleTime.Count,
+		&metrics.CycleTime.AverageHours,
+		&metrics.CycleTime.MedianHours,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate flow times: %w", err)
+	}
+	metrics.LeadTime.Count = metrics.CompletedTasks
+
+	// Completions per local week, counting only the part of the first and
+	// last week that lies within the range. DATE_TRUNC starts weeks on
+	// Monday; $4 shifts them to start that many days earlier. Timestamps
+	// are stored in UTC and compared in the time zone $5.
+	rows, err := db.Query(`
+        SELECT weeks.week_start, COUNT(t.id)
+        FROM generate_series(
+            DATE_TRUNC('week', $2::timestamp + $4 * INTERVAL '1 day') - $4 * INTERVAL '1 day',
+            $3::timestamp - INTERVAL '1 microsecond',
+            INTERVAL '1 week'
+        ) AS weeks(week_start)
+        LEFT JOIN tasks t
+            ON t.user_id = $1
+            AND t.status != 'deleted' AND t.status_category = 'done'
+            AND t.completed_at AT TIME ZONE 'UTC' AT TIME ZONE $5 >= GREATEST(weeks.week_start, $2::timestamp)
+            AND t.completed_at AT TIME ZONE 'UTC' AT TIME ZONE $5 < LEAST(weeks.week_start + INTERVAL '1 week', $3::timestamp)
+        GROUP BY weeks.week_start
+        ORDER BY weeks.week_start`, userID, from, to, prefs.weekShift(), prefs.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate throughput: %w", err)
+	}
+	defer rows.Close()
+
+	metrics.Throughput = []WeeklyThroughput{}
+	for rows.Next() {
+		var week WeeklyThroughput
+		if err := rows.Scan(&week.WeekStart, &week.Completed); err != nil {
+			return nil, err
+		}
+		week.WeekStart = time.Date(week.WeekStart.Year(), week.WeekStart.Month(), week.WeekStart.Day(), 0, 0, 0, 0, loc)
+		metrics.Throughput = append(metrics.Throughput, week)
+	}
+
+	return metrics, rows.Err()
+}
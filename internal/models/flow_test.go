@@ -0,0 +1,115 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetFlowMetrics(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	from := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 14)
+
+	mock.ExpectQuery("FROM user_preferences").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(preferencesColumns).AddRow("UTC", "en", "sunday", "YYYY-MM-DD", from))
+	mock.ExpectQuery("WITH durations AS \\( SELECT (.+) FROM tasks WHERE user_id = \\$1 AND status != 'deleted' AND status_category = 'done' "+
+		"AND completed_at >= \\$2 AND completed_at < \\$3 \\)").
+		WithArgs(1, from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "lead_avg", "lead_median", "cycle_count", "cycle_avg", "cycle_median"}).
+			AddRow(3, 48.5, 24.0, 2, 6.0, 6.0))
+	mock.ExpectQuery("SELECT weeks.week_start, COUNT\\(t.id\\) FROM generate_series").
+		WithArgs(1, from, to, 1, "UTC").
+		WillReturnRows(sqlmock.NewRows([]string{"week_start", "count"}).
+			AddRow(from, 2).
+			AddRow(from.AddDate(0, 0, 7), 1))
+
+	metrics, err := GetFlowMetrics(db, 1, from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, metrics.CompletedTasks)
+	assert.Equal(t, DurationStats{Count: 3, AverageHours: 48.5, MedianHours: 24}, metrics.LeadTime)
+	assert.Equal(t, DurationStats{Count: 2, AverageHours: 6, MedianHours: 6}, metrics.CycleTime)
+	assert.Equal(t, []WeeklyThroughput{{from, 2}, {from.AddDate(0, 0, 7), 1}}, metrics.Throughput)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetFlowMetricsTimeZone(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	losAngeles, _ := time.LoadLocation("America/Los_Angeles")
+	from := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 7)
+
+	// Completions are counted from local midnight, 07:00 UTC in May
+	mock.ExpectQuery("FROM user_preferences").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(preferencesColumns).AddRow("America/Los_Angeles", "en", "monday", "YYYY-MM-DD", from))
+	mock.ExpectQuery("WITH durations AS").
+		WithArgs(1, time.Date(2024, 5, 6, 7, 0, 0, 0, time.UTC), time.Date(2024, 5, 13, 7, 0, 0, 0, time.UTC)).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "lead_avg", "lead_median", "cycle_count", "cycle_avg", "cycle_median"}).
+			AddRow(1, 2.0, 2.0, 0, 0, 0))
+	// Weeks are built from local dates and completions compared in local time
+	mock.ExpectQuery("t.completed_at AT TIME ZONE 'UTC' AT TIME ZONE \\$5 >= GREATEST\\(weeks.week_start, \\$2::timestamp\\)").
+		WithArgs(1, from, to, 0, "America/Los_Angeles").
+		WillReturnRows(sqlmock.NewRows([]string{"week_start", "count"}).AddRow(from, 1))
+
+	metrics, err := GetFlowMetrics(db, 1, from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 6, 0, 0, 0, 0, losAngeles), metrics.From)
+	assert.Equal(t, time.Date(2024, 5, 13, 0, 0, 0, 0, losAngeles), metrics.To)
+	assert.Equal(t, []WeeklyThroughput{{time.Date(2024, 5, 6, 0, 0, 0, 0, losAngeles), 1}}, metrics.Throughput)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetFlowMetricsInvalidRange(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	from := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+
+	_, err = GetFlowMetrics(db, 1, from, from)
+	assert.EqualError(t, err, "from must be before to")
+
+	_, err = GetFlowMetrics(db, 1, from, from.AddDate(2, 0, 0))
+	assert.EqualError(t, err, "date range must not exceed 366 days")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTrackStatusTimes(t *testing.T) {
+	earlier := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	now := time.Date(2024, 5, 2, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name              string
+		previous          *Task
+		category          string
+		expectedStarted   *time.Time
+		expectedCompleted *time.Time
+	}{
+		{"New todo task", nil, StatusCategoryTodo, nil, nil},
+		{"New done task", nil, StatusCategoryDone, nil, &now},
+		{"Started", &Task{StatusCategory: StatusCategoryTodo}, StatusCategoryDoing, &now, nil},
+		{"Start is kept", &Task{StatusCategory: StatusCategoryTodo, StartedAt: &earlier}, StatusCategoryDoing, &earlier, nil},
+		{"Completed", &Task{StatusCategory: StatusCategoryDoing, StartedAt: &earlier}, StatusCategoryDone, &earlier, &now},
+		{"Completion is kept", &Task{StatusCategory: StatusCategoryDone, CompletedAt: &earlier}, StatusCategoryDone, nil, &earlier},
+		{"Reopened", &Task{StatusCategory: StatusCategoryDone, StartedAt: &earlier, CompletedAt: &earlier}, StatusCategoryTodo, &earlier, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &Task{StatusCategory: tt.category, UpdatedAt: now}
+			task.trackStatusTimes(tt.previous)
+			assert.Equal(t, tt.expectedStarted, task.StartedAt)
+			assert.Equal(t, tt.expectedCompleted, task.CompletedAt)
+		})
+	}
+}
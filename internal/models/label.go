@@ -0,0 +1,285 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/maxzhirnov/go-task-manager/pkg/database"
+)
+
+// DefaultLabelColor is assigned to labels created without a color.
+const DefaultLabelColor = "#808080"
+
+// labelColorPattern matches hex colors in the #RRGGBB form.
+var labelColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// Label represents a user-defined tag that can be attached to any
+// number of the user's tasks.
+type Label struct {
+	// ID uniquely identifies the label
+	ID int `json:"id"`
+
+	// UserID associates the label with its owner
+	UserID int `json:"user_id"`
+
+	// Name is the display name, unique per user (case-insensitive)
+	Name string `json:"name"`
+
+	// Color is the display color in #RRGGBB form
+	Color string `json:"color"`
+
+	// CreatedAt stores the timestamp when the label was created
+	CreatedAt time.Time `json:"created_at"`
+
+	// UpdatedAt stores the timestamp of the last modification
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TaskLabel is the compact form of a label embedded in task responses.
+type TaskLabel struct {
+	// ID identifies the label
+	ID int `json:"id"`
+
+	// Name is the display name of the label
+	Name string `json:"name"`
+
+	// Color is the display color in #RRGGBB form
+	Color string `json:"color"`
+}
+
+// Label filter modes define how multiple labels in TaskFilter are combined
+const (
+	// LabelModeAny matches tasks carrying at least one of the labels
+	LabelModeAny = "any"
+
+	// LabelModeAll matches tasks carrying every one of the labels
+	LabelModeAll = "all"
+)
+
+// GetLabels retrieves all labels owned by a user ordered by name.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: The ID of the user whose labels to retrieve
+//
+// Returns:
+//   - []Label: Slice of labels belonging to the user
+//   - error: Database error if query fails
+func GetLabels(db database.DB, userID int) ([]Label, error) {
+	query := `SELECT id, user_id, name, color, created_at, updated_at 
+              FROM labels 
+              WHERE user_id = $1 
+              ORDER BY LOWER(name) ASC`
+
+	rows, err := db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var labels []Label
+	for rows.Next() {
+		var l Label
+		if err := rows.Scan(&l.ID, &l.UserID, &l.Name, &l.Color, &l.CreatedAt, &l.UpdatedAt); err != nil {
+			return nil, err
+		}
+		labels = append(labels, l)
+	}
+
+	return labels, rows.Err()
+}
+
+// GetLabel retrieves a single label owned by the given user.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: The ID of the label owner
+//   - id: The unique identifier of the label
+//
+// Returns:
+//   - Label: The requested label
+//   - error: sql.ErrNoRows if the label doesn't exist or belongs to another user
+func GetLabel(db database.DB, userID, id int) (Label, error) {
+	var l Label
+
+	query := `SELECT id, user_id, name, color, created_at, updated_at 
+              FROM labels 
+              WHERE id = $1 AND user_id = $2`
+
+	err := db.QueryRow(query, id, userID).Scan(&l.ID, &l.UserID, &l.Name, &l.Color, &l.CreatedAt, &l.UpdatedAt)
+	if err != nil {
+		return Label{}, err
+	}
+
+	return l, nil
+}
+
+// Validate checks the label's name and color, trimming the name and
+// assigning DefaultLabelColor when no color is given.
+//
+// Returns:
+//   - nil: If the label is valid
+//   - error: Describing the first invalid field
+func (l *Label) Validate() error {
+	l.Name = strings.TrimSpace(l.Name)
+	if l.Name == "" {
+		return fmt.Errorf("label name is required")
+	}
+	if len(l.Name) > 50 {
+		return fmt.Errorf("label name must not exceed 50 characters")
+	}
+
+	if l.Color == "" {
+		l.Color = DefaultLabelColor
+	}
+	if !labelColorPattern.MatchString(l.Color) {
+		return fmt.Errorf("invalid color: %s", l.Color)
+	}
+
+	return nil
+}
+
+// CreateLabel inserts a new label for l.UserID.
+//
+// Returns:
+//   - error: "label already exists" if the user has a label with the same
+//     name, or other database errors
+//
+// Side Effects:
+//   - Sets l.ID, l.CreatedAt and l.UpdatedAt
+func (l *Label) CreateLabel(db database.DB) error {
+	l.CreatedAt = time.Now()
+	l.UpdatedAt = l.CreatedAt
+
+	query := `
+        INSERT INTO labels (user_id, name, color, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id`
+
+	err := db.QueryRow(query, l.UserID, l.Name, l.Color, l.CreatedAt, l.UpdatedAt).Scan(&l.ID)
+	if err != nil {
+		return labelWriteError(err, "failed to create label")
+	}
+
+	return nil
+}
+
+// UpdateLabel renames or recolors an existing label of l.UserID.
+//
+// Returns:
+//   - error: "label not found", "label already exists" or database errors
+func (l *Label) UpdateLabel(db database.DB) error {
+	l.UpdatedAt = time.Now()
+
+	query := `
+        UPDATE labels 
+        SET name = $1, color = $2, updated_at = $3
+        WHERE id = $4 AND user_id = $5
+        RETURNING created_at`
+
+	err := db.QueryRow(query, l.Name, l.Color, l.UpdatedAt, l.ID, l.UserID).Scan(&l.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("label not found")
+		}
+		return labelWriteError(err, "failed to update label")
+	}
+
+	return nil
+}
+
+// DeleteLabel permanently removes a label. It is detached from all tasks
+// through the ON DELETE CASCADE rule of tasks_labels.
+//
+// Returns:
+//   - error: "label not found" if the user owns no such label, or database errors
+func DeleteLabel(db database.DB, userID, id int) error {
+	result, err := db.Exec(`DELETE FROM labels WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete label: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("label not found")
+	}
+
+	return nil
+}
+
+// setTaskLabels replaces the labels attached to a task with labelIDs.
+// Every label must belong to userID, otherwise "label not found" is
+// returned and nothing is changed. The attached labels are returned in
+// name order.
+func setTaskLabels(q querier, taskID, userID int, labelIDs []int) ([]TaskLabel, error) {
+	ids := uniqueInts(labelIDs)
+
+	// Verify ownership and load display data
+	rows, err := q.Query(`
+        SELECT id, name, color 
+        FROM labels 
+        WHERE user_id = $1 AND id = ANY($2)
+        ORDER BY LOWER(name) ASC`, userID, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load labels: %w", err)
+	}
+	defer rows.Close()
+
+	labels := []TaskLabel{}
+	for rows.Next() {
+		var l TaskLabel
+		if err := rows.Scan(&l.ID, &l.Name, &l.Color); err != nil {
+			return nil, fmt.Errorf("failed to load labels: %w", err)
+		}
+		labels = append(labels, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load labels: %w", err)
+	}
+	if len(labels) != len(ids) {
+		return nil, fmt.Errorf("label not found")
+	}
+
+	// Replace current assignments
+	if _, err := q.Exec(`DELETE FROM tasks_labels WHERE task_id = $1`, taskID); err != nil {
+		return nil, fmt.Errorf("failed to detach labels: %w", err)
+	}
+	if len(ids) > 0 {
+		_, err := q.Exec(`
+            INSERT INTO tasks_labels (task_id, label_id)
+            SELECT $1, UNNEST($2::int[])`, taskID, pq.Array(ids))
+		if err != nil {
+			return nil, fmt.Errorf("failed to attach labels: %w", err)
+		}
+	}
+
+	return labels, nil
+}
+
+// labelWriteError translates unique violations on the label name.
+func labelWriteError(err error, message string) error {
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return fmt.Errorf("label already exists")
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
+
+// uniqueInts returns values without duplicates, keeping the first occurrence.
+func uniqueInts(values []int) []int {
+	seen := make(map[int]bool, len(values))
+	result := make([]int, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,255 @@
+package models
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetLabels(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT (.+) FROM labels WHERE user_id = \\$1 ORDER BY LOWER\\(name\\) ASC").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "color", "created_at", "updated_at"}).
+			AddRow(1, 1, "home", "#00aa00", time.Now(), time.Now()).
+			AddRow(2, 1, "work", "#ff8800", time.Now(), time.Now()))
+
+	labels, err := GetLabels(db, 1)
+	assert.NoError(t, err)
+	assert.Len(t, labels, 2)
+	assert.Equal(t, "home", labels[0].Name)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLabelValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		label         Label
+		expectedColor string
+		expectedError string
+	}{
+		{
+			name:          "Default color",
+			label:         Label{Name: " work "},
+			expectedColor: DefaultLabelColor,
+		},
+		{
+			name:          "Custom color",
+			label:         Label{Name: "work", Color: "#FF8800"},
+			expectedColor: "#FF8800",
+		},
+		{
+			name:          "Empty name",
+			label:         Label{Name: "   "},
+			expectedError: "label name is required",
+		},
+		{
+			name:          "Invalid color",
+			label:         Label{Name: "work", Color: "orange"},
+			expectedError: "invalid color: orange",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.label.Validate()
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "work", tt.label.Name)
+			assert.Equal(t, tt.expectedColor, tt.label.Color)
+		})
+	}
+}
+
+func TestCreateLabel(t *testing.T) {
+	tests := []struct {
+		name          string
+		mockSetup     func(sqlmock.Sqlmock)
+		expectedError string
+	}{
+		{
+			name: "Successful creation",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO labels").
+					WithArgs(1, "work", "#ff8800", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+			},
+		},
+		{
+			name: "Duplicate name",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO labels").
+					WithArgs(1, "work", "#ff8800", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnError(&pq.Error{Code: "23505"})
+			},
+			expectedError: "label already exists",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			tt.mockSetup(mock)
+
+			label := &Label{UserID: 1, Name: "work", Color: "#ff8800"}
+			err = label.CreateLabel(db)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, 5, label.ID)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUpdateLabelNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery("UPDATE labels SET name = \\$1, color = \\$2, updated_at = \\$3 WHERE id = \\$4 AND user_id = \\$5").
+		WithArgs("work", "#ff8800", sqlmock.AnyArg(), 3, 1).
+		WillReturnError(sql.ErrNoRows)
+
+	label := &Label{ID: 3, UserID: 1, Name: "work", Color: "#ff8800"}
+	assert.EqualError(t, label.UpdateLabel(db), "label not found")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteLabel(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec("DELETE FROM labels WHERE id = \\$1 AND user_id = \\$2").
+		WithArgs(3, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM labels WHERE id = \\$1 AND user_id = \\$2").
+		WithArgs(4, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, DeleteLabel(db, 1, 3))
+	assert.EqualError(t, DeleteLabel(db, 1, 4), "label not found")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateTaskReplacesLabels(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1 FOR UPDATE").
+		WithArgs(7).
+		WillReturnRows(lockedTaskRows(7, nil, nil, 0, "pending"))
+	expectDefaultWorkflow(mock)
+	mock.ExpectQuery("UPDATE tasks").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+	mock.ExpectQuery("SELECT id, name, color FROM labels WHERE user_id = \\$1 AND id = ANY\\(\\$2\\)").
+		WithArgs(1, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "color"}).
+			AddRow(2, "home", "#00aa00").
+			AddRow(1, "work", "#ff8800"))
+	mock.ExpectExec("DELETE FROM tasks_labels WHERE task_id = \\$1").
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO tasks_labels").
+		WithArgs(7, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("INSERT INTO task_events").
+		WithArgs(7, 1, TaskEventUpdated, "labels", "[]", "[1,2]", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	task := &Task{ID: 7, UserID: 1, Title: "Task", Status: StatusPending, Priority: PriorityNone,
+		LabelIDs: []int{1, 2, 1}}
+	assert.NoError(t, task.UpdateTask(db))
+	assert.Len(t, task.Labels, 2)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateTaskForeignLabel(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1 FOR UPDATE").
+		WithArgs(7).
+		WillReturnRows(lockedTaskRows(7, nil, nil, 0, "pending"))
+	expectDefaultWorkflow(mock)
+	mock.ExpectQuery("UPDATE tasks").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+	mock.ExpectQuery("SELECT id, name, color FROM labels").
+		WithArgs(1, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "color"}))
+	mock.ExpectRollback()
+
+	task := &Task{ID: 7, UserID: 1, Title: "Task", Status: StatusPending, Priority: PriorityNone,
+		LabelIDs: []int{99}}
+	assert.EqualError(t, task.UpdateTask(db), "label not found")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTasksLabelFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    string
+		pattern string
+		args    int
+	}{
+		{
+			name:    "Any label",
+			mode:    LabelModeAny,
+			pattern: "EXISTS \\(SELECT 1 FROM tasks_labels tl WHERE tl.task_id = tasks.id AND tl.label_id = ANY\\(\\$2\\)\\)",
+			args:    2,
+		},
+		{
+			name:    "All labels",
+			mode:    LabelModeAll,
+			pattern: "\\(SELECT COUNT\\(\\*\\) FROM tasks_labels tl WHERE tl.task_id = tasks.id AND tl.label_id = ANY\\(\\$2\\)\\) = \\$3",
+			args:    3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			row := newTaskRow(1, "Task", "", StatusPending, 1, 0)
+			row[13] = []byte(`[{"id": 1, "name": "work", "color": "#ff8800"}]`)
+
+			args := []driver.Value{1, sqlmock.AnyArg()}
+			if tt.args == 3 {
+				args = append(args, 2)
+			}
+			mock.ExpectQuery("SELECT (.+) FROM (.+) tasks WHERE user_id = \\$1 AND status != 'deleted' AND parent_id IS NULL AND " + tt.pattern).
+				WithArgs(args...).
+				WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(row...))
+
+			tasks, err := GetTasks(db, 1, TaskFilter{LabelIDs: []int{1, 2}, LabelMode: tt.mode})
+			assert.NoError(t, err)
+			assert.Len(t, tasks, 1)
+			assert.Equal(t, []TaskLabel{{ID: 1, Name: "work", Color: "#ff8800"}}, tasks[0].Labels)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata" // time zones must resolve even where the OS lacks zoneinfo
+
+	"github.com/maxzhirnov/go-task-manager/pkg/database"
+)
+
+// Preference defaults apply to users who have not changed a setting
+const (
+	// DefaultTimeZone is the time zone of users who have not chosen one
+	DefaultTimeZone = "UTC"
+
+	// DefaultLocale is the language of users who have not chosen one
+	DefaultLocale = "en"
+
+	// DefaultDateFormat is the date format of users who have not chosen one
+	DefaultDateFormat = "YYYY-MM-DD"
+)
+
+// Week start constants define the first day of a calendar week
+const (
+	WeekStartMonday = "monday"
+	WeekStartSunday = "sunday"
+)
+
+// SupportedLocales lists the languages emails can be rendered in
+var SupportedLocales = []string{"en", "ru"}
+
+// DateFormats maps the supported date formats to Go time layouts
+var DateFormats = map[string]string{
+	"YYYY-MM-DD": "2006-01-02",
+	"DD.MM.YYYY": "02.01.2006",
+	"DD/MM/YYYY": "02/01/2006",
+	"MM/DD/YYYY": "01/02/2006",
+}
+
+// Preferences holds the personal settings of a user.
+type Preferences struct {
+	// UserID identifies the user the preferences belong to
+	UserID int `json:"user_id"`
+
+	// TimeZone is an IANA time zone name such as "Europe/Berlin".
+	// It decides where "today" begins and ends for the user.
+	TimeZone string `json:"time_zone"`
+
+	// Locale is the language of emails, one of SupportedLocales
+	Locale string `json:"locale"`
+
+	// WeekStart is the first day of the week in weekly statistics,
+	// "monday" or "sunday"
+	WeekStart string `json:"week_start"`
+
+	// DateFormat is how dates are displayed to the user, one of the
+	// keys of DateFormats
+	DateFormat string `json:"date_format"`
+
+	// UpdatedAt stores the timestamp of the last modification;
+	// zero while the user still has the defaults
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DefaultPreferences returns the preferences of a user who has not
+// changed any settings.
+func DefaultPreferences(userID int) Preferences {
+	return Preferences{
+		UserID:     userID,
+		TimeZone:   DefaultTimeZone,
+		Locale:     DefaultLocale,
+		WeekStart:  WeekStartMonday,
+		DateFormat: DefaultDateFormat,
+	}
+}
+
+// GetPreferences retrieves the preferences of a user, falling back to
+// DefaultPreferences when none were saved.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: The ID of the user whose preferences to retrieve
+//
+// Returns:
+//   - Preferences: The stored or default preferences
+//   - error: Database error if the query fails
+//
+// Example Usage:
+//
+//	prefs, err := GetPreferences(db, userID)
+//	if err != nil {
+//	    return fmt.Errorf("failed to fetch preferences: %w", err)
+//	}
+//	today := time.Now().In(prefs.Location())
+func GetPreferences(db database.DB, userID int) (Preferences, error) {
+	p := Preferences{UserID: userID}
+
+	err := db.QueryRow(`
+        SELECT time_zone, locale, week_start, date_format, updated_at
+        FROM user_preferences
+        WHERE user_id = $1`, userID).Scan(&p.TimeZone, &p.Locale, &p.WeekStart, &p.DateFormat, &p.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return DefaultPreferences(userID), nil
+	}
+	if err != nil {
+		return Preferences{}, fmt.Errorf("failed to get preferences: %w", err)
+	}
+
+	return p, nil
+}
+
+// Validate checks that the preferences can be stored, assigning the
+// defaults to empty fields.
+//
+// Returns:
+//   - nil: If the preferences are valid
+//   - error: "invalid time zone: <name>" for names unknown to the IANA database,
+//     "invalid locale: <locale>", "invalid week start: <day>" or
+//     "invalid date format: <format>"
+func (p *Preferences) Validate() error {
+	defaults := DefaultPreferences(p.UserID)
+	if p.TimeZone == "" {
+		p.TimeZone = defaults.TimeZone
+	}
+	if p.Locale == "" {
+		p.Locale = defaults.Locale
+	}
+	if p.WeekStart == "" {
+		p.WeekStart = defaults.WeekStart
+	}
+	if p.DateFormat == "" {
+		p.DateFormat = defaults.DateFormat
+	}
+
+	// "Local" is the server's zone and means nothing to PostgreSQL
+	if _, err := time.LoadLocation(p.TimeZone); err != nil || p.TimeZone == "Local" {
+		return fmt.Errorf("invalid time zone: %s", p.TimeZone)
+	}
+
+	p.Locale = strings.ToLower(p.Locale)
+	if !isSupportedLocale(p.Locale) {
+		return fmt.Errorf("invalid locale: %s", p.Locale)
+	}
+
+	if p.WeekStart != WeekStartMonday && p.WeekStart != WeekStartSunday {
+		return fmt.Errorf("invalid week start: %s", p.WeekStart)
+	}
+
+	if _, ok := DateFormats[p.DateFormat]; !ok {
+		return fmt.Errorf("invalid date format: %s", p.DateFormat)
+	}
+
+	return nil
+}
+
+// isSupportedLocale reports whether emails can be rendered in the locale.
+func isSupportedLocale(locale string) bool {
+	for _, l := range SupportedLocales {
+		if l == locale {
+			return true
+		}
+	}
+	return false
+}
+
+// Location returns the time zone of the preferences, or UTC if the
+// stored name cannot be resolved.
+func (p Preferences) Location() *time.Location {
+	return loadLocation(p.TimeZone)
+}
+
+// DateLayout returns the Go time layout of the preferred date format.
+func (p Preferences) DateLayout() string {
+	if layout, ok := DateFormats[p.DateFormat]; ok {
+		return layout
+	}
+	return DateFormats[DefaultDateFormat]
+}
+
+// weekShift returns the number of days the week start lies before Monday,
+// for shifting PostgreSQL's Monday-based DATE_TRUNC('week', ...).
+func (p Preferences) weekShift() int {
+	if p.WeekStart == WeekStartSunday {
+		return 1
+	}
+	return 0
+}
+
+// locations caches resolved time zones, which are read for every task.
+var locations sync.Map
+
+// loadLocation resolves an IANA time zone name, falling back to UTC.
+func loadLocation(name string) *time.Location {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "" {
+		return time.UTC
+	}
+	locations.Store(name, loc)
+	return loc
+}
+
+// SavePreferences creates or replaces the preferences of p.UserID.
+// Validate should be called first.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//
+// Returns:
+//   - error: Database error if the upsert fails
+//
+// Side Effects:
+//   - Sets p.UpdatedAt to the current time
+func (p *Preferences) SavePreferences(db database.DB) error {
+	p.UpdatedAt = time.Now()
+
+	_, err := db.Exec(`
+        INSERT INTO user_preferences (user_id, time_zone, locale, week_start, date_format, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (user_id) DO UPDATE
+        SET time_zone = EXCLUDED.time_zone,
+            locale = EXCLUDED.locale,
+            week_start = EXCLUDED.week_start,
+            date_format = EXCLUDED.date_format,
+            updated_at = EXCLUDED.updated_at`,
+		p.UserID, p.TimeZone, p.Locale, p.WeekStart, p.DateFormat, p.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save preferences: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// preferencesColumns are the columns read by GetPreferences.
+var preferencesColumns = []string{"time_zone", "locale", "week_start", "date_format", "updated_at"}
+
+func TestGetPreferences(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	updatedAt := time.Date(2024, 5, 20, 8, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT time_zone, locale, week_start, date_format, updated_at FROM user_preferences WHERE user_id = \\$1").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(preferencesColumns).AddRow("Europe/Berlin", "ru", "sunday", "DD.MM.YYYY", updatedAt))
+	mock.ExpectQuery("FROM user_preferences").
+		WithArgs(2).
+		WillReturnError(sql.ErrNoRows)
+
+	prefs, err := GetPreferences(db, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, Preferences{
+		UserID:     1,
+		TimeZone:   "Europe/Berlin",
+		Locale:     "ru",
+		WeekStart:  WeekStartSunday,
+		DateFormat: "DD.MM.YYYY",
+		UpdatedAt:  updatedAt,
+	}, prefs)
+	assert.Equal(t, "Europe/Berlin", prefs.Location().String())
+	assert.Equal(t, "02.01.2006", prefs.DateLayout())
+	assert.Equal(t, 1, prefs.weekShift())
+
+	prefs, err = GetPreferences(db, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultPreferences(2), prefs)
+	assert.Equal(t, time.UTC, prefs.Location())
+	assert.Equal(t, "2006-01-02", prefs.DateLayout())
+	assert.Equal(t, 0, prefs.weekShift())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPreferencesValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		prefs       Preferences
+		expected    Preferences
+		expectedErr string
+	}{
+		{
+			name:     "All set",
+			prefs:    Preferences{TimeZone: "America/New_York", Locale: "RU", WeekStart: "sunday", DateFormat: "MM/DD/YYYY"},
+			expected: Preferences{TimeZone: "America/New_York", Locale: "ru", WeekStart: "sunday", DateFormat: "MM/DD/YYYY"},
+		},
+		{
+			name:     "Defaults",
+			prefs:    Preferences{},
+			expected: DefaultPreferences(0),
+		},
+		{
+			name:        "Unknown zone",
+			prefs:       Preferences{TimeZone: "Mars/Olympus"},
+			expectedErr: "invalid time zone: Mars/Olympus",
+		},
+		{
+			name:        "Server zone",
+			prefs:       Preferences{TimeZone: "Local"},
+			expectedErr: "invalid time zone: Local",
+		},
+		{
+			name:        "Unsupported locale",
+			prefs:       Preferences{Locale: "fr"},
+			expectedErr: "invalid locale: fr",
+		},
+		{
+			name:        "Invalid week start",
+			prefs:       Preferences{WeekStart: "friday"},
+			expectedErr: "invalid week start: friday",
+		},
+		{
+			name:        "Invalid date format",
+			prefs:       Preferences{DateFormat: "YYYY/DD/MM"},
+			expectedErr: "invalid date format: YYYY/DD/MM",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefs := tt.prefs
+			err := prefs.Validate()
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, prefs)
+		})
+	}
+}
+
+func TestSavePreferences(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO user_preferences (.+) ON CONFLICT \\(user_id\\) DO UPDATE").
+		WithArgs(1, "Asia/Tokyo", "en", "monday", "YYYY-MM-DD", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	prefs := Preferences{UserID: 1, TimeZone: "Asia/Tokyo", Locale: "en", WeekStart: "monday", DateFormat: "YYYY-MM-DD"}
+	assert.NoError(t, prefs.SavePreferences(db))
+	assert.False(t, prefs.UpdatedAt.IsZero())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
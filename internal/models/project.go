@@ -0,0 +1,363 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/maxzhirnov/go-task-manager/pkg/database"
+)
+
+// Project represents a named list owned by a user that groups tasks.
+// Tasks without a project belong to the user's inbox.
+type Project struct {
+	// ID uniquely identifies the project
+	ID int `json:"id"`
+
+	// UserID associates the project with its owner
+	UserID int `json:"user_id"`
+
+	// Name is the display name, unique per user (case-insensitive)
+	Name string `json:"name"`
+
+	// Description provides optional details about the project
+	Description string `json:"description"`
+
+	// Color is the display color in #RRGGBB form
+	Color string `json:"color"`
+
+	// CreatedAt stores the timestamp when the project was created
+	CreatedAt time.Time `json:"created_at"`
+
+	// UpdatedAt stores the timestamp of the last modification
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ProjectStatistics represents aggregated task counts for a single project,
+// read from the project_statistics view.
+type ProjectStatistics struct {
+	// ProjectID identifies the project these statistics belong to
+	ProjectID int `json:"project_id"`
+
+	// Name of the project
+	Name string `json:"name"`
+
+	// TotalTasks is the total number of tasks in the project
+	TotalTasks int `json:"total_tasks"`
+
+	// CompletedTasks is the number of tasks in a done status
+	CompletedTasks int `json:"completed_tasks"`
+
+	// PendingTasks is the number of tasks in a todo status
+	PendingTasks int `json:"pending_tasks"`
+
+	// InProgressTasks is the number of tasks in a doing status
+	InProgressTasks int `json:"in_progress_tasks"`
+
+	// DeletedTasks is the number of soft-deleted tasks
+	DeletedTasks int `json:"deleted_tasks"`
+
+	// OverdueTasks is the number of active tasks past their due date; all-day
+	// tasks are due until the end of the day in the owner's time zone
+	OverdueTasks int `json:"overdue_tasks"`
+
+	// CompletionRate is the share of completed non-deleted tasks in percent
+	CompletionRate float64 `json:"completion_rate"`
+}
+
+// GetProjects retrieves all projects owned by a user ordered by name.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: The ID of the user whose projects to retrieve
+//
+// Returns:
+//   - []Project: Slice of projects belonging to the user
+//   - error: Database error if query fails
+func GetProjects(db database.DB, userID int) ([]Project, error) {
+	query := `SELECT id, user_id, name, description, color, created_at, updated_at 
+              FROM projects 
+              WHERE user_id = $1 
+              ORDER BY LOWER(name) ASC`
+
+	rows, err := db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var projects []Project
+	for rows.Next() {
+		var p Project
+		err := rows.Scan(&p.ID, &p.UserID, &p.Name, &p.Description, &p.Color, &p.CreatedAt, &p.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, p)
+	}
+
+	return projects, rows.Err()
+}
+
+// GetProject retrieves a single project owned by the given user.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: The ID of the project owner
+//   - id: The unique identifier of the project
+//
+// Returns:
+//   - Project: The requested project
+//   - error: sql.ErrNoRows if the project doesn't exist or belongs to another user
+func GetProject(db database.DB, userID, id int) (Project, error) {
+	var p Project
+
+	query := `SELECT id, user_id, name, description, color, created_at, updated_at 
+              FROM projects 
+              WHERE id = $1 AND user_id = $2`
+
+	err := db.QueryRow(query, id, userID).Scan(
+		&p.ID, &p.UserID, &p.Name, &p.Description, &p.Color, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return Project{}, err
+	}
+
+	return p, nil
+}
+
+// Validate checks the project's name and color, trimming the name and
+// assigning DefaultLabelColor when no color is given.
+//
+// Returns:
+//   - nil: If the project is valid
+//   - error: Describing the first invalid field
+func (p *Project) Validate() error {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		return fmt.Errorf("project name is required")
+	}
+	if len(p.Name) > 100 {
+		return fmt.Errorf("project name must not exceed 100 characters")
+	}
+
+	if p.Color == "" {
+		p.Color = DefaultLabelColor
+	}
+	if !labelColorPattern.MatchString(p.Color) {
+		return fmt.Errorf("invalid color: %s", p.Color)
+	}
+
+	return nil
+}
+
+// CreateProject inserts a new project for p.UserID.
+//
+// Returns:
+//   - error: "project already exists" if the user has a project with the
+//     same name, or other database errors
+//
+// Side Effects:
+//   - Sets p.ID, p.CreatedAt and p.UpdatedAt
+func (p *Project) CreateProject(db database.DB) error {
+	p.CreatedAt = time.Now()
+	p.UpdatedAt = p.CreatedAt
+
+	query := `
+        INSERT INTO projects (user_id, name, description, color, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id`
+
+	err := db.QueryRow(query, p.UserID, p.Name, p.Description, p.Color, p.CreatedAt, p.UpdatedAt).Scan(&p.ID)
+	if err != nil {
+		return projectWriteError(err, "failed to create project")
+	}
+
+	return nil
+}
+
+// UpdateProject modifies the name, description and color of a project of p.UserID.
+//
+// Returns:
+//   - error: "project not found", "project already exists" or database errors
+func (p *Project) UpdateProject(db database.DB) error {
+	p.UpdatedAt = time.Now()
+
+	query := `
+        UPDATE projects 
+        SET name = $1, description = $2, color = $3, updated_at = $4
+        WHERE id = $5 AND user_id = $6
+        RETURNING created_at`
+
+	err := db.QueryRow(query, p.Name, p.Description, p.Color, p.UpdatedAt, p.ID, p.UserID).Scan(&p.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("project not found")
+		}
+		return projectWriteError(err, "failed to update project")
+	}
+
+	return nil
+}
+
+// DeleteProject removes a project and moves its tasks to the user's inbox.
+//
+// The moved tasks keep their relative order and are appended after the
+// tasks already in the inbox. Both steps run in a single transaction.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: The ID of the project owner
+//   - id: The unique identifier of the project
+//
+// Returns:
+//   - error: "project not found" if the user owns no such project, or database errors
+func DeleteProject(db database.DB, userID, id int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback in case of error
+
+	// Append project tasks to the end of the inbox and record the move
+	// in their history
+	_, err = tx.Exec(`
+        WITH moved AS (
+            UPDATE tasks 
+            SET project_id = NULL,
+                rank = CASE WHEN parent_id IS NULL THEN COALESCE((
+                    SELECT MAX(rank) 
+                    FROM tasks 
+                    WHERE user_id = $1 AND project_id IS NULL AND parent_id IS NULL
+                ), '') || rank ELSE rank END,
+                updated_at = $2
+            WHERE user_id = $1 AND project_id = $3
+            RETURNING id
+        )
+        INSERT INTO task_events (task_id, user_id, event_type, field, old_value, new_value, created_at)
+        SELECT id, $1, 'updated', 'project_id', to_jsonb($3::integer), NULL, $2
+        FROM moved`,
+		userID, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to move project tasks: %w", err)
+	}
+
+	result, err := tx.Exec(`DELETE FROM projects WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete project: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("project not found")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetProjectStatistics retrieves task statistics for every project of a user.
+//
+// This function reads from the project_statistics view, which mirrors the
+// user_statistics view on a per-project basis.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: The ID of the user whose project statistics to retrieve
+//   - projectID: Optional project to limit the result to; nil returns all projects
+//
+// Returns:
+//   - []ProjectStatistics: Statistics ordered by project name
+//   - error: Database error if query fails
+func GetProjectStatistics(db database.DB, userID int, projectID *int) ([]ProjectStatistics, error) {
+	query := `
+        SELECT 
+            project_id,
+            name,
+            total_tasks,
+            completed_tasks,
+            pending_tasks,
+            in_progress_tasks,
+            deleted_tasks,
+            overdue_tasks,
+            CASE 
+                WHEN total_tasks - deleted_tasks = 0 THEN 0
+                ELSE ROUND(completed_tasks::decimal * 100 / (total_tasks - deleted_tasks), 2)
+            END as completion_rate
+        FROM project_statistics 
+        WHERE user_id = $1 
+        AND ($2::int IS NULL OR project_id = $2)
+        ORDER BY LOWER(name) ASC`
+
+	rows, err := db.Query(query, userID, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project statistics: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []ProjectStatistics{}
+	for rows.Next() {
+		var s ProjectStatistics
+		err := rows.Scan(
+			&s.ProjectID,
+			&s.Name,
+			&s.TotalTasks,
+			&s.CompletedTasks,
+			&s.PendingTasks,
+			&s.InProgressTasks,
+			&s.DeletedTasks,
+			&s.OverdueTasks,
+			&s.CompletionRate,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan project statistics: %w", err)
+		}
+		stats = append(stats, s)
+	}
+
+	return stats, rows.Err()
+}
+
+// verifyProjectOwner checks that projectID, when set, refers to a project
+// owned by userID. It returns "project not found" otherwise.
+func verifyProjectOwner(q querier, userID int, projectID *int) error {
+	if projectID == nil {
+		return nil
+	}
+
+	var exists bool
+	err := q.QueryRow(`
+        SELECT EXISTS (SELECT 1 FROM projects WHERE id = $1 AND user_id = $2)`,
+		*projectID, userID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to verify project: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("project not found")
+	}
+
+	return nil
+}
+
+// sameProject reports whether two optional project IDs refer to the same list.
+func sameProject(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// projectWriteError translates unique violations on the project name.
+func projectWriteError(err error, message string) error {
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return fmt.Errorf("project already exists")
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
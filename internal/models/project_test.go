@@ -0,0 +1,190 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjectValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		project       Project
+		expectedColor string
+		expectedError string
+	}{
+		{
+			name:          "Default color",
+			project:       Project{Name: " Home "},
+			expectedColor: DefaultLabelColor,
+		},
+		{
+			name:          "Empty name",
+			project:       Project{Name: ""},
+			expectedError: "project name is required",
+		},
+		{
+			name:          "Invalid color",
+			project:       Project{Name: "Home", Color: "green"},
+			expectedError: "invalid color: green",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.project.Validate()
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "Home", tt.project.Name)
+			assert.Equal(t, tt.expectedColor, tt.project.Color)
+		})
+	}
+}
+
+func TestCreateProjectDuplicate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery("INSERT INTO projects").
+		WithArgs(1, "Home", "", "#808080", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnError(&pq.Error{Code: "23505"})
+
+	project := &Project{UserID: 1, Name: "Home", Color: "#808080"}
+	assert.EqualError(t, project.CreateProject(db), "project already exists")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteProject(t *testing.T) {
+	tests := []struct {
+		name          string
+		mockSetup     func(sqlmock.Sqlmock)
+		expectedError string
+	}{
+		{
+			name: "Tasks move to inbox",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE tasks SET project_id = NULL").
+					WithArgs(1, sqlmock.AnyArg(), 3).
+					WillReturnResult(sqlmock.NewResult(0, 4))
+				mock.ExpectExec("DELETE FROM projects WHERE id = \\$1 AND user_id = \\$2").
+					WithArgs(3, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Project not found",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE tasks SET project_id = NULL").
+					WithArgs(1, sqlmock.AnyArg(), 3).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("DELETE FROM projects").
+					WithArgs(3, 1).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			expectedError: "project not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			tt.mockSetup(mock)
+
+			err = DeleteProject(db, 1, 3)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetProjectStatistics(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	projectID := 3
+	mock.ExpectQuery("SELECT (.+) FROM project_statistics WHERE user_id = \\$1").
+		WithArgs(1, &projectID).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"project_id", "name", "total_tasks", "completed_tasks", "pending_tasks",
+			"in_progress_tasks", "deleted_tasks", "overdue_tasks", "completion_rate",
+		}).AddRow(3, "Home", 5, 2, 2, 1, 1, 1, 50.0))
+
+	stats, err := GetProjectStatistics(db, 1, &projectID)
+	assert.NoError(t, err)
+	assert.Len(t, stats, 1)
+	assert.Equal(t, 5, stats[0].TotalTasks)
+	assert.Equal(t, 50.0, stats[0].CompletionRate)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateTaskMovesProject(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	projectID := 3
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1 FOR UPDATE").
+		WithArgs(7).
+		WillReturnRows(lockedTaskRows(7, nil, nil, 2, "pending"))
+	expectDefaultWorkflow(mock)
+	mock.ExpectQuery("SELECT EXISTS").
+		WithArgs(3, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery("SELECT MIN\\(rank\\) FROM tasks").
+		WithArgs(1, projectID).
+		WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow("3"))
+	mock.ExpectExec("WITH RECURSIVE subtree AS (.+) UPDATE tasks SET project_id = \\$2").
+		WithArgs(7, &projectID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery("UPDATE tasks SET title").
+		WithArgs("Task", "", StatusPending, sqlmock.AnyArg(), nil, nil, false, PriorityNone, &projectID, "2", "", nil, nil, "todo", nil, nil, 7).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+	mock.ExpectExec("INSERT INTO task_events").
+		WithArgs(7, 1, TaskEventUpdated, "project_id", nil, "3", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	task := &Task{ID: 7, UserID: 1, Title: "Task", Status: StatusPending, Priority: PriorityNone,
+		ProjectID: &projectID, CreatedAt: time.Now()}
+	assert.NoError(t, task.UpdateTask(db))
+	assert.Equal(t, 0, task.Position)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateTaskForeignProject(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	expectDefaultWorkflow(mock)
+	mock.ExpectQuery("SELECT EXISTS").
+		WithArgs(9, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectRollback()
+
+	projectID := 9
+	task := &Task{UserID: 1, Title: "Task", Status: StatusPending, Priority: PriorityNone, ProjectID: &projectID}
+	assert.EqualError(t, task.CreateTask(db), "project not found")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,295 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/maxzhirnov/go-task-manager/pkg/database"
+)
+
+// Tasks are ordered within their list (the subtasks of a parent, the
+// top-level tasks of a project or the inbox) by a rank: a string of base-36
+// digits read as the fraction after a decimal point. A key can always be
+// generated between any two distinct ranks, so placing a task never requires
+// renumbering its siblings. Ranks never end with the digit 0, which keeps
+// room before every rank.
+//
+// Repeated inserts at the same spot make ranks longer; RebalanceTaskRanks
+// rewrites the ranks of such lists with short, evenly spaced keys.
+
+// rankDigits are the digits of a rank in ascending order. They sort the
+// same way byte by byte, which is how the database compares ranks.
+const rankDigits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// taskPosition computes the zero-based position of a task among the active
+// tasks of its list from the ranks. It must be used in queries on the tasks
+// table. The position is counted per row, so queries returning several
+// tasks read it from rankedTasks instead.
+const taskPosition = `(SELECT COUNT(*) FROM tasks s
+               WHERE s.user_id = tasks.user_id
+               AND (s.project_id = tasks.project_id OR (s.project_id IS NULL AND tasks.project_id IS NULL))
+               AND (s.parent_id = tasks.parent_id OR (s.parent_id IS NULL AND tasks.parent_id IS NULL))
+               AND s.status != 'deleted'
+               AND (s.rank < tasks.rank OR (s.rank = tasks.rank AND s.id < tasks.id)))`
+
+// rankedTasks returns a derived table named tasks that holds the tasks of
+// the user identified by the SQL expression userID together with their
+// position, counted in a single pass over each list. Deleted tasks get the
+// position they would have among the active tasks.
+//
+// Example:
+//
+//	query := `SELECT ` + listedTaskColumns + ` FROM ` + rankedTasks("$1") + ` WHERE status != 'deleted'`
+func rankedTasks(userID string) string {
+	return `(SELECT tasks.*,
+                     COUNT(*) FILTER (WHERE status != 'deleted') OVER (
+                         PARTITION BY project_id, parent_id
+                         ORDER BY rank, id
+                         ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING
+                     ) AS position
+              FROM tasks
+              WHERE user_id = ` + userID + `) tasks`
+}
+
+// rankBetween returns a rank that sorts after a and before b. An empty a
+// stands for the start of the list, an empty b for its end. a must sort
+// before b.
+//
+// Example:
+//
+//	rankBetween("", "")   // "i"
+//	rankBetween("i", "")  // "j"
+//	rankBetween("a", "b") // "ai"
+func rankBetween(a, b string) string {
+	// Keep the common prefix, reading missing digits of a as 0
+	if b != "" {
+		n := 0
+		for n < len(b) && rankDigitAt(a, n) == b[n] {
+			n++
+		}
+		if n > 0 {
+			return b[:n] + rankBetween(rankSuffix(a, n), b[n:])
+		}
+	}
+
+	low := 0
+	if a != "" {
+		low = strings.IndexByte(rankDigits, a[0])
+	}
+	high := len(rankDigits)
+	if b != "" {
+		high = strings.IndexByte(rankDigits, b[0])
+	}
+
+	if high-low > 1 {
+		// Step by one digit at the open ends of the list, so that
+		// repeated inserts at the top or bottom grow ranks slowly
+		switch {
+		case a == "" && b != "":
+			return string(rankDigits[high-1])
+		case a != "" && b == "":
+			return string(rankDigits[low+1])
+		default:
+			return string(rankDigits[(low+high)/2])
+		}
+	}
+
+	// The first digits are adjacent: a shorter b is already in between,
+	// otherwise extend a
+	if len(b) > 1 {
+		return b[:1]
+	}
+	return string(rankDigits[low]) + rankBetween(rankSuffix(a, 1), "")
+}
+
+// rankDigitAt returns the digit of rank at index i, padding with zeros.
+func rankDigitAt(rank string, i int) byte {
+	if i < len(rank) {
+		return rank[i]
+	}
+	return rankDigits[0]
+}
+
+// rankSuffix returns rank without its first n digits.
+func rankSuffix(rank string, n int) string {
+	if n >= len(rank) {
+		return ""
+	}
+	return rank[n:]
+}
+
+// evenRanks returns n ascending ranks, evenly spread with the shortest
+// length that leaves room for a full digit of further inserts in every gap.
+func evenRanks(n int) []string {
+	base := len(rankDigits)
+	width, capacity := 1, base
+	for capacity < base*(n+1) {
+		width++
+		capacity *= base
+	}
+	step := capacity / (n + 1)
+
+	ranks := make([]string, n)
+	for i := range ranks {
+		value := (i + 1) * step
+		digits := make([]byte, width)
+		for d := width - 1; d >= 0; d-- {
+			digits[d] = rankDigits[value%base]
+			value /= base
+		}
+		ranks[i] = strings.TrimRight(string(digits), rankDigits[:1])
+	}
+	return ranks
+}
+
+// firstRank returns a rank that places a task at the top of a list.
+func firstRank(q querier, userID int, projectID, parentID *int) (string, error) {
+	// Compare with = where possible so that the rank index is used
+	conditions := []string{"user_id = $1"}
+	args := []interface{}{userID}
+	for _, c := range []struct {
+		column string
+		value  *int
+	}{
+		{"project_id", projectID},
+		{"parent_id", parentID},
+	} {
+		if c.value == nil {
+			conditions = append(conditions, c.column+" IS NULL")
+			continue
+		}
+		args = append(args, *c.value)
+		conditions = append(conditions, fmt.Sprintf("%s = $%d", c.column, len(args)))
+	}
+
+	var first sql.NullString
+	err := q.QueryRow(`
+        SELECT MIN(rank)
+        FROM tasks
+        WHERE `+strings.Join(conditions, " AND "), args...).Scan(&first)
+	if err != nil {
+		return "", fmt.Errorf("failed to get task rank: %w", err)
+	}
+	return rankBetween("", first.String), nil
+}
+
+// RebalanceTaskRanks rewrites the ranks of every list that contains a rank
+// longer than maxLength with evenly spaced short ranks. The order of the
+// lists does not change, and neither do the versions and modification times
+// of their tasks. Each list is rebalanced in its own transaction.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - maxLength: Rank length above which a list is rebalanced
+//
+// Returns:
+//   - int64: Number of rebalanced lists
+//   - error: Database error; lists rebalanced before it are kept
+//
+// Example Usage:
+//
+//	lists, err := RebalanceTaskRanks(db, 24)
+//	if err != nil {
+//	    return fmt.Errorf("failed to rebalance ranks: %w", err)
+//	}
+func RebalanceTaskRanks(db database.DB, maxLength int) (int64, error) {
+	rows, err := db.Query(`
+        SELECT user_id, project_id, parent_id
+        FROM tasks
+        GROUP BY user_id, project_id, parent_id
+        HAVING MAX(LENGTH(rank)) > $1`, maxLength)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find lists to rebalance: %w", err)
+	}
+	type taskList struct {
+		userID              int
+		projectID, parentID *int
+	}
+	var lists []taskList
+	for rows.Next() {
+		var l taskList
+		if err := rows.Scan(&l.userID, &l.projectID, &l.parentID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		lists = append(lists, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var rebalanced int64
+	for _, l := range lists {
+		if err := rebalanceList(db, l.userID, l.projectID, l.parentID); err != nil {
+			return rebalanced, err
+		}
+		rebalanced++
+	}
+	return rebalanced, nil
+}
+
+// rebalanceList assigns evenly spaced ranks to all tasks of a list,
+// including deleted ones, keeping their order.
+func rebalanceList(db database.DB, userID int, projectID, parentID *int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+        SELECT id
+        FROM tasks
+        WHERE user_id = $1
+        AND project_id IS NOT DISTINCT FROM $2
+        AND parent_id IS NOT DISTINCT FROM $3
+        ORDER BY rank, id
+        FOR UPDATE`, userID, projectID, parentID)
+	if err != nil {
+		return fmt.Errorf("failed to lock task list: %w", err)
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if err := writeRanks(tx, ids, evenRanks(len(ids)), nil); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// writeRanks stores ranks[i] as the rank of ids[i] in one statement. When
+// updatedAt is set it becomes the modification time of the tasks.
+func writeRanks(q querier, ids []int, ranks []string, updatedAt *time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := q.Exec(`
+        UPDATE tasks
+        SET rank = v.rank, updated_at = COALESCE($3, tasks.updated_at)
+        FROM unnest($1::int[], $2::text[]) AS v(id, rank)
+        WHERE tasks.id = v.id`,
+		pq.Array(ids), pq.Array(ranks), updatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update task ranks: %w", err)
+	}
+	return nil
+}
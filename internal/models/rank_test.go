@@ -0,0 +1,144 @@
+package models
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRankBetween(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		expected string
+	}{
+		{name: "Empty list", a: "", b: "", expected: "i"},
+		{name: "End of list", a: "i", b: "", expected: "j"},
+		{name: "Start of list", a: "", b: "i", expected: "h"},
+		{name: "Middle digit", a: "a", b: "k", expected: "f"},
+		{name: "Adjacent digits", a: "a", b: "b", expected: "ai"},
+		{name: "Shorter upper bound", a: "a", b: "b5", expected: "b"},
+		{name: "Common prefix", a: "a1", b: "a3", expected: "a2"},
+		{name: "Prefix of upper bound", a: "a", b: "a1", expected: "a0i"},
+		{name: "Before the smallest digit", a: "", b: "1", expected: "0i"},
+		{name: "After the largest digit", a: "z", b: "", expected: "zi"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rank := rankBetween(tt.a, tt.b)
+			assert.Equal(t, tt.expected, rank)
+			assert.Greater(t, rank, tt.a)
+			if tt.b != "" {
+				assert.Less(t, rank, tt.b)
+			}
+		})
+	}
+}
+
+func TestRankBetweenRepeatedInserts(t *testing.T) {
+	// Always inserting right after the first task splits the same gap
+	first, next := "i", "j"
+	for i := 0; i < 200; i++ {
+		rank := rankBetween(first, next)
+		assert.Greater(t, rank, first)
+		assert.Less(t, rank, next)
+		assert.NotEqual(t, byte('0'), rank[len(rank)-1])
+		next = rank
+	}
+
+	// Each digit takes a full pass through the alphabet before the rank grows
+	assert.Equal(t, "i00000000000h", next)
+}
+
+func TestEvenRanks(t *testing.T) {
+	assert.Equal(t, []string{"9", "i", "r"}, evenRanks(3))
+	assert.Empty(t, evenRanks(0))
+
+	ranks := evenRanks(1000)
+	assert.Len(t, ranks, 1000)
+	assert.True(t, sort.StringsAreSorted(ranks))
+	for i, rank := range ranks {
+		assert.LessOrEqual(t, len(rank), 3)
+		assert.NotEqual(t, byte('0'), rank[len(rank)-1])
+		if i > 0 {
+			assert.NotEqual(t, ranks[i-1], rank)
+		}
+	}
+}
+
+func TestRankMoves(t *testing.T) {
+	ranks := map[int]string{1: "a", 2: "b", 3: "c", 4: "d"}
+
+	tests := []struct {
+		name     string
+		order    []int
+		moved    []int
+		expected map[int]string
+	}{
+		{
+			name:     "Moved task between neighbours",
+			order:    []int{2, 1, 3, 4},
+			moved:    []int{1},
+			expected: map[int]string{1: "bi", 2: "b", 3: "c", 4: "d"},
+		},
+		{
+			name:     "Moved task to the end",
+			order:    []int{2, 3, 4, 1},
+			moved:    []int{1},
+			expected: map[int]string{1: "e", 2: "b", 3: "c", 4: "d"},
+		},
+		{
+			name:     "Moved task that still fits keeps its rank",
+			order:    []int{1, 2, 3, 4},
+			moved:    []int{2},
+			expected: ranks,
+		},
+		{
+			name:     "Consecutive moved tasks",
+			order:    []int{4, 3, 1, 2},
+			moved:    []int{4, 3},
+			expected: map[int]string{1: "a", 2: "b", 3: "9i", 4: "9"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, rankMoves(tt.order, ranks, tt.moved))
+		})
+	}
+}
+
+func TestRankMovesRenumbersEqualRanks(t *testing.T) {
+	ranks := map[int]string{1: "a", 2: "a", 3: "b"}
+
+	result := rankMoves([]int{1, 3, 2}, ranks, []int{3})
+	assert.Equal(t, map[int]string{1: "9", 3: "i", 2: "r"}, result)
+}
+
+func TestRebalanceTaskRanks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT user_id, project_id, parent_id FROM tasks GROUP BY user_id, project_id, parent_id HAVING MAX\\(LENGTH\\(rank\\)\\) > \\$1").
+		WithArgs(24).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "project_id", "parent_id"}).
+			AddRow(1, 3, nil))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM tasks WHERE user_id = \\$1 AND project_id IS NOT DISTINCT FROM \\$2 AND parent_id IS NOT DISTINCT FROM \\$3 ORDER BY rank, id FOR UPDATE").
+		WithArgs(1, sqlmock.AnyArg(), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5).AddRow(2).AddRow(9))
+	// The order is kept and modification times are left alone
+	mock.ExpectExec("UPDATE tasks SET rank = v.rank, updated_at = COALESCE\\(\\$3, tasks.updated_at\\)").
+		WithArgs("{5,2,9}", `{"9","i","r"}`, nil).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	lists, err := RebalanceTaskRanks(db, 24)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), lists)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,216 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Recurrence frequency constants define how often a recurring task repeats
+const (
+	// FreqDaily repeats a task every INTERVAL days
+	FreqDaily = "DAILY"
+
+	// FreqWeekly repeats a task every INTERVAL weeks, optionally on the
+	// weekdays listed in BYDAY
+	FreqWeekly = "WEEKLY"
+
+	// FreqMonthly repeats a task every INTERVAL months, optionally on the
+	// day of month given in BYMONTHDAY
+	FreqMonthly = "MONTHLY"
+)
+
+// weekdayCodes lists the RRULE weekday codes indexed by time.Weekday.
+var weekdayCodes = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// RecurrenceRule describes when the next occurrence of a recurring task is due.
+//
+// Rules use a subset of the iCalendar RRULE syntax, with parts separated by
+// semicolons:
+//
+//	FREQ=DAILY;INTERVAL=2             every other day
+//	FREQ=WEEKLY;BYDAY=MO,TH           every Monday and Thursday
+//	FREQ=MONTHLY;BYMONTHDAY=15        on the 15th of every month
+//	FREQ=DAILY;INTERVAL=3;FROM=COMPLETION
+//	                                  three days after the task was completed
+//
+// By default occurrences follow the schedule anchored at the task's due date.
+// FROM=COMPLETION anchors the next occurrence at the moment of completion.
+type RecurrenceRule struct {
+	// Freq is one of FreqDaily, FreqWeekly or FreqMonthly
+	Freq string
+
+	// Interval is the number of periods between occurrences (at least 1)
+	Interval int
+
+	// ByDay lists the weekdays of a weekly rule
+	ByDay []time.Weekday
+
+	// ByMonthDay is the day of month of a monthly rule; 0 keeps the day
+	// of the anchor. Shorter months use their last day instead.
+	ByMonthDay int
+
+	// FromCompletion anchors the next occurrence at the completion time
+	FromCompletion bool
+}
+
+// ParseRecurrenceRule parses a rule in the syntax described on RecurrenceRule.
+//
+// Parameters:
+//   - rule: The rule text, e.g. "FREQ=WEEKLY;BYDAY=MO,WE"
+//
+// Returns:
+//   - *RecurrenceRule: The parsed rule
+//   - error: "invalid recurrence rule: ..." describing the first problem found
+//
+// Example Usage:
+//
+//	rule, err := ParseRecurrenceRule("FREQ=MONTHLY;BYMONTHDAY=1")
+//	if err != nil {
+//	    return fmt.Errorf("validation failed: %w", err)
+//	}
+//	next := rule.Next(*task.DueAt)
+func ParseRecurrenceRule(rule string) (*RecurrenceRule, error) {
+	r := &RecurrenceRule{Interval: 1}
+
+	for _, part := range strings.Split(strings.ToUpper(strings.TrimSpace(rule)), ";") {
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid recurrence rule: malformed part %q", part)
+		}
+
+		switch key {
+		case "FREQ":
+			if value != FreqDaily && value != FreqWeekly && value != FreqMonthly {
+				return nil, fmt.Errorf("invalid recurrence rule: unsupported frequency %s", value)
+			}
+			r.Freq = value
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval < 1 {
+				return nil, fmt.Errorf("invalid recurrence rule: invalid interval %s", value)
+			}
+			r.Interval = interval
+		case "BYDAY":
+			for _, code := range strings.Split(value, ",") {
+				day := weekdayIndex(code)
+				if day < 0 {
+					return nil, fmt.Errorf("invalid recurrence rule: invalid weekday %s", code)
+				}
+				r.ByDay = append(r.ByDay, time.Weekday(day))
+			}
+		case "BYMONTHDAY":
+			day, err := strconv.Atoi(value)
+			if err != nil || day < 1 || day > 31 {
+				return nil, fmt.Errorf("invalid recurrence rule: invalid month day %s", value)
+			}
+			r.ByMonthDay = day
+		case "FROM":
+			if value != "SCHEDULE" && value != "COMPLETION" {
+				return nil, fmt.Errorf("invalid recurrence rule: invalid anchor %s", value)
+			}
+			r.FromCompletion = value == "COMPLETION"
+		default:
+			return nil, fmt.Errorf("invalid recurrence rule: unsupported part %s", key)
+		}
+	}
+
+	if r.Freq == "" {
+		return nil, fmt.Errorf("invalid recurrence rule: FREQ is required")
+	}
+	if len(r.ByDay) > 0 && r.Freq != FreqWeekly {
+		return nil, fmt.Errorf("invalid recurrence rule: BYDAY requires FREQ=WEEKLY")
+	}
+	if r.ByMonthDay != 0 && r.Freq != FreqMonthly {
+		return nil, fmt.Errorf("invalid recurrence rule: BYMONTHDAY requires FREQ=MONTHLY")
+	}
+
+	return r, nil
+}
+
+// String returns the canonical text form of the rule.
+func (r *RecurrenceRule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		// List weekdays Monday first regardless of input order
+		var codes []string
+		for i := 1; i <= 7; i++ {
+			if day := time.Weekday(i % 7); containsWeekday(r.ByDay, day) {
+				codes = append(codes, weekdayCodes[day])
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+	if r.ByMonthDay != 0 {
+		parts = append(parts, "BYMONTHDAY="+strconv.Itoa(r.ByMonthDay))
+	}
+	if r.FromCompletion {
+		parts = append(parts, "FROM=COMPLETION")
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next returns the first occurrence strictly after anchor. The time of day
+// of anchor is preserved.
+func (r *RecurrenceRule) Next(anchor time.Time) time.Time {
+	switch r.Freq {
+	case FreqWeekly:
+		if len(r.ByDay) == 0 {
+			return anchor.AddDate(0, 0, 7*r.Interval)
+		}
+
+		// Weeks start on Monday; only every Interval-th week is eligible
+		weekStart := anchor.AddDate(0, 0, -((int(anchor.Weekday()) + 6) % 7))
+		for days := 1; ; days++ {
+			candidate := anchor.AddDate(0, 0, days)
+			week := int(candidate.Sub(weekStart).Hours()/24) / 7
+			if week%r.Interval == 0 && containsWeekday(r.ByDay, candidate.Weekday()) {
+				return candidate
+			}
+		}
+	case FreqMonthly:
+		day := r.ByMonthDay
+		if day == 0 {
+			day = anchor.Day()
+		}
+		for months := 0; ; months += r.Interval {
+			first := time.Date(anchor.Year(), anchor.Month()+time.Month(months), 1,
+				anchor.Hour(), anchor.Minute(), anchor.Second(), 0, anchor.Location())
+			lastDay := first.AddDate(0, 1, -1).Day()
+			candidate := first.AddDate(0, 0, min(day, lastDay)-1)
+			if candidate.After(anchor) {
+				return candidate
+			}
+		}
+	default:
+		return anchor.AddDate(0, 0, r.Interval)
+	}
+}
+
+// containsWeekday reports whether days includes day.
+func containsWeekday(days []time.Weekday, day time.Weekday) bool {
+	for _, d := range days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// weekdayIndex returns the time.Weekday value of an RRULE weekday code,
+// or -1 for unknown codes.
+func weekdayIndex(code string) int {
+	for i, c := range weekdayCodes {
+		if c == code {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRecurrenceRule(t *testing.T) {
+	tests := []struct {
+		name          string
+		rule          string
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "Daily with interval",
+			rule:     "FREQ=DAILY;INTERVAL=2",
+			expected: "FREQ=DAILY;INTERVAL=2",
+		},
+		{
+			name:     "Weekly weekdays are normalized",
+			rule:     "freq=weekly;byday=fr,mo",
+			expected: "FREQ=WEEKLY;BYDAY=MO,FR",
+		},
+		{
+			name:     "Monthly by day",
+			rule:     "FREQ=MONTHLY;BYMONTHDAY=15",
+			expected: "FREQ=MONTHLY;BYMONTHDAY=15",
+		},
+		{
+			name:     "After completion",
+			rule:     "FREQ=DAILY;INTERVAL=3;FROM=COMPLETION",
+			expected: "FREQ=DAILY;INTERVAL=3;FROM=COMPLETION",
+		},
+		{
+			name:          "Missing frequency",
+			rule:          "INTERVAL=2",
+			expectedError: "invalid recurrence rule: FREQ is required",
+		},
+		{
+			name:          "Unsupported frequency",
+			rule:          "FREQ=HOURLY",
+			expectedError: "invalid recurrence rule: unsupported frequency HOURLY",
+		},
+		{
+			name:          "Invalid interval",
+			rule:          "FREQ=DAILY;INTERVAL=0",
+			expectedError: "invalid recurrence rule: invalid interval 0",
+		},
+		{
+			name:          "Weekday on daily rule",
+			rule:          "FREQ=DAILY;BYDAY=MO",
+			expectedError: "invalid recurrence rule: BYDAY requires FREQ=WEEKLY",
+		},
+		{
+			name:          "Invalid weekday",
+			rule:          "FREQ=WEEKLY;BYDAY=XX",
+			expectedError: "invalid recurrence rule: invalid weekday XX",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRecurrenceRule(tt.rule)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, rule.String())
+		})
+	}
+}
+
+func TestRecurrenceRuleNext(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 9, 30, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		rule     string
+		anchor   time.Time
+		expected time.Time
+	}{
+		{"Every other day", "FREQ=DAILY;INTERVAL=2", date(2024, 1, 1), date(2024, 1, 3)},
+		{"Weekly without weekdays", "FREQ=WEEKLY", date(2024, 1, 1), date(2024, 1, 8)},
+		{"Next weekday in same week", "FREQ=WEEKLY;BYDAY=MO,TH", date(2024, 1, 1), date(2024, 1, 4)},
+		{"Next weekday in next week", "FREQ=WEEKLY;BYDAY=MO,TH", date(2024, 1, 4), date(2024, 1, 8)},
+		{"Every second Monday", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO", date(2024, 1, 1), date(2024, 1, 15)},
+		{"Monthly keeps day", "FREQ=MONTHLY", date(2024, 1, 10), date(2024, 2, 10)},
+		{"Monthly later in same month", "FREQ=MONTHLY;BYMONTHDAY=15", date(2024, 1, 10), date(2024, 1, 15)},
+		{"Monthly clamps to month end", "FREQ=MONTHLY;BYMONTHDAY=31", date(2024, 1, 31), date(2024, 2, 29)},
+		{"Quarterly", "FREQ=MONTHLY;INTERVAL=3;BYMONTHDAY=1", date(2024, 1, 1), date(2024, 4, 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRecurrenceRule(tt.rule)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, rule.Next(tt.anchor))
+		})
+	}
+}
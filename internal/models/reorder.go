@@ -0,0 +1,388 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/maxzhirnov/go-task-manager/pkg/database"
+)
+
+// TaskMove describes one step of a reorder: the task is taken out of its
+// list and put back either at Position or next to another task of the same
+// list. Exactly one of Position, Before and After must be set.
+type TaskMove struct {
+	// TaskID is the task being moved
+	TaskID int `json:"task_id"`
+
+	// Position is the zero-based index to move the task to. Indexes past
+	// the end of the list move the task to the end.
+	Position *int `json:"position,omitempty"`
+
+	// Before places the task directly above the given task
+	Before *int `json:"before,omitempty"`
+
+	// After places the task directly below the given task
+	After *int `json:"after,omitempty"`
+}
+
+// Validate checks that the move has exactly one valid destination.
+//
+// Returns:
+//   - nil: If the move is valid
+//   - error: "invalid move: ..." describing the problem
+func (m TaskMove) Validate() error {
+	destinations := 0
+	for _, anchor := range []*int{m.Position, m.Before, m.After} {
+		if anchor != nil {
+			destinations++
+		}
+	}
+	if destinations != 1 {
+		return fmt.Errorf("invalid move: task %d needs exactly one of position, before or after", m.TaskID)
+	}
+	if m.Position != nil && *m.Position < 0 {
+		return fmt.Errorf("invalid move: task %d has a negative position", m.TaskID)
+	}
+	if (m.Before != nil && *m.Before == m.TaskID) || (m.After != nil && *m.After == m.TaskID) {
+		return fmt.Errorf("invalid move: task %d cannot be placed next to itself", m.TaskID)
+	}
+	return nil
+}
+
+// ReorderTasks applies a sequence of moves to one list of tasks in a single
+// transaction: the subtasks of a parent, the top-level tasks of a project or
+// the user's inbox.
+//
+// The process:
+//  1. Locks every referenced task, verifying that it belongs to the user
+//  2. Locks the whole list and applies the moves in order in memory
+//  3. Ranks each moved task between its new neighbours in one statement;
+//     the other tasks of the list are not modified
+//  4. Records a "moved" event for each moved task whose position changed
+//
+// Either all moves are applied or none.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: ID of the task owner
+//   - moves: Steps to apply, in order
+//
+// Returns:
+//   - []Task: The reordered list, ordered by position
+//   - error: "invalid move: ...", "task not found" when a task does not exist
+//     or belongs to another user, "tasks are not in the same list", or
+//     database errors
+//
+// Example Usage:
+//
+//	// Put task 7 directly above task 3
+//	before := 3
+//	tasks, err := ReorderTasks(db, userID, []TaskMove{{TaskID: 7, Before: &before}})
+//	if err != nil {
+//	    return fmt.Errorf("failed to reorder tasks: %w", err)
+//	}
+func ReorderTasks(db database.DB, userID int, moves []TaskMove) ([]Task, error) {
+	if len(moves) == 0 {
+		return nil, fmt.Errorf("invalid move: no moves given")
+	}
+	var ids []int
+	for _, m := range moves {
+		if err := m.Validate(); err != nil {
+			return nil, err
+		}
+		ids = append(ids, m.TaskID)
+		if m.Before != nil {
+			ids = append(ids, *m.Before)
+		}
+		if m.After != nil {
+			ids = append(ids, *m.After)
+		}
+	}
+
+	return reorderList(db, userID, uniqueInts(ids), movedIDs(moves), func(order []int) []int {
+		return applyMoves(order, moves)
+	})
+}
+
+// SetTaskPositions moves tasks of one list to the given zero-based
+// positions in a single transaction. The listed tasks are taken out of the
+// list and inserted at their positions in ascending order, so each ends up
+// at its requested position (or at the end of the list if the position is
+// past it); the remaining tasks keep their relative order.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: ID of the task owner
+//   - positions: Map of task IDs to their new positions
+//
+// Returns:
+//   - []Task: The reordered list, ordered by position
+//   - error: Same errors as ReorderTasks
+//
+// Example Usage:
+//
+//	tasks, err := SetTaskPositions(db, userID, map[int]int{7: 0, 3: 1})
+func SetTaskPositions(db database.DB, userID int, positions map[int]int) ([]Task, error) {
+	if len(positions) == 0 {
+		return nil, fmt.Errorf("invalid move: no moves given")
+	}
+	ids := make([]int, 0, len(positions))
+	for id, position := range positions {
+		if position < 0 {
+			return nil, fmt.Errorf("invalid move: task %d has a negative position", id)
+		}
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	return reorderList(db, userID, ids, ids, func(order []int) []int {
+		return applyPositions(order, positions)
+	})
+}
+
+// reorderList locks the tasks ids, which must all belong to the same list
+// of the user, rearranges that list with rearrange and stores the new order
+// by ranking the moved tasks between their neighbours. A "moved" event is
+// recorded for each of the moved tasks whose position changed.
+func reorderList(db database.DB, userID int, ids, moved []int, rearrange func(order []int) []int) ([]Task, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the referenced tasks and find the list they belong to
+	rows, err := tx.Query(`
+        SELECT id, project_id, parent_id
+        FROM tasks
+        WHERE user_id = $1 AND id = ANY($2) AND status != 'deleted'
+        FOR UPDATE`, userID, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock tasks: %w", err)
+	}
+	found := 0
+	var projectID, parentID *int
+	for rows.Next() {
+		var id int
+		var taskProjectID, taskParentID *int
+		if err := rows.Scan(&id, &taskProjectID, &taskParentID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if found > 0 && (!sameProject(projectID, taskProjectID) || !sameProject(parentID, taskParentID)) {
+			rows.Close()
+			return nil, fmt.Errorf("tasks are not in the same list")
+		}
+		projectID, parentID = taskProjectID, taskParentID
+		found++
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if found != len(ids) {
+		return nil, fmt.Errorf("task not found")
+	}
+
+	// Lock the list in its current order
+	rows, err = tx.Query(`
+        SELECT id, rank
+        FROM tasks
+        WHERE user_id = $1
+        AND project_id IS NOT DISTINCT FROM $2
+        AND parent_id IS NOT DISTINCT FROM $3
+        AND status != 'deleted'
+        ORDER BY rank, id
+        FOR UPDATE`, userID, projectID, parentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock task list: %w", err)
+	}
+	var order []int
+	oldRanks := make(map[int]string)
+	oldPositions := make(map[int]int)
+	for rows.Next() {
+		var id int
+		var rank string
+		if err := rows.Scan(&id, &rank); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		oldPositions[id] = len(order)
+		oldRanks[id] = rank
+		order = append(order, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	order = rearrange(order)
+	newPositions := make(map[int]int, len(order))
+	for position, id := range order {
+		newPositions[id] = position
+	}
+	newRanks := rankMoves(order, oldRanks, moved)
+
+	// Store the ranks that changed
+	var changedIDs []int
+	var changedRanks []string
+	for _, id := range order {
+		if oldRanks[id] != newRanks[id] {
+			changedIDs = append(changedIDs, id)
+			changedRanks = append(changedRanks, newRanks[id])
+		}
+	}
+	now := time.Now()
+	if err := writeRanks(tx, changedIDs, changedRanks, &now); err != nil {
+		return nil, err
+	}
+
+	// Record the moves in the history of the moved tasks; neighbours
+	// shifted by a move are not recorded
+	var events []TaskEvent
+	for _, id := range moved {
+		if oldPositions[id] == newPositions[id] {
+			continue
+		}
+		task := &Task{ID: id, UserID: userID, UpdatedAt: now}
+		events = append(events, newTaskEvent(task, TaskEventMoved, "position", oldPositions[id], newPositions[id]))
+	}
+	if err := recordTaskEvents(tx, events); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return GetTasks(db, userID, TaskFilter{ProjectID: projectID, Inbox: projectID == nil, ParentID: parentID})
+}
+
+// rankMoves returns the ranks of the tasks of order, the list rearranged by
+// reorderList. The tasks that were not moved keep their ranks and each moved
+// task keeps its rank if it still fits between its neighbours, otherwise it
+// gets a new rank between them. When the ranks of two neighbours that were
+// not moved leave no room, the whole list is ranked anew.
+func rankMoves(order []int, ranks map[int]string, moved []int) map[int]string {
+	isMoved := make(map[int]bool, len(moved))
+	for _, id := range moved {
+		isMoved[id] = true
+	}
+
+	result := make(map[int]string, len(order))
+	previous := ""
+	for i, id := range order {
+		if !isMoved[id] {
+			result[id] = ranks[id]
+			previous = ranks[id]
+			continue
+		}
+
+		// Find the rank of the next task that stays in place
+		next := ""
+		for _, other := range order[i+1:] {
+			if !isMoved[other] {
+				next = ranks[other]
+				break
+			}
+		}
+		if next != "" && previous >= next {
+			even := evenRanks(len(order))
+			for j, other := range order {
+				result[other] = even[j]
+			}
+			return result
+		}
+
+		rank := ranks[id]
+		if rank <= previous || (next != "" && rank >= next) {
+			rank = rankBetween(previous, next)
+		}
+		result[id] = rank
+		previous = rank
+	}
+
+	return result
+}
+
+// movedIDs returns the distinct IDs of the moved tasks in order of their
+// first move
+func movedIDs(moves []TaskMove) []int {
+	ids := make([]int, len(moves))
+	for i, m := range moves {
+		ids[i] = m.TaskID
+	}
+	return uniqueInts(ids)
+}
+
+// applyPositions returns the task IDs of order with the tasks of positions
+// moved to their positions, which are capped at the end of the list.
+func applyPositions(order []int, positions map[int]int) []int {
+	var result, moved []int
+	for _, id := range order {
+		if _, ok := positions[id]; ok {
+			moved = append(moved, id)
+		} else {
+			result = append(result, id)
+		}
+	}
+	sort.Slice(moved, func(i, j int) bool {
+		if positions[moved[i]] != positions[moved[j]] {
+			return positions[moved[i]] < positions[moved[j]]
+		}
+		return moved[i] < moved[j]
+	})
+
+	// Inserting in ascending order never shifts tasks placed before
+	for _, id := range moved {
+		target := positions[id]
+		if target > len(result) {
+			target = len(result)
+		}
+		result = append(result, 0)
+		copy(result[target+1:], result[target:])
+		result[target] = id
+	}
+
+	return result
+}
+
+// applyMoves returns the task IDs of order rearranged by the moves, which
+// only reference IDs contained in order.
+func applyMoves(order []int, moves []TaskMove) []int {
+	order = append([]int(nil), order...)
+	indexOf := func(id int) int {
+		for i, other := range order {
+			if other == id {
+				return i
+			}
+		}
+		return -1
+	}
+
+	for _, m := range moves {
+		i := indexOf(m.TaskID)
+		order = append(order[:i], order[i+1:]...)
+
+		var target int
+		switch {
+		case m.Before != nil:
+			target = indexOf(*m.Before)
+		case m.After != nil:
+			target = indexOf(*m.After) + 1
+		default:
+			target = *m.Position
+			if target > len(order) {
+				target = len(order)
+			}
+		}
+
+		order = append(order, 0)
+		copy(order[target+1:], order[target:])
+		order[target] = m.TaskID
+	}
+
+	return order
+}
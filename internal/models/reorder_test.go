@@ -0,0 +1,181 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyMoves(t *testing.T) {
+	position := func(p int) *int { return &p }
+
+	tests := []struct {
+		name     string
+		moves    []TaskMove
+		expected []int
+	}{
+		{
+			name:     "Move before",
+			moves:    []TaskMove{{TaskID: 4, Before: position(2)}},
+			expected: []int{1, 4, 2, 3},
+		},
+		{
+			name:     "Move after",
+			moves:    []TaskMove{{TaskID: 1, After: position(3)}},
+			expected: []int{2, 3, 1, 4},
+		},
+		{
+			name:     "Move past the end",
+			moves:    []TaskMove{{TaskID: 2, Position: position(10)}},
+			expected: []int{1, 3, 4, 2},
+		},
+		{
+			name:     "Moves apply in order",
+			moves:    []TaskMove{{TaskID: 4, Position: position(0)}, {TaskID: 3, After: position(4)}},
+			expected: []int{4, 3, 1, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, applyMoves([]int{1, 2, 3, 4}, tt.moves))
+		})
+	}
+}
+
+func TestApplyPositions(t *testing.T) {
+	tests := []struct {
+		name      string
+		positions map[int]int
+		expected  []int
+	}{
+		{
+			name:      "Every task at its position",
+			positions: map[int]int{2: 3, 4: 0, 1: 2},
+			expected:  []int{4, 3, 1, 2},
+		},
+		{
+			name:      "Later task moves to the front",
+			positions: map[int]int{1: 1, 4: 0},
+			expected:  []int{4, 1, 2, 3},
+		},
+		{
+			name:      "Position past the end",
+			positions: map[int]int{1: 9},
+			expected:  []int{2, 3, 4, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, applyPositions([]int{1, 2, 3, 4}, tt.positions))
+		})
+	}
+}
+
+func TestTaskMoveValidate(t *testing.T) {
+	one, self := 1, 5
+	negative := -1
+
+	assert.NoError(t, TaskMove{TaskID: 5, Before: &one}.Validate())
+	assert.EqualError(t, TaskMove{TaskID: 5}.Validate(),
+		"invalid move: task 5 needs exactly one of position, before or after")
+	assert.EqualError(t, TaskMove{TaskID: 5, Before: &one, Position: &one}.Validate(),
+		"invalid move: task 5 needs exactly one of position, before or after")
+	assert.EqualError(t, TaskMove{TaskID: 5, Position: &negative}.Validate(),
+		"invalid move: task 5 has a negative position")
+	assert.EqualError(t, TaskMove{TaskID: 5, After: &self}.Validate(),
+		"invalid move: task 5 cannot be placed next to itself")
+}
+
+func TestReorderTasks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	projectID := 3
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, project_id, parent_id FROM tasks WHERE user_id = \\$1 AND id = ANY\\(\\$2\\) AND status != 'deleted' FOR UPDATE").
+		WithArgs(1, "{7,9}").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "project_id", "parent_id"}).
+			AddRow(7, 3, nil).
+			AddRow(9, 3, nil))
+	mock.ExpectQuery("SELECT id, rank FROM tasks WHERE user_id = \\$1 AND project_id IS NOT DISTINCT FROM \\$2 AND parent_id IS NOT DISTINCT FROM \\$3 AND status != 'deleted' ORDER BY rank, id FOR UPDATE").
+		WithArgs(1, &projectID, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "rank"}).
+			AddRow(9, "i").
+			AddRow(8, "r").
+			AddRow(7, "z"))
+	// Only the moved task gets a new rank, between its new neighbours
+	mock.ExpectExec("UPDATE tasks SET rank = v.rank, updated_at = COALESCE\\(\\$3, tasks.updated_at\\) FROM unnest").
+		WithArgs("{7}", `{"m"}`, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO task_events").
+		WithArgs(7, 1, TaskEventMoved, "position", "2", "1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT (.+) FROM (.+) tasks WHERE user_id = \\$1 AND status != 'deleted' AND parent_id IS NULL AND project_id = \\$2").
+		WithArgs(1, 3).
+		WillReturnRows(sqlmock.NewRows(taskColumnNames).
+			AddRow(newTaskRow(9, "Nine", "", StatusPending, 1, 0)...).
+			AddRow(newTaskRow(7, "Seven", "", StatusPending, 1, 1)...).
+			AddRow(newTaskRow(8, "Eight", "", StatusPending, 1, 2)...))
+
+	after := 9
+	tasks, err := ReorderTasks(db, 1, []TaskMove{{TaskID: 7, After: &after}})
+	assert.NoError(t, err)
+	if assert.Len(t, tasks, 3) {
+		assert.Equal(t, 7, tasks[1].ID)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReorderTasksErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		mockSetup     func(sqlmock.Sqlmock)
+		expectedError string
+	}{
+		{
+			name: "Foreign task",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT id, project_id, parent_id FROM tasks").
+					WithArgs(1, "{7,9}").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "project_id", "parent_id"}).
+						AddRow(7, nil, nil))
+				mock.ExpectRollback()
+			},
+			expectedError: "task not found",
+		},
+		{
+			name: "Different lists",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT id, project_id, parent_id FROM tasks").
+					WithArgs(1, "{7,9}").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "project_id", "parent_id"}).
+						AddRow(7, nil, nil).
+						AddRow(9, 3, nil))
+				mock.ExpectRollback()
+			},
+			expectedError: "tasks are not in the same list",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			tt.mockSetup(mock)
+
+			before := 9
+			_, err = ReorderTasks(db, 1, []TaskMove{{TaskID: 7, Before: &before}})
+			assert.EqualError(t, err, tt.expectedError)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
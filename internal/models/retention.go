@@ -0,0 +1,85 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/maxzhirnov/go-task-manager/pkg/database"
+)
+
+// PurgeDeletedTasks permanently removes soft-deleted tasks of all users
+// that were moved to the trash before the given cutoff.
+//
+// Subtasks, label assignments and other dependent rows are removed by the
+// ON DELETE CASCADE constraints of the schema.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - deletedBefore: Tasks deleted before this moment are purged
+//
+// Returns:
+//   - int64: Number of tasks removed
+//   - error: Database error if the deletion fails
+//
+// Example Usage:
+//
+//	purged, err := PurgeDeletedTasks(db, time.Now().Add(-30*24*time.Hour))
+//	if err != nil {
+//	    return fmt.Errorf("retention failed: %w", err)
+//	}
+func PurgeDeletedTasks(db database.DB, deletedBefore time.Time) (int64, error) {
+	result, err := db.Exec(`
+        DELETE FROM tasks 
+        WHERE status = 'deleted' 
+        AND deleted_at < $1`, deletedBefore)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted tasks: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// PurgeExpiredVerificationTokens removes email verification tokens that
+// expired before the given cutoff.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - expiredBefore: Tokens that expired before this moment are removed
+//
+// Returns:
+//   - int64: Number of tokens removed
+//   - error: Database error if the deletion fails
+func PurgeExpiredVerificationTokens(db database.DB, expiredBefore time.Time) (int64, error) {
+	result, err := db.Exec(`
+        DELETE FROM verification_tokens 
+        WHERE expires_at < $1`, expiredBefore)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge verification tokens: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// ClearExpiredResetTokens resets the password reset token of every user
+// whose token expired before the given cutoff.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - expiredBefore: Tokens that expired before this moment are cleared
+//
+// Returns:
+//   - int64: Number of users whose token was cleared
+//   - error: Database error if the update fails
+func ClearExpiredResetTokens(db database.DB, expiredBefore time.Time) (int64, error) {
+	result, err := db.Exec(`
+        UPDATE users 
+        SET reset_password_token = NULL, 
+            reset_token_expires = NULL 
+        WHERE reset_password_token IS NOT NULL 
+        AND reset_token_expires < $1`, expiredBefore)
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear reset tokens: %w", err)
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurgeDeletedTasks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	cutoff := time.Now().Add(-30 * 24 * time.Hour)
+	mock.ExpectExec("DELETE FROM tasks WHERE status = 'deleted' AND deleted_at < \\$1").
+		WithArgs(cutoff).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+
+	purged, err := PurgeDeletedTasks(db, cutoff)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), purged)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPurgeExpiredTokens(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	cutoff := time.Now()
+	mock.ExpectExec("DELETE FROM verification_tokens WHERE expires_at < \\$1").
+		WithArgs(cutoff).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("UPDATE users SET reset_password_token = NULL, reset_token_expires = NULL WHERE reset_password_token IS NOT NULL AND reset_token_expires < \\$1").
+		WithArgs(cutoff).
+		WillReturnError(errors.New("connection reset"))
+
+	purged, err := PurgeExpiredVerificationTokens(db, cutoff)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+
+	_, err = ClearExpiredResetTokens(db, cutoff)
+	assert.EqualError(t, err, "failed to clear reset tokens: connection reset")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
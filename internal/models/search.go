@@ -0,0 +1,145 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/maxzhirnov/go-task-manager/pkg/database"
+)
+
+// Search result limits
+const (
+	// DefaultSearchLimit is the number of results returned when none is requested
+	DefaultSearchLimit = 20
+
+	// MaxSearchLimit is the largest number of results returned at once
+	MaxSearchLimit = 100
+)
+
+// Highlight markers wrap the matched words in search snippets
+const (
+	HighlightStart = "<mark>"
+	HighlightStop  = "</mark>"
+)
+
+// TaskSearchResult is a task matched by SearchTasks together with its
+// relevance and highlighted excerpts.
+type TaskSearchResult struct {
+	Task
+
+	// Rank is the relevance of the match; higher is better.
+	// Matches in the title weigh more than matches in the description.
+	Rank float64 `json:"rank"`
+
+	// TitleHighlight is the title with matched words wrapped in
+	// HighlightStart and HighlightStop
+	TitleHighlight string `json:"title_highlight"`
+
+	// Snippet is a short excerpt of the description around the matched
+	// words, highlighted like TitleHighlight. It is empty when only the
+	// title matched.
+	Snippet string `json:"snippet"`
+}
+
+// TaskSearchOptions adjusts the behaviour of SearchTasks.
+type TaskSearchOptions struct {
+	// IncludeDeleted also searches tasks in the trash
+	IncludeDeleted bool
+
+	// Limit caps the number of results. Zero means DefaultSearchLimit.
+	Limit int
+}
+
+// SearchTasks finds the tasks of a user whose title or description
+// contains all words of the query.
+//
+// Every word is matched as a prefix, so "rep" finds "report" and
+// "repository". Results are ordered by relevance, then by the time of
+// the last change.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: The ID of the user whose tasks to search
+//   - query: Free text entered by the user
+//   - opts: Trash inclusion and result limit
+//
+// Returns:
+//   - []TaskSearchResult: Matching tasks, most relevant first
+//   - error: "search query is required" if the query contains no words,
+//     "limit must be between 1 and 100" for an invalid limit, or database errors
+//
+// Example Usage:
+//
+//	results, err := SearchTasks(db, userID, "quarterly rep", TaskSearchOptions{})
+//	if err != nil {
+//	    return fmt.Errorf("search failed: %w", err)
+//	}
+func SearchTasks(db database.DB, userID int, query string, opts TaskSearchOptions) ([]TaskSearchResult, error) {
+	tsQuery := prefixTSQuery(query)
+	if tsQuery == "" {
+		return nil, fmt.Errorf("search query is required")
+	}
+
+	limit := opts.Limit
+	if limit == 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit < 0 || limit > MaxSearchLimit {
+		return nil, fmt.Errorf("limit must be between 1 and %d", MaxSearchLimit)
+	}
+
+	statusCondition := "AND status != 'deleted'"
+	if opts.IncludeDeleted {
+		statusCondition = ""
+	}
+
+	highlight := "StartSel=" + HighlightStart + ", StopSel=" + HighlightStop
+	sqlQuery := `SELECT ` + listedTaskColumns + `,
+              ts_rank(search_vector, q) AS search_rank,
+              ts_headline('simple', title, q, '` + highlight + `, HighlightAll=TRUE') AS title_highlight,
+              CASE WHEN to_tsvector('simple', description) @@ q
+                  THEN ts_headline('simple', description, q, '` + highlight + `, MaxWords=20, MinWords=5, MaxFragments=2')
+                  ELSE ''
+              END AS snippet
+              FROM ` + rankedTasks("$1") + `, to_tsquery('simple', $2) q
+              WHERE user_id = $1
+              AND search_vector @@ q ` + statusCondition + `
+              ORDER BY search_rank DESC, updated_at DESC, id DESC
+              LIMIT $3`
+
+	rows, err := db.Query(sqlQuery, userID, tsQuery, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []TaskSearchResult
+	for rows.Next() {
+		var result TaskSearchResult
+		task, err := scanTask(rows, &result.Rank, &result.TitleHighlight, &result.Snippet)
+		if err != nil {
+			return nil, err
+		}
+		result.Task = task
+		results = append(results, result)
+	}
+
+	return results, rows.Err()
+}
+
+// prefixTSQuery turns free text into a tsquery that requires every word
+// as a prefix, e.g. "Fix login-bug" becomes "fix:* & login:* & bug:*".
+// Characters other than letters and digits separate words, so the result
+// never contains tsquery operators supplied by the user.
+func prefixTSQuery(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := make([]string, len(words))
+	for i, word := range words {
+		terms[i] = word + ":*"
+	}
+	return strings.Join(terms, " & ")
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrefixTSQuery(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"report", "report:*"},
+		{"Fix login-bug", "fix:* & login:* & bug:*"},
+		{"  ':* | !evil & ", "evil:*"},
+		{"Отчёт 2024", "отчёт:* & 2024:*"},
+		{"!!!", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.expected, prefixTSQuery(tt.input))
+		})
+	}
+}
+
+func TestSearchTasks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	columns := append(append([]string{}, taskColumnNames...), "search_rank", "title_highlight", "snippet")
+	row := append(newTaskRow(4, "Quarterly report", "Send the report", StatusPending, 1, 0),
+		0.6, "Quarterly <mark>report</mark>", "Send the <mark>report</mark>")
+
+	mock.ExpectQuery("SELECT (.+) FROM (.+) tasks, to_tsquery\\('simple', \\$2\\) q WHERE user_id = \\$1 "+
+		"AND search_vector @@ q AND status != 'deleted' ORDER BY search_rank DESC, updated_at DESC, id DESC LIMIT \\$3").
+		WithArgs(1, "quarterly:* & rep:*", DefaultSearchLimit).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(row...))
+
+	results, err := SearchTasks(db, 1, "quarterly rep", TaskSearchOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, 4, results[0].ID)
+	assert.Equal(t, 0.6, results[0].Rank)
+	assert.Equal(t, "Quarterly <mark>report</mark>", results[0].TitleHighlight)
+	assert.Equal(t, "Send the <mark>report</mark>", results[0].Snippet)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSearchTasksIncludeDeleted(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery("WHERE user_id = \\$1 AND search_vector @@ q ORDER BY search_rank DESC").
+		WithArgs(1, "old:*", 5).
+		WillReturnRows(sqlmock.NewRows(append(append([]string{}, taskColumnNames...), "search_rank", "title_highlight", "snippet")))
+
+	results, err := SearchTasks(db, 1, "old", TaskSearchOptions{IncludeDeleted: true, Limit: 5})
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSearchTasksInvalidInput(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	_, err = SearchTasks(db, 1, " & ", TaskSearchOptions{})
+	assert.EqualError(t, err, "search query is required")
+
+	_, err = SearchTasks(db, 1, "report", TaskSearchOptions{Limit: MaxSearchLimit + 1})
+	assert.EqualError(t, err, "limit must be between 1 and 100")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
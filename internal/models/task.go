@@ -3,14 +3,19 @@
 package models
 
 import (
+	"database/sql"
+	"encoding/json"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/maxzhirnov/go-task-manager/pkg/database"
 )
 
-// Task status constants define all possible states of a task
+// Task status constants define the statuses of the built-in workflow
+// (see DefaultWorkflow) and the internal status of deleted tasks
 const (
 	// StatusPending represents a task that hasn't been started
 	StatusPending = "pending"
@@ -25,9 +30,73 @@ const (
 	StatusDeleted = "deleted"
 )
 
-// ValidStatuses defines the list of allowed task statuses for validation.
-// Note: StatusDeleted is not included as it's only set internally.
-var ValidStatuses = []string{StatusPending, StatusInProgress, StatusCompleted}
+// Task priority constants define the importance levels of a task,
+// ordered from least to most important
+const (
+	// PriorityNone represents a task without an assigned priority
+	PriorityNone = "none"
+
+	// PriorityLow represents a task that can wait
+	PriorityLow = "low"
+
+	// PriorityMedium represents a task of normal importance
+	PriorityMedium = "medium"
+
+	// PriorityHigh represents an important task
+	PriorityHigh = "high"
+
+	// PriorityUrgent represents a task that needs immediate attention
+	PriorityUrgent = "urgent"
+)
+
+// ValidPriorities defines the list of allowed task priorities in ascending order.
+var ValidPriorities = []string{PriorityNone, PriorityLow, PriorityMedium, PriorityHigh, PriorityUrgent}
+
+// MaxTaskDepth limits how many levels of subtasks may be nested below a
+// top-level task. It is configured at startup from the application config.
+var MaxTaskDepth = 3
+
+// Subtask view constants select how GetTask returns a task's descendants
+const (
+	// SubtasksNested returns descendants as a tree in Task.Subtasks
+	SubtasksNested = "nested"
+
+	// SubtasksFlat returns all descendants in depth-first order in
+	// Task.Subtasks, each carrying its Depth and ParentID
+	SubtasksFlat = "flat"
+)
+
+// Task sort constants define the orderings supported by GetTasks
+const (
+	// TaskSortPosition orders tasks by their manual position
+	TaskSortPosition = "position"
+
+	// TaskSortPriority orders tasks by priority, most important first,
+	// falling back to the manual position
+	TaskSortPriority = "priority"
+
+	// TaskSortCreatedAt orders tasks by creation time, newest first
+	TaskSortCreatedAt = "created_at"
+
+	// TaskSortUpdatedAt orders tasks by the time of their last change,
+	// most recently updated first
+	TaskSortUpdatedAt = "updated_at"
+
+	// TaskSortTitle orders tasks alphabetically by title, ignoring case
+	TaskSortTitle = "title"
+)
+
+// Sort order constants override the default direction of a TaskSort
+const (
+	// SortOrderAsc sorts the primary key in ascending order
+	SortOrderAsc = "asc"
+
+	// SortOrderDesc sorts the primary key in descending order
+	SortOrderDesc = "desc"
+)
+
+// MaxTaskPageSize is the largest number of tasks GetTaskPage returns at once.
+const MaxTaskPageSize = 200
 
 // Task represents a single task in the system.
 // It contains all task-related information including its current state,
@@ -43,9 +112,24 @@ type Task struct {
 	Description string `json:"description"`
 
 	// Status represents the current state of the task
-	// Must be one of ValidStatuses
+	// Must be a status of the owner's workflow
 	Status string `json:"status"`
 
+	// StatusCategory is the category of Status in the owner's workflow,
+	// one of ValidStatusCategories. It is set from the workflow when the
+	// task is saved.
+	StatusCategory string `json:"status_category"`
+
+	// Priority represents the importance of the task
+	// Must be one of ValidPriorities
+	Priority string `json:"priority"`
+
+	// EstimatePoints is the optional effort estimate in story points
+	EstimatePoints *int `json:"estimate_points"`
+
+	// EstimateMinutes is the optional effort estimate in minutes
+	EstimateMinutes *int `json:"estimate_minutes"`
+
 	// UserID associates the task with a specific user
 	UserID int `json:"user_id"`
 
@@ -55,19 +139,381 @@ type Task struct {
 	// UpdatedAt stores the timestamp of the last modification
 	UpdatedAt time.Time `json:"updated_at"`
 
-	// Position represents the task's order in the user's task list
+	// Position represents the task's order among its siblings: subtasks are
+	// ordered within their parent, top-level tasks within their project or
+	// within the user's inbox when ProjectID is nil. It is computed from the
+	// task's rank when the task is read.
 	Position int `json:"position"`
+
+	// rank orders the task within its list; see rankBetween
+	rank string
+
+	// ProjectID is the optional project the task belongs to.
+	// Nil places the task in the user's inbox. Subtasks always share
+	// the project of their parent.
+	ProjectID *int `json:"project_id"`
+
+	// ParentID is the optional parent of a subtask. It is set on creation
+	// and cannot be changed afterwards.
+	ParentID *int `json:"parent_id"`
+
+	// SubtaskCount is the number of direct, non-deleted subtasks
+	SubtaskCount int `json:"subtask_count"`
+
+	// CompletedSubtasks is the number of direct subtasks in a done status
+	CompletedSubtasks int `json:"completed_subtasks"`
+
+	// Progress is the percentage of completed direct subtasks. A task without
+	// subtasks reports 100 when completed and 0 otherwise.
+	Progress int `json:"progress"`
+
+	// Depth is the nesting level below the requested task (1 for direct
+	// children). It is only set on tasks returned as Subtasks.
+	Depth int `json:"depth,omitempty"`
+
+	// Subtasks holds the descendants loaded through GetSubtasks
+	Subtasks []Task `json:"subtasks,omitempty"`
+
+	// RecurrenceRule makes the task repeat; see RecurrenceRule for the syntax.
+	// Empty means the task does not recur.
+	RecurrenceRule string `json:"recurrence_rule"`
+
+	// SeriesID groups all occurrences of a recurring task. It is the ID of
+	// the first occurrence and nil for tasks that never recurred.
+	SeriesID *int `json:"series_id"`
+
+	// DeletedAt stores when the task was moved to the trash
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+
+	// StartedAt stores when the task was first moved to a doing status.
+	// It is kept when the task goes back to a todo status.
+	StartedAt *time.Time `json:"started_at,omitempty"`
+
+	// CompletedAt stores when the task was moved to a done status; it is
+	// cleared when the task is reopened
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+
+	// Version is incremented by the database on every change of the task.
+	// UpdateTask only applies changes to the version given here; zero
+	// updates unconditionally.
+	Version int `json:"version"`
+
+	// NextOccurrence is set by UpdateTask when completing a recurring task
+	// created the next occurrence of its series
+	NextOccurrence *Task `json:"next_occurrence,omitempty"`
+
+	// StartAt is the optional moment work on the task is planned to begin
+	StartAt *time.Time `json:"start_at,omitempty"`
+
+	// DueAt is the optional deadline of the task
+	DueAt *time.Time `json:"due_at,omitempty"`
+
+	// AllDay marks StartAt and DueAt as calendar dates rather than exact times.
+	// An all-day task becomes overdue only after its due date has ended.
+	AllDay bool `json:"all_day"`
+
+	// Overdue is computed on read and reports whether an active task
+	// has passed its due date
+	Overdue bool `json:"overdue"`
+
+	// Blocked is computed on read and reports whether a task this one
+	// depends on is not done yet
+	Blocked bool `json:"blocked"`
+
+	// Dependencies holds the dependency graph of the task. It is only set
+	// on tasks loaded with GetDependencyGraph.
+	Dependencies *DependencyGraph `json:"dependencies,omitempty"`
+
+	// IgnoreBlockers lets UpdateTask start or complete a blocked task
+	IgnoreBlockers bool `json:"-"`
+
+	// TimeSpent is computed on read and sums the time entries of the task
+	// in seconds, counting a running timer up to now
+	TimeSpent int64 `json:"time_spent_seconds"`
+
+	// Labels lists the labels attached to the task
+	Labels []TaskLabel `json:"labels"`
+
+	// LabelIDs replaces the attached labels when creating or updating a task.
+	// Nil leaves the labels unchanged, an empty slice detaches all of them.
+	LabelIDs []int `json:"label_ids,omitempty"`
+
+	// timeZone is the owner's time zone, deciding when all-day tasks
+	// become overdue
+	timeZone string
+}
+
+// TaskFilter narrows down the tasks returned by GetTasks.
+// The zero value returns every active task of the user.
+type TaskFilter struct {
+	// Overdue limits the result to active tasks past their due date
+	Overdue bool
+
+	// Priorities limits the result to tasks with one of the given priorities
+	Priorities []string
+
+	// LabelIDs limits the result to tasks carrying the given labels
+	LabelIDs []int
+
+	// LabelMode combines LabelIDs with LabelModeAny (default) or LabelModeAll
+	LabelMode string
+
+	// ProjectID limits the result to tasks of the given project
+	ProjectID *int
+
+	// Inbox limits the result to tasks without a project.
+	// It takes precedence over ProjectID.
+	Inbox bool
+
+	// ParentID lists the direct subtasks of the given task.
+	// Nil lists top-level tasks only.
+	ParentID *int
+
+	// Sort selects the ordering, one of the TaskSort constants.
+	// Empty means TaskSortPosition.
+	Sort string
+
+	// Order overrides the direction of the sort, SortOrderAsc or
+	// SortOrderDesc. Empty uses the natural direction of the sort.
+	Order string
+
+	// Statuses limits the result to tasks in one of the given statuses
+	Statuses []string
+
+	// CreatedAfter and CreatedBefore limit the creation time to the
+	// half-open range [CreatedAfter, CreatedBefore)
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+
+	// UpdatedAfter and UpdatedBefore limit the modification time to the
+	// half-open range [UpdatedAfter, UpdatedBefore)
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+
+	// Query limits the result to tasks whose title or description
+	// contains the text, ignoring case
+	Query string
+
+	// Limit caps the number of tasks returned by GetTaskPage.
+	// Zero returns all matching tasks.
+	Limit int
+
+	// Cursor continues a previous page; it must come from
+	// TaskPage.NextCursor of a request with the same filter
+	Cursor string
+}
+
+// Validate checks that the filter only references known priorities
+// and sort orders.
+func (f TaskFilter) Validate() error {
+	for _, priority := range f.Priorities {
+		if !isValidPriority(priority) {
+			return fmt.Errorf("invalid priority: %s", priority)
+		}
+	}
+
+	switch f.LabelMode {
+	case "", LabelModeAny, LabelModeAll:
+	default:
+		return fmt.Errorf("invalid label mode: %s", f.LabelMode)
+	}
+
+	switch f.Sort {
+	case "", TaskSortPosition, TaskSortPriority, TaskSortCreatedAt, TaskSortUpdatedAt, TaskSortTitle:
+	default:
+		return fmt.Errorf("invalid sort: %s", f.Sort)
+	}
+
+	switch f.Order {
+	case "", SortOrderAsc, SortOrderDesc:
+	default:
+		return fmt.Errorf("invalid order: %s", f.Order)
+	}
+
+	for _, status := range f.Statuses {
+		if !isValidStatusKey(status) {
+			return fmt.Errorf("invalid status: %s", status)
+		}
+	}
+
+	if f.CreatedAfter != nil && f.CreatedBefore != nil && !f.CreatedAfter.Before(*f.CreatedBefore) {
+		return fmt.Errorf("created_after must be before created_before")
+	}
+	if f.UpdatedAfter != nil && f.UpdatedBefore != nil && !f.UpdatedAfter.Before(*f.UpdatedBefore) {
+		return fmt.Errorf("updated_after must be before updated_before")
+	}
+
+	if f.Limit < 0 || f.Limit > MaxTaskPageSize {
+		return fmt.Errorf("limit must be between 1 and %d", MaxTaskPageSize)
+	}
+
+	if f.Cursor != "" {
+		if _, err := f.decodeCursor(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// taskColumns lists the task columns read by queries returning a single
+// task. The order must match the arguments passed to Scan in scanTask.
+const taskColumns = `id, title, description, status, user_id, ` + taskPosition + ` AS position, ` + taskFields
+
+// listedTaskColumns lists the task columns read by queries returning
+// several tasks. It must be used in queries on rankedTasks.
+const listedTaskColumns = `id, title, description, status, user_id, position, ` + taskFields
+
+// taskFields lists the task columns following the position.
+const taskFields = `created_at, updated_at,
+              start_at, due_at, all_day, priority, project_id,
+              COALESCE((
+                  SELECT json_agg(json_build_object('id', l.id, 'name', l.name, 'color', l.color)
+                                  ORDER BY LOWER(l.name))
+                  FROM tasks_labels tl
+                  JOIN labels l ON l.id = tl.label_id
+                  WHERE tl.task_id = tasks.id
+              ), '[]') AS labels,
+              parent_id,
+              (SELECT COUNT(*) FROM tasks c
+               WHERE c.parent_id = tasks.id AND c.status != 'deleted') AS subtask_count,
+              (SELECT COUNT(*) FROM tasks c
+               WHERE c.parent_id = tasks.id AND c.status != 'deleted'
+               AND c.status_category = 'done') AS completed_subtasks,
+              COALESCE(recurrence_rule, '') AS recurrence_rule,
+              COALESCE(series_id, CASE WHEN recurrence_rule IS NOT NULL THEN id END) AS series_id,
+              deleted_at, started_at, completed_at, version, rank, ` + ownerTimeZone + ` AS time_zone,
+              status_category,
+              EXISTS (SELECT 1 FROM task_dependencies d
+                      JOIN tasks b ON b.id = d.blocked_by_id
+                      WHERE d.task_id = tasks.id AND b.status != 'deleted'
+                      AND b.status_category != 'done') AS blocked,
+              (SELECT COALESCE(SUM(EXTRACT(EPOCH FROM (COALESCE(e.ended_at, NOW() AT TIME ZONE 'UTC') - e.started_at))), 0)::bigint
+               FROM time_entries e WHERE e.task_id = tasks.id) AS time_spent,
+              estimate_points, estimate_minutes`
+
+// ownerTimeZone resolves the time zone of the task owner's preferences.
+// It must be used in queries on the tasks table.
+const ownerTimeZone = `COALESCE((SELECT up.time_zone FROM user_preferences up
+                  WHERE up.user_id = tasks.user_id), 'UTC')`
+
+// overdueCondition matches active tasks whose due date has passed.
+// All-day tasks remain on time until the end of their due date in the
+// owner's time zone.
+const overdueCondition = `status != 'deleted' AND status_category != 'done'
+              AND due_at IS NOT NULL
+              AND CASE WHEN all_day THEN (due_at + INTERVAL '1 day') AT TIME ZONE ` + ownerTimeZone + `
+                  ELSE due_at AT TIME ZONE 'UTC' END <= NOW()`
+
+// priorityRank maps the priority column to a number that sorts
+// from least (0) to most (4) important.
+const priorityRank = `CASE priority
+                  WHEN 'urgent' THEN 4
+                  WHEN 'high' THEN 3
+                  WHEN 'medium' THEN 2
+                  WHEN 'low' THEN 1
+                  ELSE 0
+              END`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTask reads a single row selected with taskColumns into a Task
+// and fills in the computed fields. Extra destinations are scanned from
+// columns selected after taskColumns.
+func scanTask(row rowScanner, extra ...interface{}) (Task, error) {
+	var t Task
+	var labels []byte
+	dest := []interface{}{
+		&t.ID,
+		&t.Title,
+		&t.Description,
+		&t.Status,
+		&t.UserID,
+		&t.Position,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+		&t.StartAt,
+		&t.DueAt,
+		&t.AllDay,
+		&t.Priority,
+		&t.ProjectID,
+		&labels,
+		&t.ParentID,
+		&t.SubtaskCount,
+		&t.CompletedSubtasks,
+		&t.RecurrenceRule,
+		&t.SeriesID,
+		&t.DeletedAt,
+		&t.StartedAt,
+		&t.CompletedAt,
+		&t.Version,
+		&t.rank,
+		&t.timeZone,
+		&t.StatusCategory,
+		&t.Blocked,
+		&t.TimeSpent,
+		&t.EstimatePoints,
+		&t.EstimateMinutes,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return Task{}, err
+	}
+
+	if err := json.Unmarshal(labels, &t.Labels); err != nil {
+		return Task{}, fmt.Errorf("failed to decode task labels: %w", err)
+	}
+
+	t.Overdue = t.IsOverdue(t.localNow())
+	t.Progress = t.computeProgress()
+	return t, nil
+}
+
+// computeProgress derives the completion percentage from the subtask counters.
+func (t *Task) computeProgress() int {
+	if t.SubtaskCount == 0 {
+		if t.StatusCategory == StatusCategoryDone {
+			return 100
+		}
+		return 0
+	}
+	return t.CompletedSubtasks * 100 / t.SubtaskCount
+}
+
+// trackStatusTimes maintains StartedAt and CompletedAt for a status change
+// from the previous state of the task, or for a new task when previous is
+// nil. Transitions are stamped with t.UpdatedAt.
+func (t *Task) trackStatusTimes(previous *Task) {
+	if previous != nil {
+		t.StartedAt = previous.StartedAt
+		t.CompletedAt = previous.CompletedAt
+	}
+
+	if t.StatusCategory == StatusCategoryDoing && t.StartedAt == nil {
+		startedAt := t.UpdatedAt
+		t.StartedAt = &startedAt
+	}
+
+	if t.StatusCategory != StatusCategoryDone {
+		t.CompletedAt = nil
+	} else if previous == nil || previous.StatusCategory != StatusCategoryDone || t.CompletedAt == nil {
+		completedAt := t.UpdatedAt
+		t.CompletedAt = &completedAt
+	}
 }
 
 // GetTasks retrieves all active tasks for a specific user.
 //
 // It returns tasks ordered by their position, excluding soft-deleted tasks.
 // The function performs a database query to fetch tasks associated with the
-// provided user ID.
+// provided user ID, optionally narrowed down by the given filter.
 //
 // Parameters:
 //   - db: Database interface for executing queries
 //   - userID: The ID of the user whose tasks to retrieve
+//   - filter: Additional conditions; the zero value applies none
 //
 // Returns:
 //   - []Task: Slice of tasks belonging to the user
@@ -75,53 +521,171 @@ type Task struct {
 //
 // Query Details:
 //   - Excludes tasks with status 'deleted'
-//   - Orders tasks by position ascending
+//   - Returns top-level tasks unless filter.ParentID selects a parent
+//   - Orders tasks by position ascending unless filter.Sort says otherwise
 //   - Includes all task fields
 //
 // Example Usage:
 //
-//	tasks, err := GetTasks(db, userID)
+//	tasks, err := GetTasks(db, userID, TaskFilter{Overdue: true})
 //	if err != nil {
 //	    return fmt.Errorf("failed to fetch tasks: %w", err)
 //	}
-func GetTasks(db database.DB, userID int) ([]Task, error) {
+func GetTasks(db database.DB, userID int, filter TaskFilter) ([]Task, error) {
+	page, err := GetTaskPage(db, userID, filter)
+	if err != nil {
+		return nil, err
+	}
+	return page.Tasks, nil
+}
+
+// GetTaskPage retrieves one page of active tasks for a specific user.
+//
+// Pages are addressed with keyset pagination: filter.Cursor holds the sort
+// key values of the last task of the previous page, so pages stay stable
+// while tasks are added or removed elsewhere in the list. The filter must
+// be validated with Validate before it is passed in.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: The ID of the user whose tasks to retrieve
+//   - filter: Conditions, ordering, page size and cursor
+//
+// Returns:
+//   - TaskPage: The tasks of the page and the cursor of the next page
+//   - error: Database error if query fails
+//
+// Example Usage:
+//
+//	filter := TaskFilter{Sort: TaskSortCreatedAt, Limit: 50}
+//	for {
+//	    page, err := GetTaskPage(db, userID, filter)
+//	    if err != nil {
+//	        return err
+//	    }
+//	    process(page.Tasks)
+//	    if page.NextCursor == "" {
+//	        break
+//	    }
+//	    filter.Cursor = page.NextCursor
+//	}
+func GetTaskPage(db database.DB, userID int, filter TaskFilter) (TaskPage, error) {
+	// Build conditions for active tasks of the user
+	conditions := []string{"user_id = $1", "status != 'deleted'"}
+	args := []interface{}{userID}
+
+	if filter.Overdue {
+		conditions = append(conditions, overdueCondition)
+	}
+	if filter.ParentID != nil {
+		args = append(args, *filter.ParentID)
+		conditions = append(conditions, fmt.Sprintf("parent_id = $%d", len(args)))
+	} else {
+		conditions = append(conditions, "parent_id IS NULL")
+	}
+	if filter.Inbox {
+		conditions = append(conditions, "project_id IS NULL")
+	} else if filter.ProjectID != nil {
+		args = append(args, *filter.ProjectID)
+		conditions = append(conditions, fmt.Sprintf("project_id = $%d", len(args)))
+	}
+	if len(filter.Priorities) > 0 {
+		args = append(args, pq.Array(filter.Priorities))
+		conditions = append(conditions, fmt.Sprintf("priority = ANY($%d)", len(args)))
+	}
+	if len(filter.LabelIDs) > 0 {
+		labelIDs := uniqueInts(filter.LabelIDs)
+		args = append(args, pq.Array(labelIDs))
+		if filter.LabelMode == LabelModeAll {
+			// Every requested label must be attached
+			args = append(args, len(labelIDs))
+			conditions = append(conditions, fmt.Sprintf(`(SELECT COUNT(*) FROM tasks_labels tl
+                  WHERE tl.task_id = tasks.id AND tl.label_id = ANY($%d)) = $%d`, len(args)-1, len(args)))
+		} else {
+			// At least one requested label must be attached
+			conditions = append(conditions, fmt.Sprintf(`EXISTS (SELECT 1 FROM tasks_labels tl
+                  WHERE tl.task_id = tasks.id AND tl.label_id = ANY($%d))`, len(args)))
+		}
+	}
+	if len(filter.Statuses) > 0 {
+		args = append(args, pq.Array(filter.Statuses))
+		conditions = append(conditions, fmt.Sprintf("status = ANY($%d)", len(args)))
+	}
+
+	// Time ranges are half-open: [after, before)
+	ranges := []struct {
+		condition string
+		value     *time.Time
+	}{
+		{"created_at >= $%d", filter.CreatedAfter},
+		{"created_at < $%d", filter.CreatedBefore},
+		{"updated_at >= $%d", filter.UpdatedAfter},
+		{"updated_at < $%d", filter.UpdatedBefore},
+	}
+	for _, r := range ranges {
+		if r.value != nil {
+			args = append(args, *r.value)
+			conditions = append(conditions, fmt.Sprintf(r.condition, len(args)))
+		}
+	}
+
+	if query := strings.TrimSpace(filter.Query); query != "" {
+		args = append(args, "%"+likeEscaper.Replace(query)+"%")
+		conditions = append(conditions, fmt.Sprintf("(title ILIKE $%d OR description ILIKE $%d)", len(args), len(args)))
+	}
+
+	// Continue after the last task of the previous page
+	if filter.Cursor != "" {
+		cursor, err := filter.decodeCursor()
+		if err != nil {
+			return TaskPage{}, err
+		}
+		conditions = append(conditions, filter.keysetCondition(cursor, &args))
+	}
+
 	// SQL query to fetch active tasks for user
-	query := `SELECT id, title, description, status, user_id, position, created_at, updated_at 
-              FROM tasks 
-              WHERE user_id = $1 
-              AND status != 'deleted'
-              ORDER BY position ASC`
+	query := `SELECT ` + listedTaskColumns + `
+              FROM ` + rankedTasks("$1") + `
+              WHERE ` + strings.Join(conditions, " AND ") + `
+              ORDER BY ` + filter.orderBy()
 
-	// Execute query with user ID
-	rows, err := db.Query(query, userID)
+	// Fetch one extra row to learn whether another page follows
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit+1)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	// Execute query with collected arguments
+	rows, err := db.Query(query, args...)
 	if err != nil {
-		return nil, err
+		return TaskPage{}, err
 	}
 	defer rows.Close()
 
 	// Iterate through results and build tasks slice
-	var tasks []Task
+	var page TaskPage
 	for rows.Next() {
-		var t Task
-		err := rows.Scan(
-			&t.ID,
-			&t.Title,
-			&t.Description,
-			&t.Status,
-			&t.UserID,
-			&t.Position,
-			&t.CreatedAt,
-			&t.UpdatedAt,
-		)
+		t, err := scanTask(rows)
 		if err != nil {
-			return nil, err
+			return TaskPage{}, err
 		}
-		tasks = append(tasks, t)
+		page.Tasks = append(page.Tasks, t)
+	}
+	if err := rows.Err(); err != nil {
+		return TaskPage{}, err
+	}
+
+	if filter.Limit > 0 && len(page.Tasks) > filter.Limit {
+		page.Tasks = page.Tasks[:filter.Limit]
+		page.NextCursor = filter.newTaskCursor(page.Tasks[filter.Limit-1])
 	}
 
-	return tasks, nil
+	return page, nil
 }
 
+// likeEscaper escapes the wildcard characters of LIKE patterns.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
 // GetTask retrieves a single task by its ID.
 //
 // It performs a database query to fetch a specific task's details.
@@ -151,33 +715,113 @@ func GetTasks(db database.DB, userID int) ([]Task, error) {
 //	    return Task{}, fmt.Errorf("failed to fetch task: %w", err)
 //	}
 func GetTask(db database.DB, id int) (Task, error) {
-	var t Task
-
 	// SQL query to fetch task by ID
-	query := `SELECT id, title, description, status, user_id, position, created_at, updated_at 
+	query := `SELECT ` + taskColumns + `
               FROM tasks 
               WHERE id = $1`
 
 	// Execute query and scan results into Task struct
-	err := db.QueryRow(query, id).Scan(
-		&t.ID,
-		&t.Title,
-		&t.Description,
-		&t.Status,
-		&t.UserID,
-		&t.Position,
-		&t.CreatedAt,
-		&t.UpdatedAt,
-	)
+	return scanTask(db.QueryRow(query, id))
+}
+
+// GetSubtasks retrieves all non-deleted descendants of a task.
+//
+// It walks the hierarchy with a recursive query and returns the descendants
+// in depth-first order, siblings ordered by rank. Every returned task has
+// its Depth set relative to the given task.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - id: The unique identifier of the parent task
+//
+// Returns:
+//   - []Task: Descendants in depth-first order
+//   - error: Database error if query fails
+//
+// Example Usage:
+//
+//	subtasks, err := GetSubtasks(db, taskID)
+//	if err != nil {
+//	    return fmt.Errorf("failed to fetch subtasks: %w", err)
+//	}
+func GetSubtasks(db database.DB, id int) ([]Task, error) {
+	query := `
+        WITH RECURSIVE subtree AS (
+            SELECT id AS task_id, 1 AS depth, ARRAY[rank, LPAD(id::text, 10, '0')] AS path
+            FROM tasks
+            WHERE parent_id = $1 AND status != 'deleted'
+            UNION ALL
+            SELECT t.id, s.depth + 1, s.path || ARRAY[t.rank, LPAD(t.id::text, 10, '0')]
+            FROM tasks t
+            JOIN subtree s ON t.parent_id = s.task_id
+            WHERE t.status != 'deleted'
+        )
+        SELECT ` + listedTaskColumns + `, subtree.depth
+        FROM ` + rankedTasks("(SELECT user_id FROM tasks WHERE id = $1)") + `
+        JOIN subtree ON subtree.task_id = tasks.id
+        ORDER BY subtree.path`
 
-	return t, err
+	rows, err := db.Query(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subtasks []Task
+	for rows.Next() {
+		var depth int
+		t, err := scanTask(rows, &depth)
+		if err != nil {
+			return nil, err
+		}
+		t.Depth = depth
+		subtasks = append(subtasks, t)
+	}
+
+	return subtasks, rows.Err()
+}
+
+// NestSubtasks arranges descendants returned by GetSubtasks into a tree
+// below the given task.
+//
+// Parameters:
+//   - t: The task the descendants belong to
+//   - subtasks: Descendants in depth-first order
+//
+// Side Effects:
+//   - Replaces t.Subtasks with the direct children, each holding
+//     its own children recursively
+func (t *Task) NestSubtasks(subtasks []Task) {
+	children := make(map[int][]Task)
+	for _, s := range subtasks {
+		if s.ParentID != nil {
+			children[*s.ParentID] = append(children[*s.ParentID], s)
+		}
+	}
+
+	var attach func(parentID int) []Task
+	attach = func(parentID int) []Task {
+		nodes := children[parentID]
+		for i := range nodes {
+			nodes[i].Subtasks = attach(nodes[i].ID)
+		}
+		return nodes
+	}
+	t.Subtasks = attach(t.ID)
 }
 
-// CreateTask inserts a new task into the database and updates task positions.
+// CreateTask inserts a new task at the top of its list.
 //
 // This method uses a transaction to ensure atomicity of the operation:
-// 1. Increments positions of existing tasks for the user
-// 2. Inserts the new task at position 0
+// 1. Checks t.Status against the user's workflow (default: first todo status)
+// 2. Verifies that t.ProjectID or t.ParentID, when set, belong to the user
+// 3. Checks the WIP limit of the board column of a top-level task
+// 4. Ranks the new task before its siblings (same parent, project or inbox)
+// 5. Inserts the new task at position 0
+// 6. Attaches the labels listed in t.LabelIDs
+//
+// Subtasks inherit the project of their parent and may be nested at most
+// MaxTaskDepth levels below a top-level task.
 //
 // The method also sets the creation and update timestamps.
 //
@@ -185,12 +829,16 @@ func GetTask(db database.DB, id int) (Task, error) {
 //   - db: Database interface for executing queries
 //
 // Returns:
-//   - error: Any error encountered during the process
+//   - error: "invalid status: ...", "project not found", "parent task not found",
+//     "maximum subtask depth exceeded", "label not found",
+//     "wip limit reached: ...", or any other
+//     error encountered during the process
 //
 // Side Effects:
 //   - Sets t.ID with the newly created task's ID
 //   - Sets t.CreatedAt and t.UpdatedAt to current time
 //   - Sets t.Position to 0
+//   - Sets t.Labels to the attached labels
 //
 // Example Usage:
 //
@@ -211,37 +859,131 @@ func (t *Task) CreateTask(db database.DB) error {
 	}
 	defer tx.Rollback() // Rollback in case of error
 
-	// Increment positions of existing tasks
-	_, err = tx.Exec(`
-        UPDATE tasks 
-        SET position = position + 1
-        WHERE user_id = $1`, t.UserID)
+	if err := t.createTask(tx); err != nil {
+		return err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// createTask performs the steps of CreateTask within the caller's transaction.
+func (t *Task) createTask(q querier) error {
+	// New tasks start in the first todo status unless one is given
+	workflow, err := getWorkflow(q, t.UserID)
 	if err != nil {
-		return fmt.Errorf("failed to update task positions: %w", err)
+		return err
+	}
+	if t.Status == "" {
+		t.Status = workflow.InitialStatus()
+	}
+	if err := t.ValidateTransition(workflow, ""); err != nil {
+		return err
 	}
 
-	// Set the position of the new task to 0
+	// Make sure the parent or the target project belongs to the user
+	if t.ParentID != nil {
+		if err := t.inheritParent(q); err != nil {
+			return err
+		}
+	} else if err := verifyProjectOwner(q, t.UserID, t.ProjectID); err != nil {
+		return err
+	}
+	if err := t.checkWIPLimit(q, workflow, nil); err != nil {
+		return err
+	}
+
+	// Rank the new task before its siblings
+	rank, err := firstRank(q, t.UserID, t.ProjectID, t.ParentID)
+	if err != nil {
+		return err
+	}
+	t.rank = rank
 	t.Position = 0
 
 	// Set creation and update timestamps
 	t.CreatedAt = time.Now()
 	t.UpdatedAt = t.CreatedAt
+	t.trackStatusTimes(nil)
 
 	// Insert the new task
 	query := `
-        INSERT INTO tasks (title, description, status, user_id, position, created_at, updated_at)
-        VALUES ($1, $2, $3, $4, $5, $6, $7)
-        RETURNING id`
+        INSERT INTO tasks (title, description, status, user_id, rank, created_at, updated_at,
+                           start_at, due_at, all_day, priority, project_id, parent_id,
+                           recurrence_rule, series_id, started_at, completed_at, status_category,
+                           estimate_points, estimate_minutes)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, NULLIF($14, ''), $15, $16, $17, $18, $19, $20)
+        RETURNING id, ` + ownerTimeZone
 
-	err = tx.QueryRow(query, t.Title, t.Description, t.Status, t.UserID, t.Position, t.CreatedAt, t.UpdatedAt).Scan(&t.ID)
+	err = q.QueryRow(query, t.Title, t.Description, t.Status, t.UserID, t.rank, t.CreatedAt, t.UpdatedAt,
+		t.StartAt, t.DueAt, t.AllDay, t.Priority, t.ProjectID, t.ParentID,
+		t.RecurrenceRule, t.SeriesID, t.StartedAt, t.CompletedAt, t.StatusCategory,
+		t.EstimatePoints, t.EstimateMinutes).Scan(&t.ID, &t.timeZone)
 	if err != nil {
 		log.Printf("Error inserting task into database: %v", err)
 		return fmt.Errorf("failed to insert task: %w", err)
 	}
 
-	// Commit the transaction
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	// The first occurrence of a recurring task starts its series
+	if t.RecurrenceRule != "" && t.SeriesID == nil {
+		seriesID := t.ID
+		t.SeriesID = &seriesID
+	}
+
+	// Attach requested labels
+	t.Labels = []TaskLabel{}
+	if len(t.LabelIDs) > 0 {
+		if t.Labels, err = setTaskLabels(q, t.ID, t.UserID, t.LabelIDs); err != nil {
+			return err
+		}
+	}
+
+	// Start the task's history
+	if err := recordTaskEvents(q, []TaskEvent{newTaskEvent(t, TaskEventCreated, "", nil, nil)}); err != nil {
+		return err
+	}
+
+	t.Overdue = t.IsOverdue(t.localNow())
+	t.Progress = t.computeProgress()
+	return nil
+}
+
+// inheritParent verifies that t.ParentID is an active task of the same user,
+// that the new subtask stays within MaxTaskDepth, and copies the parent's
+// project onto t.
+func (t *Task) inheritParent(q querier) error {
+	err := q.QueryRow(`
+        SELECT project_id 
+        FROM tasks 
+        WHERE id = $1 AND user_id = $2 AND status != 'deleted'`,
+		*t.ParentID, t.UserID).Scan(&t.ProjectID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("parent task not found")
+		}
+		return fmt.Errorf("failed to get parent task: %w", err)
+	}
+
+	// The new subtask sits one level below each of its ancestors
+	var depth int
+	err = q.QueryRow(`
+        WITH RECURSIVE ancestors AS (
+            SELECT id, parent_id FROM tasks WHERE id = $1
+            UNION ALL
+            SELECT t.id, t.parent_id
+            FROM tasks t
+            JOIN ancestors a ON t.id = a.parent_id
+        )
+        SELECT COUNT(*) FROM ancestors`, *t.ParentID).Scan(&depth)
+	if err != nil {
+		return fmt.Errorf("failed to get task depth: %w", err)
+	}
+	if depth > MaxTaskDepth {
+		return fmt.Errorf("maximum subtask depth exceeded")
 	}
 
 	return nil
@@ -249,21 +991,49 @@ func (t *Task) CreateTask(db database.DB) error {
 
 // UpdateTask modifies an existing task's details in the database.
 //
-// This method updates the task's title, description, and status,
-// while automatically updating the updated_at timestamp. The task's
-// ID must be set before calling this method.
+// This method updates the task's title, description, status, priority, schedule
+// and project, while automatically updating the updated_at timestamp. When
+// t.LabelIDs is not nil the attached labels are replaced within the same
+// transaction. Moving a top-level task to another project places it at the top
+// of that project and moves its subtasks along.
+// Subtasks keep their parent and the project of their parent.
+//
+// The status must belong to the owner's workflow and be reachable from the
+// current status, and a top-level task may only enter a board column below
+// the WIP limit of the status. A blocked task may not move to a doing or
+// done status unless t.IgnoreBlockers is set. Moving a recurring task to a done status
+// creates the next occurrence of its series within the same transaction and
+// stores it in t.NextOccurrence.
+// The task's ID and UserID must be set before calling this method.
+//
+// A non-zero t.Version makes the update conditional: it fails with
+// "version conflict" when the task has changed since that version was read.
+// On success t.Version holds the new version.
 //
 // Parameters:
 //   - db: Database interface for executing queries
 //
 // Returns:
-//   - error: Database error if update fails
+//   - error: Database error if update fails, "task not found" if the task
+//     doesn't exist, "version conflict" if t.Version is outdated,
+//     "invalid status: ..." or "status transition not allowed" when the
+//     workflow rejects the status, "task is blocked" when a blocked task
+//     would start or finish, "wip limit reached: ..." when the task
+//     enters a full board column, or
+//     "project not found"/"label not found" when ProjectID or LabelIDs
+//     reference entities the user doesn't own
 //
 // Fields Updated:
 //   - title
 //   - description
-//   - status
+//   - status, status_category
+//   - priority
+//   - start_at, due_at, all_day
+//   - project_id (and rank when the project changes)
+//   - recurrence_rule
+//   - labels (only when LabelIDs is set)
 //   - updated_at (automatically set to current time)
+//   - version (incremented by the database)
 //
 // Example Usage:
 //
@@ -273,38 +1043,245 @@ func (t *Task) CreateTask(db database.DB) error {
 //	    return fmt.Errorf("failed to update task: %w", err)
 //	}
 //
-// Note: This method does not update the task's position or user_id
-// as these should be modified through separate specialized methods.
+// Note: This method does not change the task's position within its project
+// or its user_id, as these should be modified through separate specialized methods.
 func (t *Task) UpdateTask(db database.DB) error {
+	// Start transaction
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback in case of error
+
+	if err := t.updateTask(tx); err != nil {
+		return err
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// updateTask performs the steps of UpdateTask within the caller's transaction.
+func (t *Task) updateTask(q querier) error {
+	// Lock the task and read its current placement
+	old, err := scanTask(q.QueryRow(`
+        SELECT `+taskColumns+` 
+        FROM tasks 
+        WHERE id = $1 
+        FOR UPDATE`, t.ID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("task not found")
+		}
+		return fmt.Errorf("failed to get task: %w", err)
+	}
+	if t.Version != 0 && t.Version != old.Version {
+		return fmt.Errorf("version conflict")
+	}
+
+	// The new status must be reachable in the owner's workflow
+	workflow, err := getWorkflow(q, t.UserID)
+	if err != nil {
+		return err
+	}
+	if err := t.ValidateTransition(workflow, old.Status); err != nil {
+		return err
+	}
+	if err := t.checkBlockers(&old); err != nil {
+		return err
+	}
+	oldProjectID := old.ProjectID
+	t.ParentID = old.ParentID
+	t.Blocked = old.Blocked
+	t.TimeSpent = old.TimeSpent
+	t.timeZone = old.timeZone
+
+	// Move the task to the top of its new project when the project changes
+	t.Position, t.rank = old.Position, old.rank
+	if t.ParentID != nil {
+		t.ProjectID = oldProjectID
+	} else if !sameProject(oldProjectID, t.ProjectID) {
+		if err := t.moveToProject(q); err != nil {
+			return err
+		}
+	}
+	if err := t.checkWIPLimit(q, workflow, &old); err != nil {
+		return err
+	}
+
 	// SQL query to update task fields
 	query := `
         UPDATE tasks
-        SET title = $1, description = $2, status = $3, updated_at = $4
-        WHERE id = $5`
+        SET title = $1, description = $2, status = $3, updated_at = $4,
+            start_at = $5, due_at = $6, all_day = $7, priority = $8,
+            project_id = $9, rank = $10, recurrence_rule = NULLIF($11, ''),
+            started_at = $12, completed_at = $13, status_category = $14,
+            estimate_points = $15, estimate_minutes = $16
+        WHERE id = $17
+        RETURNING version`
 
 	// Set current timestamp
 	t.UpdatedAt = time.Now()
+	t.trackStatusTimes(&old)
 
-	// Execute update query
-	_, err := db.Exec(query, t.Title, t.Description, t.Status, t.UpdatedAt, t.ID)
+	// Execute update query; the database increments the version
+	err = q.QueryRow(query, t.Title, t.Description, t.Status, t.UpdatedAt,
+		t.StartAt, t.DueAt, t.AllDay, t.Priority, t.ProjectID, t.rank, t.RecurrenceRule,
+		t.StartedAt, t.CompletedAt, t.StatusCategory, t.EstimatePoints, t.EstimateMinutes, t.ID).Scan(&t.Version)
 	if err != nil {
 		return fmt.Errorf("failed to update task: %w", err)
 	}
 
+	// Replace labels if requested
+	if t.LabelIDs != nil {
+		if t.Labels, err = setTaskLabels(q, t.ID, t.UserID, t.LabelIDs); err != nil {
+			return err
+		}
+	} else {
+		t.Labels = old.Labels
+	}
+
+	// Record every changed field in the task's history
+	if err := recordTaskEvents(q, taskChangeEvents(&old, t)); err != nil {
+		return err
+	}
+
+	// Schedule the next occurrence when a recurring task gets completed
+	if t.RecurrenceRule != "" && old.StatusCategory != StatusCategoryDone && t.StatusCategory == StatusCategoryDone {
+		if err := t.spawnNextOccurrence(q, t.UpdatedAt); err != nil {
+			return err
+		}
+	}
+
+	t.Overdue = t.IsOverdue(t.localNow())
+	t.Progress = t.computeProgress()
 	return nil
 }
 
-// UpdateTaskPosition changes a task's position in the user's task list.
-//
-// This method uses a transaction to ensure atomicity when reordering tasks.
-// It handles both moving a task up (to a lower position number) and down
-// (to a higher position number) while maintaining the integrity of all
-// task positions.
+// spawnNextOccurrence creates the next occurrence of a recurring task that has
+// just been completed and stores it in t.NextOccurrence. Nothing is created
+// when the series already has another active occurrence, so reopening and
+// completing a task again doesn't duplicate it.
+func (t *Task) spawnNextOccurrence(q querier, completedAt time.Time) error {
+	rule, err := ParseRecurrenceRule(t.RecurrenceRule)
+	if err != nil {
+		return err
+	}
+
+	seriesID := t.ID
+	if t.SeriesID != nil {
+		seriesID = *t.SeriesID
+	}
+	t.SeriesID = &seriesID
+
+	var active bool
+	err = q.QueryRow(`
+        SELECT EXISTS (
+            SELECT 1 FROM tasks 
+            WHERE (id = $1 OR series_id = $1) 
+            AND id != $2 
+            AND status != 'deleted' AND status_category != 'done'
+        )`, seriesID, t.ID).Scan(&active)
+	if err != nil {
+		return fmt.Errorf("failed to check task series: %w", err)
+	}
+	if active {
+		return nil
+	}
+
+	// Anchor at the due date, or at the completion for FROM=COMPLETION rules
+	// and tasks without a due date
+	anchor := completedAt
+	if !rule.FromCompletion && t.DueAt != nil {
+		anchor = *t.DueAt
+	}
+	anchor = *normalizeTaskDate(&anchor, t.AllDay)
+
+	due := rule.Next(anchor)
+	if !rule.FromCompletion {
+		// Skip occurrences that have already passed
+		for !due.After(completedAt) {
+			due = rule.Next(due)
+		}
+	}
+
+	next := &Task{
+		Title:           t.Title,
+		Description:     t.Description,
+		Priority:        t.Priority,
+		UserID:          t.UserID,
+		ProjectID:       t.ProjectID,
+		ParentID:        t.ParentID,
+		DueAt:           &due,
+		AllDay:          t.AllDay,
+		RecurrenceRule:  t.RecurrenceRule,
+		SeriesID:        &seriesID,
+		EstimatePoints:  t.EstimatePoints,
+		EstimateMinutes: t.EstimateMinutes,
+	}
+
+	// Keep the distance between start and due date
+	if t.StartAt != nil && t.DueAt != nil {
+		start := due.Add(-t.DueAt.Sub(*t.StartAt))
+		next.StartAt = &start
+	}
+
+	for _, label := range t.Labels {
+		next.LabelIDs = append(next.LabelIDs, label.ID)
+	}
+
+	if err := next.createTask(q); err != nil {
+		return fmt.Errorf("failed to create next occurrence: %w", err)
+	}
+
+	t.NextOccurrence = next
+	return nil
+}
+
+// moveToProject ranks the top-level task before the other tasks of
+// t.ProjectID and moves all subtasks along. It sets t.Position to 0 but
+// leaves writing the task row itself to the caller.
+func (t *Task) moveToProject(q querier) error {
+	if err := verifyProjectOwner(q, t.UserID, t.ProjectID); err != nil {
+		return err
+	}
+
+	rank, err := firstRank(q, t.UserID, t.ProjectID, nil)
+	if err != nil {
+		return err
+	}
+	t.rank = rank
+
+	// Subtasks follow their top-level task
+	_, err = q.Exec(`
+        WITH RECURSIVE subtree AS (
+            SELECT id FROM tasks WHERE parent_id = $1
+            UNION ALL
+            SELECT t.id
+            FROM tasks t
+            JOIN subtree s ON t.parent_id = s.id
+        )
+        UPDATE tasks 
+        SET project_id = $2 
+        WHERE id IN (SELECT id FROM subtree)`,
+		t.ID, t.ProjectID)
+	if err != nil {
+		return fmt.Errorf("failed to move subtasks: %w", err)
+	}
+
+	t.Position = 0
+	return nil
+}
+
+// UpdateTaskPosition changes a task's position among its siblings: within its
+// parent for subtasks, otherwise within its project or the user's inbox.
 //
-// The process:
-// 1. Verifies task ownership and gets current position
-// 2. Shifts other tasks' positions to make space
-// 3. Updates the target task's position
+// It moves the single task with SetTaskPositions; only the task itself gets
+// a new rank, so its siblings are not modified.
 //
 // Parameters:
 //   - db: Database interface for executing queries
@@ -312,7 +1289,11 @@ func (t *Task) UpdateTask(db database.DB) error {
 //   - newPosition: Desired position for the task
 //
 // Returns:
-//   - error: Any error encountered during the process
+//   - error: Same errors as SetTaskPositions
+//
+// Side Effects:
+//   - Sets t.ProjectID, t.ParentID, t.Position and t.UpdatedAt from the
+//     moved task
 //
 // Example Usage:
 //
@@ -320,125 +1301,241 @@ func (t *Task) UpdateTask(db database.DB) error {
 //	if err := task.UpdateTaskPosition(db, userID, 3); err != nil {
 //	    return fmt.Errorf("failed to update task position: %w", err)
 //	}
-//
-// Note: This method updates the updated_at timestamp for all affected tasks.
 func (t *Task) UpdateTaskPosition(db database.DB, userID int, newPosition int) error {
-	// Start transaction
-	tx, err := db.Begin()
+	tasks, err := SetTaskPositions(db, userID, map[int]int{t.ID: newPosition})
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return err
 	}
-	defer tx.Rollback() // Rollback in case of error
 
-	// Get current position and verify ownership
-	var oldPosition int
-	err = tx.QueryRow(`
-        SELECT position 
-        FROM tasks 
-        WHERE id = $1 AND user_id = $2`, t.ID, userID).Scan(&oldPosition)
-	if err != nil {
-		return fmt.Errorf("failed to get current position: %w", err)
+	for _, moved := range tasks {
+		if moved.ID == t.ID {
+			t.ProjectID = moved.ProjectID
+			t.ParentID = moved.ParentID
+			t.Position = moved.Position
+			t.UpdatedAt = moved.UpdatedAt
+			t.rank = moved.rank
+		}
 	}
+	return nil
+}
 
-	// Update positions of affected tasks
-	if oldPosition < newPosition {
-		// Moving task down: shift intermediate tasks up
-		_, err = tx.Exec(`
-            UPDATE tasks 
-            SET position = position - 1,
-                updated_at = $1
-            WHERE user_id = $2 
-            AND position > $3 
-            AND position <= $4`,
-			time.Now(), userID, oldPosition, newPosition)
-	} else {
-		// Moving task up: shift intermediate tasks down
-		_, err = tx.Exec(`
-            UPDATE tasks 
-            SET position = position + 1,
-                updated_at = $1
-            WHERE user_id = $2 
-            AND position >= $3 
-            AND position < $4`,
-			time.Now(), userID, newPosition, oldPosition)
-	}
-	if err != nil {
-		return fmt.Errorf("failed to update intermediate positions: %w", err)
+// ValidatePriority checks if the task's priority is one of the allowed values.
+//
+// It compares the task's priority against the ValidPriorities slice.
+// Callers that accept an omitted
+// priority should default it to PriorityNone before validating.
+//
+// Returns:
+//   - nil: If the priority is valid
+//   - error: If the priority is not in ValidPriorities
+//
+// Example Usage:
+//
+//	task := &Task{Priority: "critical"}
+//	if err := task.ValidatePriority(); err != nil {
+//	    return fmt.Errorf("validation failed: %w", err)
+//	}
+func (t *Task) ValidatePriority() error {
+	if isValidPriority(t.Priority) {
+		return nil
 	}
 
-	// Update target task's position
-	t.Position = newPosition
-	t.UpdatedAt = time.Now()
-	_, err = tx.Exec(`
-        UPDATE tasks 
-        SET position = $1,
-            updated_at = $2
-        WHERE id = $3 AND user_id = $4`,
-		t.Position, t.UpdatedAt, t.ID, userID)
-	if err != nil {
-		return fmt.Errorf("failed to update task position: %w", err)
+	// Return error for invalid priority
+	return fmt.Errorf("invalid priority: %s", t.Priority)
+}
+
+// isValidPriority reports whether priority is one of ValidPriorities.
+func isValidPriority(priority string) bool {
+	for _, p := range ValidPriorities {
+		if priority == p {
+			return true
+		}
 	}
+	return false
+}
 
-	// Commit transaction
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+// ValidateDates checks and normalizes the task's start and due dates.
+//
+// Both dates are optional. Exact times are converted to UTC, while all-day
+// dates are truncated to midnight UTC of the given calendar day. When both
+// dates are present the start date must not be after the due date.
+//
+// Returns:
+//   - nil: If the schedule is valid
+//   - error: If StartAt is after DueAt
+//
+// Example Usage:
+//
+//	task := &Task{StartAt: &start, DueAt: &due, AllDay: true}
+//	if err := task.ValidateDates(); err != nil {
+//	    return fmt.Errorf("validation failed: %w", err)
+//	}
+func (t *Task) ValidateDates() error {
+	t.StartAt = normalizeTaskDate(t.StartAt, t.AllDay)
+	t.DueAt = normalizeTaskDate(t.DueAt, t.AllDay)
+
+	if t.StartAt != nil && t.DueAt != nil && t.StartAt.After(*t.DueAt) {
+		return fmt.Errorf("start date must not be after due date")
 	}
 
 	return nil
 }
 
-// ValidateStatus checks if the task's status is one of the allowed values.
-//
-// It compares the task's status against the ValidStatuses slice to ensure
-// only permitted status values are used. This method is typically called
-// before creating or updating a task.
+// ValidateRecurrence checks the task's recurrence rule and rewrites it
+// in canonical form. An empty rule is valid and means the task doesn't recur.
 //
 // Returns:
-//   - nil: If the status is valid
-//   - error: If the status is not in ValidStatuses
-//
-// Valid Statuses:
-//   - "pending"
-//   - "in_progress"
-//   - "completed"
+//   - nil: If the rule is empty or valid
+//   - error: "invalid recurrence rule: ..." describing the problem
 //
 // Example Usage:
 //
-//	task := &Task{Status: "invalid_status"}
-//	if err := task.ValidateStatus(); err != nil {
+//	task := &Task{RecurrenceRule: "freq=weekly;byday=fr,mo"}
+//	if err := task.ValidateRecurrence(); err != nil {
 //	    return fmt.Errorf("validation failed: %w", err)
 //	}
+//	// task.RecurrenceRule == "FREQ=WEEKLY;BYDAY=MO,FR"
+func (t *Task) ValidateRecurrence() error {
+	if strings.TrimSpace(t.RecurrenceRule) == "" {
+		t.RecurrenceRule = ""
+		return nil
+	}
+
+	rule, err := ParseRecurrenceRule(t.RecurrenceRule)
+	if err != nil {
+		return err
+	}
+
+	t.RecurrenceRule = rule.String()
+	return nil
+}
+
+// IsOverdue reports whether the task is still active at the given moment
+// although its due date has already passed. All-day tasks are considered
+// overdue once their whole due day is over in the time zone of now.
+func (t *Task) IsOverdue(now time.Time) bool {
+	if t.DueAt == nil {
+		return false
+	}
+	if t.Status == StatusDeleted || t.StatusCategory == StatusCategoryDone {
+		return false
+	}
+
+	deadline := *t.DueAt
+	if t.AllDay {
+		// All-day dates are stored as UTC midnight of the calendar day
+		day := deadline.UTC()
+		deadline = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, now.Location())
+	}
+	return !now.Before(deadline)
+}
+
+// localNow returns the current time in the time zone of the task owner.
+func (t *Task) localNow() time.Time {
+	return time.Now().In(loadLocation(t.timeZone))
+}
+
+// normalizeTaskDate converts a schedule date to the form stored in the database.
+func normalizeTaskDate(date *time.Time, allDay bool) *time.Time {
+	if date == nil {
+		return nil
+	}
+
+	d := date.UTC()
+	if allDay {
+		d = time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return &d
+}
+
+// DeleteTaskSeries soft-deletes every occurrence of the recurring series the
+// given task belongs to, including completed occurrences and all subtasks.
+// A task that never recurred is deleted on its own.
 //
-// Note: StatusDeleted is not included in valid statuses as it should
-// only be set through internal deletion processes.
-func (t *Task) ValidateStatus() error {
-	// Check if status matches any valid status
-	for _, status := range ValidStatuses {
-		if t.Status == status {
-			return nil
-		}
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: The ID of the task owner
+//   - id: The ID of any occurrence of the series
+//
+// Returns:
+//   - int64: Number of tasks deleted
+//   - error: Database error or "task not found" if the user owns no such
+//     task or the series is already deleted
+//
+// Example Usage:
+//
+//	deleted, err := DeleteTaskSeries(db, userID, taskID)
+//	if err != nil {
+//	    return fmt.Errorf("failed to delete series: %w", err)
+//	}
+func DeleteTaskSeries(db database.DB, userID, id int) (int64, error) {
+	query := `
+        WITH RECURSIVE series AS (
+            SELECT COALESCE(series_id, id) AS id
+            FROM tasks
+            WHERE id = $2 AND user_id = $3
+        ),
+        subtree AS (
+            SELECT t.id
+            FROM tasks t
+            JOIN series s ON t.id = s.id OR t.series_id = s.id
+            UNION ALL
+            SELECT t.id
+            FROM tasks t
+            JOIN subtree st ON t.parent_id = st.id
+        ),
+        deleted AS (
+            UPDATE tasks 
+            SET previous_status = status, status = 'deleted', deleted_at = $1, updated_at = $1 
+            WHERE id IN (SELECT id FROM subtree) 
+            AND status != 'deleted'
+            RETURNING id, user_id, previous_status
+        )
+        ` + deletedEventsInsert
+
+	result, err := db.Exec(query, time.Now(), id, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete task series: %w", err)
 	}
 
-	// Return error for invalid status
-	return fmt.Errorf("invalid status: %s", t.Status)
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if rowsAffected == 0 {
+		return 0, fmt.Errorf("task not found")
+	}
+
+	return rowsAffected, nil
 }
 
+// deletedEventsInsert records a deleted event for every row returned by a
+// preceding "deleted" CTE of a soft delete. It expects the deletion time
+// as $1; the number of affected rows equals the number of deleted tasks.
+const deletedEventsInsert = `INSERT INTO task_events (task_id, user_id, event_type, field, old_value, new_value, created_at)
+        SELECT id, user_id, 'deleted', 'status', to_jsonb(previous_status), to_jsonb('deleted'::text), $1
+        FROM deleted`
+
 // DeleteTask performs a soft delete of a task by marking its status as 'deleted'.
 //
 // Instead of removing the task from the database, this function updates the
 // task's status to 'deleted' and its updated_at timestamp. This approach
 // allows for potential task restoration and maintains data history.
+// All active subtasks of the task are soft-deleted along with it, and the
+// previous status and deletion time are kept so RestoreTask can undo it.
 //
 // Parameters:
 //   - db: Database interface for executing queries
+//   - userID: ID of the task owner
 //   - id: The unique identifier of the task to delete
 //
 // Returns:
-//   - error: Database error or "task not found" if task doesn't exist
+//   - error: Database error or "task not found" if task doesn't exist,
+//     belongs to another user or is already deleted
 //
 // Example Usage:
 //
-//	if err := DeleteTask(db, taskID); err != nil {
+//	if err := DeleteTask(db, userID, taskID); err != nil {
 //	    if err.Error() == "task not found" {
 //	        return fmt.Errorf("cannot delete: task %d not found", taskID)
 //	    }
@@ -446,17 +1543,69 @@ func (t *Task) ValidateStatus() error {
 //	}
 //
 // Note: This is a soft delete operation. The task will still exist in the
-// database but won't appear in normal task listings.
-func DeleteTask(db database.DB, id int) error {
-	// SQL query for soft delete
+// database but won't appear in normal task listings. Use PurgeTask to
+// remove it permanently.
+func DeleteTask(db database.DB, userID, id int) error {
+	return DeleteTaskVersion(db, userID, id, 0)
+}
+
+// DeleteTaskVersion soft-deletes a task like DeleteTask, but only if the
+// task is still at the given version. A version of zero deletes
+// unconditionally.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: ID of the task owner
+//   - id: The ID of the task to delete
+//   - version: The version the caller expects the task to have
+//
+// Returns:
+//   - error: "task not found" if the task doesn't exist, belongs to another
+//     user or is already deleted, "version conflict" if the task has a
+//     different version, or database errors
+//
+// Example Usage:
+//
+//	if err := DeleteTaskVersion(db, userID, taskID, task.Version); err != nil {
+//	    if err.Error() == "version conflict" {
+//	        // reload the task and let the user decide
+//	    }
+//	}
+func DeleteTaskVersion(db database.DB, userID, id, version int) error {
+	return deleteTask(db, userID, id, version)
+}
+
+// deleteTask performs the steps of DeleteTaskVersion with q, which may be
+// the caller's transaction.
+func deleteTask(q querier, userID, id, version int) error {
+	args := []interface{}{time.Now(), id, userID}
+	versionCondition := ""
+	if version != 0 {
+		args = append(args, version)
+		versionCondition = " AND version = $4"
+	}
+
+	// SQL query for soft delete of the task and its subtree
 	query := `
-        UPDATE tasks 
-        SET status = 'deleted', updated_at = $1 
-        WHERE id = $2
-    `
+        WITH RECURSIVE subtree AS (
+            SELECT id FROM tasks WHERE id = $2 AND user_id = $3` + versionCondition + `
+            UNION ALL
+            SELECT t.id
+            FROM tasks t
+            JOIN subtree s ON t.parent_id = s.id
+            WHERE t.user_id = $3
+        ),
+        deleted AS (
+            UPDATE tasks 
+            SET previous_status = status, status = 'deleted', deleted_at = $1, updated_at = $1 
+            WHERE id IN (SELECT id FROM subtree) 
+            AND status != 'deleted'
+            RETURNING id, user_id, previous_status
+        )
+        ` + deletedEventsInsert
 
 	// Execute update
-	result, err := db.Exec(query, time.Now(), id)
+	result, err := q.Exec(query, args...)
 	if err != nil {
 		return err
 	}
@@ -467,8 +1616,22 @@ func DeleteTask(db database.DB, id int) error {
 		return err
 	}
 
-	// Return error if no task was found
 	if rowsAffected == 0 {
+		if version == 0 {
+			return fmt.Errorf("task not found")
+		}
+		// Tell a stale version apart from a missing task
+		var exists bool
+		err := q.QueryRow(`
+            SELECT EXISTS (
+                SELECT 1 FROM tasks WHERE id = $1 AND user_id = $2 AND status != 'deleted'
+            )`, id, userID).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("failed to check task: %w", err)
+		}
+		if exists {
+			return fmt.Errorf("version conflict")
+		}
 		return fmt.Errorf("task not found")
 	}
 
@@ -0,0 +1,202 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TaskPage is a single page of tasks returned by GetTaskPage.
+type TaskPage struct {
+	// Tasks holds the tasks of the page in the requested order
+	Tasks []Task
+
+	// NextCursor continues the listing after the last task of the page.
+	// It is empty when there are no more tasks.
+	NextCursor string
+}
+
+// taskCursor identifies the last task of a page by the values of the
+// sort keys it was ordered by. It is handed to clients as an opaque
+// base64-encoded JSON string.
+type taskCursor struct {
+	Sort     string     `json:"s"`
+	Desc     bool       `json:"d,omitempty"`
+	ListRank string     `json:"l,omitempty"`
+	Rank     int        `json:"r,omitempty"`
+	Time     *time.Time `json:"t,omitempty"`
+	Title    string     `json:"n,omitempty"`
+	ID       int        `json:"id"`
+}
+
+// taskSortKey is a single expression of the ORDER BY clause.
+type taskSortKey struct {
+	expr string
+	desc bool
+}
+
+// sortName returns the effective sort of the filter.
+func (f TaskFilter) sortName() string {
+	if f.Sort == "" {
+		return TaskSortPosition
+	}
+	return f.Sort
+}
+
+// sortDesc reports whether the primary sort key is ordered descending.
+// Timestamps and priority are newest or most important first unless
+// Order says otherwise.
+func (f TaskFilter) sortDesc() bool {
+	switch f.Order {
+	case SortOrderAsc:
+		return false
+	case SortOrderDesc:
+		return true
+	}
+
+	switch f.sortName() {
+	case TaskSortPriority, TaskSortCreatedAt, TaskSortUpdatedAt:
+		return true
+	default:
+		return false
+	}
+}
+
+// sortKeys returns the ORDER BY expressions of the filter. The task ID is
+// always the last key so that the ordering is total and keyset pagination
+// never skips or repeats tasks.
+func (f TaskFilter) sortKeys() []taskSortKey {
+	desc := f.sortDesc()
+
+	switch f.sortName() {
+	case TaskSortPriority:
+		return []taskSortKey{{priorityRank, desc}, {"rank", false}, {"id", false}}
+	case TaskSortCreatedAt:
+		return []taskSortKey{{"created_at", desc}, {"id", desc}}
+	case TaskSortUpdatedAt:
+		return []taskSortKey{{"updated_at", desc}, {"id", desc}}
+	case TaskSortTitle:
+		return []taskSortKey{{"LOWER(title)", desc}, {"id", desc}}
+	default:
+		return []taskSortKey{{"rank", desc}, {"id", desc}}
+	}
+}
+
+// orderBy renders the ORDER BY clause of the filter.
+func (f TaskFilter) orderBy() string {
+	keys := f.sortKeys()
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		direction := "ASC"
+		if key.desc {
+			direction = "DESC"
+		}
+		parts[i] = key.expr + " " + direction
+	}
+	return strings.Join(parts, ", ")
+}
+
+// newTaskCursor builds the cursor that continues the listing after t.
+func (f TaskFilter) newTaskCursor(t Task) string {
+	c := taskCursor{Sort: f.sortName(), Desc: f.sortDesc(), ID: t.ID}
+
+	switch c.Sort {
+	case TaskSortPriority:
+		c.Rank = priorityIndex(t.Priority)
+		c.ListRank = t.rank
+	case TaskSortCreatedAt:
+		c.Time = &t.CreatedAt
+	case TaskSortUpdatedAt:
+		c.Time = &t.UpdatedAt
+	case TaskSortTitle:
+		c.Title = strings.ToLower(t.Title)
+	default:
+		c.ListRank = t.rank
+	}
+
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses the filter's cursor and checks that it was issued
+// for the same sort and direction.
+func (f TaskFilter) decodeCursor() (taskCursor, error) {
+	var c taskCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(f.Cursor)
+	if err != nil {
+		return c, fmt.Errorf("invalid cursor")
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("invalid cursor")
+	}
+	if c.Sort != f.sortName() || c.Desc != f.sortDesc() || c.ID <= 0 {
+		return c, fmt.Errorf("invalid cursor")
+	}
+	if (c.Sort == TaskSortCreatedAt || c.Sort == TaskSortUpdatedAt) && c.Time == nil {
+		return c, fmt.Errorf("invalid cursor")
+	}
+	if (c.Sort == TaskSortPosition || c.Sort == TaskSortPriority) && c.ListRank == "" {
+		return c, fmt.Errorf("invalid cursor")
+	}
+
+	return c, nil
+}
+
+// values returns the cursor's values in the order of the sort keys.
+func (c taskCursor) values() []interface{} {
+	switch c.Sort {
+	case TaskSortPriority:
+		return []interface{}{c.Rank, c.ListRank, c.ID}
+	case TaskSortCreatedAt, TaskSortUpdatedAt:
+		return []interface{}{*c.Time, c.ID}
+	case TaskSortTitle:
+		return []interface{}{c.Title, c.ID}
+	default:
+		return []interface{}{c.ListRank, c.ID}
+	}
+}
+
+// keysetCondition renders the condition selecting the tasks that follow
+// the cursor in the filter's ordering and appends its values to args.
+//
+// For keys k1, k2 and values v1, v2 the condition reads
+// (k1 > v1 OR (k1 = v1 AND k2 > v2)), with < for descending keys.
+func (f TaskFilter) keysetCondition(c taskCursor, args *[]interface{}) string {
+	keys := f.sortKeys()
+	values := c.values()
+
+	placeholders := make([]string, len(keys))
+	for i, value := range values {
+		*args = append(*args, value)
+		placeholders[i] = fmt.Sprintf("$%d", len(*args))
+	}
+
+	alternatives := make([]string, len(keys))
+	for i, key := range keys {
+		var terms []string
+		for j := 0; j < i; j++ {
+			terms = append(terms, keys[j].expr+" = "+placeholders[j])
+		}
+		op := " > "
+		if key.desc {
+			op = " < "
+		}
+		terms = append(terms, key.expr+op+placeholders[i])
+		alternatives[i] = "(" + strings.Join(terms, " AND ") + ")"
+	}
+
+	return "(" + strings.Join(alternatives, " OR ") + ")"
+}
+
+// priorityIndex returns the rank of a priority as computed by priorityRank.
+func priorityIndex(priority string) int {
+	for i, p := range ValidPriorities {
+		if p == priority {
+			return i
+		}
+	}
+	return 0
+}
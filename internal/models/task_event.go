@@ -0,0 +1,263 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/maxzhirnov/go-task-manager/pkg/database"
+)
+
+// Task event type constants describe the kinds of changes recorded in a
+// task's history
+const (
+	// TaskEventCreated is recorded when a task is created
+	TaskEventCreated = "created"
+
+	// TaskEventUpdated is recorded for every changed field of a task,
+	// with the old and new value of the field
+	TaskEventUpdated = "updated"
+
+	// TaskEventStatusChanged is recorded when the status of a task changes
+	TaskEventStatusChanged = "status_changed"
+
+	// TaskEventMoved is recorded when a task is reordered among its siblings
+	TaskEventMoved = "moved"
+
+	// TaskEventDeleted is recorded when a task is moved to the trash
+	TaskEventDeleted = "deleted"
+
+	// TaskEventRestored is recorded when a task is restored from the trash
+	TaskEventRestored = "restored"
+)
+
+// TaskEvent is a single entry in the history of a task.
+type TaskEvent struct {
+	// ID uniquely identifies the event
+	ID int `json:"id"`
+
+	// TaskID is the task the event belongs to
+	TaskID int `json:"task_id"`
+
+	// UserID is the user who performed the change
+	UserID int `json:"user_id"`
+
+	// Type is one of the TaskEvent constants
+	Type string `json:"type"`
+
+	// Field names the changed task field for updated, status_changed
+	// and moved events
+	Field string `json:"field,omitempty"`
+
+	// OldValue and NewValue hold the JSON-encoded field values before
+	// and after the change
+	OldValue json.RawMessage `json:"old_value,omitempty"`
+	NewValue json.RawMessage `json:"new_value,omitempty"`
+
+	// CreatedAt stores when the change happened
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// newTaskEvent builds an event of the given task, encoding the values as JSON.
+// Nil values are stored as NULL.
+func newTaskEvent(t *Task, eventType, field string, oldValue, newValue interface{}) TaskEvent {
+	return TaskEvent{
+		TaskID:    t.ID,
+		UserID:    t.UserID,
+		Type:      eventType,
+		Field:     field,
+		OldValue:  encodeEventValue(oldValue),
+		NewValue:  encodeEventValue(newValue),
+		CreatedAt: t.UpdatedAt,
+	}
+}
+
+// encodeEventValue encodes a field value for storage in a task event.
+func encodeEventValue(value interface{}) json.RawMessage {
+	if value == nil {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil || string(data) == "null" {
+		return nil
+	}
+	return data
+}
+
+// recordTaskEvents stores the events in a single statement.
+//
+// Parameters:
+//   - q: Database or transaction to write with
+//   - events: Events to store; nothing is written when empty
+//
+// Returns:
+//   - error: Database error if the insert fails
+func recordTaskEvents(q querier, events []TaskEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	values := make([]string, len(events))
+	args := make([]interface{}, 0, len(events)*7)
+	for i, e := range events {
+		n := len(args)
+		values[i] = fmt.Sprintf("($%d, $%d, $%d, NULLIF($%d, ''), $%d::jsonb, $%d::jsonb, $%d)",
+			n+1, n+2, n+3, n+4, n+5, n+6, n+7)
+		args = append(args, e.TaskID, e.UserID, e.Type, e.Field,
+			eventValueArg(e.OldValue), eventValueArg(e.NewValue), e.CreatedAt)
+	}
+
+	_, err := q.Exec(`
+        INSERT INTO task_events (task_id, user_id, event_type, field, old_value, new_value, created_at)
+        VALUES `+strings.Join(values, ", "), args...)
+	if err != nil {
+		return fmt.Errorf("failed to record task history: %w", err)
+	}
+
+	return nil
+}
+
+// eventValueArg converts an encoded value into a query argument.
+// JSON is passed as text so PostgreSQL parses it into jsonb.
+func eventValueArg(value json.RawMessage) interface{} {
+	if value == nil {
+		return nil
+	}
+	return string(value)
+}
+
+// taskChangeEvents compares two states of a task and returns an event for
+// every field that differs. Labels are only compared when the update
+// replaced them.
+func taskChangeEvents(old, updated *Task) []TaskEvent {
+	var events []TaskEvent
+	changed := func(field string, oldValue, newValue interface{}) {
+		events = append(events, newTaskEvent(updated, TaskEventUpdated, field, oldValue, newValue))
+	}
+
+	if old.Title != updated.Title {
+		changed("title", old.Title, updated.Title)
+	}
+	if old.Description != updated.Description {
+		changed("description", old.Description, updated.Description)
+	}
+	if old.Priority != updated.Priority {
+		changed("priority", old.Priority, updated.Priority)
+	}
+	if !sameInt(old.EstimatePoints, updated.EstimatePoints) {
+		changed("estimate_points", old.EstimatePoints, updated.EstimatePoints)
+	}
+	if !sameInt(old.EstimateMinutes, updated.EstimateMinutes) {
+		changed("estimate_minutes", old.EstimateMinutes, updated.EstimateMinutes)
+	}
+	if !sameTime(old.StartAt, updated.StartAt) {
+		changed("start_at", old.StartAt, updated.StartAt)
+	}
+	if !sameTime(old.DueAt, updated.DueAt) {
+		changed("due_at", old.DueAt, updated.DueAt)
+	}
+	if old.AllDay != updated.AllDay {
+		changed("all_day", old.AllDay, updated.AllDay)
+	}
+	if !sameProject(old.ProjectID, updated.ProjectID) {
+		changed("project_id", old.ProjectID, updated.ProjectID)
+	}
+	if old.RecurrenceRule != updated.RecurrenceRule {
+		changed("recurrence_rule", old.RecurrenceRule, updated.RecurrenceRule)
+	}
+	if updated.LabelIDs != nil {
+		oldLabels, newLabels := taskLabelIDs(old.Labels), taskLabelIDs(updated.Labels)
+		if fmt.Sprint(oldLabels) != fmt.Sprint(newLabels) {
+			changed("labels", oldLabels, newLabels)
+		}
+	}
+	if old.Status != updated.Status {
+		events = append(events, newTaskEvent(updated, TaskEventStatusChanged, "status", old.Status, updated.Status))
+	}
+
+	return events
+}
+
+// sameTime reports whether two optional timestamps are equal.
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// sameInt reports whether two optional numbers are equal.
+func sameInt(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// taskLabelIDs returns the sorted IDs of the labels.
+func taskLabelIDs(labels []TaskLabel) []int {
+	ids := make([]int, len(labels))
+	for i, label := range labels {
+		ids[i] = label.ID
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// GetTaskHistory retrieves the recorded changes of a task, oldest first.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: The ID of the task owner
+//   - taskID: The task whose history to retrieve
+//
+// Returns:
+//   - []TaskEvent: Events of the task; empty for tasks created before
+//     history was recorded
+//   - error: "task not found" if the user has no such task, or database errors
+//
+// Example Usage:
+//
+//	events, err := GetTaskHistory(db, userID, taskID)
+//	if err != nil {
+//	    return fmt.Errorf("failed to fetch history: %w", err)
+//	}
+func GetTaskHistory(db database.DB, userID, taskID int) ([]TaskEvent, error) {
+	var exists bool
+	err := db.QueryRow(`
+        SELECT EXISTS (
+            SELECT 1 FROM tasks WHERE id = $1 AND user_id = $2
+        )`, taskID, userID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check task: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("task not found")
+	}
+
+	rows, err := db.Query(`
+        SELECT id, task_id, user_id, event_type, COALESCE(field, ''), old_value, new_value, created_at
+        FROM task_events
+        WHERE task_id = $1
+        ORDER BY created_at ASC, id ASC`, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []TaskEvent
+	for rows.Next() {
+		var e TaskEvent
+		var oldValue, newValue []byte
+		if err := rows.Scan(&e.ID, &e.TaskID, &e.UserID, &e.Type, &e.Field,
+			&oldValue, &newValue, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.OldValue, e.NewValue = oldValue, newValue
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
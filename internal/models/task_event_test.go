@@ -0,0 +1,84 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskChangeEvents(t *testing.T) {
+	due := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	projectID := 3
+
+	old := &Task{ID: 7, UserID: 1, Title: "Task", Status: StatusPending, Priority: PriorityNone,
+		Labels: []TaskLabel{{ID: 2}, {ID: 1}}}
+	updated := &Task{ID: 7, UserID: 1, Title: "Task", Status: StatusInProgress, Priority: PriorityHigh,
+		DueAt: &due, ProjectID: &projectID, LabelIDs: []int{1, 2}, Labels: []TaskLabel{{ID: 1}, {ID: 2}}}
+
+	events := taskChangeEvents(old, updated)
+
+	type change struct{ eventType, field, oldValue, newValue string }
+	var changes []change
+	for _, e := range events {
+		assert.Equal(t, 7, e.TaskID)
+		assert.Equal(t, 1, e.UserID)
+		changes = append(changes, change{e.Type, e.Field, string(e.OldValue), string(e.NewValue)})
+	}
+
+	assert.Equal(t, []change{
+		{TaskEventUpdated, "priority", `"none"`, `"high"`},
+		{TaskEventUpdated, "due_at", "", `"2024-05-01T00:00:00Z"`},
+		{TaskEventUpdated, "project_id", "", "3"},
+		{TaskEventStatusChanged, "status", `"pending"`, `"in_progress"`},
+	}, changes)
+}
+
+func TestTaskChangeEventsUnchanged(t *testing.T) {
+	due := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	sameDue := due.In(time.FixedZone("UTC+3", 3*3600))
+
+	old := &Task{Title: "Task", Status: StatusPending, DueAt: &due, Labels: []TaskLabel{{ID: 1}}}
+	updated := &Task{Title: "Task", Status: StatusPending, DueAt: &sameDue}
+
+	assert.Empty(t, taskChangeEvents(old, updated))
+}
+
+func TestGetTaskHistory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectQuery("SELECT EXISTS \\( SELECT 1 FROM tasks WHERE id = \\$1 AND user_id = \\$2 \\)").
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery("SELECT (.+) FROM task_events WHERE task_id = \\$1 ORDER BY created_at ASC, id ASC").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "task_id", "user_id", "event_type", "field", "old_value", "new_value", "created_at"}).
+			AddRow(1, 7, 1, TaskEventCreated, "", nil, nil, now).
+			AddRow(2, 7, 1, TaskEventStatusChanged, "status", []byte(`"pending"`), []byte(`"completed"`), now))
+
+	events, err := GetTaskHistory(db, 1, 7)
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+	assert.Nil(t, events[0].OldValue)
+	assert.Equal(t, json.RawMessage(`"completed"`), events[1].NewValue)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTaskHistoryNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT EXISTS").
+		WithArgs(7, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	_, err = GetTaskHistory(db, 2, 7)
+	assert.EqualError(t, err, "task not found")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"database/sql"
+	"database/sql/driver"
 	"encoding/json"
 	"fmt"
 	"testing"
@@ -11,6 +12,46 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// taskColumnNames lists the columns returned by task SELECT queries.
+var taskColumnNames = []string{
+	"id", "title", "description", "status", "user_id", "position", "created_at", "updated_at",
+	"start_at", "due_at", "all_day", "priority", "project_id", "labels",
+	"parent_id", "subtask_count", "completed_subtasks", "recurrence_rule", "series_id",
+	"deleted_at", "started_at", "completed_at", "version", "rank", "time_zone",
+	"status_category", "blocked", "time_spent", "estimate_points", "estimate_minutes",
+}
+
+// newTaskRow builds a task row for taskColumnNames with fresh timestamps
+// no schedule, no priority, no project, no labels, no subtasks and no recurrence.
+func newTaskRow(id driver.Value, title, description, status string, userID, position int) []driver.Value {
+	return []driver.Value{
+		id, title, description, status, userID, position, time.Now(), time.Now(),
+		nil, nil, false, "none", nil, []byte("[]"),
+		nil, 0, 0, "", nil,
+		nil, nil, nil, 1, "i", "UTC",
+		testStatusCategory(status), false, int64(0), nil, nil,
+	}
+}
+
+// testStatusCategory returns the category of a status of the built-in workflow.
+func testStatusCategory(status string) string {
+	switch status {
+	case "completed":
+		return "done"
+	case "in_progress":
+		return "doing"
+	default:
+		return "todo"
+	}
+}
+
+// expectDefaultWorkflow expects the workflow lookup of a user without
+// custom statuses.
+func expectDefaultWorkflow(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("SELECT key, name, category, transitions, wip_limit FROM workflow_statuses").
+		WillReturnRows(sqlmock.NewRows([]string{"key", "name", "category", "transitions", "wip_limit"}))
+}
+
 func TestGetTasks(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -24,14 +65,12 @@ func TestGetTasks(t *testing.T) {
 			name:   "Successfully get multiple tasks",
 			userID: 1,
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{
-					"id", "title", "description", "status", "user_id", "position", "created_at", "updated_at",
-				}).
-					AddRow(1, "Task 1", "Description 1", "pending", 1, 0, time.Now(), time.Now()).
-					AddRow(2, "Task 2", "Description 2", "in_progress", 1, 1, time.Now(), time.Now())
+				rows := sqlmock.NewRows(taskColumnNames).
+					AddRow(newTaskRow(1, "Task 1", "Description 1", "pending", 1, 0)...).
+					AddRow(newTaskRow(2, "Task 2", "Description 2", "in_progress", 1, 1)...)
 
 				// Updated SQL query pattern to match the new query
-				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE user_id = \\$1 AND status != 'deleted' ORDER BY position ASC").
+				mock.ExpectQuery("SELECT (.+) OVER \\( PARTITION BY project_id, parent_id ORDER BY rank, id (.+) tasks WHERE user_id = \\$1 AND status != 'deleted' AND parent_id IS NULL ORDER BY rank ASC").
 					WithArgs(1).
 					WillReturnRows(rows)
 			},
@@ -59,11 +98,9 @@ func TestGetTasks(t *testing.T) {
 			name:   "No tasks found",
 			userID: 1,
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{
-					"id", "title", "description", "status", "user_id", "position", "created_at", "updated_at",
-				})
+				rows := sqlmock.NewRows(taskColumnNames)
 
-				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE user_id = \\$1 AND status != 'deleted' ORDER BY position ASC").
+				mock.ExpectQuery("SELECT (.+) FROM (.+) tasks WHERE user_id = \\$1 AND status != 'deleted' AND parent_id IS NULL ORDER BY rank ASC").
 					WithArgs(1).
 					WillReturnRows(rows)
 			},
@@ -74,7 +111,7 @@ func TestGetTasks(t *testing.T) {
 			name:   "Database error",
 			userID: 1,
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE user_id = \\$1 AND status != 'deleted' ORDER BY position ASC").
+				mock.ExpectQuery("SELECT (.+) FROM (.+) tasks WHERE user_id = \\$1 AND status != 'deleted' AND parent_id IS NULL ORDER BY rank ASC").
 					WithArgs(1).
 					WillReturnError(sql.ErrConnDone)
 			},
@@ -85,12 +122,10 @@ func TestGetTasks(t *testing.T) {
 			name:   "Row scan error",
 			userID: 1,
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{
-					"id", "title", "description", "status", "user_id", "position", "created_at", "updated_at",
-				}).
-					AddRow("invalid", "Task 1", "Description 1", "pending", 1, 0, time.Now(), time.Now())
+				rows := sqlmock.NewRows(taskColumnNames).
+					AddRow(newTaskRow("invalid", "Task 1", "Description 1", "pending", 1, 0)...)
 
-				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE user_id = \\$1 AND status != 'deleted' ORDER BY position ASC").
+				mock.ExpectQuery("SELECT (.+) FROM (.+) tasks WHERE user_id = \\$1 AND status != 'deleted' AND parent_id IS NULL ORDER BY rank ASC").
 					WithArgs(1).
 					WillReturnRows(rows)
 			},
@@ -113,7 +148,7 @@ func TestGetTasks(t *testing.T) {
 			tt.mockSetup(mock)
 
 			// Execute function
-			tasks, err := GetTasks(db, tt.userID)
+			tasks, err := GetTasks(db, tt.userID, TaskFilter{})
 
 			// Assert error
 			if tt.expectedError != nil {
@@ -192,13 +227,9 @@ func TestGetTask(t *testing.T) {
 			name:   "Successfully get task",
 			taskID: 1,
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				createdAt := time.Now()
-				updatedAt := time.Now()
 				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1").
 					WithArgs(1).
-					WillReturnRows(sqlmock.NewRows([]string{
-						"id", "title", "description", "status", "user_id", "position", "created_at", "updated_at",
-					}).AddRow(1, "Test Task", "Test Description", "pending", 1, 0, createdAt, updatedAt))
+					WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(newTaskRow(1, "Test Task", "Test Description", "pending", 1, 0)...))
 			},
 			expectedTask: &Task{
 				ID:          1,
@@ -238,9 +269,7 @@ func TestGetTask(t *testing.T) {
 			mockSetup: func(mock sqlmock.Sqlmock) {
 				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1").
 					WithArgs(1).
-					WillReturnRows(sqlmock.NewRows([]string{
-						"id", "title", "description", "status", "user_id", "position", "created_at", "updated_at",
-					}).AddRow("invalid", "Test Task", "Test Description", "pending", 1, 0, time.Now(), time.Now()))
+					WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(newTaskRow("invalid", "Test Task", "Test Description", "pending", 1, 0)...))
 			},
 			expectedTask:  nil,
 			expectedError: fmt.Errorf("scan error"), // We just need any error here
@@ -300,9 +329,18 @@ func TestUpdateTask(t *testing.T) {
 	defer db.Close()
 
 	// Mock the expected SQL query and result
-	mock.ExpectExec("UPDATE tasks").
-		WithArgs("Updated Task", "Updated Description", "completed", sqlmock.AnyArg(), 1).
-		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1 FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(newTaskRow(1, "Task", "Description", "pending", 1, 2)...))
+	expectDefaultWorkflow(mock)
+	mock.ExpectQuery("UPDATE tasks").
+		WithArgs("Updated Task", "Updated Description", "completed", sqlmock.AnyArg(), nil, nil, false, "high", nil, "i", "",
+			nil, sqlmock.AnyArg(), "done", nil, nil, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+	mock.ExpectExec("INSERT INTO task_events (.+) VALUES \\(\\$1, (.+)\\), \\((.+)\\), \\((.+)\\), \\(\\$22, (.+)\\)$").
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectCommit()
 
 	// Create test task
 	task := &Task{
@@ -310,6 +348,7 @@ func TestUpdateTask(t *testing.T) {
 		Title:       "Updated Task",
 		Description: "Updated Description",
 		Status:      "completed",
+		Priority:    PriorityHigh,
 	}
 
 	// Call the UpdateTask function
@@ -320,7 +359,48 @@ func TestUpdateTask(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestUpdateTaskVersionConflict(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	// The locked row is at version 1, the client edited version 3
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1 FOR UPDATE").
+		WithArgs(7).
+		WillReturnRows(lockedTaskRows(7, nil, nil, 0, "pending"))
+	mock.ExpectRollback()
+
+	task := &Task{ID: 7, UserID: 1, Title: "Task", Status: StatusPending, Priority: PriorityNone, Version: 3}
+	assert.EqualError(t, task.UpdateTask(db), "version conflict")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestUpdateTaskPosition(t *testing.T) {
+	// The inbox holds tasks 1 to 4 in this order
+	expectList := func(mock sqlmock.Sqlmock, id int) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT id, project_id, parent_id FROM tasks WHERE user_id = \\$1 AND id = ANY\\(\\$2\\)").
+			WithArgs(1, fmt.Sprintf("{%d}", id)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "project_id", "parent_id"}).AddRow(id, nil, nil))
+		mock.ExpectQuery("SELECT id, rank FROM tasks").
+			WithArgs(1, nil, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "rank"}).
+				AddRow(1, "a").
+				AddRow(2, "b").
+				AddRow(3, "c").
+				AddRow(4, "d"))
+	}
+	expectReload := func(mock sqlmock.Sqlmock, order ...int) {
+		rows := sqlmock.NewRows(taskColumnNames)
+		for position, id := range order {
+			rows.AddRow(newTaskRow(id, "Task", "", StatusPending, 1, position)...)
+		}
+		mock.ExpectQuery("SELECT (.+) FROM (.+) tasks WHERE user_id = \\$1 AND status != 'deleted' AND parent_id IS NULL AND project_id IS NULL").
+			WithArgs(1).
+			WillReturnRows(rows)
+	}
+
 	tests := []struct {
 		name        string
 		task        Task
@@ -330,52 +410,39 @@ func TestUpdateTaskPosition(t *testing.T) {
 		expectError bool
 	}{
 		{
-			name: "Move task forward",
-			task: Task{
-				ID:       1,
-				Position: 1,
-			},
+			name:        "Move task forward",
+			task:        Task{ID: 1},
 			userID:      1,
 			newPosition: 3,
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				mock.ExpectBegin()
-				mock.ExpectQuery("SELECT position FROM tasks WHERE id = \\$1 AND user_id = \\$2").
-					WithArgs(1, 1).
-					WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(1))
-				mock.ExpectExec("UPDATE tasks SET position = position - 1, updated_at = \\$1 "+
-					"WHERE user_id = \\$2 AND position > \\$3 AND position <= \\$4").
-					WithArgs(sqlmock.AnyArg(), 1, 1, 3).
-					WillReturnResult(sqlmock.NewResult(0, 2))
-				mock.ExpectExec("UPDATE tasks SET position = \\$1, updated_at = \\$2 "+
-					"WHERE id = \\$3 AND user_id = \\$4").
-					WithArgs(3, sqlmock.AnyArg(), 1, 1).
+				expectList(mock, 1)
+				// Only the moved task gets a new rank
+				mock.ExpectExec("UPDATE tasks SET rank = v.rank").
+					WithArgs("{1}", `{"e"}`, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO task_events").
+					WithArgs(1, 1, TaskEventMoved, "position", "0", "3", sqlmock.AnyArg()).
 					WillReturnResult(sqlmock.NewResult(0, 1))
 				mock.ExpectCommit()
+				expectReload(mock, 2, 3, 4, 1)
 			},
 			expectError: false,
 		},
 		{
-			name: "Move task backward",
-			task: Task{
-				ID:       1,
-				Position: 3,
-			},
+			name:        "Move task backward",
+			task:        Task{ID: 4},
 			userID:      1,
 			newPosition: 1,
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				mock.ExpectBegin()
-				mock.ExpectQuery("SELECT position FROM tasks WHERE id = \\$1 AND user_id = \\$2").
-					WithArgs(1, 1).
-					WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(3))
-				mock.ExpectExec("UPDATE tasks SET position = position \\+ 1, updated_at = \\$1 "+
-					"WHERE user_id = \\$2 AND position >= \\$3 AND position < \\$4").
-					WithArgs(sqlmock.AnyArg(), 1, 1, 3).
-					WillReturnResult(sqlmock.NewResult(0, 2))
-				mock.ExpectExec("UPDATE tasks SET position = \\$1, updated_at = \\$2 "+
-					"WHERE id = \\$3 AND user_id = \\$4").
-					WithArgs(1, sqlmock.AnyArg(), 1, 1).
+				expectList(mock, 4)
+				mock.ExpectExec("UPDATE tasks SET rank = v.rank").
+					WithArgs("{4}", `{"ai"}`, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO task_events").
+					WithArgs(4, 1, TaskEventMoved, "position", "3", "1", sqlmock.AnyArg()).
 					WillReturnResult(sqlmock.NewResult(0, 1))
 				mock.ExpectCommit()
+				expectReload(mock, 1, 4, 2, 3)
 			},
 			expectError: false,
 		},
@@ -388,82 +455,43 @@ func TestUpdateTaskPosition(t *testing.T) {
 			expectError: true,
 		},
 		{
-			name:   "Position query error",
+			name:   "Task not found",
 			task:   Task{ID: 1},
 			userID: 1,
 			mockSetup: func(mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
-				mock.ExpectQuery("SELECT position FROM tasks").
-					WithArgs(1, 1).
-					WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery("SELECT id, project_id, parent_id FROM tasks").
+					WithArgs(1, "{1}").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "project_id", "parent_id"}))
 				mock.ExpectRollback()
 			},
 			expectError: true,
 		},
 		{
-			name: "Update other tasks error",
-			task: Task{
-				ID:       1,
-				Position: 1,
-			},
+			name:        "Update rank error",
+			task:        Task{ID: 1},
 			userID:      1,
 			newPosition: 3,
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				mock.ExpectBegin()
-				mock.ExpectQuery("SELECT position FROM tasks").
-					WithArgs(1, 1).
-					WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(1))
-				mock.ExpectExec("UPDATE tasks SET position").
-					WithArgs(sqlmock.AnyArg(), 1, 1, 3).
+				expectList(mock, 1)
+				mock.ExpectExec("UPDATE tasks SET rank = v.rank").
 					WillReturnError(sql.ErrConnDone)
 				mock.ExpectRollback()
 			},
 			expectError: true,
 		},
 		{
-			name: "Update current task error",
-			task: Task{
-				ID:       1,
-				Position: 1,
-			},
+			name:        "Commit error",
+			task:        Task{ID: 1},
 			userID:      1,
 			newPosition: 3,
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				mock.ExpectBegin()
-				mock.ExpectQuery("SELECT position FROM tasks").
-					WithArgs(1, 1).
-					WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(1))
-				mock.ExpectExec("UPDATE tasks SET position = position - 1").
-					WithArgs(sqlmock.AnyArg(), 1, 1, 3).
-					WillReturnResult(sqlmock.NewResult(0, 2))
-				mock.ExpectExec("UPDATE tasks SET position = \\$1").
-					WithArgs(3, sqlmock.AnyArg(), 1, 1).
-					WillReturnError(sql.ErrConnDone)
-				mock.ExpectRollback()
-			},
-			expectError: true,
-		},
-		{
-			name: "Commit error",
-			task: Task{
-				ID:       1,
-				Position: 1,
-			},
-			userID:      1,
-			newPosition: 3,
-			mockSetup: func(mock sqlmock.Sqlmock) {
-				mock.ExpectBegin()
-				mock.ExpectQuery("SELECT position FROM tasks").
-					WithArgs(1, 1).
-					WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(1))
-				mock.ExpectExec("UPDATE tasks SET position = position - 1").
-					WithArgs(sqlmock.AnyArg(), 1, 1, 3).
-					WillReturnResult(sqlmock.NewResult(0, 2))
-				mock.ExpectExec("UPDATE tasks SET position = \\$1").
-					WithArgs(3, sqlmock.AnyArg(), 1, 1).
+				expectList(mock, 1)
+				mock.ExpectExec("UPDATE tasks SET rank = v.rank").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO task_events").
 					WillReturnResult(sqlmock.NewResult(0, 1))
 				mock.ExpectCommit().WillReturnError(sql.ErrConnDone)
-				// Removed ExpectRollback() as it's not called after commit error
 			},
 			expectError: true,
 		},
@@ -497,51 +525,69 @@ func TestUpdateTaskPosition(t *testing.T) {
 	}
 }
 
-func TestValidateStatus(t *testing.T) {
+func TestValidateTransition(t *testing.T) {
+	workflow := Workflow{Statuses: []WorkflowStatus{
+		{Key: "todo", Name: "To do", Category: StatusCategoryTodo, Transitions: []string{"doing"}},
+		{Key: "doing", Name: "Doing", Category: StatusCategoryDoing},
+		{Key: "done", Name: "Done", Category: StatusCategoryDone, Transitions: []string{}},
+	}}
+
 	tests := []struct {
-		name          string
-		status        string
-		expectedError string
+		name             string
+		status           string
+		from             string
+		expectedCategory string
+		expectedError    string
 	}{
 		{
-			name:          "Valid status - pending",
-			status:        StatusPending,
-			expectedError: "",
+			name:             "New task",
+			status:           "done",
+			expectedCategory: StatusCategoryDone,
 		},
 		{
-			name:          "Valid status - in_progress",
-			status:        StatusInProgress,
-			expectedError: "",
+			name:             "Allowed transition",
+			status:           "doing",
+			from:             "todo",
+			expectedCategory: StatusCategoryDoing,
 		},
 		{
-			name:          "Valid status - completed",
-			status:        StatusCompleted,
-			expectedError: "",
+			name:             "Unrestricted status",
+			status:           "todo",
+			from:             "doing",
+			expectedCategory: StatusCategoryTodo,
 		},
 		{
-			name:          "Empty status",
-			status:        "",
-			expectedError: "invalid status: ",
+			name:             "Unchanged status",
+			status:           "done",
+			from:             "done",
+			expectedCategory: StatusCategoryDone,
 		},
 		{
-			name:          "Invalid status",
-			status:        "invalid_status",
-			expectedError: "invalid status: invalid_status",
+			name:          "Forbidden transition",
+			status:        "done",
+			from:          "todo",
+			expectedError: "status transition not allowed",
 		},
 		{
-			name:          "Case sensitive check",
-			status:        "PENDING",
-			expectedError: "invalid status: PENDING",
+			name:          "Status outside the workflow",
+			status:        StatusPending,
+			expectedError: "invalid status: pending",
+		},
+		{
+			name:          "Empty status",
+			status:        "",
+			expectedError: "invalid status: ",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			task := &Task{Status: tt.status}
-			err := task.ValidateStatus()
+			err := task.ValidateTransition(workflow, tt.from)
 
 			if tt.expectedError == "" {
 				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedCategory, task.StatusCategory)
 			} else {
 				assert.Error(t, err)
 				assert.Equal(t, tt.expectedError, err.Error())
@@ -549,6 +595,7 @@ func TestValidateStatus(t *testing.T) {
 		})
 	}
 }
+
 func TestCreateTask(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -563,29 +610,51 @@ func TestCreateTask(t *testing.T) {
 				Title:       "Test Task",
 				Description: "Test Description",
 				Status:      "pending",
+				Priority:    PriorityMedium,
 				UserID:      1,
 			},
 			mockSetup: func(mock sqlmock.Sqlmock) {
 				// Expect transaction begin
 				mock.ExpectBegin()
 
-				// Expect update of existing tasks positions
-				mock.ExpectExec("UPDATE tasks SET position = position \\+ 1").
-					WithArgs(1).                              // userID
-					WillReturnResult(sqlmock.NewResult(0, 2)) // 2 rows affected
+				// Expect the workflow of the user
+				expectDefaultWorkflow(mock)
+
+				// Expect the rank of the first sibling
+				mock.ExpectQuery("SELECT MIN\\(rank\\) FROM tasks").
+					WithArgs(1). // userID; project_id and parent_id are NULL
+					WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow("i"))
 
 				// Expect task insertion
-				mock.ExpectQuery("INSERT INTO tasks \\(title, description, status, user_id, position, created_at, updated_at\\)").
+				mock.ExpectQuery("INSERT INTO tasks \\(title, description, status, user_id, rank, created_at, updated_at,\\s+start_at, due_at, all_day, priority, project_id, parent_id,\\s+recurrence_rule, series_id, started_at, completed_at, status_category,\\s+estimate_points, estimate_minutes\\)").
 					WithArgs(
 						"Test Task",
 						"Test Description",
 						"pending",
 						1,
-						0,
+						"h",
 						sqlmock.AnyArg(), // created_at
 						sqlmock.AnyArg(), // updated_at
+						nil,              // start_at
+						nil,              // due_at
+						false,            // all_day
+						"medium",         // priority
+						nil,              // project_id
+						nil,              // parent_id
+						"",               // recurrence_rule
+						nil,              // series_id
+						nil,              // started_at
+						nil,              // completed_at
+						"todo",           // status_category
+						nil,              // estimate_points
+						nil,              // estimate_minutes
 					).
-					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "time_zone"}).AddRow(1, "UTC"))
+
+				// Expect the creation to start the task history
+				mock.ExpectExec("INSERT INTO task_events").
+					WithArgs(1, 1, TaskEventCreated, "", nil, nil, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
 
 				// Expect transaction commit
 				mock.ExpectCommit()
@@ -599,6 +668,7 @@ func TestCreateTask(t *testing.T) {
 				Title:       "Test Task",
 				Description: "Test Description",
 				Status:      "pending",
+				Priority:    PriorityMedium,
 				UserID:      1,
 			},
 			mockSetup: func(mock sqlmock.Sqlmock) {
@@ -607,44 +677,76 @@ func TestCreateTask(t *testing.T) {
 			expectError: true,
 		},
 		{
-			name: "Position update error",
+			name: "Rank query error",
 			task: Task{
 				Title:       "Test Task",
 				Description: "Test Description",
 				Status:      "pending",
+				Priority:    PriorityMedium,
 				UserID:      1,
 			},
 			mockSetup: func(mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
-				mock.ExpectExec("UPDATE tasks SET position = position \\+ 1").
+				expectDefaultWorkflow(mock)
+				mock.ExpectQuery("SELECT MIN\\(rank\\) FROM tasks").
 					WithArgs(1).
 					WillReturnError(sql.ErrConnDone)
 				mock.ExpectRollback()
 			},
 			expectError: true,
 		},
+		{
+			name: "Status outside the workflow",
+			task: Task{
+				Title:    "Test Task",
+				Status:   "blocked",
+				Priority: PriorityMedium,
+				UserID:   1,
+			},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				expectDefaultWorkflow(mock)
+				mock.ExpectRollback()
+			},
+			expectError: true,
+		},
 		{
 			name: "Insert error",
 			task: Task{
 				Title:       "Test Task",
 				Description: "Test Description",
 				Status:      "pending",
+				Priority:    PriorityMedium,
 				UserID:      1,
 			},
 			mockSetup: func(mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
-				mock.ExpectExec("UPDATE tasks SET position = position \\+ 1").
+				expectDefaultWorkflow(mock)
+				mock.ExpectQuery("SELECT MIN\\(rank\\) FROM tasks").
 					WithArgs(1).
-					WillReturnResult(sqlmock.NewResult(0, 1))
+					WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow("i"))
 				mock.ExpectQuery("INSERT INTO tasks").
 					WithArgs(
 						"Test Task",
 						"Test Description",
 						"pending",
 						1,
-						0,
+						"h",
 						sqlmock.AnyArg(),
 						sqlmock.AnyArg(),
+						nil,
+						nil,
+						false,
+						"medium",
+						nil,
+						nil,
+						"",
+						nil,
+						nil,
+						nil,
+						"todo",
+						nil,
+						nil,
 					).
 					WillReturnError(sql.ErrConnDone)
 				mock.ExpectRollback()
@@ -690,13 +792,16 @@ func TestDeleteTask(t *testing.T) {
 	assert.NoError(t, err)
 	defer db.Close()
 
-	// Mock the expected SQL query and result
-	mock.ExpectExec("UPDATE tasks").
-		WithArgs(sqlmock.AnyArg(), 1). // Use AnyArg() for timestamp
+	// Mock the expected SQL query and result; only the owner's subtree is
+	// deleted and the deletion is recorded in the history
+	mock.ExpectExec("WHERE id = \\$2 AND user_id = \\$3 (.+) WHERE t.user_id = \\$3 \\), "+
+		"deleted AS \\( UPDATE tasks (.+) RETURNING id, user_id, previous_status \\) "+
+		"INSERT INTO task_events (.+) SELECT id, user_id, 'deleted'").
+		WithArgs(sqlmock.AnyArg(), 1, 1). // Use AnyArg() for timestamp
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
 	// Call the DeleteTask function
-	err = DeleteTask(db, 1)
+	err = DeleteTask(db, 1, 1)
 	assert.NoError(t, err)
 
 	// Ensure all expectations were met
@@ -712,14 +817,572 @@ func TestDeleteTaskNotFound(t *testing.T) {
 
 	// Mock the expected SQL query with no rows affected
 	mock.ExpectExec("UPDATE tasks").
-		WithArgs(sqlmock.AnyArg(), 1).
+		WithArgs(sqlmock.AnyArg(), 1, 1).
 		WillReturnResult(sqlmock.NewResult(0, 0))
 
 	// Call the DeleteTask function
-	err = DeleteTask(db, 1)
+	err = DeleteTask(db, 1, 1)
 	assert.Error(t, err)
 	assert.Equal(t, "task not found", err.Error())
 
 	// Ensure all expectations were met
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestDeleteTaskVersion(t *testing.T) {
+	tests := []struct {
+		name          string
+		mockSetup     func(sqlmock.Sqlmock)
+		expectedError string
+	}{
+		{
+			name: "Current version",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("SELECT id FROM tasks WHERE id = \\$2 AND user_id = \\$3 AND version = \\$4").
+					WithArgs(sqlmock.AnyArg(), 1, 1, 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name: "Stale version",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("SELECT id FROM tasks WHERE id = \\$2 AND user_id = \\$3 AND version = \\$4").
+					WithArgs(sqlmock.AnyArg(), 1, 1, 2).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("SELECT EXISTS").
+					WithArgs(1, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			},
+			expectedError: "version conflict",
+		},
+		{
+			name: "Task not found",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("SELECT id FROM tasks WHERE id = \\$2 AND user_id = \\$3 AND version = \\$4").
+					WithArgs(sqlmock.AnyArg(), 1, 1, 2).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("SELECT EXISTS").
+					WithArgs(1, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			},
+			expectedError: "task not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			tt.mockSetup(mock)
+
+			err = DeleteTaskVersion(db, 1, 1, 2)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetTasksOverdueFilter(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	due := time.Now().Add(-time.Hour)
+	row := newTaskRow(1, "Late Task", "", StatusPending, 1, 0)
+	row[9] = due
+
+	mock.ExpectQuery("SELECT (.+) FROM (.+) tasks WHERE user_id = \\$1 AND status != 'deleted' " +
+		"(.+)AND status_category != 'done' AND due_at IS NOT NULL (.+) ORDER BY rank ASC").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(row...))
+
+	tasks, err := GetTasks(db, 1, TaskFilter{Overdue: true})
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 1)
+	assert.True(t, tasks[0].Overdue)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestValidateDates(t *testing.T) {
+	start := time.Date(2024, 5, 2, 15, 30, 0, 0, time.FixedZone("UTC+3", 3*3600))
+	due := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		task          Task
+		expectedStart *time.Time
+		expectedDue   *time.Time
+		expectedError string
+	}{
+		{
+			name: "No dates",
+			task: Task{},
+		},
+		{
+			name:          "Start after due",
+			task:          Task{StartAt: &start, DueAt: &due},
+			expectedError: "start date must not be after due date",
+		},
+		{
+			name:          "Exact times converted to UTC",
+			task:          Task{StartAt: &start},
+			expectedStart: timePtr(time.Date(2024, 5, 2, 12, 30, 0, 0, time.UTC)),
+		},
+		{
+			name:          "All-day dates truncated",
+			task:          Task{StartAt: &due, DueAt: &start, AllDay: true},
+			expectedStart: timePtr(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)),
+			expectedDue:   timePtr(time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.task.ValidateDates()
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStart, tt.task.StartAt)
+			assert.Equal(t, tt.expectedDue, tt.task.DueAt)
+		})
+	}
+}
+
+func TestIsOverdue(t *testing.T) {
+	now := time.Date(2024, 5, 1, 18, 0, 0, 0, time.UTC)
+	today := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	earlier := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		task     Task
+		expected bool
+	}{
+		{"No due date", Task{Status: StatusPending}, false},
+		{"Timed task past due", Task{Status: StatusPending, DueAt: &earlier}, true},
+		{"All-day task due today", Task{Status: StatusInProgress, DueAt: &today, AllDay: true}, false},
+		{"Completed task past due", Task{Status: StatusCompleted, StatusCategory: StatusCategoryDone, DueAt: &earlier}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.task.IsOverdue(now))
+		})
+	}
+}
+
+func TestIsOverdueInTimeZone(t *testing.T) {
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	newYork, _ := time.LoadLocation("America/New_York")
+	dueDay := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	task := Task{Status: StatusPending, DueAt: &dueDay, AllDay: true}
+
+	// 2024-05-02 01:00 UTC is already May 2 in Tokyo but still May 1 in New York
+	now := time.Date(2024, 5, 2, 1, 0, 0, 0, time.UTC)
+	assert.True(t, task.IsOverdue(now.In(tokyo)))
+	assert.False(t, task.IsOverdue(now.In(newYork)))
+	assert.True(t, task.IsOverdue(now))
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestGetTasksPriorityFilterAndSort(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	urgent := newTaskRow(2, "Urgent Task", "", StatusPending, 1, 1)
+	urgent[11] = PriorityUrgent
+	high := newTaskRow(1, "High Task", "", StatusPending, 1, 0)
+	high[11] = PriorityHigh
+
+	mock.ExpectQuery("SELECT (.+) FROM (.+) tasks WHERE user_id = \\$1 AND status != 'deleted' "+
+		"AND parent_id IS NULL AND priority = ANY\\(\\$2\\) ORDER BY CASE priority (.+) END DESC, rank ASC").
+		WithArgs(1, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(urgent...).AddRow(high...))
+
+	tasks, err := GetTasks(db, 1, TaskFilter{
+		Priorities: []string{PriorityHigh, PriorityUrgent},
+		Sort:       TaskSortPriority,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 2)
+	assert.Equal(t, PriorityUrgent, tasks[0].Priority)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestValidatePriority(t *testing.T) {
+	for _, priority := range ValidPriorities {
+		task := &Task{Priority: priority}
+		assert.NoError(t, task.ValidatePriority())
+	}
+
+	task := &Task{Priority: "critical"}
+	assert.EqualError(t, task.ValidatePriority(), "invalid priority: critical")
+
+	task = &Task{}
+	assert.EqualError(t, task.ValidatePriority(), "invalid priority: ")
+}
+
+func TestTaskFilterValidate(t *testing.T) {
+	assert.NoError(t, TaskFilter{}.Validate())
+	assert.NoError(t, TaskFilter{Priorities: []string{PriorityLow}, Sort: TaskSortPriority}.Validate())
+	assert.EqualError(t, TaskFilter{Priorities: []string{"top"}}.Validate(), "invalid priority: top")
+	assert.EqualError(t, TaskFilter{Sort: "random"}.Validate(), "invalid sort: random")
+}
+
+func TestCreateSubtask(t *testing.T) {
+	tests := []struct {
+		name          string
+		depth         int
+		expectedError string
+	}{
+		{
+			name:  "Within max depth",
+			depth: 1,
+		},
+		{
+			name:          "Max depth exceeded",
+			depth:         MaxTaskDepth + 1,
+			expectedError: "maximum subtask depth exceeded",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			projectID := 3
+			mock.ExpectBegin()
+			expectDefaultWorkflow(mock)
+			mock.ExpectQuery("SELECT project_id FROM tasks WHERE id = \\$1 AND user_id = \\$2 AND status != 'deleted'").
+				WithArgs(5, 1).
+				WillReturnRows(sqlmock.NewRows([]string{"project_id"}).AddRow(projectID))
+			mock.ExpectQuery("WITH RECURSIVE ancestors AS (.+) SELECT COUNT\\(\\*\\) FROM ancestors").
+				WithArgs(5).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.depth))
+			if tt.expectedError != "" {
+				mock.ExpectRollback()
+			} else {
+				mock.ExpectQuery("SELECT MIN\\(rank\\) FROM tasks").
+					WithArgs(1, projectID, sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow("i"))
+				mock.ExpectQuery("INSERT INTO tasks").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "time_zone"}).AddRow(6, "UTC"))
+				mock.ExpectExec("INSERT INTO task_events").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			}
+
+			parentID := 5
+			task := &Task{Title: "Step", Status: StatusPending, Priority: PriorityNone, UserID: 1, ParentID: &parentID}
+			err = task.CreateTask(db)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, 3, *task.ProjectID)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCreateSubtaskParentNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	expectDefaultWorkflow(mock)
+	mock.ExpectQuery("SELECT project_id FROM tasks").
+		WithArgs(5, 1).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	parentID := 5
+	task := &Task{Title: "Step", Status: StatusPending, Priority: PriorityNone, UserID: 1, ParentID: &parentID}
+	assert.EqualError(t, task.CreateTask(db), "parent task not found")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetSubtasksNested(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	child := newTaskRow(2, "Child", "", "completed", 1, 0)
+	child[14] = 1
+	second := newTaskRow(4, "Second child", "", "pending", 1, 1)
+	second[14] = 1
+	grandchild := newTaskRow(3, "Grandchild", "", "pending", 1, 0)
+	grandchild[14] = 2
+
+	mock.ExpectQuery("WITH RECURSIVE subtree AS (.+) ORDER BY subtree.path").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(append(taskColumnNames, "depth")).
+			AddRow(append(child, 1)...).
+			AddRow(append(grandchild, 2)...).
+			AddRow(append(second, 1)...))
+
+	subtasks, err := GetSubtasks(db, 1)
+	assert.NoError(t, err)
+	assert.Len(t, subtasks, 3)
+	assert.Equal(t, 2, subtasks[1].Depth)
+	assert.Equal(t, 100, subtasks[0].Progress)
+
+	root := &Task{ID: 1}
+	root.NestSubtasks(subtasks)
+	assert.Len(t, root.Subtasks, 2)
+	assert.Equal(t, "Child", root.Subtasks[0].Title)
+	assert.Len(t, root.Subtasks[0].Subtasks, 1)
+	assert.Equal(t, "Grandchild", root.Subtasks[0].Subtasks[0].Title)
+	assert.Empty(t, root.Subtasks[1].Subtasks)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTaskProgress(t *testing.T) {
+	tests := []struct {
+		name     string
+		task     Task
+		expected int
+	}{
+		{"No subtasks pending", Task{Status: StatusPending}, 0},
+		{"No subtasks completed", Task{Status: StatusCompleted, StatusCategory: StatusCategoryDone}, 100},
+		{"Partially completed", Task{Status: StatusPending, SubtaskCount: 3, CompletedSubtasks: 1}, 33},
+		{"All subtasks completed", Task{Status: StatusInProgress, SubtaskCount: 2, CompletedSubtasks: 2}, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.task.computeProgress())
+		})
+	}
+}
+
+func TestUpdateTaskSpawnsNextOccurrence(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	due := time.Now().AddDate(0, 0, -1).Truncate(time.Second).UTC()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1 FOR UPDATE").
+		WithArgs(7).
+		WillReturnRows(lockedTaskRows(7, nil, nil, 0, "pending"))
+	expectDefaultWorkflow(mock)
+	mock.ExpectQuery("UPDATE tasks SET title").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+	mock.ExpectExec("INSERT INTO task_events").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT EXISTS \\( SELECT 1 FROM tasks WHERE \\(id = \\$1 OR series_id = \\$1\\)").
+		WithArgs(7, 7).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	expectDefaultWorkflow(mock)
+	mock.ExpectQuery("SELECT MIN\\(rank\\) FROM tasks").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow("i"))
+	mock.ExpectQuery("INSERT INTO tasks").
+		WithArgs("Water plants", "", StatusPending, 1, "h", sqlmock.AnyArg(), sqlmock.AnyArg(),
+			nil, sqlmock.AnyArg(), false, PriorityNone, nil, nil, "FREQ=DAILY;INTERVAL=2", sqlmock.AnyArg(),
+			nil, nil, StatusCategoryTodo, nil, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "time_zone"}).AddRow(8, "UTC"))
+	mock.ExpectExec("INSERT INTO task_events").
+		WithArgs(8, 1, TaskEventCreated, "", nil, nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	task := &Task{ID: 7, UserID: 1, Title: "Water plants", Status: StatusCompleted, Priority: PriorityNone,
+		DueAt: &due, RecurrenceRule: "FREQ=DAILY;INTERVAL=2"}
+	assert.NoError(t, task.UpdateTask(db))
+
+	if assert.NotNil(t, task.NextOccurrence) {
+		assert.Equal(t, 8, task.NextOccurrence.ID)
+		assert.Equal(t, StatusPending, task.NextOccurrence.Status)
+		assert.Equal(t, due.AddDate(0, 0, 2), *task.NextOccurrence.DueAt)
+		assert.Equal(t, 7, *task.NextOccurrence.SeriesID)
+	}
+	assert.Equal(t, 7, *task.SeriesID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateTaskSkipsActiveOccurrence(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	seriesID := 3
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1 FOR UPDATE").
+		WithArgs(7).
+		WillReturnRows(lockedTaskRows(7, nil, nil, 0, "in_progress"))
+	expectDefaultWorkflow(mock)
+	mock.ExpectQuery("UPDATE tasks SET title").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+	mock.ExpectExec("INSERT INTO task_events").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT EXISTS").
+		WithArgs(3, 7).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectCommit()
+
+	task := &Task{ID: 7, UserID: 1, Title: "Water plants", Status: StatusCompleted, Priority: PriorityNone,
+		RecurrenceRule: "FREQ=WEEKLY", SeriesID: &seriesID}
+	assert.NoError(t, task.UpdateTask(db))
+	assert.Nil(t, task.NextOccurrence)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteTaskSeries(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec("WITH RECURSIVE series AS (.+) UPDATE tasks SET previous_status = status, status = 'deleted'").
+		WithArgs(sqlmock.AnyArg(), 7, 1).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectExec("WITH RECURSIVE series AS").
+		WithArgs(sqlmock.AnyArg(), 9, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	deleted, err := DeleteTaskSeries(db, 1, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), deleted)
+
+	_, err = DeleteTaskSeries(db, 1, 9)
+	assert.EqualError(t, err, "task not found")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTaskPage(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	created := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows(taskColumnNames)
+	for id := 3; id >= 1; id-- {
+		row := newTaskRow(id, fmt.Sprintf("Task %d", id), "", StatusPending, 1, id)
+		row[6] = created.Add(time.Duration(id) * time.Hour)
+		rows.AddRow(row...)
+	}
+
+	filter := TaskFilter{Sort: TaskSortCreatedAt, Limit: 2}
+	mock.ExpectQuery("SELECT (.+) FROM (.+) tasks WHERE user_id = \\$1 AND status != 'deleted' AND parent_id IS NULL "+
+		"ORDER BY created_at DESC, id DESC LIMIT \\$2").
+		WithArgs(1, 3).
+		WillReturnRows(rows)
+
+	page, err := GetTaskPage(db, 1, filter)
+	assert.NoError(t, err)
+	assert.Len(t, page.Tasks, 2)
+	assert.Equal(t, 2, page.Tasks[1].ID)
+	assert.NotEmpty(t, page.NextCursor)
+
+	// The next page continues after task 2
+	filter.Cursor = page.NextCursor
+	assert.NoError(t, filter.Validate())
+	mock.ExpectQuery("SELECT (.+) FROM (.+) tasks WHERE user_id = \\$1 AND status != 'deleted' AND parent_id IS NULL "+
+		"AND \\(\\(created_at < \\$2\\) OR \\(created_at = \\$2 AND id < \\$3\\)\\) "+
+		"ORDER BY created_at DESC, id DESC LIMIT \\$4").
+		WithArgs(1, created.Add(2*time.Hour), 2, 3).
+		WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(newTaskRow(1, "Task 1", "", StatusPending, 1, 1)...))
+
+	page, err = GetTaskPage(db, 1, filter)
+	assert.NoError(t, err)
+	assert.Len(t, page.Tasks, 1)
+	assert.Empty(t, page.NextCursor)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTasksStatusRangeAndTextFilter(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	after := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	before := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT (.+) FROM (.+) tasks WHERE user_id = \\$1 AND status != 'deleted' AND parent_id IS NULL "+
+		"AND status = ANY\\(\\$2\\) AND created_at >= \\$3 AND updated_at < \\$4 "+
+		"AND \\(title ILIKE \\$5 OR description ILIKE \\$5\\) ORDER BY LOWER\\(title\\) ASC, id ASC").
+		WithArgs(1, sqlmock.AnyArg(), after, before, `%50\%%`).
+		WillReturnRows(sqlmock.NewRows(taskColumnNames))
+
+	tasks, err := GetTasks(db, 1, TaskFilter{
+		Statuses:      []string{StatusPending, StatusInProgress},
+		CreatedAfter:  &after,
+		UpdatedBefore: &before,
+		Query:         " 50% ",
+		Sort:          TaskSortTitle,
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, tasks)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTaskFilterValidatePagination(t *testing.T) {
+	positionCursor := TaskFilter{}.newTaskCursor(Task{ID: 4, Position: 3, rank: "k"})
+	after := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	before := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		filter        TaskFilter
+		expectedError string
+	}{
+		{"Matching cursor", TaskFilter{Cursor: positionCursor, Limit: 10}, ""},
+		{"Cursor of another sort", TaskFilter{Cursor: positionCursor, Sort: TaskSortTitle}, "invalid cursor"},
+		{"Cursor of another order", TaskFilter{Cursor: positionCursor, Order: SortOrderDesc}, "invalid cursor"},
+		{"Malformed cursor", TaskFilter{Cursor: "not-a-cursor"}, "invalid cursor"},
+		{"Limit too large", TaskFilter{Limit: MaxTaskPageSize + 1}, "limit must be between 1 and 200"},
+		{"Deleted status", TaskFilter{Statuses: []string{StatusDeleted}}, "invalid status: deleted"},
+		{"Invalid order", TaskFilter{Order: "up"}, "invalid order: up"},
+		{"Empty range", TaskFilter{CreatedAfter: &after, CreatedBefore: &before}, "created_after must be before created_before"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Validate()
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.expectedError)
+		})
+	}
+}
+
+func TestTaskFilterKeysetCondition(t *testing.T) {
+	filter := TaskFilter{Sort: TaskSortPriority}
+	cursor := taskCursor{Sort: TaskSortPriority, Desc: true, Rank: 3, ListRank: "i", ID: 9}
+
+	var args []interface{}
+	condition := filter.keysetCondition(cursor, &args)
+
+	assert.Equal(t, "(("+priorityRank+" < $1) OR ("+priorityRank+" = $1 AND rank > $2) OR ("+
+		priorityRank+" = $1 AND rank = $2 AND id > $3))", condition)
+	assert.Equal(t, []interface{}{3, "i", 9}, args)
+}
+
+// lockedTaskRows returns the row read by UpdateTask when it locks a task.
+func lockedTaskRows(id int, projectID, parentID interface{}, position int, status string) *sqlmock.Rows {
+	row := newTaskRow(id, "Task", "", status, 1, position)
+	row[12] = projectID
+	row[14] = parentID
+	return sqlmock.NewRows(taskColumnNames).AddRow(row...)
+}
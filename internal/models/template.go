@@ -0,0 +1,461 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/maxzhirnov/go-task-manager/pkg/database"
+)
+
+// MaxTemplateTasks limits the number of tasks a template creates, counting
+// the top-level task and all of its subtasks.
+const MaxTemplateTasks = 100
+
+// TemplateTask describes a task created from a template together with its
+// subtasks.
+type TemplateTask struct {
+	// Title is the title of the created task
+	Title string `json:"title"`
+
+	// Description is the description of the created task
+	Description string `json:"description"`
+
+	// Status is the status the created task starts in. Empty, or a status
+	// the owner's workflow no longer has, starts it in the first todo status.
+	Status string `json:"status"`
+
+	// Priority is the priority of the created task, one of ValidPriorities
+	Priority string `json:"priority"`
+
+	// LabelIDs lists the labels attached to the created task. Labels
+	// deleted since the template was saved are skipped.
+	LabelIDs []int `json:"label_ids"`
+
+	// Subtasks describes the subtasks of the created task in list order
+	Subtasks []TemplateTask `json:"subtasks"`
+}
+
+// TaskTemplate is a reusable task tree owned by a user, such as an
+// onboarding or release checklist. Instantiating it creates the top-level
+// task and all of its subtasks at once.
+type TaskTemplate struct {
+	// ID uniquely identifies the template
+	ID int `json:"id"`
+
+	// UserID associates the template with its owner
+	UserID int `json:"user_id"`
+
+	// Name is the display name, unique per user (case-insensitive)
+	Name string `json:"name"`
+
+	// TemplateTask describes the top-level task and its subtasks
+	TemplateTask
+
+	// CreatedAt stores the timestamp when the template was created
+	CreatedAt time.Time `json:"created_at"`
+
+	// UpdatedAt stores the timestamp of the last modification
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Validate checks the template's name and task tree, trimming names and
+// titles and assigning PriorityNone to tasks without a priority.
+//
+// Returns:
+//   - nil: If the template is valid
+//   - error: Describing the first invalid field
+func (t *TaskTemplate) Validate() error {
+	t.Name = strings.TrimSpace(t.Name)
+	if t.Name == "" {
+		return fmt.Errorf("template name is required")
+	}
+	if len(t.Name) > 100 {
+		return fmt.Errorf("template name must not exceed 100 characters")
+	}
+
+	count := 0
+	return t.TemplateTask.validate(0, &count)
+}
+
+// validate checks a task of a template at the given depth below the
+// top-level task and its subtasks, counting the tasks in count.
+func (n *TemplateTask) validate(depth int, count *int) error {
+	*count++
+	if *count > MaxTemplateTasks {
+		return fmt.Errorf("template must not exceed %d tasks", MaxTemplateTasks)
+	}
+	if depth > MaxTaskDepth {
+		return fmt.Errorf("maximum subtask depth exceeded")
+	}
+
+	n.Title = strings.TrimSpace(n.Title)
+	if n.Title == "" {
+		return fmt.Errorf("template task title is required")
+	}
+	if n.Priority == "" {
+		n.Priority = PriorityNone
+	}
+	if !isValidPriority(n.Priority) {
+		return fmt.Errorf("invalid priority: %s", n.Priority)
+	}
+	if n.Status != "" && !isValidStatusKey(n.Status) {
+		return fmt.Errorf("invalid status: %s", n.Status)
+	}
+
+	n.LabelIDs = uniqueInts(n.LabelIDs)
+	if n.Subtasks == nil {
+		n.Subtasks = []TemplateTask{}
+	}
+	for i := range n.Subtasks {
+		if err := n.Subtasks[i].validate(depth+1, count); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// labelIDs returns the labels used anywhere in the task tree.
+func (n TemplateTask) labelIDs() []int {
+	ids := append([]int{}, n.LabelIDs...)
+	for _, s := range n.Subtasks {
+		ids = append(ids, s.labelIDs()...)
+	}
+	return uniqueInts(ids)
+}
+
+// templateTaskFrom describes task and the subtasks nested below it by
+// NestSubtasks. Statuses of done tasks are not copied, so a template saved
+// from a finished checklist starts over.
+func templateTaskFrom(task Task) TemplateTask {
+	n := TemplateTask{
+		Title:       task.Title,
+		Description: task.Description,
+		Priority:    task.Priority,
+		LabelIDs:    []int{},
+		Subtasks:    []TemplateTask{},
+	}
+	if task.StatusCategory != StatusCategoryDone {
+		n.Status = task.Status
+	}
+	for _, label := range task.Labels {
+		n.LabelIDs = append(n.LabelIDs, label.ID)
+	}
+	for _, subtask := range task.Subtasks {
+		n.Subtasks = append(n.Subtasks, templateTaskFrom(subtask))
+	}
+	return n
+}
+
+// templateColumns lists the template columns read by scanTemplate.
+const templateColumns = `id, user_id, name, task, created_at, updated_at`
+
+// scanTemplate reads a single row selected with templateColumns.
+func scanTemplate(row rowScanner) (TaskTemplate, error) {
+	var t TaskTemplate
+	var task []byte
+	if err := row.Scan(&t.ID, &t.UserID, &t.Name, &task, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return TaskTemplate{}, err
+	}
+	if err := json.Unmarshal(task, &t.TemplateTask); err != nil {
+		return TaskTemplate{}, fmt.Errorf("failed to decode template: %w", err)
+	}
+	return t, nil
+}
+
+// GetTemplates retrieves all templates owned by a user ordered by name.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: The ID of the user whose templates to retrieve
+//
+// Returns:
+//   - []TaskTemplate: Slice of templates belonging to the user
+//   - error: Database error if query fails
+func GetTemplates(db database.DB, userID int) ([]TaskTemplate, error) {
+	rows, err := db.Query(`
+        SELECT `+templateColumns+`
+        FROM task_templates
+        WHERE user_id = $1
+        ORDER BY LOWER(name) ASC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []TaskTemplate
+	for rows.Next() {
+		t, err := scanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+
+	return templates, rows.Err()
+}
+
+// GetTemplate retrieves a single template owned by the given user.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: The ID of the template owner
+//   - id: The unique identifier of the template
+//
+// Returns:
+//   - TaskTemplate: The requested template
+//   - error: sql.ErrNoRows if the template doesn't exist or belongs to another user
+func GetTemplate(db database.DB, userID, id int) (TaskTemplate, error) {
+	return getTemplate(db, userID, id)
+}
+
+// getTemplate performs GetTemplate with the given querier.
+func getTemplate(q querier, userID, id int) (TaskTemplate, error) {
+	return scanTemplate(q.QueryRow(`
+        SELECT `+templateColumns+`
+        FROM task_templates
+        WHERE id = $1 AND user_id = $2`, id, userID))
+}
+
+// NewTemplateFromTask describes an existing task and its subtasks as a
+// template. The template is not saved; see CreateTemplate.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: ID of the task owner
+//   - taskID: The top-level task of the template
+//   - name: Name of the new template
+//
+// Returns:
+//   - TaskTemplate: The unsaved template
+//   - error: "task not found" if the task doesn't exist, is deleted or
+//     belongs to another user, or database errors
+//
+// Example Usage:
+//
+//	template, err := NewTemplateFromTask(db, userID, taskID, "Release checklist")
+//	if err != nil {
+//	    return fmt.Errorf("failed to describe task: %w", err)
+//	}
+//	if err := template.Validate(); err != nil {
+//	    return err
+//	}
+//	err = template.CreateTemplate(db)
+func NewTemplateFromTask(db database.DB, userID, taskID int, name string) (TaskTemplate, error) {
+	task, err := GetTask(db, taskID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return TaskTemplate{}, fmt.Errorf("task not found")
+		}
+		return TaskTemplate{}, fmt.Errorf("failed to get task: %w", err)
+	}
+	if task.UserID != userID || task.Status == StatusDeleted {
+		return TaskTemplate{}, fmt.Errorf("task not found")
+	}
+
+	subtasks, err := GetSubtasks(db, taskID)
+	if err != nil {
+		return TaskTemplate{}, fmt.Errorf("failed to get subtasks: %w", err)
+	}
+	task.NestSubtasks(subtasks)
+
+	return TaskTemplate{UserID: userID, Name: name, TemplateTask: templateTaskFrom(task)}, nil
+}
+
+// CreateTemplate inserts a new template for t.UserID. The template should
+// be validated first.
+//
+// Returns:
+//   - error: "label not found" if the template uses a label of another
+//     user, "template already exists" if the user has a template with the
+//     same name, or other database errors
+//
+// Side Effects:
+//   - Sets t.ID, t.CreatedAt and t.UpdatedAt
+func (t *TaskTemplate) CreateTemplate(db database.DB) error {
+	if ids := t.labelIDs(); len(ids) > 0 {
+		var found int
+		err := db.QueryRow(`
+            SELECT COUNT(*) FROM labels WHERE user_id = $1 AND id = ANY($2)`,
+			t.UserID, pq.Array(ids)).Scan(&found)
+		if err != nil {
+			return fmt.Errorf("failed to verify labels: %w", err)
+		}
+		if found != len(ids) {
+			return fmt.Errorf("label not found")
+		}
+	}
+
+	task, err := json.Marshal(t.TemplateTask)
+	if err != nil {
+		return fmt.Errorf("failed to encode template: %w", err)
+	}
+
+	t.CreatedAt = time.Now()
+	t.UpdatedAt = t.CreatedAt
+
+	err = db.QueryRow(`
+        INSERT INTO task_templates (user_id, name, task, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id`, t.UserID, t.Name, task, t.CreatedAt, t.UpdatedAt).Scan(&t.ID)
+	if err != nil {
+		return templateWriteError(err, "failed to create template")
+	}
+
+	return nil
+}
+
+// DeleteTemplate removes a template of the given user. Tasks created from
+// the template are kept.
+//
+// Returns:
+//   - error: "template not found" if the user owns no such template, or database errors
+func DeleteTemplate(db database.DB, userID, id int) error {
+	result, err := db.Exec(`DELETE FROM task_templates WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("template not found")
+	}
+
+	return nil
+}
+
+// InstantiateTemplate creates the task tree of a template in a single
+// transaction: the top-level task at the top of the project or inbox and
+// its subtasks in template order, each created like CreateTask.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: ID of the template owner
+//   - id: ID of the template
+//   - projectID: Project of the new tasks, nil for the inbox
+//
+// Returns:
+//   - Task: The top-level task with its subtasks nested in Subtasks
+//   - error: "template not found", "project not found",
+//     "maximum subtask depth exceeded", "wip limit reached: ..." or
+//     database errors; nothing is created on error
+//
+// Example Usage:
+//
+//	task, err := InstantiateTemplate(db, userID, templateID, &projectID)
+//	if err != nil {
+//	    return fmt.Errorf("failed to instantiate template: %w", err)
+//	}
+func InstantiateTemplate(db database.DB, userID, id int, projectID *int) (Task, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return Task{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback in case of error
+
+	template, err := getTemplate(tx, userID, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Task{}, fmt.Errorf("template not found")
+		}
+		return Task{}, fmt.Errorf("failed to get template: %w", err)
+	}
+
+	workflow, err := getWorkflow(tx, userID)
+	if err != nil {
+		return Task{}, err
+	}
+
+	// Skip labels deleted since the template was saved
+	labels := make(map[int]bool)
+	if ids := template.labelIDs(); len(ids) > 0 {
+		rows, err := tx.Query(`
+            SELECT id FROM labels WHERE user_id = $1 AND id = ANY($2)`,
+			userID, pq.Array(ids))
+		if err != nil {
+			return Task{}, fmt.Errorf("failed to load labels: %w", err)
+		}
+		for rows.Next() {
+			var labelID int
+			if err := rows.Scan(&labelID); err != nil {
+				rows.Close()
+				return Task{}, fmt.Errorf("failed to load labels: %w", err)
+			}
+			labels[labelID] = true
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return Task{}, fmt.Errorf("failed to load labels: %w", err)
+		}
+	}
+
+	task, err := template.TemplateTask.instantiate(tx, workflow, labels, userID, projectID, nil)
+	if err != nil {
+		return Task{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Task{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return task, nil
+}
+
+// instantiate creates the task described by n below parentID, or in
+// projectID for a top-level task, followed by its subtasks. Only labels
+// in labels are attached.
+func (n TemplateTask) instantiate(q querier, w Workflow, labels map[int]bool, userID int, projectID, parentID *int) (Task, error) {
+	task := Task{
+		UserID:      userID,
+		Title:       n.Title,
+		Description: n.Description,
+		Priority:    n.Priority,
+		ProjectID:   projectID,
+		ParentID:    parentID,
+		LabelIDs:    []int{},
+	}
+	if _, ok := w.Status(n.Status); ok {
+		task.Status = n.Status
+	}
+	for _, labelID := range n.LabelIDs {
+		if labels[labelID] {
+			task.LabelIDs = append(task.LabelIDs, labelID)
+		}
+	}
+	if err := task.createTask(q); err != nil {
+		return Task{}, err
+	}
+
+	// New tasks go to the top of their list, so the subtasks are created
+	// from the last to the first to keep their order
+	task.Subtasks = make([]Task, len(n.Subtasks))
+	for i := len(n.Subtasks) - 1; i >= 0; i-- {
+		subtask, err := n.Subtasks[i].instantiate(q, w, labels, userID, nil, &task.ID)
+		if err != nil {
+			return Task{}, err
+		}
+		task.Subtasks[i] = subtask
+		if subtask.StatusCategory == StatusCategoryDone {
+			task.CompletedSubtasks++
+		}
+	}
+	task.SubtaskCount = len(n.Subtasks)
+	task.Progress = task.computeProgress()
+
+	return task, nil
+}
+
+// templateWriteError translates unique violations on the template name.
+func templateWriteError(err error, message string) error {
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return fmt.Errorf("template already exists")
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
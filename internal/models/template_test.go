@@ -0,0 +1,235 @@
+package models
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var templateColumnNames = []string{"id", "user_id", "name", "task", "created_at", "updated_at"}
+
+// expectTemplateTaskInsert expects the insertion of a task created from a
+// template, returning id.
+func expectTemplateTaskInsert(mock sqlmock.Sqlmock, title string, id int) {
+	args := []driver.Value{title}
+	for i := 0; i < 19; i++ {
+		args = append(args, sqlmock.AnyArg())
+	}
+	mock.ExpectQuery("INSERT INTO tasks").
+		WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "time_zone"}).AddRow(id, "UTC"))
+}
+
+func TestTaskTemplateValidate(t *testing.T) {
+	deep := TemplateTask{Title: "Level 0"}
+	node := &deep
+	for i := 1; i <= MaxTaskDepth+1; i++ {
+		node.Subtasks = []TemplateTask{{Title: "Level"}}
+		node = &node.Subtasks[0]
+	}
+
+	tests := []struct {
+		name     string
+		template TaskTemplate
+		wantErr  string
+	}{
+		{"Valid", TaskTemplate{Name: " Release ", TemplateTask: TemplateTask{Title: "Release", Subtasks: []TemplateTask{{Title: "Tag"}}}}, ""},
+		{"Missing name", TaskTemplate{Name: " ", TemplateTask: TemplateTask{Title: "Release"}}, "template name is required"},
+		{"Long name", TaskTemplate{Name: strings.Repeat("a", 101), TemplateTask: TemplateTask{Title: "Release"}}, "template name must not exceed 100 characters"},
+		{"Subtask without title", TaskTemplate{Name: "Release", TemplateTask: TemplateTask{Title: "Release", Subtasks: []TemplateTask{{Title: " "}}}}, "template task title is required"},
+		{"Invalid priority", TaskTemplate{Name: "Release", TemplateTask: TemplateTask{Title: "Release", Priority: "critical"}}, "invalid priority: critical"},
+		{"Deleted status", TaskTemplate{Name: "Release", TemplateTask: TemplateTask{Title: "Release", Status: StatusDeleted}}, "invalid status: deleted"},
+		{"Too deep", TaskTemplate{Name: "Release", TemplateTask: deep}, "maximum subtask depth exceeded"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.template.Validate()
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "Release", tt.template.Name)
+			assert.Equal(t, PriorityNone, tt.template.Subtasks[0].Priority)
+			assert.Equal(t, []int{}, tt.template.Subtasks[0].LabelIDs)
+			assert.Equal(t, []TemplateTask{}, tt.template.Subtasks[0].Subtasks)
+		})
+	}
+}
+
+func TestNewTemplateFromTask(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	root := newTaskRow(1, "Onboarding", "New hire", "in_progress", 1, 0)
+	root[13] = []byte(`[{"id": 4, "name": "hr", "color": "#808080"}]`)
+	child := newTaskRow(2, "Laptop", "", "completed", 1, 0)
+	child[14] = 1
+	mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(root...))
+	mock.ExpectQuery("WITH RECURSIVE subtree AS").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(append(taskColumnNames, "depth")).AddRow(append(child, 1)...))
+
+	template, err := NewTemplateFromTask(db, 1, 1, "Onboarding")
+	assert.NoError(t, err)
+	assert.Equal(t, "Onboarding", template.Name)
+	assert.Equal(t, "in_progress", template.Status)
+	assert.Equal(t, []int{4}, template.LabelIDs)
+	assert.Len(t, template.Subtasks, 1)
+	assert.Equal(t, "Laptop", template.Subtasks[0].Title)
+	// Done tasks start over
+	assert.Equal(t, "", template.Subtasks[0].Status)
+
+	// Tasks of other users can't be copied
+	mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(root...))
+	_, err = NewTemplateFromTask(db, 2, 1, "Onboarding")
+	assert.EqualError(t, err, "task not found")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateTemplate(t *testing.T) {
+	template := TaskTemplate{
+		UserID:       1,
+		Name:         "Release",
+		TemplateTask: TemplateTask{Title: "Release", LabelIDs: []int{4}, Subtasks: []TemplateTask{{Title: "Tag", LabelIDs: []int{4, 9}}}},
+	}
+
+	t.Run("Creates template", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM labels WHERE user_id = \\$1 AND id = ANY\\(\\$2\\)").
+			WithArgs(1, "{4,9}").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+		mock.ExpectQuery("INSERT INTO task_templates").
+			WithArgs(1, "Release", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+
+		created := template
+		assert.NoError(t, created.CreateTemplate(db))
+		assert.Equal(t, 3, created.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Label of another user", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM labels").
+			WithArgs(1, "{4,9}").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+		created := template
+		assert.EqualError(t, created.CreateTemplate(db), "label not found")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestInstantiateTemplate(t *testing.T) {
+	t.Run("Creates the task tree", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		// Label 9 has been deleted and the workflow has no "review" status
+		task := `{"title": "Release", "status": "review", "priority": "high", "label_ids": [4, 9],
+			"subtasks": [{"title": "Tag", "priority": "none", "label_ids": [], "subtasks": []},
+			             {"title": "Announce", "priority": "none", "label_ids": [], "subtasks": []}]}`
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT (.+) FROM task_templates WHERE id = \\$1 AND user_id = \\$2").
+			WithArgs(3, 1).
+			WillReturnRows(sqlmock.NewRows(templateColumnNames).
+				AddRow(3, 1, "Release", []byte(task), time.Now(), time.Now()))
+		expectDefaultWorkflow(mock)
+		mock.ExpectQuery("SELECT id FROM labels WHERE user_id = \\$1 AND id = ANY\\(\\$2\\)").
+			WithArgs(1, "{4,9}").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+
+		// The top-level task starts in the first todo status with label 4
+		expectDefaultWorkflow(mock)
+		mock.ExpectQuery("SELECT MIN\\(rank\\) FROM tasks").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow("i"))
+		expectTemplateTaskInsert(mock, "Release", 10)
+		mock.ExpectQuery("SELECT id, name, color FROM labels").
+			WithArgs(1, "{4}").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "color"}).AddRow(4, "release", "#808080"))
+		mock.ExpectExec("DELETE FROM tasks_labels").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO tasks_labels").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO task_events").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		// Subtasks are created from the last to the first
+		for i, title := range []string{"Announce", "Tag"} {
+			expectDefaultWorkflow(mock)
+			mock.ExpectQuery("SELECT project_id FROM tasks").
+				WithArgs(10, 1).
+				WillReturnRows(sqlmock.NewRows([]string{"project_id"}).AddRow(nil))
+			mock.ExpectQuery("WITH RECURSIVE ancestors AS").
+				WithArgs(10).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+			mock.ExpectQuery("SELECT MIN\\(rank\\) FROM tasks").
+				WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow(nil))
+			expectTemplateTaskInsert(mock, title, 11+i)
+			mock.ExpectExec("INSERT INTO task_events").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		mock.ExpectCommit()
+
+		created, err := InstantiateTemplate(db, 1, 3, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 10, created.ID)
+		assert.Equal(t, StatusPending, created.Status)
+		assert.Equal(t, PriorityHigh, created.Priority)
+		assert.Len(t, created.Labels, 1)
+		assert.Equal(t, 2, created.SubtaskCount)
+		assert.Equal(t, "Tag", created.Subtasks[0].Title)
+		assert.Equal(t, 12, created.Subtasks[0].ID)
+		assert.Equal(t, "Announce", created.Subtasks[1].Title)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Template not found", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT (.+) FROM task_templates").
+			WithArgs(3, 1).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		_, err = InstantiateTemplate(db, 1, 3, nil)
+		assert.EqualError(t, err, "template not found")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestDeleteTemplate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec("DELETE FROM task_templates WHERE id = \\$1 AND user_id = \\$2").
+		WithArgs(3, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.EqualError(t, DeleteTemplate(db, 1, 3), "template not found")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,479 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/maxzhirnov/go-task-manager/pkg/database"
+)
+
+// MaxTimeEntryDuration limits the length of a manual time entry.
+const MaxTimeEntryDuration = 24 * time.Hour
+
+// MaxTimeReportRangeDays limits the date range of a time report.
+const MaxTimeReportRangeDays = 366
+
+// Time report grouping constants select the rows of a TimeReport
+const (
+	// TimeReportByDay sums the time per day the entries started on
+	TimeReportByDay = "day"
+
+	// TimeReportByTask sums the time per task
+	TimeReportByTask = "task"
+
+	// TimeReportByLabel sums the time per label of the tasks; entries of
+	// tasks with several labels count for each of them
+	TimeReportByLabel = "label"
+)
+
+// TimeEntry records time spent on a task, either measured by a timer or
+// entered manually.
+type TimeEntry struct {
+	// ID uniquely identifies the entry
+	ID int `json:"id"`
+
+	// UserID associates the entry with the user who spent the time
+	UserID int `json:"user_id"`
+
+	// TaskID is the task the time was spent on
+	TaskID int `json:"task_id"`
+
+	// StartedAt is when the work began
+	StartedAt time.Time `json:"started_at"`
+
+	// EndedAt is when the work ended; nil while the timer is running
+	EndedAt *time.Time `json:"ended_at"`
+
+	// Duration is the length of the entry in seconds. It is computed on
+	// read; a running timer counts up to now. Manual entries may give a
+	// duration instead of EndedAt.
+	Duration int64 `json:"duration_seconds"`
+
+	// Note describes the work done
+	Note string `json:"note"`
+
+	// CreatedAt stores the timestamp when the entry was created
+	CreatedAt time.Time `json:"created_at"`
+
+	// UpdatedAt stores the timestamp of the last modification
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TimeReportRow is the time of one day, task or label of a TimeReport.
+type TimeReportRow struct {
+	// Key identifies the row: the date as YYYY-MM-DD, the task ID or the
+	// label ID. It is empty for the entries of tasks without labels.
+	Key string `json:"key"`
+
+	// Name is the date, the task title or the label name
+	Name string `json:"name"`
+
+	// Entries is the number of time entries of the row
+	Entries int `json:"entries"`
+
+	// Seconds is the time of the row
+	Seconds int64 `json:"seconds"`
+}
+
+// TimeReport sums up a user's time entries that started within a date
+// range.
+type TimeReport struct {
+	// From is the inclusive start of the range in the user's time zone
+	From time.Time `json:"from"`
+
+	// To is the exclusive end of the range in the user's time zone
+	To time.Time `json:"to"`
+
+	// TimeZone is the time zone days are counted in
+	TimeZone string `json:"time_zone"`
+
+	// GroupBy is the grouping of Rows, one of the TimeReportBy constants
+	GroupBy string `json:"group_by"`
+
+	// TotalSeconds is the time of all entries in the range
+	TotalSeconds int64 `json:"total_seconds"`
+
+	// Rows holds the time per group: days in date order, tasks and labels
+	// with the most time first
+	Rows []TimeReportRow `json:"rows"`
+}
+
+// Validate checks a manual time entry, deriving EndedAt from Duration when
+// only the duration is given and trimming the note.
+//
+// Returns:
+//   - nil: If the entry is valid
+//   - error: Describing the first invalid field
+func (e *TimeEntry) Validate() error {
+	if e.StartedAt.IsZero() {
+		return fmt.Errorf("started_at is required")
+	}
+	e.StartedAt = e.StartedAt.UTC()
+
+	if e.EndedAt == nil && e.Duration > 0 {
+		endedAt := e.StartedAt.Add(time.Duration(e.Duration) * time.Second)
+		e.EndedAt = &endedAt
+	}
+	if e.EndedAt == nil {
+		return fmt.Errorf("ended_at or duration_seconds is required")
+	}
+	endedAt := e.EndedAt.UTC()
+	e.EndedAt = &endedAt
+	if !e.EndedAt.After(e.StartedAt) {
+		return fmt.Errorf("ended_at must be after started_at")
+	}
+	if e.EndedAt.Sub(e.StartedAt) > MaxTimeEntryDuration {
+		return fmt.Errorf("time entry must not exceed 24 hours")
+	}
+	e.Duration = int64(e.EndedAt.Sub(e.StartedAt) / time.Second)
+
+	e.Note = strings.TrimSpace(e.Note)
+	if len(e.Note) > 1000 {
+		return fmt.Errorf("note must not exceed 1000 characters")
+	}
+
+	return nil
+}
+
+// timeEntryColumns lists the time entry columns read by scanTimeEntry.
+const timeEntryColumns = `id, user_id, task_id, started_at, ended_at, note, created_at, updated_at`
+
+// scanTimeEntry reads a single row selected with timeEntryColumns and
+// computes the duration of the entry.
+func scanTimeEntry(row rowScanner) (TimeEntry, error) {
+	var e TimeEntry
+	err := row.Scan(&e.ID, &e.UserID, &e.TaskID, &e.StartedAt, &e.EndedAt, &e.Note, &e.CreatedAt, &e.UpdatedAt)
+	if err != nil {
+		return TimeEntry{}, err
+	}
+
+	end := time.Now()
+	if e.EndedAt != nil {
+		end = *e.EndedAt
+	}
+	e.Duration = int64(end.Sub(e.StartedAt) / time.Second)
+	return e, nil
+}
+
+// verifyTaskOwner checks that taskID is an active task of userID. It
+// returns "task not found" otherwise.
+func verifyTaskOwner(q querier, userID, taskID int) error {
+	var exists bool
+	err := q.QueryRow(`
+        SELECT EXISTS (SELECT 1 FROM tasks WHERE id = $1 AND user_id = $2 AND status != 'deleted')`,
+		taskID, userID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to verify task: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("task not found")
+	}
+	return nil
+}
+
+// StartTimer starts measuring time on a task. A user runs one timer at a
+// time: a timer running on any task is stopped at the same moment.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: ID of the task owner
+//   - taskID: The task to measure
+//
+// Returns:
+//   - TimeEntry: The running entry
+//   - error: "task not found" if the task doesn't exist, is deleted or
+//     belongs to another user, or database errors
+//
+// Example Usage:
+//
+//	entry, err := StartTimer(db, userID, taskID)
+//	if err != nil {
+//	    return fmt.Errorf("failed to start timer: %w", err)
+//	}
+func StartTimer(db database.DB, userID, taskID int) (TimeEntry, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return TimeEntry{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback in case of error
+
+	// Serialize timer changes of the user
+	if _, err := tx.Exec(`SELECT id FROM users WHERE id = $1 FOR UPDATE`, userID); err != nil {
+		return TimeEntry{}, fmt.Errorf("failed to lock user: %w", err)
+	}
+	if err := verifyTaskOwner(tx, userID, taskID); err != nil {
+		return TimeEntry{}, err
+	}
+
+	now := time.Now().UTC()
+	_, err = tx.Exec(`
+        UPDATE time_entries SET ended_at = $2, updated_at = $2
+        WHERE user_id = $1 AND ended_at IS NULL`, userID, now)
+	if err != nil {
+		return TimeEntry{}, fmt.Errorf("failed to stop running timer: %w", err)
+	}
+
+	entry := TimeEntry{UserID: userID, TaskID: taskID, StartedAt: now, CreatedAt: now, UpdatedAt: now}
+	err = tx.QueryRow(`
+        INSERT INTO time_entries (user_id, task_id, started_at, created_at, updated_at)
+        VALUES ($1, $2, $3, $3, $3)
+        RETURNING id`, userID, taskID, now).Scan(&entry.ID)
+	if err != nil {
+		return TimeEntry{}, fmt.Errorf("failed to start timer: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return TimeEntry{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return entry, nil
+}
+
+// StopTimer stops the running timer of a user.
+//
+// Returns:
+//   - TimeEntry: The stopped entry
+//   - error: "no running timer" if the user has no running timer, or database errors
+func StopTimer(db database.DB, userID int) (TimeEntry, error) {
+	entry, err := scanTimeEntry(db.QueryRow(`
+        UPDATE time_entries SET ended_at = $2, updated_at = $2
+        WHERE user_id = $1 AND ended_at IS NULL
+        RETURNING `+timeEntryColumns, userID, time.Now().UTC()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return TimeEntry{}, fmt.Errorf("no running timer")
+		}
+		return TimeEntry{}, fmt.Errorf("failed to stop timer: %w", err)
+	}
+	return entry, nil
+}
+
+// GetRunningTimer retrieves the running timer of a user.
+//
+// Returns:
+//   - TimeEntry: The running entry, its duration counted up to now
+//   - error: "no running timer" if the user has no running timer, or database errors
+func GetRunningTimer(db database.DB, userID int) (TimeEntry, error) {
+	entry, err := scanTimeEntry(db.QueryRow(`
+        SELECT `+timeEntryColumns+`
+        FROM time_entries
+        WHERE user_id = $1 AND ended_at IS NULL`, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return TimeEntry{}, fmt.Errorf("no running timer")
+		}
+		return TimeEntry{}, fmt.Errorf("failed to get timer: %w", err)
+	}
+	return entry, nil
+}
+
+// CreateTimeEntry inserts a manual time entry for e.UserID on e.TaskID.
+// The entry should be validated first.
+//
+// Returns:
+//   - error: "task not found" if the task doesn't exist, is deleted or
+//     belongs to another user, or database errors
+//
+// Side Effects:
+//   - Sets e.ID, e.CreatedAt and e.UpdatedAt
+func (e *TimeEntry) CreateTimeEntry(db database.DB) error {
+	if err := verifyTaskOwner(db, e.UserID, e.TaskID); err != nil {
+		return err
+	}
+
+	e.CreatedAt = time.Now().UTC()
+	e.UpdatedAt = e.CreatedAt
+
+	err := db.QueryRow(`
+        INSERT INTO time_entries (user_id, task_id, started_at, ended_at, note, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id`,
+		e.UserID, e.TaskID, e.StartedAt, e.EndedAt, e.Note, e.CreatedAt, e.UpdatedAt).Scan(&e.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create time entry: %w", err)
+	}
+
+	return nil
+}
+
+// GetTimeEntries retrieves the time entries of a task, most recent first.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: ID of the task owner
+//   - taskID: The task whose entries to retrieve
+//
+// Returns:
+//   - []TimeEntry: The entries of the task, including a running timer
+//   - error: "task not found" if the task belongs to another user, or
+//     database errors
+func GetTimeEntries(db database.DB, userID, taskID int) ([]TimeEntry, error) {
+	var exists bool
+	err := db.QueryRow(`
+        SELECT EXISTS (SELECT 1 FROM tasks WHERE id = $1 AND user_id = $2)`,
+		taskID, userID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify task: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("task not found")
+	}
+
+	rows, err := db.Query(`
+        SELECT `+timeEntryColumns+`
+        FROM time_entries
+        WHERE task_id = $1 AND user_id = $2
+        ORDER BY started_at DESC, id DESC`, taskID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get time entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []TimeEntry{}
+	for rows.Next() {
+		entry, err := scanTimeEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// DeleteTimeEntry removes a time entry of the given user. Deleting a
+// running timer discards it.
+//
+// Returns:
+//   - error: "time entry not found" if the user owns no such entry, or database errors
+func DeleteTimeEntry(db database.DB, userID, id int) error {
+	result, err := db.Exec(`DELETE FROM time_entries WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete time entry: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("time entry not found")
+	}
+
+	return nil
+}
+
+// GetTimeReport sums up the time entries a user started within a range of
+// days, grouped by day, task or label. Days are counted in the time zone of
+// the user's preferences; a running timer counts up to now.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: The ID of the user whose time to report
+//   - from: First day of the range; only the date is used
+//   - to: Day after the last day of the range; only the date is used
+//   - groupBy: One of the TimeReportBy constants
+//
+// Returns:
+//   - *TimeReport: The total and the time per group
+//   - error: "invalid group: ...", "from must be before to",
+//     "date range must not exceed 366 days" or database errors
+//
+// Example Usage:
+//
+//	// Time per task in March 2024
+//	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+//	report, err := GetTimeReport(db, userID, from, from.AddDate(0, 1, 0), TimeReportByTask)
+//	if err != nil {
+//	    return fmt.Errorf("failed to fetch time report: %w", err)
+//	}
+func GetTimeReport(db database.DB, userID int, from, to time.Time, groupBy string) (*TimeReport, error) {
+	if groupBy != TimeReportByDay && groupBy != TimeReportByTask && groupBy != TimeReportByLabel {
+		return nil, fmt.Errorf("invalid group: %s", groupBy)
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("from must be before to")
+	}
+	if to.Sub(from) > MaxTimeReportRangeDays*24*time.Hour {
+		return nil, fmt.Errorf("date range must not exceed %d days", MaxTimeReportRangeDays)
+	}
+
+	prefs, err := GetPreferences(db, userID)
+	if err != nil {
+		return nil, err
+	}
+	loc := prefs.Location()
+
+	report := &TimeReport{
+		From:     time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc),
+		To:       time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, loc),
+		TimeZone: prefs.TimeZone,
+		GroupBy:  groupBy,
+		Rows:     []TimeReportRow{},
+	}
+
+	// Timestamps are stored in UTC; running timers end now
+	entries := `
+        WITH entries AS (
+            SELECT task_id, started_at,
+                   EXTRACT(EPOCH FROM (COALESCE(ended_at, $4) - started_at))::bigint AS seconds
+            FROM time_entries
+            WHERE user_id = $1 AND started_at >= $2 AND started_at < $3
+        )`
+	args := []interface{}{userID, report.From.UTC(), report.To.UTC(), time.Now().UTC()}
+
+	if err := db.QueryRow(entries+`
+        SELECT COALESCE(SUM(seconds), 0) FROM entries`, args...).Scan(&report.TotalSeconds); err != nil {
+		return nil, fmt.Errorf("failed to get total time: %w", err)
+	}
+
+	var query string
+	switch groupBy {
+	case TimeReportByDay:
+		query = entries + `
+        SELECT day, day, COUNT(*), SUM(seconds)
+        FROM (
+            SELECT to_char(started_at AT TIME ZONE 'UTC' AT TIME ZONE $5, 'YYYY-MM-DD') AS day, seconds
+            FROM entries
+        ) days
+        GROUP BY day
+        ORDER BY day`
+		args = append(args, prefs.TimeZone)
+	case TimeReportByTask:
+		query = entries + `
+        SELECT e.task_id::text, t.title, COUNT(*), SUM(e.seconds)
+        FROM entries e
+        JOIN tasks t ON t.id = e.task_id
+        GROUP BY e.task_id, t.title
+        ORDER BY SUM(e.seconds) DESC, e.task_id`
+	case TimeReportByLabel:
+		query = entries + `
+        SELECT COALESCE(l.id::text, ''), COALESCE(l.name, ''), COUNT(*), SUM(e.seconds)
+        FROM entries e
+        LEFT JOIN tasks_labels tl ON tl.task_id = e.task_id
+        LEFT JOIN labels l ON l.id = tl.label_id
+        GROUP BY l.id, l.name
+        ORDER BY SUM(e.seconds) DESC, l.id NULLS LAST`
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get time report: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row TimeReportRow
+		if err := rows.Scan(&row.Key, &row.Name, &row.Entries, &row.Seconds); err != nil {
+			return nil, err
+		}
+		report.Rows = append(report.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
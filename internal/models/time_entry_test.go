@@ -0,0 +1,230 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var timeEntryColumnNames = []string{"id", "user_id", "task_id", "started_at", "ended_at", "note", "created_at", "updated_at"}
+
+func TestTimeEntryValidate(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	end := start.Add(90 * time.Minute)
+	before := start.Add(-time.Minute)
+	tooLate := start.Add(25 * time.Hour)
+
+	tests := []struct {
+		name    string
+		entry   TimeEntry
+		wantErr string
+	}{
+		{"End time", TimeEntry{StartedAt: start, EndedAt: &end, Note: " Review "}, ""},
+		{"Duration", TimeEntry{StartedAt: start, Duration: 5400, Note: "Review"}, ""},
+		{"Missing start", TimeEntry{EndedAt: &end}, "started_at is required"},
+		{"Missing end", TimeEntry{StartedAt: start}, "ended_at or duration_seconds is required"},
+		{"End before start", TimeEntry{StartedAt: start, EndedAt: &before}, "ended_at must be after started_at"},
+		{"Too long", TimeEntry{StartedAt: start, EndedAt: &tooLate}, "time entry must not exceed 24 hours"},
+		{"Long note", TimeEntry{StartedAt: start, EndedAt: &end, Note: strings.Repeat("a", 1001)}, "note must not exceed 1000 characters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.entry.Validate()
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, end, *tt.entry.EndedAt)
+			assert.Equal(t, int64(5400), tt.entry.Duration)
+			assert.Equal(t, "Review", tt.entry.Note)
+		})
+	}
+}
+
+func TestStartTimer(t *testing.T) {
+	t.Run("Stops the running timer", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec("SELECT id FROM users WHERE id = \\$1 FOR UPDATE").
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("SELECT EXISTS (.+) FROM tasks").
+			WithArgs(5, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectExec("UPDATE time_entries SET ended_at = \\$2, updated_at = \\$2 WHERE user_id = \\$1 AND ended_at IS NULL").
+			WithArgs(1, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("INSERT INTO time_entries").
+			WithArgs(1, 5, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(31))
+		mock.ExpectCommit()
+
+		entry, err := StartTimer(db, 1, 5)
+		assert.NoError(t, err)
+		assert.Equal(t, 31, entry.ID)
+		assert.Equal(t, 5, entry.TaskID)
+		assert.Nil(t, entry.EndedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Task not found", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec("SELECT id FROM users").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("SELECT EXISTS (.+) FROM tasks").
+			WithArgs(5, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectRollback()
+
+		_, err = StartTimer(db, 1, 5)
+		assert.EqualError(t, err, "task not found")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestStopTimer(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	start := time.Now().Add(-time.Hour)
+	end := start.Add(25 * time.Minute)
+	mock.ExpectQuery("UPDATE time_entries (.+) RETURNING").
+		WithArgs(1, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows(timeEntryColumnNames).AddRow(31, 1, 5, start, end, "", start, end))
+
+	entry, err := StopTimer(db, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1500), entry.Duration)
+
+	mock.ExpectQuery("UPDATE time_entries (.+) RETURNING").
+		WithArgs(1, sqlmock.AnyArg()).
+		WillReturnError(sql.ErrNoRows)
+	_, err = StopTimer(db, 1)
+	assert.EqualError(t, err, "no running timer")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetRunningTimer(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	start := time.Now().Add(-10 * time.Minute)
+	mock.ExpectQuery("SELECT (.+) FROM time_entries WHERE user_id = \\$1 AND ended_at IS NULL").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(timeEntryColumnNames).AddRow(31, 1, 5, start, nil, "", start, start))
+
+	// A running timer counts up to now
+	entry, err := GetRunningTimer(db, 1)
+	assert.NoError(t, err)
+	assert.Nil(t, entry.EndedAt)
+	assert.InDelta(t, 600, entry.Duration, 5)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateTimeEntry(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	entry := TimeEntry{UserID: 1, TaskID: 5, StartedAt: start, EndedAt: &end, Note: "Review"}
+
+	mock.ExpectQuery("SELECT EXISTS (.+) FROM tasks WHERE id = \\$1 AND user_id = \\$2 AND status != 'deleted'").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery("INSERT INTO time_entries").
+		WithArgs(1, 5, start, &end, "Review", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(32))
+
+	assert.NoError(t, entry.CreateTimeEntry(db))
+	assert.Equal(t, 32, entry.ID)
+
+	// Tasks of other users are not found
+	mock.ExpectQuery("SELECT EXISTS (.+) FROM tasks").
+		WithArgs(5, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	entry.UserID = 2
+	assert.EqualError(t, entry.CreateTimeEntry(db), "task not found")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTimeReport(t *testing.T) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)
+
+	t.Run("By label", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		// Days start at midnight in the user's time zone
+		start := time.Date(2024, 3, 1, 0, 0, 0, 0, berlin).UTC()
+		end := time.Date(2024, 3, 3, 0, 0, 0, 0, berlin).UTC()
+		mock.ExpectQuery("FROM user_preferences").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(preferencesColumns).AddRow("Europe/Berlin", "en", "monday", "YYYY-MM-DD", time.Now()))
+		mock.ExpectQuery("WITH entries AS (.+) SELECT COALESCE\\(SUM\\(seconds\\), 0\\) FROM entries").
+			WithArgs(1, start, end, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(9000))
+		mock.ExpectQuery("WITH entries AS (.+) LEFT JOIN tasks_labels").
+			WithArgs(1, start, end, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"key", "name", "entries", "seconds"}).
+				AddRow("4", "review", 2, 5400).
+				AddRow("", "", 1, 3600))
+
+		report, err := GetTimeReport(db, 1, from, to, TimeReportByLabel)
+		assert.NoError(t, err)
+		assert.Equal(t, "Europe/Berlin", report.TimeZone)
+		assert.Equal(t, int64(9000), report.TotalSeconds)
+		assert.Len(t, report.Rows, 2)
+		assert.Equal(t, "review", report.Rows[0].Name)
+		assert.Equal(t, "", report.Rows[1].Key)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("By day", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery("FROM user_preferences").
+			WithArgs(1).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery("SELECT COALESCE\\(SUM\\(seconds\\), 0\\) FROM entries").
+			WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(0))
+		mock.ExpectQuery("SELECT day, day, COUNT\\(\\*\\), SUM\\(seconds\\)").
+			WithArgs(1, from, to, sqlmock.AnyArg(), "UTC").
+			WillReturnRows(sqlmock.NewRows([]string{"key", "name", "entries", "seconds"}))
+
+		report, err := GetTimeReport(db, 1, from, to, TimeReportByDay)
+		assert.NoError(t, err)
+		assert.Equal(t, []TimeReportRow{}, report.Rows)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Invalid arguments", func(t *testing.T) {
+		_, err := GetTimeReport(nil, 1, from, to, "project")
+		assert.EqualError(t, err, "invalid group: project")
+		_, err = GetTimeReport(nil, 1, to, from, TimeReportByDay)
+		assert.EqualError(t, err, "from must be before to")
+		_, err = GetTimeReport(nil, 1, from, from.AddDate(0, 0, 367), TimeReportByDay)
+		assert.EqualError(t, err, "date range must not exceed 366 days")
+	})
+}
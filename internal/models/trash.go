@@ -0,0 +1,227 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/maxzhirnov/go-task-manager/pkg/database"
+)
+
+// GetTrash retrieves the soft-deleted tasks of a user, most recently deleted first.
+//
+// Subtasks that were deleted together with their parent are not listed on
+// their own; they are restored or purged along with the parent.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: The ID of the user whose trash to retrieve
+//
+// Returns:
+//   - []Task: Deleted tasks with DeletedAt set
+//   - error: Database error if query fails
+//
+// Example Usage:
+//
+//	trash, err := GetTrash(db, userID)
+//	if err != nil {
+//	    return fmt.Errorf("failed to fetch trash: %w", err)
+//	}
+func GetTrash(db database.DB, userID int) ([]Task, error) {
+	query := `SELECT ` + listedTaskColumns + `
+              FROM ` + rankedTasks("$1") + `
+              WHERE user_id = $1 
+              AND status = 'deleted' 
+              AND NOT EXISTS (
+                  SELECT 1 FROM tasks p 
+                  WHERE p.id = tasks.parent_id AND p.status = 'deleted'
+              )
+              ORDER BY deleted_at DESC NULLS LAST, id DESC`
+
+	rows, err := db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []Task
+	for rows.Next() {
+		t, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, t)
+	}
+
+	return tasks, rows.Err()
+}
+
+// RestoreTask moves a soft-deleted task back out of the trash.
+//
+// The task gets the status it had before deletion and is placed at the top
+// of its siblings, like a newly created task. Subtasks that were deleted
+// together with it are restored as well. If its project was deleted in the
+// meantime, the task is restored to the inbox.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: The ID of the task owner
+//   - id: The unique identifier of the task to restore
+//
+// Returns:
+//   - Task: The restored task
+//   - error: "task not found", "task is not deleted", "parent task is deleted"
+//     or database errors
+//
+// Example Usage:
+//
+//	task, err := RestoreTask(db, userID, taskID)
+//	if err != nil {
+//	    return fmt.Errorf("failed to restore task: %w", err)
+//	}
+func RestoreTask(db database.DB, userID, id int) (Task, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return Task{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback in case of error
+
+	task, err := restoreTask(tx, userID, id)
+	if err != nil {
+		return Task{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Task{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return task, nil
+}
+
+// restoreTask performs the steps of RestoreTask within the caller's
+// transaction.
+func restoreTask(q querier, userID, id int) (Task, error) {
+	// Lock the task and read its placement
+	var status string
+	var deletedAt *time.Time
+	var projectID, parentID *int
+	err := q.QueryRow(`
+        SELECT status, deleted_at, project_id, parent_id 
+        FROM tasks 
+        WHERE id = $1 AND user_id = $2 
+        FOR UPDATE`, id, userID).Scan(&status, &deletedAt, &projectID, &parentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Task{}, fmt.Errorf("task not found")
+		}
+		return Task{}, fmt.Errorf("failed to get task: %w", err)
+	}
+	if status != StatusDeleted {
+		return Task{}, fmt.Errorf("task is not deleted")
+	}
+
+	// Subtasks can only come back below an active parent
+	if parentID != nil {
+		var parentStatus string
+		err = q.QueryRow(`SELECT status FROM tasks WHERE id = $1`, *parentID).Scan(&parentStatus)
+		if err != nil {
+			return Task{}, fmt.Errorf("failed to get parent task: %w", err)
+		}
+		if parentStatus == StatusDeleted {
+			return Task{}, fmt.Errorf("parent task is deleted")
+		}
+	}
+
+	now := time.Now()
+
+	// Rank the task before its siblings
+	rank, err := firstRank(q, userID, projectID, parentID)
+	if err != nil {
+		return Task{}, err
+	}
+
+	// Restore the task and the subtasks deleted together with it and
+	// record the restore in their history
+	_, err = q.Exec(`
+        WITH RECURSIVE subtree AS (
+            SELECT id FROM tasks WHERE id = $1
+            UNION ALL
+            SELECT t.id
+            FROM tasks t
+            JOIN subtree s ON t.parent_id = s.id
+            WHERE t.status = 'deleted' AND t.deleted_at IS NOT DISTINCT FROM $2
+        ),
+        restored AS (
+            UPDATE tasks 
+            SET status = COALESCE(previous_status, 'pending'),
+                previous_status = NULL,
+                deleted_at = NULL,
+                rank = CASE WHEN id = $1 THEN $4 ELSE rank END,
+                updated_at = $3
+            WHERE id IN (SELECT id FROM subtree)
+            RETURNING id, user_id, status
+        )
+        INSERT INTO task_events (task_id, user_id, event_type, field, old_value, new_value, created_at)
+        SELECT id, user_id, 'restored', 'status', to_jsonb('deleted'::text), to_jsonb(status), $3
+        FROM restored`, id, deletedAt, now, rank)
+	if err != nil {
+		return Task{}, fmt.Errorf("failed to restore task: %w", err)
+	}
+
+	task, err := scanTask(q.QueryRow(`SELECT `+taskColumns+` FROM tasks WHERE id = $1`, id))
+	if err != nil {
+		return Task{}, fmt.Errorf("failed to get restored task: %w", err)
+	}
+
+	return task, nil
+}
+
+// PurgeTask permanently deletes a task from the trash together with its
+// subtasks, labels assignments and other dependent rows.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: The ID of the task owner
+//   - id: The unique identifier of the task to purge
+//
+// Returns:
+//   - error: "task not found" if the user has no such task in the trash,
+//     or database errors
+//
+// Note: Only soft-deleted tasks can be purged; call DeleteTask first.
+func PurgeTask(db database.DB, userID, id int) error {
+	result, err := db.Exec(`
+        DELETE FROM tasks 
+        WHERE id = $1 AND user_id = $2 AND status = 'deleted'`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to purge task: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("task not found")
+	}
+
+	return nil
+}
+
+// EmptyTrash permanently deletes all soft-deleted tasks of a user.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: The ID of the user whose trash to empty
+//
+// Returns:
+//   - int64: Number of tasks removed
+//   - error: Database error if the deletion fails
+func EmptyTrash(db database.DB, userID int) (int64, error) {
+	result, err := db.Exec(`DELETE FROM tasks WHERE user_id = $1 AND status = 'deleted'`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to empty trash: %w", err)
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,150 @@
+package models
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTrash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	row := newTaskRow(3, "Old task", "", StatusDeleted, 1, 0)
+	row[19] = time.Now()
+
+	mock.ExpectQuery("SELECT (.+) FROM (.+) tasks WHERE user_id = \\$1 AND status = 'deleted' AND NOT EXISTS (.+) ORDER BY deleted_at DESC").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(taskColumnNames).AddRow(row...))
+
+	tasks, err := GetTrash(db, 1)
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 1)
+	assert.NotNil(t, tasks[0].DeletedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRestoreTask(t *testing.T) {
+	deletedAt := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name          string
+		mockSetup     func(sqlmock.Sqlmock)
+		expectedError string
+	}{
+		{
+			name: "Successful restore",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT status, deleted_at, project_id, parent_id FROM tasks WHERE id = \\$1 AND user_id = \\$2 FOR UPDATE").
+					WithArgs(3, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"status", "deleted_at", "project_id", "parent_id"}).
+						AddRow(StatusDeleted, deletedAt, nil, nil))
+				mock.ExpectQuery("SELECT MIN\\(rank\\) FROM tasks").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow("i"))
+				mock.ExpectExec("WITH RECURSIVE subtree AS (.+) UPDATE tasks SET status = COALESCE\\(previous_status, 'pending'\\)").
+					WithArgs(3, deletedAt, sqlmock.AnyArg(), "h").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id = \\$1").
+					WithArgs(3).
+					WillReturnRows(sqlmock.NewRows(taskColumnNames).
+						AddRow(newTaskRow(3, "Old task", "", StatusInProgress, 1, 0)...))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Task not found",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT status, deleted_at").
+					WithArgs(3, 1).
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			expectedError: "task not found",
+		},
+		{
+			name: "Task not deleted",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT status, deleted_at").
+					WithArgs(3, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"status", "deleted_at", "project_id", "parent_id"}).
+						AddRow(StatusPending, nil, nil, nil))
+				mock.ExpectRollback()
+			},
+			expectedError: "task is not deleted",
+		},
+		{
+			name: "Parent still deleted",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT status, deleted_at").
+					WithArgs(3, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"status", "deleted_at", "project_id", "parent_id"}).
+						AddRow(StatusDeleted, deletedAt, nil, 2))
+				mock.ExpectQuery("SELECT status FROM tasks WHERE id = \\$1").
+					WithArgs(2).
+					WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(StatusDeleted))
+				mock.ExpectRollback()
+			},
+			expectedError: "parent task is deleted",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			tt.mockSetup(mock)
+
+			task, err := RestoreTask(db, 1, 3)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, StatusInProgress, task.Status)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPurgeTask(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec("DELETE FROM tasks WHERE id = \\$1 AND user_id = \\$2 AND status = 'deleted'").
+		WithArgs(3, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM tasks WHERE id = \\$1 AND user_id = \\$2 AND status = 'deleted'").
+		WithArgs(4, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, PurgeTask(db, 1, 3))
+	assert.EqualError(t, PurgeTask(db, 1, 4), "task not found")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEmptyTrash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec("DELETE FROM tasks WHERE user_id = \\$1 AND status = 'deleted'").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 5))
+
+	purged, err := EmptyTrash(db, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), purged)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -84,19 +84,19 @@ type UserStatistics struct {
 	// TotalTasks is the total number of tasks created by the user
 	TotalTasks int `json:"total_tasks"`
 
-	// CompletedTasks is the number of tasks marked as completed
+	// CompletedTasks is the number of tasks in a done status
 	CompletedTasks int `json:"completed_tasks"`
 
-	// PendingTasks is the number of tasks not yet started
+	// PendingTasks is the number of tasks in a todo status
 	PendingTasks int `json:"pending_tasks"`
 
-	// InProgressTasks is the number of tasks currently being worked on
+	// InProgressTasks is the number of tasks in a doing status
 	InProgressTasks int `json:"in_progress_tasks"`
 
 	// DeletedTasks is the number of soft-deleted tasks
 	DeletedTasks int `json:"deleted_tasks"`
 
-	// TasksCreatedToday is the number of tasks created in the last 24 hours
+	// TasksCreatedToday is the number of tasks created today in the user's time zone
 	TasksCreatedToday int `json:"tasks_created_today"`
 
 	// TasksLastWeek is the number of tasks created in the last week
@@ -111,7 +111,7 @@ type UserStatistics struct {
 	// WeeklyTrendValue is the value of the trend for tasks created in the last week
 	WeeklyTrendValue int `json:"weekly_trend_value"`
 
-	// PendingTasksLastWeek is the number of pending tasks in the last week
+	// PendingTasksLastWeek is the number of tasks in a todo status created in the last week
 	PendingTasksLastWeek int `json:"pending_tasks_last_week"`
 
 	// PendingTrendUp indicates whether the trend for pending tasks in the last week is up or down
@@ -122,6 +122,22 @@ type UserStatistics struct {
 
 	// AverageDailyTasks is the average number of tasks created per day
 	AverageDailyTasks float64 `json:"average_daily_tasks"`
+
+	// OverdueTasks is the number of active tasks past their due date
+	OverdueTasks int `json:"overdue_tasks"`
+
+	// DueTodayTasks is the number of active tasks due today in the user's time zone
+	DueTodayTasks int `json:"due_today_tasks"`
+
+	// PriorityCounts is the number of active tasks per priority level
+	PriorityCounts map[string]int `json:"priority_counts"`
+
+	// StatusCounts is the number of tasks per status of the user's
+	// workflow, excluding deleted tasks
+	StatusCounts map[string]int `json:"status_counts"`
+
+	// Effort sums the estimates of remaining and completed tasks
+	Effort EffortStatistics `json:"effort"`
 }
 
 // GenerateVerificationToken creates a secure random token for email verification.
@@ -657,8 +673,12 @@ func GetUserByEmail(db database.DB, email string) (User, error) {
 //
 // Statistics Included:
 //   - Total tasks count
-//   - Tasks by status (completed, pending, in progress, deleted)
-//   - Tasks created in the last 24 hours
+//   - Tasks by status category (done, todo, doing) and deleted tasks
+//   - Tasks per status of the user's workflow
+//   - Tasks created today
+//   - Overdue tasks and tasks due today
+//   - Active tasks per priority level
+//   - Remaining and completed effort in story points and minutes
 //
 // Example Usage:
 //
@@ -671,7 +691,8 @@ func GetUserByEmail(db database.DB, email string) (User, error) {
 //	}
 //
 // Note: This function relies on a database view 'user_statistics'
-// which should be kept up to date with task changes.
+// which should be kept up to date with task changes. "Today" is the
+// current day in the time zone of the user's preferences.
 func GetUserStatistics(db database.DB, userID int) (*UserStatistics, error) {
 	stats := &UserStatistics{}
 
@@ -682,11 +703,11 @@ func GetUserStatistics(db database.DB, userID int) (*UserStatistics, error) {
                 COUNT(*) FILTER (WHERE created_at >= NOW() - INTERVAL '14 days' 
                     AND created_at < NOW() - INTERVAL '7 days') as last_week,
                 COUNT(*) FILTER (
-                    WHERE status = 'pending' 
+                    WHERE status != 'deleted' AND status_category = 'todo'
                     AND created_at >= NOW() - INTERVAL '7 days'
                 ) as pending_this_week,
                 COUNT(*) FILTER (
-                    WHERE status = 'pending' 
+                    WHERE status != 'deleted' AND status_category = 'todo'
                     AND created_at >= NOW() - INTERVAL '14 days'
                     AND created_at < NOW() - INTERVAL '7 days'
                 ) as pending_last_week
@@ -703,6 +724,31 @@ func GetUserStatistics(db database.DB, userID int) (*UserStatistics, error) {
             FROM tasks 
             WHERE user_id = $1
             AND created_at >= NOW() - INTERVAL '30 days'
+        ),
+        local_day AS (
+            SELECT tz.time_zone, (NOW() AT TIME ZONE tz.time_zone)::date as today
+            FROM (
+                SELECT COALESCE(
+                    (SELECT time_zone FROM user_preferences WHERE user_id = $1),
+                    'UTC'
+                ) as time_zone
+            ) tz
+        ),
+        due_stats AS (
+            SELECT 
+                COUNT(*) FILTER (WHERE ` + overdueCondition + `) as overdue_tasks,
+                COUNT(*) FILTER (
+                    WHERE status != 'deleted' AND status_category != 'done'
+                    AND CASE WHEN all_day THEN due_at::date
+                        ELSE (due_at AT TIME ZONE 'UTC' AT TIME ZONE ld.time_zone)::date
+                    END = ld.today
+                ) as due_today_tasks,
+                COUNT(*) FILTER (
+                    WHERE (created_at AT TIME ZONE 'UTC' AT TIME ZONE ld.time_zone)::date = ld.today
+                ) as tasks_created_today
+            FROM tasks 
+            CROSS JOIN local_day ld
+            WHERE user_id = $1
         )
         SELECT 
             us.user_id, 
@@ -712,7 +758,7 @@ func GetUserStatistics(db database.DB, userID int) (*UserStatistics, error) {
             us.pending_tasks, 
             us.in_progress_tasks, 
             us.deleted_tasks,
-            us.tasks_created_today,
+            ds.tasks_created_today,
             ws.last_week as tasks_last_week,
             ws.this_week as tasks_this_week,
             CASE 
@@ -740,10 +786,13 @@ func GetUserStatistics(db database.DB, userID int) (*UserStatistics, error) {
                     END
                 ELSE ((ws.pending_this_week - ws.pending_last_week)::float / ws.pending_last_week * 100)::int
             END as pending_trend_value,
-            COALESCE(da.avg_daily_tasks, 0) as average_daily_tasks
+            COALESCE(da.avg_daily_tasks, 0) as average_daily_tasks,
+            ds.overdue_tasks,
+            ds.due_today_tasks
         FROM user_statistics us
         CROSS JOIN weekly_stats ws
         CROSS JOIN daily_average da
+        CROSS JOIN due_stats ds
         WHERE us.user_id = $1`
 
 	err := db.QueryRow(query, userID).Scan(
@@ -763,11 +812,80 @@ func GetUserStatistics(db database.DB, userID int) (*UserStatistics, error) {
 		&stats.PendingTrendUp,
 		&stats.PendingTrendValue,
 		&stats.AverageDailyTasks,
+		&stats.OverdueTasks,
+		&stats.DueTodayTasks,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user statistics: %w", err)
 	}
 
+	// Count active tasks per priority level
+	stats.PriorityCounts = make(map[string]int, len(ValidPriorities))
+	for _, priority := range ValidPriorities {
+		stats.PriorityCounts[priority] = 0
+	}
+
+	rows, err := db.Query(`
+        SELECT priority, COUNT(*) 
+        FROM tasks 
+        WHERE user_id = $1 
+        AND status != 'deleted' AND status_category != 'done'
+        GROUP BY priority`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get priority statistics: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var priority string
+		var count int
+		if err := rows.Scan(&priority, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan priority statistics: %w", err)
+		}
+		stats.PriorityCounts[priority] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read priority statistics: %w", err)
+	}
+
+	// Count tasks per status of the workflow
+	workflow, err := getWorkflow(db, userID)
+	if err != nil {
+		return nil, err
+	}
+	stats.StatusCounts = make(map[string]int, len(workflow.Statuses))
+	for _, status := range workflow.Statuses {
+		stats.StatusCounts[status.Key] = 0
+	}
+
+	statusRows, err := db.Query(`
+        SELECT status, COUNT(*) 
+        FROM tasks 
+        WHERE user_id = $1 
+        AND status != 'deleted'
+        GROUP BY status`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status statistics: %w", err)
+	}
+	defer statusRows.Close()
+
+	for statusRows.Next() {
+		var status string
+		var count int
+		if err := statusRows.Scan(&status, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan status statistics: %w", err)
+		}
+		stats.StatusCounts[status] = count
+	}
+	if err := statusRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read status statistics: %w", err)
+	}
+
+	// Sum the estimates of remaining and completed tasks
+	if stats.Effort, err = getEffortStatistics(db, userID); err != nil {
+		return nil, err
+	}
+
 	return stats, nil
 }
 
@@ -348,68 +348,113 @@ func TestGetUserByEmail(t *testing.T) {
 	}
 }
 
-// func TestGetUserStatistics(t *testing.T) {
-// 	db, mock, err := sqlmock.New()
-// 	assert.NoError(t, err)
-// 	defer db.Close()
-
-// 	tests := []struct {
-// 		name    string
-// 		userID  int
-// 		mockSQL func()
-// 		want    *UserStatistics
-// 		wantErr bool
-// 	}{
-// 		{
-// 			name:   "Statistics exist",
-// 			userID: 1,
-// 			mockSQL: func() {
-// 				rows := sqlmock.NewRows([]string{
-// 					"user_id", "username", "total_tasks", "completed_tasks",
-// 					"pending_tasks", "in_progress_tasks", "deleted_tasks",
-// 					"tasks_created_today",
-// 				}).AddRow(1, "testuser", 10, 5, 3, 2, 0, 1)
-// 				mock.ExpectQuery("SELECT (.+) FROM user_statistics").
-// 					WithArgs(1).
-// 					WillReturnRows(rows)
-// 			},
-// 			want: &UserStatistics{
-// 				UserID:            1,
-// 				Username:          "testuser",
-// 				TotalTasks:        10,
-// 				CompletedTasks:    5,
-// 				PendingTasks:      3,
-// 				InProgressTasks:   2,
-// 				DeletedTasks:      0,
-// 				TasksCreatedToday: 1,
-// 			},
-// 			wantErr: false,
-// 		},
-// 		{
-// 			name:   "Statistics not found",
-// 			userID: 2,
-// 			mockSQL: func() {
-// 				mock.ExpectQuery("SELECT (.+) FROM user_statistics").
-// 					WithArgs(2).
-// 					WillReturnError(sql.ErrNoRows)
-// 			},
-// 			wantErr: true,
-// 		},
-// 	}
-
-// 	for _, tt := range tests {
-// 		t.Run(tt.name, func(t *testing.T) {
-// 			tt.mockSQL()
-// 			got, err := GetUserStatistics(db, tt.userID)
-// 			if tt.wantErr {
-// 				assert.Error(t, err)
-// 			} else {
-// 				assert.NoError(t, err)
-// 				assert.Equal(t, tt.want, got)
-// 			}
-// 		})
-// 	}
-// }
+var userStatisticsColumns = []string{
+	"user_id", "username", "total_tasks", "completed_tasks", "pending_tasks",
+	"in_progress_tasks", "deleted_tasks", "tasks_created_today", "tasks_last_week",
+	"tasks_this_week", "weekly_trend_up", "weekly_trend_value", "pending_last_week",
+	"pending_trend_up", "pending_trend_value", "average_daily_tasks",
+	"overdue_tasks", "due_today_tasks",
+}
+
+func TestGetUserStatistics(t *testing.T) {
+	effortColumns := []string{"remaining_points", "completed_points", "remaining_minutes", "completed_minutes", "unestimated"}
+
+	// expectCounts expects the queries preceding the effort statistics
+	expectCounts := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery("WITH weekly_stats AS (.+) FROM user_statistics us").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(userStatisticsColumns).
+				AddRow(1, "testuser", 10, 5, 3, 2, 0, 1, 4, 6, true, 50, 1, true, 100, 1.5, 2, 1))
+		mock.ExpectQuery("SELECT priority, COUNT\\(\\*\\) FROM tasks").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"priority", "count"}))
+		expectDefaultWorkflow(mock)
+		mock.ExpectQuery("SELECT status, COUNT\\(\\*\\) FROM tasks").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"status", "count"}))
+	}
+
+	t.Run("Includes the effort", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		expectCounts(mock)
+		mock.ExpectQuery("SUM\\(estimate_points\\) (.+) FROM tasks WHERE user_id = \\$1 AND status != 'deleted'").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(effortColumns).AddRow(21, 13, 480, 300, 2))
+
+		stats, err := GetUserStatistics(db, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 10, stats.TotalTasks)
+		assert.Equal(t, EffortStatistics{
+			RemainingPoints:  21,
+			CompletedPoints:  13,
+			RemainingMinutes: 480,
+			CompletedMinutes: 300,
+			UnestimatedTasks: 2,
+		}, stats.Effort)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Counts by priority, status and due date", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		// Overdue and due today count active tasks only
+		mock.ExpectQuery("WITH weekly_stats AS (.+) COUNT\\(\\*\\) FILTER \\(WHERE status != 'deleted' AND status_category != 'done' AND due_at IS NOT NULL (.+) as overdue_tasks, " +
+			"COUNT\\(\\*\\) FILTER \\( WHERE status != 'deleted' AND status_category != 'done' (.+) as due_today_tasks").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(userStatisticsColumns).
+				AddRow(1, "testuser", 10, 3, 4, 2, 1, 1, 4, 6, true, 50, 1, true, 100, 1.5, 2, 1))
+		// Done and deleted tasks have no priority count
+		mock.ExpectQuery("SELECT priority, COUNT\\(\\*\\) FROM tasks WHERE user_id = \\$1 AND status != 'deleted' AND status_category != 'done' GROUP BY priority").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"priority", "count"}).
+				AddRow("high", 2).
+				AddRow("none", 3))
+		mock.ExpectQuery("SELECT key, name, category, transitions, wip_limit FROM workflow_statuses").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"key", "name", "category", "transitions", "wip_limit"}).
+				AddRow("todo", "To do", "todo", nil, nil).
+				AddRow("review", "Review", "doing", nil, nil).
+				AddRow("shipped", "Shipped", "done", nil, nil))
+		mock.ExpectQuery("SELECT status, COUNT\\(\\*\\) FROM tasks WHERE user_id = \\$1 AND status != 'deleted' GROUP BY status").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"status", "count"}).
+				AddRow("todo", 4).
+				AddRow("shipped", 3))
+		mock.ExpectQuery("SUM\\(estimate_points\\)").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(effortColumns).AddRow(0, 0, 0, 0, 0))
+
+		stats, err := GetUserStatistics(db, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, stats.OverdueTasks)
+		assert.Equal(t, 1, stats.DueTodayTasks)
+		// Every priority and every status of the workflow is listed
+		assert.Equal(t, map[string]int{"none": 3, "low": 0, "medium": 0, "high": 2, "urgent": 0}, stats.PriorityCounts)
+		assert.Equal(t, map[string]int{"todo": 4, "review": 0, "shipped": 3}, stats.StatusCounts)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Effort query fails", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		expectCounts(mock)
+		mock.ExpectQuery("SUM\\(estimate_points\\)").
+			WithArgs(1).
+			WillReturnError(sql.ErrConnDone)
+
+		stats, err := GetUserStatistics(db, 1)
+		assert.Nil(t, stats)
+		assert.EqualError(t, err, "failed to get effort statistics: sql: connection is already closed")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
 
 func TestHashPassword(t *testing.T) {
 	tests := []struct {
@@ -1,6 +1,9 @@
 package models
 
-import "fmt"
+import (
+	"database/sql"
+	"fmt"
+)
 
 // validateUsername validates the username
 func validateUsername(username string) error {
@@ -22,3 +25,11 @@ func validatePassword(password string) error {
 	// TODO: Add more validation rules as needed
 	return nil
 }
+
+// querier is implemented by both database.DB and *sql.Tx, allowing
+// helpers to run standalone or as part of a larger transaction.
+type querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
@@ -0,0 +1,399 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/maxzhirnov/go-task-manager/pkg/database"
+)
+
+// Status category constants group the statuses of a workflow by what they
+// mean for statistics, due dates and recurrence
+const (
+	// StatusCategoryTodo holds statuses of tasks that haven't been started
+	StatusCategoryTodo = "todo"
+
+	// StatusCategoryDoing holds statuses of tasks being worked on
+	StatusCategoryDoing = "doing"
+
+	// StatusCategoryDone holds statuses of finished tasks
+	StatusCategoryDone = "done"
+)
+
+// ValidStatusCategories lists the categories a workflow status can belong to.
+var ValidStatusCategories = []string{StatusCategoryTodo, StatusCategoryDoing, StatusCategoryDone}
+
+// MaxWorkflowStatuses is the largest number of statuses a workflow may define.
+const MaxWorkflowStatuses = 20
+
+// statusKeyPattern matches status keys: lowercase letters, digits and
+// underscores starting with a letter, at most 20 characters as stored in
+// tasks.status.
+var statusKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,19}$`)
+
+// WorkflowStatus is a single status of a user's workflow.
+type WorkflowStatus struct {
+	// Key is stored as the status of tasks, e.g. "in_review"
+	Key string `json:"key"`
+
+	// Name is shown to the user, e.g. "In review"
+	Name string `json:"name"`
+
+	// Category is one of ValidStatusCategories
+	Category string `json:"category"`
+
+	// Position orders the statuses of the workflow, starting at 0
+	Position int `json:"position"`
+
+	// Transitions lists the statuses a task may move to from this one.
+	// Nil allows every status of the workflow, an empty slice none.
+	Transitions []string `json:"transitions"`
+
+	// WIPLimit caps the number of top-level tasks of a board, a project
+	// or the inbox, in this status. Nil means unlimited.
+	WIPLimit *int `json:"wip_limit"`
+}
+
+// Workflow is the ordered set of statuses available to a user's tasks.
+type Workflow struct {
+	// Statuses holds the statuses in order
+	Statuses []WorkflowStatus `json:"statuses"`
+
+	// Custom reports whether the user defined the workflow; false means
+	// the built-in workflow of DefaultWorkflow
+	Custom bool `json:"custom"`
+}
+
+// DefaultWorkflow returns the built-in workflow of users who haven't
+// defined their own: pending, in_progress and completed with all
+// transitions allowed.
+func DefaultWorkflow() Workflow {
+	return Workflow{Statuses: []WorkflowStatus{
+		{Key: StatusPending, Name: "Pending", Category: StatusCategoryTodo, Position: 0},
+		{Key: StatusInProgress, Name: "In progress", Category: StatusCategoryDoing, Position: 1},
+		{Key: StatusCompleted, Name: "Completed", Category: StatusCategoryDone, Position: 2},
+	}}
+}
+
+// Validate checks the workflow and normalizes names and positions.
+//
+// A workflow needs at least one todo status, where new tasks start, and at
+// least one done status, which completes tasks. Transitions may only name
+// statuses of the workflow and WIP limits must be positive.
+//
+// Returns:
+//   - nil: If the workflow is valid
+//   - error: Describing the first problem found
+//
+// Example Usage:
+//
+//	workflow := Workflow{Statuses: []WorkflowStatus{
+//	    {Key: "todo", Name: "To do", Category: StatusCategoryTodo},
+//	    {Key: "in_review", Name: "In review", Category: StatusCategoryDoing},
+//	    {Key: "done", Name: "Done", Category: StatusCategoryDone},
+//	}}
+//	if err := workflow.Validate(); err != nil {
+//	    return fmt.Errorf("validation failed: %w", err)
+//	}
+func (w *Workflow) Validate() error {
+	if len(w.Statuses) == 0 {
+		return fmt.Errorf("at least one status is required")
+	}
+	if len(w.Statuses) > MaxWorkflowStatuses {
+		return fmt.Errorf("too many statuses: at most %d", MaxWorkflowStatuses)
+	}
+
+	keys := make(map[string]bool, len(w.Statuses))
+	categories := make(map[string]bool, len(ValidStatusCategories))
+	for i := range w.Statuses {
+		s := &w.Statuses[i]
+		if !isValidStatusKey(s.Key) {
+			return fmt.Errorf("invalid status key: %s", s.Key)
+		}
+		if keys[s.Key] {
+			return fmt.Errorf("duplicate status: %s", s.Key)
+		}
+		keys[s.Key] = true
+
+		s.Name = strings.TrimSpace(s.Name)
+		if s.Name == "" {
+			return fmt.Errorf("status name is required")
+		}
+		if len(s.Name) > 50 {
+			return fmt.Errorf("status name must be at most 50 characters")
+		}
+
+		if !isValidStatusCategory(s.Category) {
+			return fmt.Errorf("invalid status category: %s", s.Category)
+		}
+		if s.WIPLimit != nil && *s.WIPLimit < 1 {
+			return fmt.Errorf("wip limit must be positive")
+		}
+		categories[s.Category] = true
+		s.Position = i
+	}
+
+	if !categories[StatusCategoryTodo] || !categories[StatusCategoryDone] {
+		return fmt.Errorf("a todo and a done status are required")
+	}
+
+	for _, s := range w.Statuses {
+		for _, target := range s.Transitions {
+			if !keys[target] {
+				return fmt.Errorf("unknown transition target: %s", target)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Status returns the status of the workflow with the given key.
+func (w Workflow) Status(key string) (WorkflowStatus, bool) {
+	for _, s := range w.Statuses {
+		if s.Key == key {
+			return s, true
+		}
+	}
+	return WorkflowStatus{}, false
+}
+
+// CanTransition reports whether a task may move from one status to another.
+// Staying in a status is always allowed, as is leaving a status that is not
+// part of the workflow.
+func (w Workflow) CanTransition(from, to string) bool {
+	if from == to {
+		return true
+	}
+	status, ok := w.Status(from)
+	if !ok || status.Transitions == nil {
+		return true
+	}
+	for _, target := range status.Transitions {
+		if target == to {
+			return true
+		}
+	}
+	return false
+}
+
+// InitialStatus returns the first todo status, which new tasks start in.
+func (w Workflow) InitialStatus() string {
+	return w.firstOfCategory(StatusCategoryTodo)
+}
+
+// DoneStatus returns the first done status, which completes a task.
+func (w Workflow) DoneStatus() string {
+	return w.firstOfCategory(StatusCategoryDone)
+}
+
+// firstOfCategory returns the key of the first status in category.
+func (w Workflow) firstOfCategory(category string) string {
+	for _, s := range w.Statuses {
+		if s.Category == category {
+			return s.Key
+		}
+	}
+	return ""
+}
+
+// GetWorkflow retrieves the workflow of a user. Users who never defined
+// one receive DefaultWorkflow.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: ID of the workflow owner
+//
+// Returns:
+//   - Workflow: The user's statuses in order
+//   - error: Database error if the query fails
+//
+// Example Usage:
+//
+//	workflow, err := GetWorkflow(db, userID)
+//	if err != nil {
+//	    return fmt.Errorf("failed to fetch workflow: %w", err)
+//	}
+func GetWorkflow(db database.DB, userID int) (Workflow, error) {
+	return getWorkflow(db, userID)
+}
+
+// getWorkflow performs GetWorkflow on q.
+func getWorkflow(q querier, userID int) (Workflow, error) {
+	rows, err := q.Query(`
+        SELECT key, name, category, transitions, wip_limit
+        FROM workflow_statuses
+        WHERE user_id = $1
+        ORDER BY position`, userID)
+	if err != nil {
+		return Workflow{}, fmt.Errorf("failed to get workflow: %w", err)
+	}
+	defer rows.Close()
+
+	workflow := Workflow{Statuses: []WorkflowStatus{}, Custom: true}
+	for rows.Next() {
+		var s WorkflowStatus
+		var transitions pq.StringArray
+		if err := rows.Scan(&s.Key, &s.Name, &s.Category, &transitions, &s.WIPLimit); err != nil {
+			return Workflow{}, fmt.Errorf("failed to scan workflow status: %w", err)
+		}
+		s.Position = len(workflow.Statuses)
+		s.Transitions = transitions
+		workflow.Statuses = append(workflow.Statuses, s)
+	}
+	if err := rows.Err(); err != nil {
+		return Workflow{}, fmt.Errorf("failed to read workflow: %w", err)
+	}
+
+	if len(workflow.Statuses) == 0 {
+		return DefaultWorkflow(), nil
+	}
+	return workflow, nil
+}
+
+// SetWorkflow replaces the workflow of a user.
+//
+// Statuses that are still used by tasks, including tasks in the trash,
+// cannot be removed. When a status changes its category the tasks in that
+// status follow: they get a completion time when they become done and a
+// start time when they first become doing, like tasks changing their status.
+//
+// Parameters:
+//   - db: Database interface for executing queries
+//   - userID: ID of the workflow owner
+//   - w: The new workflow; it must be valid
+//
+// Returns:
+//   - Workflow: The stored workflow
+//   - error: "status in use: ..." naming a removed status that tasks still
+//     have, or database errors
+//
+// Example Usage:
+//
+//	workflow, err := SetWorkflow(db, userID, workflow)
+//	if err != nil {
+//	    return fmt.Errorf("failed to save workflow: %w", err)
+//	}
+func SetWorkflow(db database.DB, userID int, w Workflow) (Workflow, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return Workflow{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback in case of error
+
+	// Serialize workflow changes of the user
+	if _, err := tx.Exec(`SELECT id FROM users WHERE id = $1 FOR UPDATE`, userID); err != nil {
+		return Workflow{}, fmt.Errorf("failed to lock user: %w", err)
+	}
+
+	keys := make([]string, len(w.Statuses))
+	categories := make([]string, len(w.Statuses))
+	for i, s := range w.Statuses {
+		keys[i] = s.Key
+		categories[i] = s.Category
+	}
+
+	// Deleted tasks are restored to their previous status
+	var inUse sql.NullString
+	err = tx.QueryRow(`
+        SELECT MIN(COALESCE(NULLIF(status, 'deleted'), previous_status))
+        FROM tasks
+        WHERE user_id = $1
+        AND COALESCE(NULLIF(status, 'deleted'), previous_status) != ALL($2)`,
+		userID, pq.Array(keys)).Scan(&inUse)
+	if err != nil {
+		return Workflow{}, fmt.Errorf("failed to check statuses in use: %w", err)
+	}
+	if inUse.Valid {
+		return Workflow{}, fmt.Errorf("status in use: %s", inUse.String)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM workflow_statuses WHERE user_id = $1`, userID); err != nil {
+		return Workflow{}, fmt.Errorf("failed to delete workflow: %w", err)
+	}
+	for i, s := range w.Statuses {
+		_, err := tx.Exec(`
+            INSERT INTO workflow_statuses (user_id, key, name, category, position, transitions, wip_limit)
+            VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			userID, s.Key, s.Name, s.Category, i, pq.Array(s.Transitions), s.WIPLimit)
+		if err != nil {
+			return Workflow{}, fmt.Errorf("failed to insert workflow status: %w", err)
+		}
+	}
+
+	// Move tasks whose status changed its category
+	_, err = tx.Exec(`
+        UPDATE tasks
+        SET status_category = v.category,
+            started_at = CASE WHEN v.category = 'doing' THEN COALESCE(started_at, NOW()) ELSE started_at END,
+            completed_at = CASE WHEN v.category = 'done' THEN COALESCE(completed_at, NOW()) END
+        FROM unnest($2::text[], $3::text[]) AS v(key, category)
+        WHERE tasks.user_id = $1
+        AND COALESCE(NULLIF(tasks.status, 'deleted'), tasks.previous_status) = v.key
+        AND tasks.status_category != v.category`,
+		userID, pq.Array(keys), pq.Array(categories))
+	if err != nil {
+		return Workflow{}, fmt.Errorf("failed to update task categories: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Workflow{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	w.Custom = true
+	for i := range w.Statuses {
+		w.Statuses[i].Position = i
+	}
+	return w, nil
+}
+
+// ValidateTransition checks that the task's status belongs to the workflow
+// and that a task in status from may move to it, and sets t.StatusCategory.
+// An empty from skips the transition check, as for new tasks.
+//
+// Parameters:
+//   - w: The workflow of the task owner
+//   - from: The status the task is currently in
+//
+// Returns:
+//   - nil: If the status is valid
+//   - error: "invalid status: ..." or "status transition not allowed"
+//
+// Example Usage:
+//
+//	task.Status = "in_review"
+//	if err := task.ValidateTransition(workflow, StatusInProgress); err != nil {
+//	    return fmt.Errorf("validation failed: %w", err)
+//	}
+func (t *Task) ValidateTransition(w Workflow, from string) error {
+	status, ok := w.Status(t.Status)
+	if !ok {
+		return fmt.Errorf("invalid status: %s", t.Status)
+	}
+	if from != "" && !w.CanTransition(from, t.Status) {
+		return fmt.Errorf("status transition not allowed")
+	}
+
+	t.StatusCategory = status.Category
+	return nil
+}
+
+// isValidStatusCategory reports whether category is one of ValidStatusCategories.
+func isValidStatusCategory(category string) bool {
+	for _, c := range ValidStatusCategories {
+		if category == c {
+			return true
+		}
+	}
+	return false
+}
+
+// isValidStatusKey reports whether key is a well-formed status key other
+// than the internal StatusDeleted. Whether the status exists depends on the
+// owner's workflow.
+func isValidStatusKey(key string) bool {
+	return statusKeyPattern.MatchString(key) && key != StatusDeleted
+}
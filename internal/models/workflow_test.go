@@ -0,0 +1,167 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkflowValidate(t *testing.T) {
+	status := func(key, category string, transitions ...string) WorkflowStatus {
+		return WorkflowStatus{Key: key, Name: strings.ToUpper(key), Category: category, Transitions: transitions}
+	}
+
+	tests := []struct {
+		name     string
+		statuses []WorkflowStatus
+		wantErr  string
+	}{
+		{"Default workflow", DefaultWorkflow().Statuses, ""},
+		{"Restricted transitions", []WorkflowStatus{
+			status("todo", StatusCategoryTodo, "review"),
+			status("review", StatusCategoryDoing, "todo", "done"),
+			status("done", StatusCategoryDone),
+		}, ""},
+		{"No statuses", nil, "at least one status is required"},
+		{"Invalid key", []WorkflowStatus{status("To Do", StatusCategoryTodo)}, "invalid status key: To Do"},
+		{"Reserved key", []WorkflowStatus{status("deleted", StatusCategoryTodo)}, "invalid status key: deleted"},
+		{"Duplicate key", []WorkflowStatus{
+			status("todo", StatusCategoryTodo),
+			status("todo", StatusCategoryDone),
+		}, "duplicate status: todo"},
+		{"Missing name", []WorkflowStatus{{Key: "todo", Category: StatusCategoryTodo}}, "status name is required"},
+		{"Invalid category", []WorkflowStatus{status("todo", "later")}, "invalid status category: later"},
+		{"No done status", []WorkflowStatus{
+			status("todo", StatusCategoryTodo),
+			status("doing", StatusCategoryDoing),
+		}, "a todo and a done status are required"},
+		{"Unknown target", []WorkflowStatus{
+			status("todo", StatusCategoryTodo, "review"),
+			status("done", StatusCategoryDone),
+		}, "unknown transition target: review"},
+		{"Zero WIP limit", []WorkflowStatus{
+			status("todo", StatusCategoryTodo),
+			{Key: "doing", Name: "Doing", Category: StatusCategoryDoing, WIPLimit: new(int)},
+			status("done", StatusCategoryDone),
+		}, "wip limit must be positive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := Workflow{Statuses: tt.statuses}
+			err := w.Validate()
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestWorkflowCanTransition(t *testing.T) {
+	w := Workflow{Statuses: []WorkflowStatus{
+		{Key: "todo", Category: StatusCategoryTodo, Transitions: []string{"review"}},
+		{Key: "review", Category: StatusCategoryDoing, Transitions: []string{}},
+		{Key: "done", Category: StatusCategoryDone},
+	}}
+
+	assert.True(t, w.CanTransition("todo", "review"))
+	assert.False(t, w.CanTransition("todo", "done"))
+	assert.False(t, w.CanTransition("review", "todo"))
+	assert.True(t, w.CanTransition("review", "review"))
+	assert.True(t, w.CanTransition("done", "todo"))
+	assert.Equal(t, "todo", w.InitialStatus())
+	assert.Equal(t, "done", w.DoneStatus())
+}
+
+func TestGetWorkflow(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT key, name, category, transitions, wip_limit FROM workflow_statuses WHERE user_id = \\$1 ORDER BY position").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"key", "name", "category", "transitions", "wip_limit"}))
+	mock.ExpectQuery("SELECT key, name, category, transitions, wip_limit FROM workflow_statuses").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"key", "name", "category", "transitions", "wip_limit"}).
+			AddRow("todo", "To do", "todo", "{review}", nil).
+			AddRow("review", "In review", "doing", nil, 3).
+			AddRow("done", "Done", "done", nil, nil))
+
+	w, err := GetWorkflow(db, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultWorkflow(), w)
+
+	w, err = GetWorkflow(db, 2)
+	assert.NoError(t, err)
+	assert.True(t, w.Custom)
+	assert.Len(t, w.Statuses, 3)
+	assert.Equal(t, []string{"review"}, w.Statuses[0].Transitions)
+	assert.Nil(t, w.Statuses[1].Transitions)
+	assert.Equal(t, 3, *w.Statuses[1].WIPLimit)
+	assert.Equal(t, 2, w.Statuses[2].Position)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetWorkflow(t *testing.T) {
+	workflow := Workflow{Statuses: []WorkflowStatus{
+		{Key: "todo", Name: "To do", Category: StatusCategoryTodo},
+		{Key: "done", Name: "Done", Category: StatusCategoryDone},
+	}}
+
+	t.Run("Success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec("SELECT id FROM users WHERE id = \\$1 FOR UPDATE").
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("SELECT MIN\\(COALESCE\\(NULLIF\\(status, 'deleted'\\), previous_status\\)\\) FROM tasks").
+			WithArgs(1, `{"todo","done"}`).
+			WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow(nil))
+		mock.ExpectExec("DELETE FROM workflow_statuses WHERE user_id = \\$1").
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec("INSERT INTO workflow_statuses").
+			WithArgs(1, "todo", "To do", "todo", 0, nil, nil).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO workflow_statuses").
+			WithArgs(1, "done", "Done", "done", 1, nil, nil).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE tasks SET status_category = v.category").
+			WithArgs(1, `{"todo","done"}`, `{"todo","done"}`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		w, err := SetWorkflow(db, 1, workflow)
+		assert.NoError(t, err)
+		assert.True(t, w.Custom)
+		assert.Equal(t, 1, w.Statuses[1].Position)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Removed status in use", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec("SELECT id FROM users WHERE id = \\$1 FOR UPDATE").
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("SELECT MIN").
+			WithArgs(1, `{"todo","done"}`).
+			WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow("in_progress"))
+		mock.ExpectRollback()
+
+		_, err = SetWorkflow(db, 1, workflow)
+		assert.EqualError(t, err, "status in use: in_progress")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
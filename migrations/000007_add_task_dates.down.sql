@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_tasks_user_due_at;
+ALTER TABLE tasks
+    DROP COLUMN IF EXISTS start_at,
+    DROP COLUMN IF EXISTS due_at,
+    DROP COLUMN IF EXISTS all_day;
@@ -0,0 +1,9 @@
+-- Add optional schedule to tasks
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS start_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS due_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS all_day BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Speed up overdue lookups
+CREATE INDEX IF NOT EXISTS idx_tasks_user_due_at ON tasks(user_id, due_at)
+    WHERE due_at IS NOT NULL;
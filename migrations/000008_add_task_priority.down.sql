@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_tasks_user_priority;
+ALTER TABLE tasks
+    DROP CONSTRAINT IF EXISTS chk_tasks_priority,
+    DROP COLUMN IF EXISTS priority;
@@ -0,0 +1,9 @@
+-- Add priority to tasks
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS priority VARCHAR(10) NOT NULL DEFAULT 'none';
+
+ALTER TABLE tasks
+    ADD CONSTRAINT chk_tasks_priority
+    CHECK (priority IN ('none', 'low', 'medium', 'high', 'urgent'));
+
+CREATE INDEX IF NOT EXISTS idx_tasks_user_priority ON tasks(user_id, priority);
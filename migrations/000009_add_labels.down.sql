@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS tasks_labels;
+DROP TABLE IF EXISTS labels;
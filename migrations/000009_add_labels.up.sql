@@ -0,0 +1,21 @@
+-- Create user-scoped labels
+CREATE TABLE IF NOT EXISTS labels (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    color VARCHAR(7) NOT NULL DEFAULT '#808080',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Label names are unique per user regardless of case
+CREATE UNIQUE INDEX IF NOT EXISTS idx_labels_user_name ON labels(user_id, LOWER(name));
+
+-- Create many-to-many relation between tasks and labels
+CREATE TABLE IF NOT EXISTS tasks_labels (
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    label_id INTEGER NOT NULL REFERENCES labels(id) ON DELETE CASCADE,
+    PRIMARY KEY (task_id, label_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_tasks_labels_label_id ON tasks_labels(label_id);
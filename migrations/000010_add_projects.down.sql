@@ -0,0 +1,4 @@
+DROP VIEW IF EXISTS project_statistics;
+DROP INDEX IF EXISTS idx_tasks_user_project_position;
+ALTER TABLE tasks DROP COLUMN IF EXISTS project_id;
+DROP TABLE IF EXISTS projects;
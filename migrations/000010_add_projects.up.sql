@@ -0,0 +1,42 @@
+-- Create user-owned projects (lists) to group tasks
+CREATE TABLE IF NOT EXISTS projects (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    color VARCHAR(7) NOT NULL DEFAULT '#808080',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Project names are unique per user regardless of case
+CREATE UNIQUE INDEX IF NOT EXISTS idx_projects_user_name ON projects(user_id, LOWER(name));
+
+-- Tasks without a project belong to the user's inbox
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS project_id INTEGER REFERENCES projects(id) ON DELETE SET NULL;
+
+-- Positions are scoped per user and project
+CREATE INDEX IF NOT EXISTS idx_tasks_user_project_position ON tasks(user_id, project_id, position);
+
+-- Create view for per-project statistics
+CREATE OR REPLACE VIEW project_statistics AS
+SELECT 
+    p.id as project_id,
+    p.user_id,
+    p.name,
+    COUNT(t.id) as total_tasks,
+    COUNT(CASE WHEN t.status = 'completed' THEN 1 END) as completed_tasks,
+    COUNT(CASE WHEN t.status = 'pending' THEN 1 END) as pending_tasks,
+    COUNT(CASE WHEN t.status = 'in_progress' THEN 1 END) as in_progress_tasks,
+    COUNT(CASE WHEN t.status = 'deleted' THEN 1 END) as deleted_tasks,
+    COUNT(CASE WHEN t.status IN ('pending', 'in_progress')
+                AND t.due_at IS NOT NULL
+                AND CASE WHEN t.all_day THEN t.due_at + INTERVAL '1 day' ELSE t.due_at END <= NOW()
+               THEN 1 END) as overdue_tasks
+FROM 
+    projects p
+LEFT JOIN 
+    tasks t ON p.id = t.project_id
+GROUP BY 
+    p.id, p.user_id, p.name;
@@ -0,0 +1,6 @@
+-- Allow tasks to be nested below a parent task
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS parent_id INTEGER REFERENCES tasks(id) ON DELETE CASCADE;
+
+-- Speed up subtask lookups and recursive traversal
+CREATE INDEX IF NOT EXISTS idx_tasks_parent_id ON tasks(parent_id) WHERE parent_id IS NOT NULL;
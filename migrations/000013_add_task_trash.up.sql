@@ -0,0 +1,9 @@
+-- Remember when and from which status a task was moved to the trash
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS previous_status VARCHAR(20),
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+-- Backfill tasks deleted before the trash existed
+UPDATE tasks SET deleted_at = updated_at WHERE status = 'deleted' AND deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_user_deleted_at ON tasks(user_id, deleted_at) WHERE status = 'deleted';
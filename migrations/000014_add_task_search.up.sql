@@ -0,0 +1,11 @@
+-- Full-text search over task titles (weight A) and descriptions (weight B).
+-- The 'simple' configuration avoids language-specific stemming so titles in
+-- any language are matched word by word.
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', COALESCE(title, '')), 'A') ||
+        setweight(to_tsvector('simple', COALESCE(description, '')), 'B')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_search_vector ON tasks USING GIN (search_vector);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_tasks_user_completed_at;
+ALTER TABLE tasks
+    DROP COLUMN IF EXISTS completed_at,
+    DROP COLUMN IF EXISTS started_at;
@@ -0,0 +1,26 @@
+-- Remember when work on a task started and when it was completed
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS started_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS completed_at TIMESTAMP;
+
+-- Backfill from the recorded history where available
+UPDATE tasks SET started_at = (
+    SELECT MIN(e.created_at) FROM task_events e
+    WHERE e.task_id = tasks.id
+    AND e.event_type = 'status_changed'
+    AND e.new_value = '"in_progress"'::jsonb
+)
+WHERE started_at IS NULL;
+
+UPDATE tasks SET completed_at = COALESCE((
+    SELECT MAX(e.created_at) FROM task_events e
+    WHERE e.task_id = tasks.id
+    AND e.event_type = 'status_changed'
+    AND e.new_value = '"completed"'::jsonb
+), updated_at)
+WHERE status = 'completed' AND completed_at IS NULL;
+
+-- Tasks in progress before history existed started at their last change at the latest
+UPDATE tasks SET started_at = updated_at WHERE status = 'in_progress' AND started_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_user_completed_at ON tasks(user_id, completed_at) WHERE status = 'completed';
@@ -0,0 +1,4 @@
+ALTER TABLE user_preferences
+    DROP COLUMN IF EXISTS date_format,
+    DROP COLUMN IF EXISTS week_start,
+    DROP COLUMN IF EXISTS locale;
@@ -0,0 +1,6 @@
+-- Language, calendar and date display settings
+ALTER TABLE user_preferences
+    ADD COLUMN IF NOT EXISTS locale VARCHAR(10) NOT NULL DEFAULT 'en',
+    ADD COLUMN IF NOT EXISTS week_start VARCHAR(10) NOT NULL DEFAULT 'monday'
+        CHECK (week_start IN ('monday', 'sunday')),
+    ADD COLUMN IF NOT EXISTS date_format VARCHAR(20) NOT NULL DEFAULT 'YYYY-MM-DD';
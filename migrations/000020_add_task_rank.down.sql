@@ -0,0 +1,26 @@
+CREATE OR REPLACE FUNCTION increment_task_version() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS position INTEGER NOT NULL DEFAULT 0;
+
+UPDATE tasks
+SET position = ranked.position
+FROM (
+    SELECT id,
+           ROW_NUMBER() OVER (
+               PARTITION BY user_id, project_id, parent_id
+               ORDER BY rank, id
+           ) - 1 AS position
+    FROM tasks
+) ranked
+WHERE tasks.id = ranked.id;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_user_position ON tasks(user_id, position);
+CREATE INDEX IF NOT EXISTS idx_tasks_user_project_position ON tasks(user_id, project_id, position);
+
+DROP INDEX IF EXISTS idx_tasks_list_rank;
+ALTER TABLE tasks DROP COLUMN IF EXISTS rank;
@@ -0,0 +1,39 @@
+-- Order tasks within their list by a lexicographic rank instead of a
+-- contiguous position, so that inserts and moves only write the moved row.
+-- Ranks are compared byte by byte, hence the "C" collation.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS rank TEXT COLLATE "C";
+
+-- Spread the existing order over fixed-width hexadecimal ranks ending in 8,
+-- which leaves room before, between and after every task
+UPDATE tasks
+SET rank = ranked.rank
+FROM (
+    SELECT id,
+           LPAD(TO_HEX(ROW_NUMBER() OVER (
+               PARTITION BY user_id, project_id, parent_id
+               ORDER BY position, id
+           ) * 16 + 8), 8, '0') AS rank
+    FROM tasks
+) ranked
+WHERE tasks.id = ranked.id;
+
+ALTER TABLE tasks ALTER COLUMN rank SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_list_rank ON tasks(user_id, project_id, parent_id, rank);
+
+DROP INDEX IF EXISTS idx_tasks_user_position;
+DROP INDEX IF EXISTS idx_tasks_user_project_position;
+ALTER TABLE tasks DROP COLUMN IF EXISTS position;
+
+-- Rebalancing rewrites ranks without changing the order of a list, so
+-- updates that only touch the rank keep the version
+CREATE OR REPLACE FUNCTION increment_task_version() RETURNS TRIGGER AS $$
+BEGIN
+    IF to_jsonb(NEW) - 'rank' - 'version' = to_jsonb(OLD) - 'rank' - 'version' THEN
+        NEW.version := OLD.version;
+    ELSE
+        NEW.version := OLD.version + 1;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
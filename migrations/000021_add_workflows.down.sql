@@ -0,0 +1,60 @@
+-- Custom statuses fall back to the built-in status of their category
+UPDATE tasks
+SET status = CASE status_category
+    WHEN 'done' THEN 'completed'
+    WHEN 'doing' THEN 'in_progress'
+    ELSE 'pending'
+END
+WHERE status NOT IN ('pending', 'in_progress', 'completed', 'deleted');
+
+UPDATE tasks
+SET previous_status = CASE status_category
+    WHEN 'done' THEN 'completed'
+    WHEN 'doing' THEN 'in_progress'
+    ELSE 'pending'
+END
+WHERE status = 'deleted' AND previous_status NOT IN ('pending', 'in_progress', 'completed');
+
+CREATE OR REPLACE VIEW user_statistics AS
+SELECT 
+    u.id as user_id,
+    u.username,
+    COUNT(t.id) as total_tasks,
+    COUNT(CASE WHEN t.status = 'completed' THEN 1 END) as completed_tasks,
+    COUNT(CASE WHEN t.status = 'pending' THEN 1 END) as pending_tasks,
+    COUNT(CASE WHEN t.status = 'in_progress' THEN 1 END) as in_progress_tasks,
+    COUNT(CASE WHEN t.status = 'deleted' THEN 1 END) as deleted_tasks,
+    COUNT(CASE WHEN DATE(t.created_at) = CURRENT_DATE THEN 1 END) as tasks_created_today
+FROM 
+    users u
+LEFT JOIN 
+    tasks t ON u.id = t.user_id
+GROUP BY 
+    u.id, u.username;
+
+CREATE OR REPLACE VIEW project_statistics AS
+SELECT 
+    p.id as project_id,
+    p.user_id,
+    p.name,
+    COUNT(t.id) as total_tasks,
+    COUNT(CASE WHEN t.status = 'completed' THEN 1 END) as completed_tasks,
+    COUNT(CASE WHEN t.status = 'pending' THEN 1 END) as pending_tasks,
+    COUNT(CASE WHEN t.status = 'in_progress' THEN 1 END) as in_progress_tasks,
+    COUNT(CASE WHEN t.status = 'deleted' THEN 1 END) as deleted_tasks,
+    COUNT(CASE WHEN t.status IN ('pending', 'in_progress')
+                AND t.due_at IS NOT NULL
+                AND CASE WHEN t.all_day THEN t.due_at + INTERVAL '1 day' ELSE t.due_at END <= NOW()
+               THEN 1 END) as overdue_tasks
+FROM 
+    projects p
+LEFT JOIN 
+    tasks t ON p.id = t.project_id
+GROUP BY 
+    p.id, p.user_id, p.name;
+
+DROP INDEX IF EXISTS idx_tasks_user_completed_at;
+CREATE INDEX IF NOT EXISTS idx_tasks_user_completed_at ON tasks(user_id, completed_at) WHERE status = 'completed';
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS status_category;
+DROP TABLE IF EXISTS workflow_statuses;
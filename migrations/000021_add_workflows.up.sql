@@ -0,0 +1,82 @@
+-- User-defined task statuses. Users without rows use the built-in
+-- pending/in_progress/completed workflow.
+CREATE TABLE IF NOT EXISTS workflow_statuses (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    key VARCHAR(20) NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    category VARCHAR(10) NOT NULL CHECK (category IN ('todo', 'doing', 'done')),
+    position INTEGER NOT NULL,
+    -- Statuses a task may move to from this one; NULL allows all
+    transitions TEXT[],
+    UNIQUE (user_id, key)
+);
+
+-- The category of a task's status, kept on the task so that queries don't
+-- depend on the status names. Deleted tasks keep the category of the status
+-- they are restored to.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS status_category VARCHAR(10) NOT NULL DEFAULT 'todo'
+    CHECK (status_category IN ('todo', 'doing', 'done'));
+
+-- Deleted tasks without a previous status are restored as pending; make
+-- that explicit so the status counts as in use
+UPDATE tasks SET previous_status = 'pending' WHERE status = 'deleted' AND previous_status IS NULL;
+
+UPDATE tasks
+SET status_category = CASE COALESCE(NULLIF(status, 'deleted'), previous_status)
+    WHEN 'completed' THEN 'done'
+    WHEN 'in_progress' THEN 'doing'
+    ELSE 'todo'
+END;
+
+DROP INDEX IF EXISTS idx_tasks_user_completed_at;
+CREATE INDEX IF NOT EXISTS idx_tasks_user_completed_at ON tasks(user_id, completed_at)
+    WHERE status_category = 'done' AND status != 'deleted';
+
+-- Count tasks by category; the column names keep their meaning for the
+-- built-in workflow. Days are counted in the owner's time zone, and all-day
+-- tasks remain on time until the end of their due date there.
+CREATE OR REPLACE VIEW user_statistics AS
+SELECT 
+    u.id as user_id,
+    u.username,
+    COUNT(t.id) as total_tasks,
+    COUNT(CASE WHEN t.status != 'deleted' AND t.status_category = 'done' THEN 1 END) as completed_tasks,
+    COUNT(CASE WHEN t.status != 'deleted' AND t.status_category = 'todo' THEN 1 END) as pending_tasks,
+    COUNT(CASE WHEN t.status != 'deleted' AND t.status_category = 'doing' THEN 1 END) as in_progress_tasks,
+    COUNT(CASE WHEN t.status = 'deleted' THEN 1 END) as deleted_tasks,
+    COUNT(CASE WHEN (t.created_at AT TIME ZONE 'UTC' AT TIME ZONE COALESCE(up.time_zone, 'UTC'))::date
+                    = (NOW() AT TIME ZONE COALESCE(up.time_zone, 'UTC'))::date
+               THEN 1 END) as tasks_created_today
+FROM 
+    users u
+LEFT JOIN 
+    user_preferences up ON up.user_id = u.id
+LEFT JOIN 
+    tasks t ON u.id = t.user_id
+GROUP BY 
+    u.id, u.username, up.time_zone;
+
+CREATE OR REPLACE VIEW project_statistics AS
+SELECT 
+    p.id as project_id,
+    p.user_id,
+    p.name,
+    COUNT(t.id) as total_tasks,
+    COUNT(CASE WHEN t.status != 'deleted' AND t.status_category = 'done' THEN 1 END) as completed_tasks,
+    COUNT(CASE WHEN t.status != 'deleted' AND t.status_category = 'todo' THEN 1 END) as pending_tasks,
+    COUNT(CASE WHEN t.status != 'deleted' AND t.status_category = 'doing' THEN 1 END) as in_progress_tasks,
+    COUNT(CASE WHEN t.status = 'deleted' THEN 1 END) as deleted_tasks,
+    COUNT(CASE WHEN t.status != 'deleted' AND t.status_category != 'done'
+                AND t.due_at IS NOT NULL
+                AND CASE WHEN t.all_day THEN (t.due_at + INTERVAL '1 day') AT TIME ZONE COALESCE(up.time_zone, 'UTC')
+                    ELSE t.due_at AT TIME ZONE 'UTC' END <= NOW()
+               THEN 1 END) as overdue_tasks
+FROM 
+    projects p
+LEFT JOIN 
+    user_preferences up ON up.user_id = p.user_id
+LEFT JOIN 
+    tasks t ON p.id = t.project_id
+GROUP BY 
+    p.id, p.user_id, p.name, up.time_zone;
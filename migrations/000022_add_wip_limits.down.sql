@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_tasks_board_column;
+ALTER TABLE workflow_statuses DROP COLUMN IF EXISTS wip_limit;
@@ -0,0 +1,7 @@
+-- Work-in-progress limit of a board column: the number of top-level tasks
+-- of one project (or the inbox) that may be in the status; NULL is unlimited
+ALTER TABLE workflow_statuses ADD COLUMN IF NOT EXISTS wip_limit INTEGER CHECK (wip_limit > 0);
+
+-- Count the tasks of a board column
+CREATE INDEX IF NOT EXISTS idx_tasks_board_column ON tasks(user_id, project_id, status)
+    WHERE parent_id IS NULL AND status != 'deleted';
@@ -0,0 +1,14 @@
+-- Reusable task trees: the task column holds the title, description,
+-- status, priority and labels of the top-level task and, recursively,
+-- of its subtasks
+CREATE TABLE IF NOT EXISTS task_templates (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    task JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Template names are unique per user regardless of case
+CREATE UNIQUE INDEX IF NOT EXISTS idx_task_templates_user_name ON task_templates(user_id, LOWER(name));
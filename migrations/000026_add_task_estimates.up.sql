@@ -0,0 +1,3 @@
+-- Optional effort estimates of a task in story points and in minutes
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS estimate_points INTEGER CHECK (estimate_points >= 0);
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS estimate_minutes INTEGER CHECK (estimate_minutes >= 0);
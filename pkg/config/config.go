@@ -6,6 +6,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -52,6 +53,21 @@ type Config struct {
 	JWT struct {
 		Secret string // Secret key for signing JWTs
 	}
+
+	// Tasks contains task hierarchy settings
+	Tasks struct {
+		MaxDepth              int           // Maximum number of subtask levels below a top-level task
+		MaxRankLength         int           // Rank length above which a task list is rebalanced
+		RankRebalanceInterval time.Duration // Time between two rank rebalancing runs
+	}
+
+	// Retention contains the cleanup policy of the background retention job
+	Retention struct {
+		Enabled      bool          // Whether the retention job runs at all
+		Interval     time.Duration // Time between two cleanup runs
+		DeletedTasks time.Duration // How long deleted tasks stay in the trash
+		TokenGrace   time.Duration // How long expired tokens are kept after expiry
+	}
 }
 
 // LoadConfig reads configuration from environment variables and returns a Config instance.
@@ -82,6 +98,17 @@ type Config struct {
 //	JWT:
 //	  - JWT_SECRET: JWT signing key (default: "your-secret-key")
 //
+//	Tasks:
+//	  - TASK_MAX_DEPTH: Maximum subtask nesting depth (default: 3)
+//	  - TASK_MAX_RANK_LENGTH: Rank length that triggers rebalancing of a list (default: 24)
+//	  - TASK_RANK_REBALANCE_INTERVAL: Time between rank rebalancing runs (default: "1h")
+//
+//	Retention:
+//	  - RETENTION_ENABLED: Run the background cleanup job (default: true)
+//	  - RETENTION_INTERVAL: Time between cleanup runs (default: "24h")
+//	  - RETENTION_DELETED_TASKS: Age after which deleted tasks are purged (default: "720h")
+//	  - RETENTION_TOKEN_GRACE: Time expired tokens are kept after expiry (default: "24h")
+//
 // Returns:
 //   - *Config: Populated configuration struct
 //   - error: Any error encountered during loading
@@ -117,6 +144,17 @@ func LoadConfig() (*Config, error) {
 	// JWT configuration
 	config.JWT.Secret = getEnv("JWT_SECRET", "your-secret-key")
 
+	// Task configuration
+	config.Tasks.MaxDepth = getEnvAsInt("TASK_MAX_DEPTH", 3)
+	config.Tasks.MaxRankLength = getEnvAsInt("TASK_MAX_RANK_LENGTH", 24)
+	config.Tasks.RankRebalanceInterval = getEnvAsDuration("TASK_RANK_REBALANCE_INTERVAL", time.Hour)
+
+	// Retention configuration
+	config.Retention.Enabled = getEnvAsBool("RETENTION_ENABLED", true)
+	config.Retention.Interval = getEnvAsDuration("RETENTION_INTERVAL", 24*time.Hour)
+	config.Retention.DeletedTasks = getEnvAsDuration("RETENTION_DELETED_TASKS", 30*24*time.Hour)
+	config.Retention.TokenGrace = getEnvAsDuration("RETENTION_TOKEN_GRACE", 24*time.Hour)
+
 	return config, nil
 }
 
@@ -156,3 +194,46 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return intValue
 }
+
+// getEnvAsBool retrieves an environment variable and converts it to a boolean.
+// Returns the default value if the variable is not set or cannot be converted.
+//
+// Parameters:
+//   - key: The environment variable name
+//   - defaultValue: Value to return if environment variable is not set or invalid
+//
+// Returns:
+//   - bool: The parsed boolean value or default
+func getEnvAsBool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	boolValue, err := strconv.ParseBool(value)
+	if err != nil {
+		return defaultValue
+	}
+	return boolValue
+}
+
+// getEnvAsDuration retrieves an environment variable and parses it as a
+// duration such as "90m" or "720h".
+// Returns the default value if the variable is not set or cannot be parsed.
+//
+// Parameters:
+//   - key: The environment variable name
+//   - defaultValue: Value to return if environment variable is not set or invalid
+//
+// Returns:
+//   - time.Duration: The parsed duration or default
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return defaultValue
+	}
+	return duration
+}
@@ -16,12 +16,63 @@ import (
 // implementation.
 type EmailSender interface {
 	// SendWelcomeEmail sends a welcome email to new users
-	SendWelcomeEmail(to, username string) error
+	SendWelcomeEmail(to, username string, locale Locale) error
 
 	// SendVerificationEmail sends an email verification link
-	SendVerificationEmail(to, username, token string) error
+	SendVerificationEmail(to, username, token string, locale Locale) error
 
-	SendPasswordResetEmail(email, resetLins string) error
+	SendPasswordResetEmail(email, resetLins string, locale Locale) error
+}
+
+// Locale describes how an email is rendered for its recipient.
+// The zero value renders English emails with UTC timestamps.
+type Locale struct {
+	// Language selects the template set, e.g. "ru" uses templates/email/ru.
+	// Languages without templates fall back to English.
+	Language string
+
+	// Location is the time zone timestamps are shown in; nil means UTC
+	Location *time.Location
+
+	// DateLayout is the Go layout of dates in timestamps; empty means 2006-01-02
+	DateLayout string
+}
+
+// FormatTime renders a timestamp as the recipient expects it,
+// e.g. "20.05.2024 14:30 CEST".
+func (l Locale) FormatTime(t time.Time) string {
+	loc := l.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	layout := l.DateLayout
+	if layout == "" {
+		layout = "2006-01-02"
+	}
+	return t.In(loc).Format(layout + " 15:04 MST")
+}
+
+// subjects holds the translated subject lines by language and email.
+// English subjects are used for languages missing here.
+var subjects = map[string]map[string]string{
+	"en": {
+		"welcome.html":        "Welcome to Task Manager!",
+		"verification.html":   "Verify Your Email Address",
+		"password-reset.html": "Reset Your Password - ActionHub",
+	},
+	"ru": {
+		"welcome.html":        "Добро пожаловать в Task Manager!",
+		"verification.html":   "Подтвердите адрес электронной почты",
+		"password-reset.html": "Сброс пароля - ActionHub",
+	},
+}
+
+// subject returns the subject line of an email in the given language.
+func subject(language, name string) string {
+	if s, ok := subjects[language][name]; ok {
+		return s
+	}
+	return subjects["en"][name]
 }
 
 // EmailService implements the EmailSender interface and handles
@@ -71,6 +122,7 @@ type WelcomeEmailData struct {
 // Parameters:
 //   - to: Recipient email address
 //   - username: Recipient's username
+//   - locale: Language and time display of the recipient
 //
 // Returns:
 //   - error: Any error encountered during email sending
@@ -79,7 +131,7 @@ type WelcomeEmailData struct {
 //   - Username: User's display name
 //   - LoginURL: URL to the login page
 //   - Year: Current year for copyright
-func (s *EmailService) SendWelcomeEmail(to, username string) error {
+func (s *EmailService) SendWelcomeEmail(to, username string, locale Locale) error {
 	// Prepare template data
 	data := WelcomeEmailData{
 		Username: username,
@@ -88,7 +140,7 @@ func (s *EmailService) SendWelcomeEmail(to, username string) error {
 	}
 
 	// Execute email template
-	body, err := s.templates.ExecuteTemplate("welcome.html", data)
+	body, err := s.templates.ExecuteLocalizedTemplate(locale.Language, "welcome.html", data)
 	if err != nil {
 		return err
 	}
@@ -97,7 +149,7 @@ func (s *EmailService) SendWelcomeEmail(to, username string) error {
 	m := mail.NewMessage()
 	m.SetHeader("From", s.from)
 	m.SetHeader("To", to)
-	m.SetHeader("Subject", "Welcome to Task Manager!")
+	m.SetHeader("Subject", subject(locale.Language, "welcome.html"))
 	m.SetBody("text/html", body)
 
 	return s.dialer.DialAndSend(m)
@@ -117,6 +169,7 @@ type VerificationEmailData struct {
 //   - to: Recipient email address
 //   - username: Recipient's username
 //   - token: Verification token
+//   - locale: Language and time display of the recipient
 //
 // Returns:
 //   - error: Any error encountered during email sending
@@ -125,23 +178,23 @@ type VerificationEmailData struct {
 //   - Username: User's display name
 //   - VerificationLink: Complete verification URL with token
 //   - Year: Current year for copyright
-func (s *EmailService) SendVerificationEmail(to, username, token string) error {
+func (s *EmailService) SendVerificationEmail(to, username, token string, locale Locale) error {
 	// Create email message
 	m := mail.NewMessage()
 	m.SetHeader("From", s.from)
 	m.SetHeader("To", to)
-	m.SetHeader("Subject", "Verify Your Email Address")
+	m.SetHeader("Subject", subject(locale.Language, "verification.html"))
 
 	// Prepare template data
 	data := VerificationEmailData{
 		Username:         username,
 		VerificationLink: fmt.Sprintf("%s/verify-email?token=%s", s.baseURL, token),
 		Year:             time.Now().Year(),
-		CurrentTime:      time.Now().Format(time.RFC3339),
+		CurrentTime:      locale.FormatTime(time.Now()),
 	}
 
 	// Execute template
-	body, err := s.templates.ExecuteTemplate("verification.html", data)
+	body, err := s.templates.ExecuteLocalizedTemplate(locale.Language, "verification.html", data)
 	if err != nil {
 		return fmt.Errorf("failed to execute email template: %v", err)
 	}
@@ -157,7 +210,7 @@ type PasswordResetEmailData struct {
 	Timestamp string
 }
 
-func (s *EmailService) SendPasswordResetEmail(to, resetLink string) error {
+func (s *EmailService) SendPasswordResetEmail(to, resetLink string, locale Locale) error {
 	maskedTo := maskEmail(to)
 	log.Printf("Sending password reset email to: %s", maskedTo)
 
@@ -169,8 +222,8 @@ func (s *EmailService) SendPasswordResetEmail(to, resetLink string) error {
 	m.SetHeader("To", to)
 	log.Printf("Setting header To: %s", maskedTo)
 
-	m.SetHeader("Subject", "Reset Your Password - ActionHub")
-	log.Printf("Setting header Subject: Reset Your Password - ActionHub")
+	m.SetHeader("Subject", subject(locale.Language, "password-reset.html"))
+	log.Printf("Setting header Subject: %s", subject(locale.Language, "password-reset.html"))
 
 	// Get IP address from request context (you might need to pass this through)
 	ipAddress := "Unknown"
@@ -180,11 +233,11 @@ func (s *EmailService) SendPasswordResetEmail(to, resetLink string) error {
 		ResetLink: resetLink,
 		IPAddress: ipAddress,
 		Year:      time.Now().Year(),
-		Timestamp: time.Now().Format(time.RFC3339),
+		Timestamp: locale.FormatTime(time.Now()),
 	}
 
 	// Execute template
-	body, err := s.templates.ExecuteTemplate("password-reset.html", data)
+	body, err := s.templates.ExecuteLocalizedTemplate(locale.Language, "password-reset.html", data)
 	if err != nil {
 		log.Printf("Failed to execute password reset email template: %v", err)
 		return fmt.Errorf("failed to execute email template: %v", err)
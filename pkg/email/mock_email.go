@@ -10,17 +10,17 @@ func NewMockEmailService() EmailSender {
 	return &MockEmailService{}
 }
 
-func (s *MockEmailService) SendWelcomeEmail(to, username string) error {
+func (s *MockEmailService) SendWelcomeEmail(to, username string, locale Locale) error {
 	log.Printf("Mock: Sending welcome email to %s (%s)", username, to)
 	return nil
 }
 
-func (s *MockEmailService) SendVerificationEmail(to, username, token string) error {
+func (s *MockEmailService) SendVerificationEmail(to, username, token string, locale Locale) error {
 	log.Printf("Mock: Sending verification email to %s (%s)", username, to)
 	return nil
 }
 
-func (s *MockEmailService) SendPasswordResetEmail(email, resetLins string) error {
+func (s *MockEmailService) SendPasswordResetEmail(email, resetLins string, locale Locale) error {
 	log.Printf("Mock: Sending password reser email")
 	return nil
 }
@@ -4,20 +4,24 @@ package email
 import (
 	"bytes"
 	"html/template"
+	"path/filepath"
 )
 
 // EmailTemplate manages HTML email templates for the application.
 // It wraps the standard template.Template to provide email-specific
 // template execution and management.
 type EmailTemplate struct {
-	templates *template.Template // Compiled HTML templates
+	templates *template.Template            // Compiled English HTML templates
+	localized map[string]*template.Template // Compiled translations by language
 }
 
 // NewEmailTemplate initializes a new email template manager by loading
 // all HTML templates from the templates/email directory.
 //
 // The function expects templates to be located in "templates/email/*.html"
-// and will parse all files matching this pattern.
+// and will parse all files matching this pattern. Translations live in
+// one subdirectory per language, e.g. "templates/email/ru/*.html", and
+// may cover only some of the emails.
 //
 // Returns:
 //   - *EmailTemplate: Template manager instance
@@ -37,7 +41,28 @@ func NewEmailTemplate() (*EmailTemplate, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &EmailTemplate{templates: templates}, nil
+
+	// Load translations, grouped by their language directory
+	files, err := filepath.Glob("templates/email/*/*.html")
+	if err != nil {
+		return nil, err
+	}
+	byLanguage := make(map[string][]string)
+	for _, file := range files {
+		language := filepath.Base(filepath.Dir(file))
+		byLanguage[language] = append(byLanguage[language], file)
+	}
+
+	localized := make(map[string]*template.Template, len(byLanguage))
+	for language, files := range byLanguage {
+		t, err := template.ParseFiles(files...)
+		if err != nil {
+			return nil, err
+		}
+		localized[language] = t
+	}
+
+	return &EmailTemplate{templates: templates, localized: localized}, nil
 }
 
 // ExecuteTemplate renders a specific template with the provided data.
@@ -67,3 +92,25 @@ func (et *EmailTemplate) ExecuteTemplate(name string, data interface{}) (string,
 	}
 	return buf.String(), nil
 }
+
+// ExecuteLocalizedTemplate renders a template in the given language,
+// falling back to the English template when no translation exists.
+//
+// Parameters:
+//   - language: Language of the recipient (e.g., "ru")
+//   - name: Name of the template to execute (e.g., "welcome.html")
+//   - data: Data to be passed to the template
+//
+// Returns:
+//   - string: The rendered template as a string
+//   - error: Any error encountered during template execution
+func (et *EmailTemplate) ExecuteLocalizedTemplate(language, name string, data interface{}) (string, error) {
+	if t, ok := et.localized[language]; ok && t.Lookup(name) != nil {
+		var buf bytes.Buffer
+		if err := t.ExecuteTemplate(&buf, name, data); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+	return et.ExecuteTemplate(name, data)
+}
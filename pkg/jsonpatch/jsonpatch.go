@@ -0,0 +1,466 @@
+// Package jsonpatch applies partial updates to JSON documents, either as
+// a JSON Merge Patch (RFC 7396) or as a JSON Patch (RFC 6902).
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Media types identifying the two patch formats in a Content-Type header
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+// MergePatch applies a JSON Merge Patch to doc and returns the result.
+// Members of the patch replace those of the document, objects are merged
+// recursively and null removes a member.
+//
+// Example:
+//
+//	doc:    {"title": "Old", "due_at": "2024-01-01T00:00:00Z"}
+//	patch:  {"title": "New", "due_at": null}
+//	result: {"title": "New"}
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid document: %w", err)
+	}
+	value, err := decode(patch)
+	if err != nil {
+		return nil, fmt.Errorf("invalid patch: %w", err)
+	}
+
+	return json.Marshal(merge(target, value))
+}
+
+// merge implements the MergePatch algorithm of RFC 7396, section 2
+func merge(target, patch interface{}) interface{} {
+	members, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	result, ok := target.(map[string]interface{})
+	if !ok {
+		result = map[string]interface{}{}
+	}
+	for name, value := range members {
+		if value == nil {
+			delete(result, name)
+			continue
+		}
+		result[name] = merge(result[name], value)
+	}
+
+	return result
+}
+
+// Patch is a parsed JSON Patch: a list of operations applied in order
+type Patch []Operation
+
+// Operation is a single JSON Patch operation
+type Operation struct {
+	// Op is one of "add", "remove", "replace", "move", "copy" or "test"
+	Op string
+
+	// Path is the JSON Pointer (RFC 6901) the operation targets
+	Path string
+
+	// From is the source location of "move" and "copy"
+	From string
+
+	// Value is the operand of "add", "replace" and "test"
+	Value interface{}
+}
+
+// ApplyError reports an operation that could not be applied to the
+// document, as opposed to a malformed patch.
+type ApplyError struct {
+	// Index is the position of the failed operation in the patch
+	Index int
+
+	// Test is true when a "test" operation did not match
+	Test bool
+
+	msg string
+}
+
+func (e *ApplyError) Error() string {
+	return fmt.Sprintf("operation %d: %s", e.Index, e.msg)
+}
+
+// Parse reads a JSON Patch document and checks that each operation is
+// complete.
+//
+// Returns:
+//   - Patch: The parsed operations
+//   - error: If the document is not an array of valid operations
+func Parse(data []byte) (Patch, error) {
+	var raw []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid patch: %w", err)
+	}
+
+	patch := make(Patch, 0, len(raw))
+	for i, fields := range raw {
+		var op Operation
+		if err := unmarshalMember(fields, "op", &op.Op); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+		if err := unmarshalMember(fields, "path", &op.Path); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+		if _, err := parsePointer(op.Path); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+
+		switch op.Op {
+		case "add", "replace", "test":
+			value, ok := fields["value"]
+			if !ok {
+				return nil, fmt.Errorf("operation %d: missing value", i)
+			}
+			v, err := decode(value)
+			if err != nil {
+				return nil, fmt.Errorf("operation %d: invalid value: %w", i, err)
+			}
+			op.Value = v
+		case "move", "copy":
+			if err := unmarshalMember(fields, "from", &op.From); err != nil {
+				return nil, fmt.Errorf("operation %d: %w", i, err)
+			}
+			if _, err := parsePointer(op.From); err != nil {
+				return nil, fmt.Errorf("operation %d: %w", i, err)
+			}
+		case "remove":
+		default:
+			return nil, fmt.Errorf("operation %d: unknown op: %s", i, op.Op)
+		}
+
+		patch = append(patch, op)
+	}
+
+	return patch, nil
+}
+
+// unmarshalMember decodes the required string member name of an operation
+func unmarshalMember(fields map[string]json.RawMessage, name string, dst *string) error {
+	value, ok := fields[name]
+	if !ok {
+		return fmt.Errorf("missing %s", name)
+	}
+	if err := json.Unmarshal(value, dst); err != nil {
+		return fmt.Errorf("invalid %s", name)
+	}
+	return nil
+}
+
+// Apply applies the operations to doc and returns the result. The
+// operations are atomic: when one fails, no result is returned.
+//
+// Returns:
+//   - []byte: The patched document
+//   - error: *ApplyError when an operation cannot be applied
+//
+// Example Usage:
+//
+//	patch, err := jsonpatch.Parse([]byte(`[{"op": "replace", "path": "/status", "value": "completed"}]`))
+//	if err != nil {
+//	    return err
+//	}
+//	result, err := patch.Apply(doc)
+func (p Patch) Apply(doc []byte) ([]byte, error) {
+	root, err := decode(doc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid document: %w", err)
+	}
+
+	for i, op := range p {
+		if root, err = op.apply(root); err != nil {
+			var applyErr *ApplyError
+			if errors.As(err, &applyErr) {
+				applyErr.Index = i
+			}
+			return nil, err
+		}
+	}
+
+	return json.Marshal(root)
+}
+
+// apply performs a single operation on the document root
+func (op Operation) apply(root interface{}) (interface{}, error) {
+	path, _ := parsePointer(op.Path)
+
+	switch op.Op {
+	case "add":
+		return add(root, path, op.Value)
+	case "remove":
+		root, _, err := remove(root, path)
+		return root, err
+	case "replace":
+		root, _, err := remove(root, path)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, path, op.Value)
+	case "move":
+		from, _ := parsePointer(op.From)
+		if strings.HasPrefix(op.Path+"/", op.From+"/") && op.Path != op.From {
+			return nil, &ApplyError{msg: "cannot move " + op.From + " into itself"}
+		}
+		root, value, err := remove(root, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, path, value)
+	case "copy":
+		from, _ := parsePointer(op.From)
+		value, err := get(root, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, path, clone(value))
+	default: // test
+		value, err := get(root, path)
+		if err != nil {
+			return nil, err
+		}
+		if !equal(value, op.Value) {
+			return nil, &ApplyError{Test: true, msg: "test failed: " + op.Path}
+		}
+		return root, nil
+	}
+}
+
+// parsePointer splits a JSON Pointer into its unescaped reference tokens.
+// The empty pointer refers to the whole document.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid path: %s", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+// get returns the value the path refers to
+func get(node interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch container := node.(type) {
+		case map[string]interface{}:
+			value, ok := container[token]
+			if !ok {
+				return nil, notFound(path)
+			}
+			node = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(container)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = container[index]
+		default:
+			return nil, notFound(path)
+		}
+	}
+	return node, nil
+}
+
+// add inserts value at path and returns the new root. Array elements
+// after the insertion point are shifted; "-" appends to an array.
+func add(root interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, err := get(root, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	token := path[len(path)-1]
+
+	switch container := parent.(type) {
+	case map[string]interface{}:
+		container[token] = value
+		return root, nil
+	case []interface{}:
+		index := len(container)
+		if token != "-" {
+			if index, err = arrayIndex(token, len(container)); err != nil {
+				return nil, err
+			}
+		}
+		container = append(container, nil)
+		copy(container[index+1:], container[index:])
+		container[index] = value
+		return replaceParent(root, path[:len(path)-1], container)
+	default:
+		return nil, notFound(path)
+	}
+}
+
+// remove deletes the value at path and returns the new root and the
+// removed value
+func remove(root interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, root, nil
+	}
+
+	parent, err := get(root, path[:len(path)-1])
+	if err != nil {
+		return nil, nil, err
+	}
+	token := path[len(path)-1]
+
+	switch container := parent.(type) {
+	case map[string]interface{}:
+		value, ok := container[token]
+		if !ok {
+			return nil, nil, notFound(path)
+		}
+		delete(container, token)
+		return root, value, nil
+	case []interface{}:
+		index, err := arrayIndex(token, len(container)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		value := container[index]
+		container = append(container[:index:index], container[index+1:]...)
+		root, err = replaceParent(root, path[:len(path)-1], container)
+		return root, value, err
+	default:
+		return nil, nil, notFound(path)
+	}
+}
+
+// replaceParent stores a resized array back at path, since appending to
+// or removing from a slice may not modify it in place
+func replaceParent(root interface{}, path []string, array []interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return array, nil
+	}
+
+	parent, err := get(root, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	token := path[len(path)-1]
+
+	switch container := parent.(type) {
+	case map[string]interface{}:
+		container[token] = array
+	case []interface{}:
+		index, _ := strconv.Atoi(token)
+		container[index] = array
+	}
+	return root, nil
+}
+
+// arrayIndex parses an array index token, which may not exceed max
+func arrayIndex(token string, max int) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || index > max || (len(token) > 1 && token[0] == '0') {
+		return 0, &ApplyError{msg: "invalid array index: " + token}
+	}
+	return index, nil
+}
+
+// notFound reports a path that does not exist in the document
+func notFound(path []string) error {
+	tokens := make([]string, len(path))
+	for i, token := range path {
+		tokens[i] = strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+	}
+	return &ApplyError{msg: "path not found: /" + strings.Join(tokens, "/")}
+}
+
+// decode parses a JSON value, keeping numbers exact
+func decode(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+	return value, nil
+}
+
+// clone returns a deep copy of a decoded JSON value, so that copied
+// values do not share containers with their source
+func clone(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for name, member := range v {
+			result[name] = clone(member)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, element := range v {
+			result[i] = clone(element)
+		}
+		return result
+	default:
+		return v
+	}
+}
+
+// equal compares decoded JSON values; numbers are equal when they have
+// the same numeric value regardless of their notation
+func equal(a, b interface{}) bool {
+	na, aok := a.(json.Number)
+	nb, bok := b.(json.Number)
+	if aok && bok {
+		fa, errA := na.Float64()
+		fb, errB := nb.Float64()
+		return errA == nil && errB == nil && fa == fb
+	}
+
+	switch va := a.(type) {
+	case map[string]interface{}:
+		vb, ok := b.(map[string]interface{})
+		if !ok || len(va) != len(vb) {
+			return false
+		}
+		for name, member := range va {
+			other, ok := vb[name]
+			if !ok || !equal(member, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		vb, ok := b.([]interface{})
+		if !ok || len(va) != len(vb) {
+			return false
+		}
+		for i := range va {
+			if !equal(va[i], vb[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(a, b)
+	}
+}
@@ -0,0 +1,142 @@
+package jsonpatch
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		doc      string
+		patch    string
+		expected string
+	}{
+		{
+			name:     "Replace and remove members",
+			doc:      `{"title": "Old", "due_at": "2024-01-01T00:00:00Z", "position": 1}`,
+			patch:    `{"title": "New", "due_at": null}`,
+			expected: `{"title": "New", "position": 1}`,
+		},
+		{
+			name:     "Merge nested objects",
+			doc:      `{"a": {"b": 1, "c": 2}}`,
+			patch:    `{"a": {"c": null, "d": [1]}}`,
+			expected: `{"a": {"b": 1, "d": [1]}}`,
+		},
+		{
+			name:     "Replace whole document",
+			doc:      `{"a": 1}`,
+			patch:    `[1, 2]`,
+			expected: `[1, 2]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := MergePatch([]byte(tt.doc), []byte(tt.patch))
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(result))
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	doc := `{"title": "Task", "label_ids": [1, 2], "a/b": {"~": 3}}`
+
+	tests := []struct {
+		name          string
+		patch         string
+		expected      string
+		expectedError string
+		test          bool
+	}{
+		{
+			name: "Add, replace and remove",
+			patch: `[
+				{"op": "add", "path": "/label_ids/1", "value": 5},
+				{"op": "add", "path": "/label_ids/-", "value": 9},
+				{"op": "replace", "path": "/title", "value": "Renamed"},
+				{"op": "remove", "path": "/a~1b/~0"}
+			]`,
+			expected: `{"title": "Renamed", "label_ids": [1, 5, 2, 9], "a/b": {}}`,
+		},
+		{
+			name: "Move and copy",
+			patch: `[
+				{"op": "copy", "from": "/label_ids", "path": "/previous"},
+				{"op": "move", "from": "/title", "path": "/name"},
+				{"op": "remove", "path": "/label_ids/0"}
+			]`,
+			expected: `{"name": "Task", "label_ids": [2], "previous": [1, 2], "a/b": {"~": 3}}`,
+		},
+		{
+			name:     "Successful test",
+			patch:    `[{"op": "test", "path": "/label_ids", "value": [1, 2.0]}]`,
+			expected: doc,
+		},
+		{
+			name:          "Failed test",
+			patch:         `[{"op": "add", "path": "/x", "value": 1}, {"op": "test", "path": "/title", "value": "Other"}]`,
+			expectedError: "operation 1: test failed: /title",
+			test:          true,
+		},
+		{
+			name:          "Missing path",
+			patch:         `[{"op": "replace", "path": "/due_at", "value": null}]`,
+			expectedError: "operation 0: path not found: /due_at",
+		},
+		{
+			name:          "Index out of range",
+			patch:         `[{"op": "remove", "path": "/label_ids/2"}]`,
+			expectedError: "operation 0: invalid array index: 2",
+		},
+		{
+			name:          "Move into itself",
+			patch:         `[{"op": "move", "from": "/a~1b", "path": "/a~1b/child"}]`,
+			expectedError: "operation 0: cannot move /a~1b into itself",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := Parse([]byte(tt.patch))
+			assert.NoError(t, err)
+
+			result, err := patch.Apply([]byte(doc))
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				var applyErr *ApplyError
+				assert.True(t, errors.As(err, &applyErr))
+				assert.Equal(t, tt.test, applyErr.Test)
+				return
+			}
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(result))
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name          string
+		patch         string
+		expectedError string
+	}{
+		{name: "Not an array", patch: `{"op": "add"}`, expectedError: "invalid patch"},
+		{name: "Unknown op", patch: `[{"op": "merge", "path": "/a"}]`, expectedError: "operation 0: unknown op: merge"},
+		{name: "Missing value", patch: `[{"op": "add", "path": "/a"}]`, expectedError: "operation 0: missing value"},
+		{name: "Missing from", patch: `[{"op": "copy", "path": "/a"}]`, expectedError: "operation 0: missing from"},
+		{name: "Invalid path", patch: `[{"op": "remove", "path": "a"}]`, expectedError: "operation 0: invalid path: a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.patch))
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedError)
+		})
+	}
+}